@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xuanyiying/cleanup-cli/internal/ai/cache"
+	"github.com/xuanyiying/cleanup-cli/internal/cleaner"
+	"github.com/xuanyiying/cleanup-cli/internal/config"
+	"github.com/xuanyiying/cleanup-cli/internal/scheduler"
+)
+
+// defaultAICacheMaxAge is how long prune keeps unused answers by default
+const defaultAICacheMaxAge = 90 * 24 * time.Hour
+
+var (
+	aiCachePath    string
+	aiCacheMaxSize int64
+	aiCacheMaxAge  = defaultAICacheMaxAge
+	cacheOlderThan string
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of AI answers",
+	Long: `The AI cache keeps the names and categories AI models suggested, so that
+organizing the same files again, or after an interrupted run, does not ask the
+model again. Answers are kept apart by provider, model and prompt, and the
+least recently used ones are dropped when the cache outgrows its limit.
+
+The cache is stored in ~/.cleanup/ai-cache.json.gz; see ai.cache in the
+configuration to move, resize or disable it.`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the size and contents of the AI cache",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheStats,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached AI answer",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheClear,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove AI answers that were not used for a while",
+	Long: `Remove the AI answers not used within the given age, then the least
+recently used ones beyond the size limit. The age defaults to ai.cache.maxAge
+in the configuration, or 90 days.`,
+	Args: cobra.NoArgs,
+	RunE: runCachePrune,
+}
+
+func init() {
+	cachePruneCmd.Flags().StringVar(&cacheOlderThan, "older-than", "", "Remove answers not used within this age (e.g., 30d, 720h)")
+
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// newAICache applies the cache settings of the configuration and returns
+// the store AI answers are kept in, or nil if the cache is disabled
+func newAICache(cfg *config.CleanupConfig) *cache.Store {
+	homeDir, _ := os.UserHomeDir()
+	aiCachePath = filepath.Join(homeDir, ".cleanup", "ai-cache.json.gz")
+	aiCacheMaxSize = cache.DefaultMaxSize
+
+	var cc *config.AICacheConfig
+	if cfg != nil {
+		cc = cfg.AI.Cache
+	}
+	if cc != nil {
+		if cc.Path != "" {
+			aiCachePath = cleaner.ExpandPath(cc.Path)
+		}
+		if cc.MaxSizeMB > 0 {
+			aiCacheMaxSize = int64(cc.MaxSizeMB) << 20
+		}
+		if cc.MaxAge != "" {
+			age, err := scheduler.ParseAge(cc.MaxAge)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: invalid AI cache max age, using 90d: %v\n", err)
+			} else {
+				aiCacheMaxAge = age
+			}
+		}
+		if cc.Disabled {
+			return nil
+		}
+	}
+	return cache.NewStore(aiCachePath, aiCacheMaxSize)
+}
+
+// saveAICache writes the AI answers of the run back to disk
+func saveAICache() {
+	if aiCache == nil {
+		return
+	}
+	if err := aiCache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save AI cache: %v\n", err)
+	}
+}
+
+// printAICacheHits reports how many AI answers a run took from the cache
+func printAICacheHits() {
+	if aiCache == nil {
+		return
+	}
+	if hits, _ := aiCache.Hits(); hits > 0 {
+		fmt.Printf("Reused %d AI answers from the cache\n", hits)
+	}
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	store, err := cache.Open(aiCachePath, aiCacheMaxSize)
+	if err != nil {
+		return err
+	}
+
+	stats := store.Stats()
+	fmt.Printf("AI cache: %s\n", stats.Path)
+	if aiCache == nil {
+		fmt.Println("  Disabled in the configuration")
+	}
+	if stats.SavedAt.IsZero() {
+		fmt.Println("  Empty; it fills in as you organize with AI")
+		return nil
+	}
+	fmt.Printf("  File size:   %d bytes\n", stats.FileSize)
+	fmt.Printf("  Answers:     %.2f of %d MB\n", float64(stats.Size)/1024/1024, stats.MaxSize>>20)
+	fmt.Printf("  Updated:     %s\n", stats.SavedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Entries:     %d (%d names, %d categories)\n", stats.Entries, stats.Kinds["name"], stats.Kinds["category"])
+	if !stats.Oldest.IsZero() {
+		fmt.Printf("  Oldest used: %s\n", stats.Oldest.Format("2006-01-02 15:04:05"))
+	}
+
+	models := make([]string, 0, len(stats.Models))
+	for model := range stats.Models {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		fmt.Printf("    %-30s %d\n", model, stats.Models[model])
+	}
+	return nil
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	store := cache.NewStore(aiCachePath, aiCacheMaxSize)
+	removed := store.Clear()
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Removed %d cached AI answers\n", removed)
+	return nil
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	maxAge := aiCacheMaxAge
+	if cacheOlderThan != "" {
+		age, err := scheduler.ParseAge(cacheOlderThan)
+		if err != nil {
+			return err
+		}
+		maxAge = age
+	}
+
+	store, err := cache.Open(aiCachePath, aiCacheMaxSize)
+	if err != nil {
+		return err
+	}
+	removed := store.Prune(maxAge)
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Removed %d cached AI answers, %d remain\n", removed, store.Len())
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xuanyiying/cleanup-cli/internal/jobs"
+	"github.com/xuanyiying/cleanup-cli/internal/scheduler"
+)
+
+var daemonGracePeriod time.Duration
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run scheduled tasks in the foreground",
+	Long: `Run the tasks added with 'cleanup schedule add' until interrupted.
+
+The daemon picks up tasks added, changed or removed while it runs; send
+SIGHUP to reload immediately. On SIGINT or SIGTERM it stops starting new runs
+and gives running tasks the grace period to finish before cancelling them.
+
+Every run is recorded; see 'cleanup schedule runs <id>'.
+
+Examples:
+  cleanup daemon
+  cleanup daemon --grace 2m`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+func init() {
+	daemonCmd.Flags().DurationVar(&daemonGracePeriod, "grace", scheduler.DefaultGracePeriod, "How long running tasks may finish on shutdown")
+
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	daemon := scheduler.NewDaemon(scheduleStore, runHistory, os.Stdout)
+	daemon.GracePeriod = daemonGracePeriod
+
+	daemon.Jobs = newJobRunner()
+
+	// Reload on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for range hup {
+			if err := daemon.Reload(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to reload scheduled tasks: %v\n", err)
+			} else {
+				fmt.Println("Reloaded scheduled tasks")
+			}
+		}
+	}()
+
+	fmt.Printf("Starting scheduler daemon (tasks: %s)\n", scheduleStore.Path())
+	if err := daemon.Start(); err != nil {
+		return fmt.Errorf("failed to start daemon: %w", err)
+	}
+
+	for _, task := range daemon.Tasks() {
+		switch {
+		case !task.Enabled:
+		case task.Trigger != nil:
+			fmt.Printf("  ⚡ %s (%s trigger on %s) checked every %s\n", task.ID, task.Trigger.Kind, task.Trigger.Path, task.Trigger.Interval)
+		default:
+			fmt.Printf("  ⏰ %s (%s) next run %s\n", task.ID, task.Schedule, task.NextRun.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	if err := daemon.Serve(ctx); err != nil {
+		return fmt.Errorf("daemon stopped with errors: %w", err)
+	}
+
+	fmt.Println("Scheduler daemon stopped")
+	return nil
+}
+
+// newJobRunner creates the runner for built-in jobs, which run in-process
+// with the same services as the commands
+func newJobRunner() *jobs.Runner {
+	runner := jobs.NewRunner(fileAnalyzer, fileOrganizer, systemCleaner, txnMgr)
+	runner.ScanOptions = buildScanOptions()
+	runner.LockDir = lockDir
+	return runner
+}
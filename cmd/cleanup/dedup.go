@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
 	"github.com/xuanyiying/cleanup-cli/internal/dedup"
 	"github.com/xuanyiying/cleanup-cli/internal/output"
 )
@@ -16,6 +17,7 @@ var (
 	dedupMaxSize     int64
 	dedupKeepStrategy string
 	dedupAutoRemove  bool
+	dedupArchives    bool
 )
 
 // dedupCmd represents the dedup command
@@ -30,7 +32,11 @@ Examples:
   cleanup dedup ~/Downloads
   cleanup dedup ~/Documents --keep newest
   cleanup dedup . --min-size 1048576  # Only files >= 1MB
-  cleanup dedup . --dry-run           # Preview without removing`,
+  cleanup dedup . --dry-run           # Preview without removing
+  cleanup dedup . --archives          # Also report copies inside zips and tarballs
+
+Files inside archives are never removed; they only show that a file on disk
+is already archived.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runDedup,
 }
@@ -41,6 +47,7 @@ func init() {
 	dedupCmd.Flags().StringVar(&dedupKeepStrategy, "keep", "newest", "Which file to keep: newest, oldest, first")
 	dedupCmd.Flags().BoolVar(&dedupAutoRemove, "auto", false, "Automatically remove duplicates without confirmation")
 	dedupCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview changes without executing")
+	dedupCmd.Flags().BoolVar(&dedupArchives, "archives", false, "Compare files with those inside ZIP, TAR and 7z archives")
 
 	rootCmd.AddCommand(dedupCmd)
 }
@@ -66,16 +73,35 @@ func runDedup(cmd *cobra.Command, args []string) error {
 	console := output.NewConsole(os.Stdout)
 
 	// Create deduplicator
+	scanOpts := buildScanOptions()
 	deduplicator := dedup.NewDeduplicator()
 	deduplicator.MinSize = dedupMinSize
 	deduplicator.MaxSize = dedupMaxSize
+	deduplicator.Traversal = scanOpts.Traversal
+	if idx := openIndex(); idx != nil {
+		deduplicator.Cache = idx
+		defer saveIndex(idx)
+	}
 
 	// Find duplicates
 	console.Info(fmt.Sprintf("Scanning for duplicates in: %s", absPath))
 	console.Info(fmt.Sprintf("Min size: %d bytes, Max size: %d bytes",
 		dedupMinSize, dedupMaxSize))
 
-	groups, err := deduplicator.FindDuplicates(ctx, absPath)
+	var groups []*dedup.DuplicateGroup
+	if dedupArchives {
+		results := fileAnalyzer.AnalyzeStream(ctx, absPath, &analyzer.ScanOptions{
+			Recursive:     true,
+			IncludeHidden: true,
+			SkipContent:   true,
+			CalculateHash: true,
+			Archives:      true,
+			Traversal:     scanOpts.Traversal,
+		})
+		groups, err = deduplicator.FindDuplicatesStream(ctx, results)
+	} else {
+		groups, err = deduplicator.FindDuplicates(ctx, absPath)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to find duplicates: %w", err)
 	}
@@ -92,6 +118,19 @@ func runDedup(cmd *cobra.Command, args []string) error {
 	console.Info(fmt.Sprintf("  Duplicate files: %d", stats.TotalDuplicates))
 	console.Info(fmt.Sprintf("  Wasted space: %d bytes", stats.WastedSpace))
 	console.Info(fmt.Sprintf("  Largest duplicate: %d bytes", stats.LargestDuplicate))
+	if stats.ArchivedCopies > 0 {
+		console.Info(fmt.Sprintf("  Copies inside archives: %d", stats.ArchivedCopies))
+	}
+	if stats.HardLinks > 0 {
+		console.Info(fmt.Sprintf("  Hard links (not duplicates): %d", stats.HardLinks))
+	}
+
+	// Create removal plan
+	plan := deduplicator.CreateRemovalPlan(groups, dedupKeepStrategy)
+	kept := make(map[*dedup.FileInfo]bool, len(plan.ToKeep))
+	for _, file := range plan.ToKeep {
+		kept[file] = true
+	}
 
 	// Display duplicate groups
 	fmt.Println("\nDuplicate Groups:")
@@ -106,26 +145,37 @@ func runDedup(cmd *cobra.Command, args []string) error {
 		fmt.Printf("\nGroup %d (Hash: %s, Size: %d bytes):\n",
 			i+1, group.Hash[:16]+"...", group.Size)
 
-		for j, file := range group.Files {
+		for _, file := range group.Files {
 			marker := " "
-			if j == 0 {
+			if file.Archive != "" {
+				marker = "📦" // Copy inside an archive, never removed
+			} else if kept[file] {
 				marker = "✓" // File to keep
 			} else {
 				marker = "✗" // File to remove
 			}
 
 			relPath, _ := filepath.Rel(absPath, file.Path)
+			if file.Archive != "" {
+				archiveRel, _ := filepath.Rel(absPath, file.Archive)
+				fmt.Printf("  %s %s (already in %s)\n", marker, relPath, archiveRel)
+				continue
+			}
 			fmt.Printf("  %s %s (modified: %s)\n",
 				marker, relPath, file.ModTime.Format("2006-01-02 15:04:05"))
+			for _, link := range file.Links {
+				linkRel, _ := filepath.Rel(absPath, link)
+				fmt.Printf("      🔗 %s (hard link to the same file)\n", linkRel)
+			}
 		}
 	}
 
-	// Create removal plan
-	plan := deduplicator.CreateRemovalPlan(groups, dedupKeepStrategy)
-
 	fmt.Println("\n==========================================")
 	console.Warning(fmt.Sprintf("Will remove %d files, saving %d bytes",
 		len(plan.ToRemove), plan.SpaceSaved))
+	if len(plan.ToRemove) == 0 {
+		return nil // Only archived copies were found
+	}
 
 	// Confirm or auto-remove
 	if !dedupAutoRemove && !dryRun {
@@ -146,6 +196,12 @@ func runDedup(cmd *cobra.Command, args []string) error {
 			fmt.Printf("  Would remove: %s\n", relPath)
 		}
 	} else {
+		lock, err := lockTarget(absPath)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock()
+
 		console.Info("\nRemoving duplicate files...")
 		if err := deduplicator.ExecuteRemovalPlan(ctx, plan, false); err != nil {
 			return fmt.Errorf("failed to remove duplicates: %w", err)
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xuanyiying/cleanup-cli/internal/cleaner"
+	"github.com/xuanyiying/cleanup-cli/internal/organizer"
+	"github.com/xuanyiying/cleanup-cli/internal/prune"
+)
+
+var (
+	flattenDepth     int
+	flattenInto      string
+	flattenKeepEmpty bool
+)
+
+// flattenCmd represents the flatten command
+var flattenCmd = &cobra.Command{
+	Use:     "flatten <dir>",
+	Aliases: []string{"flat", "collect"},
+	Short:   "Collect files from nested subdirectories into one level",
+	Long: `Move files out of nested subdirectories into a single directory.
+This is the inverse of organize and is useful for undoing folder structures
+created by other tools before applying your own rules.
+
+Name conflicts get a unique suffix, directories emptied by the flatten are
+removed, and everything is recorded so 'cleanup undo' can restore it.
+
+Examples:
+  cleanup flatten ~/Downloads
+  cleanup flatten ~/Photos --depth 2
+  cleanup flatten ~/Archive --into ~/Archive/all
+  cleanup flatten . --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFlatten,
+}
+
+func init() {
+	flattenCmd.Flags().IntVar(&flattenDepth, "depth", 0, "Only collect files up to this many levels deep (0 = unlimited)")
+	flattenCmd.Flags().StringVar(&flattenInto, "into", "", "Destination directory (default: the directory being flattened)")
+	flattenCmd.Flags().BoolVar(&flattenKeepEmpty, "keep-empty", false, "Keep directories that become empty")
+
+	rootCmd.AddCommand(flattenCmd)
+}
+
+func runFlatten(cmd *cobra.Command, args []string) error {
+	absPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	if info, err := os.Stat(absPath); err != nil {
+		return fmt.Errorf("directory not found: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", absPath)
+	}
+
+	into := ""
+	if flattenInto != "" {
+		into, err = filepath.Abs(flattenInto)
+		if err != nil {
+			return fmt.Errorf("failed to resolve destination: %w", err)
+		}
+	}
+
+	if !dryRun {
+		for _, key := range flattenLockKeys(absPath, into) {
+			lock, err := lockTarget(key)
+			if err != nil {
+				return err
+			}
+			defer lock.Unlock()
+		}
+	}
+
+	ctx := context.Background()
+
+	fmt.Printf("Scanning directory: %s\n", absPath)
+	scanOpts := buildScanOptions()
+	files, err := fileAnalyzer.AnalyzeDirectory(ctx, absPath, scanOpts)
+	if err != nil {
+		return fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	result, err := fileOrganizer.Flatten(ctx, absPath, files, &organizer.FlattenOptions{
+		Depth:            flattenDepth,
+		Into:             into,
+		ConflictStrategy: organizer.ConflictSuffix,
+		DryRun:           dryRun,
+		KeepEmptyDirs:    flattenKeepEmpty,
+		PruneOptions: &prune.Options{
+			ExcludeDirs: scanOpts.ExcludeDirs,
+			IsProtected: cleaner.IsProtectedPath,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to flatten directory: %w", err)
+	}
+
+	fmt.Println("\n╔════════════════════════════════════════╗")
+	fmt.Println("║            Flatten Results             ║")
+	fmt.Println("╚════════════════════════════════════════╝")
+
+	maxDisplay := 20
+	for i, op := range result.Moved {
+		if i >= maxDisplay {
+			fmt.Printf("  ... and %d more files\n", len(result.Moved)-maxDisplay)
+			break
+		}
+		relSrc, _ := filepath.Rel(absPath, op.Source)
+		relDst, _ := filepath.Rel(absPath, op.Target)
+		fmt.Printf("  📁 %s → %s\n", relSrc, relDst)
+	}
+
+	fmt.Printf("\n  Moved:        %d\n", len(result.Moved))
+	fmt.Printf("  Skipped:      %d\n", len(result.Skipped))
+	fmt.Printf("  Failed:       %d\n", len(result.FailedFiles))
+	fmt.Printf("  Removed dirs: %d\n", len(result.RemovedDirs))
+
+	if len(result.FailedFiles) > 0 {
+		fmt.Println("\n  Errors encountered:")
+		for file, err := range result.FailedFiles {
+			fmt.Printf("    ✗ %s: %v\n", filepath.Base(file), err)
+		}
+	}
+
+	if dryRun {
+		fmt.Println("\n[DRY-RUN MODE] No files were actually modified")
+		return nil
+	}
+
+	if result.TransactionID != "" {
+		fmt.Printf("\n  Transaction ID (for undo): %s\n", result.TransactionID)
+		fmt.Println("\n  💡 Tip: Use 'cleanup undo' to revert changes if needed")
+	}
+
+	return nil
+}
+
+// flattenLockKeys returns the directories to lock for a flatten of root into
+// into. A lock covers the subdirectories of its directory, so a directory
+// inside the other is not locked again.
+func flattenLockKeys(root, into string) []string {
+	if into == "" {
+		return []string{root}
+	}
+	if isSubdir(root, into) {
+		return []string{root}
+	}
+	if isSubdir(into, root) {
+		return []string{into}
+	}
+	return []string{root, into}
+}
+
+// isSubdir reports whether path is dir or inside it
+func isSubdir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/index"
+)
+
+var (
+	noIndex   bool
+	indexPath string
+)
+
+// indexCmd represents the index command
+var indexCmd = &cobra.Command{
+	Use:     "index",
+	Aliases: []string{"idx"},
+	Short:   "Manage the file index",
+	Long: `The file index remembers what scan, organize and dedup learned about each
+file: its type, hashes, content preview, metadata and AI suggestions. Files
+keep their entry while their inode, size and modification time are unchanged,
+so repeat scans only read the files that changed.
+
+The index is stored in ~/.cleanup/index.json.gz. Use --no-index to bypass it.`,
+}
+
+var indexStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show how many indexed files are still current",
+	Args:  cobra.NoArgs,
+	RunE:  runIndexStatus,
+}
+
+var indexRebuildCmd = &cobra.Command{
+	Use:   "rebuild [path]",
+	Short: "Drop and re-create the entries of a directory",
+	Long: `Drop the index entries of a directory and analyze it again, hashing every
+file. Without a path the current directory is rebuilt.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runIndexRebuild,
+}
+
+var indexPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove entries of deleted and changed files",
+	Args:  cobra.NoArgs,
+	RunE:  runIndexPrune,
+}
+
+func init() {
+	homeDir, _ := os.UserHomeDir()
+	indexPath = filepath.Join(homeDir, ".cleanup", "index.json.gz")
+
+	rootCmd.PersistentFlags().BoolVar(&noIndex, "no-index", false, "Analyze every file instead of reusing the file index")
+
+	indexCmd.AddCommand(indexStatusCmd)
+	indexCmd.AddCommand(indexRebuildCmd)
+	indexCmd.AddCommand(indexPruneCmd)
+	rootCmd.AddCommand(indexCmd)
+}
+
+// openIndex loads the file index and attaches it to the analyzer and the
+// organizer. It returns nil if the index is disabled. An unreadable index
+// is replaced by an empty one.
+func openIndex() *index.Index {
+	if noIndex {
+		return nil
+	}
+
+	idx, err := index.Open(indexPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: starting a new file index: %v\n", err)
+		idx = index.NewIndex(indexPath)
+	}
+
+	if fa, ok := fileAnalyzer.(*analyzer.FileAnalyzer); ok {
+		fa.SetCache(idx)
+	}
+	fileOrganizer.SetSuggestionCache(idx)
+	return idx
+}
+
+// saveIndex writes the file index back to disk
+func saveIndex(idx *index.Index) {
+	if idx == nil {
+		return
+	}
+	if err := idx.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save file index: %v\n", err)
+	}
+}
+
+// printIndexHits reports how many files a scan took from the file index
+func printIndexHits(idx *index.Index) {
+	if idx == nil {
+		return
+	}
+	if hits, _ := idx.Hits(); hits > 0 {
+		fmt.Printf("Reused %d unchanged files from the index\n", hits)
+	}
+}
+
+func runIndexStatus(cmd *cobra.Command, args []string) error {
+	idx, err := index.Open(indexPath)
+	if err != nil {
+		return err
+	}
+
+	status, err := idx.Check(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to check index: %w", err)
+	}
+
+	fmt.Printf("Index: %s\n", status.Path)
+	if status.SavedAt.IsZero() {
+		fmt.Println("  Not built yet; it fills in as you scan")
+		return nil
+	}
+	fmt.Printf("  Size:      %d bytes\n", status.FileSize)
+	fmt.Printf("  Updated:   %s\n", status.SavedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Files:     %d\n", status.Entries)
+	fmt.Printf("  Current:   %d\n", status.Current)
+	fmt.Printf("  Changed:   %d\n", status.Changed)
+	fmt.Printf("  Missing:   %d\n", status.Missing)
+	fmt.Printf("  Hashed:    %d\n", status.Hashed)
+	fmt.Printf("  Suggested: %d\n", status.Suggested)
+	if status.Changed+status.Missing > 0 {
+		fmt.Println("\nRun 'cleanup index prune' to drop changed and missing files")
+	}
+	return nil
+}
+
+func runIndexRebuild(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return fmt.Errorf("directory not found: %w", err)
+	}
+
+	noIndex = false
+	idx := openIndex()
+	removed := idx.Clear(absPath)
+
+	scanOpts := buildScanOptions()
+	scanOpts.CalculateHash = true
+	files, err := fileAnalyzer.AnalyzeDirectory(context.Background(), absPath, scanOpts)
+	if err != nil {
+		return fmt.Errorf("failed to scan directory: %w", err)
+	}
+	if err := idx.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Re-indexed %d files in %s (%d old entries dropped)\n", len(files), absPath, removed)
+	return nil
+}
+
+func runIndexPrune(cmd *cobra.Command, args []string) error {
+	idx, err := index.Open(indexPath)
+	if err != nil {
+		return err
+	}
+
+	removed, err := idx.Prune(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to prune index: %w", err)
+	}
+	if err := idx.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Removed %d stale entries, %d files remain indexed\n", removed, idx.Len())
+	return nil
+}
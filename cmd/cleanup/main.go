@@ -2,24 +2,36 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/xuanyiying/cleanup-cli/internal/ai"
+	"github.com/xuanyiying/cleanup-cli/internal/ai/cache"
+	"github.com/xuanyiying/cleanup-cli/internal/ai/heuristic"
 	"github.com/xuanyiying/cleanup-cli/internal/ai/openai"
+	"github.com/xuanyiying/cleanup-cli/internal/ai/privacy"
 	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/archive"
 	"github.com/xuanyiying/cleanup-cli/internal/cleaner"
 	"github.com/xuanyiying/cleanup-cli/internal/config"
+	"github.com/xuanyiying/cleanup-cli/internal/jobs"
 	"github.com/xuanyiying/cleanup-cli/internal/ollama"
 	"github.com/xuanyiying/cleanup-cli/internal/organizer"
 	"github.com/xuanyiying/cleanup-cli/internal/output"
+	"github.com/xuanyiying/cleanup-cli/internal/prune"
 	"github.com/xuanyiying/cleanup-cli/internal/rules"
+	"github.com/xuanyiying/cleanup-cli/internal/scheduler"
 	"github.com/xuanyiying/cleanup-cli/internal/setup"
 	"github.com/xuanyiying/cleanup-cli/internal/shell"
 	"github.com/xuanyiying/cleanup-cli/internal/transaction"
 	"github.com/xuanyiying/cleanup-cli/internal/visualizer"
+	"github.com/xuanyiying/cleanup-cli/internal/walk"
+	"github.com/xuanyiying/cleanup-cli/pkg/filelock"
 )
 
 var (
@@ -33,6 +45,10 @@ var (
 	excludeDirs       []string
 	junkCategory      string
 	forceDelete       bool
+	pruneEmpty        bool
+	scanArchives      bool
+	followSymlinks    bool
+	oneFileSystem     bool
 
 	// Global managers
 	configMgr     *config.Manager
@@ -42,6 +58,12 @@ var (
 	fileOrganizer *organizer.Organizer
 	systemCleaner *cleaner.SystemCleaner
 	aiClient      ai.Client
+	aiChain       *ai.Chain
+	aiGuards      []*privacy.Guard
+	aiCache       *cache.Store
+	scheduleStore *scheduler.Store
+	runHistory    *scheduler.History
+	lockDir       string
 )
 
 var (
@@ -107,16 +129,42 @@ If no path is provided, the current directory is used.`,
 			fmt.Printf("Excluding directories: %v\n", scanOpts.ExcludeDirs)
 		}
 
-		files, err := fileAnalyzer.AnalyzeDirectory(ctx, absPath, scanOpts)
-		if err != nil {
-			return fmt.Errorf("failed to scan directory: %w", err)
-		}
+		idx := openIndex()
+		defer saveIndex(idx)
 
-		fmt.Printf("Found %d files\n", len(files))
-		for _, file := range files {
+		// Print files as they are analyzed
+		count, archived := 0, 0
+		for result := range fileAnalyzer.AnalyzeStream(ctx, absPath, scanOpts) {
+			if result.Err != nil {
+				if result.Path == "" {
+					return result.Err
+				}
+				switch {
+				case errors.Is(result.Err, walk.ErrBrokenLink):
+					fmt.Printf("  ! %s (broken symbolic link)\n", filepath.Base(result.Path))
+				case errors.Is(result.Err, walk.ErrLinkLoop):
+					fmt.Printf("  ! %s (symbolic link loop, not followed)\n", filepath.Base(result.Path))
+				}
+				continue // Skip files that can't be analyzed
+			}
+			file := result.Metadata
+			if file.IsArchived() {
+				// Entries follow their archive
+				archived++
+				_, name, _ := archive.SplitPath(file.Path)
+				fmt.Printf("      📦 %s (%d bytes)\n", name, file.Size)
+				continue
+			}
+			count++
 			fmt.Printf("  - %s (%s, %d bytes)\n", file.Name, file.MimeType, file.Size)
 		}
 
+		fmt.Printf("Found %d files\n", count)
+		if archived > 0 {
+			fmt.Printf("Listed %d files inside archives\n", archived)
+		}
+		printIndexHits(idx)
+
 		return nil
 	},
 }
@@ -146,6 +194,15 @@ If no path is provided, the current directory is used.`,
 			return fmt.Errorf("directory not found: %w", err)
 		}
 
+		// Keep scheduled jobs out of the directory while we organize it
+		if !dryRun {
+			lock, err := lockTarget(absPath)
+			if err != nil {
+				return err
+			}
+			defer lock.Unlock()
+		}
+
 		ctx := context.Background()
 
 		// Build scan options with exclusions
@@ -163,12 +220,8 @@ If no path is provided, the current directory is used.`,
 			fmt.Printf("Excluding directories: %v\n", scanOpts.ExcludeDirs)
 		}
 
-		files, err := fileAnalyzer.AnalyzeDirectory(ctx, absPath, scanOpts)
-		if err != nil {
-			return fmt.Errorf("failed to scan directory: %w", err)
-		}
-
-		fmt.Printf("Found %d files\n", len(files))
+		idx := openIndex()
+		defer saveIndex(idx)
 
 		// Capture state before execution for diff
 		diffRenderer := visualizer.NewDiffRenderer(output.NewConsole(os.Stdout))
@@ -185,13 +238,27 @@ If no path is provided, the current directory is used.`,
 			ConflictStrategy: organizer.ConflictSuffix,
 			DryRun:           dryRun,
 			MaxConcurrency:   4,
-		}
-
-		plan, err := fileOrganizer.Organize(ctx, files, strategy)
+			PruneEmptyDirs:   pruneEmpty,
+			PruneRoot:        absPath,
+			PruneOptions: &prune.Options{
+				ExcludeDirs: scanOpts.ExcludeDirs,
+				IsProtected: cleaner.IsProtectedPath,
+			},
+		}
+
+		// Plan files while the scan is still running
+		scanCtx, cancelScan := context.WithCancel(ctx)
+		defer cancelScan()
+		scanOpts.OnProgress = printScanProgress()
+		plan, err := fileOrganizer.OrganizeStream(scanCtx, fileAnalyzer.AnalyzeStream(scanCtx, absPath, scanOpts), strategy)
 		if err != nil {
 			return fmt.Errorf("failed to generate organization plan: %w", err)
 		}
 
+		fmt.Printf("Found %d files\n", plan.Summary.TotalFiles)
+		printIndexHits(idx)
+		printAICacheHits()
+
 		// Display plan summary
 		fmt.Println("\n╔════════════════════════════════════════╗")
 		fmt.Println("║       Organization Plan Summary        ║")
@@ -202,6 +269,8 @@ If no path is provided, the current directory is used.`,
 		fmt.Printf("  Renames:          %d\n", plan.Summary.RenameCount)
 		fmt.Printf("  Skipped:          %d\n", plan.Summary.SkipCount)
 		fmt.Printf("  Estimated size:   %.2f MB\n", float64(plan.Summary.EstimatedSize)/1024/1024)
+		printPrivacyStats()
+		printAIStats()
 
 		// Display detailed operations
 		if len(plan.Operations) > 0 && !dryRun {
@@ -269,6 +338,9 @@ If no path is provided, the current directory is used.`,
 		fmt.Printf("  ✓ Successful:  %d\n", result.Successful)
 		fmt.Printf("  ✗ Failed:      %d\n", result.Failed)
 		fmt.Printf("  ⊘ Skipped:     %d\n", result.Skipped)
+		if len(result.PrunedDirs) > 0 {
+			fmt.Printf("  🗑  Empty dirs:  %d removed\n", len(result.PrunedDirs))
+		}
 
 		if len(result.TransactionIDs) > 0 {
 			fmt.Printf("\n  Transaction IDs (for undo):\n")
@@ -386,6 +458,7 @@ var junkScanCmd = &cobra.Command{
 		if err == nil && cfg != nil && cfg.Cleaner != nil {
 			systemCleaner.Configure(cfg.Cleaner.JunkLocations, cfg.Cleaner.ImportantPatterns)
 		}
+		systemCleaner.SetTraversal(buildScanOptions().Traversal)
 
 		// Parse category
 		var categories []cleaner.JunkCategory
@@ -422,6 +495,7 @@ var junkCleanCmd = &cobra.Command{
 		if err == nil && cfg != nil && cfg.Cleaner != nil {
 			systemCleaner.Configure(cfg.Cleaner.JunkLocations, cfg.Cleaner.ImportantPatterns)
 		}
+		systemCleaner.SetTraversal(buildScanOptions().Traversal)
 
 		// Parse category
 		var categories []cleaner.JunkCategory
@@ -429,11 +503,22 @@ var junkCleanCmd = &cobra.Command{
 			categories = []cleaner.JunkCategory{cleaner.JunkCategory(junkCategory)}
 		}
 
+		if !dryRun {
+			lock, err := lockTarget(jobs.JunkCleanLockKey)
+			if err != nil {
+				return err
+			}
+			defer lock.Unlock()
+		}
+
 		opts := &cleaner.CleanOptions{
 			DryRun:      dryRun,
 			Force:       forceDelete,
 			Categories:  categories,
 			Interactive: true, // Default to interactive
+
+			PruneEmptyDirs: pruneEmpty,
+			ExcludeDirs:    buildScanOptions().ExcludeDirs,
 		}
 
 		_, err = systemCleaner.Clean(ctx, opts)
@@ -460,7 +545,9 @@ var versionCmd = &cobra.Command{
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	saveAICache()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -471,6 +558,11 @@ func buildScanOptions() *analyzer.ScanOptions {
 	opts := &analyzer.ScanOptions{
 		Recursive:     true,
 		IncludeHidden: false,
+		Archives:      scanArchives,
+		Traversal: walk.Options{
+			FollowSymlinks: followSymlinks,
+			OneFileSystem:  oneFileSystem,
+		},
 	}
 
 	// Merge exclusions from command-line flags
@@ -498,9 +590,210 @@ func buildScanOptions() *analyzer.ScanOptions {
 		}
 	}
 
+	// The config file can turn traversal options on; flags can't turn them off
+	if err == nil && cfg != nil && cfg.Traversal != nil {
+		opts.Traversal.FollowSymlinks = opts.Traversal.FollowSymlinks || cfg.Traversal.FollowSymlinks
+		opts.Traversal.OneFileSystem = opts.Traversal.OneFileSystem || cfg.Traversal.OneFileSystem
+		opts.Traversal.IncludePseudo = cfg.Traversal.IncludePseudo
+	}
+
 	return opts
 }
 
+// printScanProgress returns a progress callback that keeps a line on stderr
+// up to date with the files found and analyzed so far
+func printScanProgress() func(analyzer.Progress) {
+	var last time.Time
+	return func(p analyzer.Progress) {
+		finished := p.Done && p.Analyzed == p.Discovered
+		if !finished && time.Since(last) < 200*time.Millisecond {
+			return
+		}
+		last = time.Now()
+		fmt.Fprintf(os.Stderr, "\rAnalyzing: %d/%d files", p.Analyzed, p.Discovered)
+		if finished {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+}
+
+// printPrivacyStats reports the files whose content the privacy policy
+// redacted or kept from the AI provider
+func printPrivacyStats() {
+	if len(aiGuards) == 0 {
+		return
+	}
+	stats := privacy.MergeStats(aiGuards...)
+	if stats.Redacted == 0 && stats.Blocked == 0 {
+		return
+	}
+	fmt.Printf("  AI privacy:       %d redacted, %d withheld", stats.Redacted, stats.Blocked)
+	var kinds []string
+	for _, kind := range privacy.Kinds {
+		if n := stats.Kinds[kind]; n > 0 {
+			kinds = append(kinds, fmt.Sprintf("%s %d", kind, n))
+		}
+	}
+	if len(kinds) > 0 {
+		fmt.Printf(" (%s)", strings.Join(kinds, ", "))
+	}
+	fmt.Println()
+}
+
+// printAIStats warns when AI providers failed during the run and reports
+// which providers answered instead
+func printAIStats() {
+	if aiChain == nil {
+		return
+	}
+	stats := aiChain.Stats()
+	if !stats.Degraded() {
+		return
+	}
+	if stats.Unanswered > 0 {
+		fmt.Printf("\n⚠️  AI was unavailable for %d requests; those files keep their names and rule-based folders\n", stats.Unanswered)
+	} else {
+		fmt.Println("\n⚠️  Some AI providers failed; other providers answered instead")
+	}
+	for _, p := range stats.Providers {
+		fmt.Printf("  %-10s %d answered, %d failed, %d skipped", p.Name, p.Answered, p.Failed, p.Skipped)
+		if p.State == ai.BreakerOpen {
+			fmt.Print(" (paused)")
+		}
+		fmt.Println()
+		if p.LastError != "" {
+			fmt.Printf("             last error: %s\n", p.LastError)
+		}
+	}
+}
+
+// newAIClient builds the providers of the configuration in the order they
+// are tried, each model behind the privacy policy for where it runs. The
+// offline heuristic client names files by the scorer names.
+func newAIClient(cfg *config.CleanupConfig, names *analyzer.NameScorer, store *cache.Store) (*ai.Chain, []*privacy.Guard) {
+	list := cfg.AI.Providers
+	if len(list) == 0 {
+		// A single model is OpenAI if asked for, and Ollama otherwise,
+		// with the heuristic client for when it is unavailable
+		name := "ollama"
+		if cfg.AI.Provider == "openai" {
+			name = "openai"
+		}
+		list = []string{name, "heuristic"}
+	}
+
+	var providers []ai.Provider
+	var guards []*privacy.Guard
+	policyWarned := false
+	for _, name := range list {
+		name = strings.ToLower(strings.TrimSpace(name))
+		var client ai.Client
+		var model string
+		var remote bool
+		switch name {
+		case "heuristic", "offline":
+			// Content never leaves the machine, so no policy applies
+			providers = append(providers, ai.Provider{Name: name, Client: heuristic.NewClient(names)})
+			continue
+		case "ollama":
+			client = ollama.NewClient(&cfg.Ollama)
+			model = cfg.Ollama.Model
+			remote = cfg.Ollama.BaseURL != "" && !privacy.IsLocal(cfg.Ollama.BaseURL)
+		case "openai":
+			client = openai.NewClient(&cfg.AI.OpenAI)
+			model = cfg.AI.OpenAI.Model
+			remote = !privacy.IsLocal(cfg.AI.OpenAI.BaseURL)
+		default:
+			fmt.Fprintf(os.Stderr, "Warning: unknown AI provider %q, skipping it\n", name)
+			continue
+		}
+
+		// Keep secrets and personal data out of AI requests
+		policy, err := privacy.NewPolicy(cfg.AI.Privacy, remote)
+		if err != nil {
+			if !policyWarned {
+				fmt.Fprintf(os.Stderr, "Warning: invalid privacy settings, using the strict defaults: %v\n", err)
+				policyWarned = true
+			}
+			policy = privacy.RemotePolicy()
+		}
+		guard := privacy.NewGuard(withAICache(client, store, name, model), policy)
+		providers = append(providers, ai.Provider{Name: name, Client: guard})
+		guards = append(guards, guard)
+	}
+
+	var opts ai.ChainOptions
+	if f := cfg.AI.Fallback; f != nil {
+		opts = ai.ChainOptions{
+			FailureThreshold: f.FailureThreshold,
+			Cooldown:         f.Cooldown,
+			ProbeTimeout:     f.ProbeTimeout,
+		}
+	}
+	return ai.NewChain(providers, opts), guards
+}
+
+// withAICache answers the requests of a provider's model from the store
+// when it can. The cache sits behind the privacy guard, so it is keyed by
+// the content as it is sent. Without a store the client is used as is.
+func withAICache(client ai.Client, store *cache.Store, provider, model string) ai.Client {
+	if store == nil {
+		return client
+	}
+	return cache.NewClient(client, store, provider, model)
+}
+
+// loadNameScorer builds the filename scorer of the configuration
+func loadNameScorer(cfg *config.NamingConfig) (*analyzer.NameScorer, error) {
+	opts := analyzer.NamingOptions{
+		Threshold:    cfg.Threshold,
+		Locales:      cfg.Locales,
+		Placeholders: cfg.Placeholders,
+		GenericWords: cfg.GenericWords,
+	}
+	for _, p := range cfg.Patterns {
+		if p == nil {
+			continue
+		}
+		opts.Rules = append(opts.Rules, analyzer.NameRule{Pattern: p.Pattern, Regex: p.Regex, Score: p.Score, Reason: p.Reason})
+	}
+	return analyzer.NewNameScorer(opts)
+}
+
+// loadSignatures adds the custom file type signatures of the configuration to
+// the built-in ones. Invalid signatures are skipped and reported.
+func loadSignatures(custom []*config.SignatureConfig) (*analyzer.SignatureDB, error) {
+	db := analyzer.DefaultSignatures()
+	var errs []error
+	for _, sc := range custom {
+		magic, mask, err := analyzer.ParseMagic(sc.Magic)
+		if err == nil {
+			err = db.Add(analyzer.Signature{
+				MimeType:   sc.MimeType,
+				Offset:     sc.Offset,
+				Magic:      magic,
+				Mask:       mask,
+				Extensions: sc.Extensions,
+			})
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("signature %q: %w", sc.MimeType, err))
+		}
+	}
+	return db, errors.Join(errs...)
+}
+
+// lockTarget takes the process lock of key (usually a directory) so that
+// scheduled jobs and other cleanup processes don't change it at the same time.
+// A directory lock also keeps them out of its subdirectories and parents.
+func lockTarget(key string) (*filelock.ProcessLock, error) {
+	lock, err := filelock.TryLockProcess(lockDir, key)
+	if errors.Is(err, filelock.ErrLocked) {
+		return nil, fmt.Errorf("another cleanup is already running: %w", err)
+	}
+	return lock, err
+}
+
 func init() {
 	// Initialize global managers
 	homeDir, _ := os.UserHomeDir()
@@ -512,6 +805,9 @@ func init() {
 	ruleEngine = rules.NewEngine()
 	fileOrganizer = organizer.NewOrganizerWithDeps(txnMgr, ruleEngine, fileAnalyzer)
 	systemCleaner = cleaner.NewSystemCleaner(txnMgr)
+	scheduleStore = scheduler.NewStore(filepath.Join(homeDir, ".cleanup", "schedule.yaml"))
+	runHistory = scheduler.NewHistory(filepath.Join(homeDir, ".cleanup", "schedule-runs.json"))
+	lockDir = filepath.Join(homeDir, ".cleanup", "locks")
 
 	// Load configuration
 	cfg, err := configMgr.Load()
@@ -530,23 +826,51 @@ func init() {
 		}
 	}
 
-	// Initialize AI client
 	if cfg != nil {
-		if cfg.AI.Provider == "openai" {
-			aiClient = openai.NewClient(&cfg.AI.OpenAI)
-		} else {
-			aiClient = ollama.NewClient(&cfg.Ollama)
-		}
-
 		// Load rules into rule engine
 		if len(cfg.Rules) > 0 {
 			if err := ruleEngine.LoadRules(cfg.Rules); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to load rules: %v\n", err)
 			}
 		}
+
+		// Add custom file type signatures
+		if fa, ok := fileAnalyzer.(*analyzer.FileAnalyzer); ok && len(cfg.Signatures) > 0 {
+			signatures, err := loadSignatures(cfg.Signatures)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to load signatures: %v\n", err)
+			}
+			fa.SetSignatures(signatures)
+		}
+
+		// Score filenames with the configured patterns and words
+		if fa, ok := fileAnalyzer.(*analyzer.FileAnalyzer); ok && cfg.Naming != nil {
+			scorer, err := loadNameScorer(cfg.Naming)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: invalid naming settings, using the defaults: %v\n", err)
+			} else {
+				fa.SetNameScorer(scorer)
+			}
+		}
+	}
+
+	// Initialize AI client
+	names := analyzer.DefaultNameScorer()
+	if fa, ok := fileAnalyzer.(*analyzer.FileAnalyzer); ok {
+		names = fa.NameScorer()
+	}
+	aiCache = newAICache(cfg)
+	if cfg != nil {
+		aiChain, aiGuards = newAIClient(cfg, names, aiCache)
 	} else {
-		aiClient = ollama.NewClient(nil)
+		guard := privacy.NewGuard(withAICache(ollama.NewClient(nil), aiCache, "ollama", "llama3.2"), privacy.LocalPolicy())
+		aiChain = ai.NewChain([]ai.Provider{
+			{Name: "ollama", Client: guard},
+			{Name: "heuristic", Client: heuristic.NewClient(names)},
+		}, ai.ChainOptions{})
+		aiGuards = []*privacy.Guard{guard}
 	}
+	aiClient = aiChain
 
 	// Set AI client in organizer for AI features
 	fileOrganizer.SetOllamaClient(aiClient)
@@ -558,10 +882,20 @@ func init() {
 	rootCmd.PersistentFlags().StringSliceVar(&excludeExtensions, "exclude-ext", []string{}, "File extensions to exclude (e.g., log,tmp)")
 	rootCmd.PersistentFlags().StringSliceVar(&excludePatterns, "exclude-pattern", []string{}, "File name patterns to exclude (e.g., *.bak,temp*)")
 	rootCmd.PersistentFlags().StringSliceVar(&excludeDirs, "exclude-dir", []string{}, "Directory names to exclude (e.g., .git,node_modules)")
+	rootCmd.PersistentFlags().BoolVar(&followSymlinks, "follow-symlinks", false, "Follow symbolic links into directories and files (loops are detected)")
+	rootCmd.PersistentFlags().BoolVar(&oneFileSystem, "one-file-system", false, "Stay on the file system of the scanned directory")
 
 	// Junk command flags
 	junkCmd.PersistentFlags().StringVarP(&junkCategory, "category", "c", "", "Filter by junk category (cache, logs, temp, trash, all)")
 	junkCleanCmd.Flags().BoolVarP(&forceDelete, "force", "f", false, "Permanently delete files instead of moving to trash")
+	junkCleanCmd.Flags().BoolVar(&pruneEmpty, "prune-empty", false, "Remove directories left empty by the cleanup")
+
+	// Scan command flags
+	scanCmd.Flags().BoolVar(&scanArchives, "archives", false, "List the files inside ZIP, TAR and 7z archives (never extracted)")
+
+	// Organize command flags
+	organizeCmd.Flags().BoolVar(&pruneEmpty, "prune-empty", false, "Remove directories left empty by the organize run")
+	organizeCmd.Flags().BoolVar(&scanArchives, "archives", false, "List archive contents for \"archive\" rule conditions")
 
 	// Add subcommands
 	rootCmd.AddCommand(scanCmd)
@@ -2,10 +2,15 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/config"
 )
 
 func TestCommandAliases(t *testing.T) {
@@ -106,3 +111,94 @@ func TestCommandExecutionWithAliases(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadSignatures(t *testing.T) {
+	db, err := loadSignatures([]*config.SignatureConfig{
+		{MimeType: "application/x-blender", Magic: "42 4c 45 4e 44 45 52"},
+		{MimeType: "application/x-broken", Magic: "zz"},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "application/x-broken")
+
+	// The valid signature is still added to the built-in ones
+	path := filepath.Join(t.TempDir(), "scene.blend")
+	assert.NoError(t, os.WriteFile(path, []byte("BLENDER-v293"), 0644))
+	fa := analyzer.NewAnalyzer()
+	fa.SetSignatures(db)
+	mimeType, err := fa.DetectType(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/x-blender", mimeType)
+}
+
+func TestLoadNameScorer(t *testing.T) {
+	scorer, err := loadNameScorer(&config.NamingConfig{
+		Threshold: 70,
+		Locales:   []string{"en"},
+		Patterns: []*config.NamePatternConfig{
+			{Pattern: `^scan_\d+\.pdf$`, Regex: true, Score: -80, Reason: "scanner output"},
+			nil,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 70, scorer.Threshold())
+	assert.Equal(t, analyzer.FileNameMeaningless, scorer.Score("scan_0042.pdf").Quality)
+	assert.Contains(t, scorer.Score("scan_0042.pdf").Reasons, "-80 scanner output")
+
+	_, err = loadNameScorer(&config.NamingConfig{Locales: []string{"klingon"}})
+	assert.Error(t, err)
+}
+
+func TestNewAIClient(t *testing.T) {
+	cfg := &config.CleanupConfig{
+		Ollama: config.OllamaConfig{BaseURL: "http://localhost:11434"},
+		AI: config.AIConfig{
+			Provider:  "openai",
+			Providers: []string{"Ollama", "bogus", "openai"},
+			OpenAI:    config.OpenAIConfig{BaseURL: "https://api.openai.com/v1"},
+		},
+	}
+	chain, guards := newAIClient(cfg, nil, nil)
+	stats := chain.Stats()
+	assert.Len(t, stats.Providers, 2, "unknown providers are skipped")
+	assert.Equal(t, "ollama", stats.Providers[0].Name)
+	assert.Equal(t, "openai", stats.Providers[1].Name)
+	assert.Len(t, guards, 2)
+
+	// Without a list, the single provider falls back to the offline client
+	cfg.AI.Providers = nil
+	chain, guards = newAIClient(cfg, nil, nil)
+	stats = chain.Stats()
+	assert.Len(t, stats.Providers, 2)
+	assert.Equal(t, "openai", stats.Providers[0].Name)
+	assert.Equal(t, "heuristic", stats.Providers[1].Name)
+	assert.Len(t, guards, 1, "the offline client needs no privacy guard")
+}
+
+func TestNewAICache(t *testing.T) {
+	path, maxSize, maxAge := aiCachePath, aiCacheMaxSize, aiCacheMaxAge
+	defer func() { aiCachePath, aiCacheMaxSize, aiCacheMaxAge = path, maxSize, maxAge }()
+
+	dir := t.TempDir()
+	cfg := &config.CleanupConfig{AI: config.AIConfig{Cache: &config.AICacheConfig{
+		Path:      filepath.Join(dir, "answers.json.gz"),
+		MaxSizeMB: 4,
+		MaxAge:    "30d",
+	}}}
+	store := newAICache(cfg)
+	if assert.NotNil(t, store) {
+		assert.Equal(t, filepath.Join(dir, "answers.json.gz"), store.Path())
+		assert.Equal(t, int64(4<<20), store.Stats().MaxSize)
+	}
+	assert.Equal(t, 30*24*time.Hour, aiCacheMaxAge)
+
+	cfg.AI.Cache.Disabled = true
+	assert.Nil(t, newAICache(cfg))
+	assert.Equal(t, filepath.Join(dir, "answers.json.gz"), aiCachePath, "cache commands still find a disabled cache")
+}
+
+func TestFlattenLockKeys(t *testing.T) {
+	assert.Equal(t, []string{"/data/inbox"}, flattenLockKeys("/data/inbox", ""))
+	assert.Equal(t, []string{"/data/inbox"}, flattenLockKeys("/data/inbox", "/data/inbox/all"))
+	assert.Equal(t, []string{"/data"}, flattenLockKeys("/data/inbox", "/data"))
+	assert.Equal(t, []string{"/data/inbox", "/data/inbox2"}, flattenLockKeys("/data/inbox", "/data/inbox2"))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+var namesAuditBelow bool
+
+// namesCmd represents the names command
+var namesCmd = &cobra.Command{
+	Use:   "names",
+	Short: "Inspect how filenames are scored",
+	Long: `Filenames are scored from 0 (meaningless) to 100 (clear and specific).
+Files scoring below the threshold get a smarter name from the AI when you
+organize. Placeholder words, camera and screenshot naming schemes, random
+identifiers and names made of digits lower the score; patterns in the
+"naming" section of the config file adjust it.`,
+}
+
+var namesAuditCmd = &cobra.Command{
+	Use:   "audit [path]",
+	Short: "Show the score of every filename in a directory",
+	Long: `Show the score of every filename in a directory, lowest first, with the
+reasons it lost or gained points. Files marked with * would be renamed.
+
+Examples:
+  cleanup names audit ~/Downloads
+  cleanup names audit . --below`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runNamesAudit,
+}
+
+func init() {
+	namesAuditCmd.Flags().BoolVar(&namesAuditBelow, "below", false, "Only show files scoring below the threshold")
+
+	namesCmd.AddCommand(namesAuditCmd)
+	rootCmd.AddCommand(namesCmd)
+}
+
+func runNamesAudit(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if info, err := os.Stat(absPath); err != nil {
+		return fmt.Errorf("directory not found: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", absPath)
+	}
+
+	scorer := analyzer.DefaultNameScorer()
+	if fa, ok := fileAnalyzer.(*analyzer.FileAnalyzer); ok {
+		scorer = fa.NameScorer()
+	}
+
+	// Only names are scored, so the content is not read
+	scanOpts := buildScanOptions()
+	scanOpts.SkipContent = true
+	scanOpts.CalculateHash = false
+	files, err := fileAnalyzer.AnalyzeDirectory(context.Background(), absPath, scanOpts)
+	if err != nil {
+		return fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	type audited struct {
+		rel   string
+		score analyzer.NameScore
+	}
+	var results []audited
+	counts := make(map[analyzer.FileNameQuality]int)
+	for _, file := range files {
+		score := scorer.Score(file.Name)
+		counts[score.Quality]++
+		if namesAuditBelow && score.Score >= scorer.Threshold() {
+			continue
+		}
+		rel, err := filepath.Rel(absPath, file.Path)
+		if err != nil {
+			rel = file.Path
+		}
+		results = append(results, audited{rel, score})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score.Score != results[j].score.Score {
+			return results[i].score.Score < results[j].score.Score
+		}
+		return results[i].rel < results[j].rel
+	})
+
+	fmt.Printf("Filename scores in %s (threshold %d)\n\n", absPath, scorer.Threshold())
+	for _, r := range results {
+		mark := " "
+		if r.score.Score < scorer.Threshold() {
+			mark = "*"
+		}
+		fmt.Printf("  %s %3d  %-11s  %s\n", mark, r.score.Score, r.score.Quality, r.rel)
+		if len(r.score.Reasons) > 0 {
+			fmt.Printf("                      %s\n", strings.Join(r.score.Reasons, "; "))
+		}
+	}
+
+	rename := counts[analyzer.FileNameMeaningless] + counts[analyzer.FileNameGeneric]
+	fmt.Printf("\n%d files, %d need a smarter name (%d meaningless, %d generic)\n",
+		len(files), rename, counts[analyzer.FileNameMeaningless], counts[analyzer.FileNameGeneric])
+	return nil
+}
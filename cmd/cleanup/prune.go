@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/xuanyiying/cleanup-cli/internal/cleaner"
+	"github.com/xuanyiying/cleanup-cli/internal/prune"
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+)
+
+var pruneIncludeHidden bool
+
+// pruneEmptyCmd represents the prune-empty command
+var pruneEmptyCmd = &cobra.Command{
+	Use:     "prune-empty <path>",
+	Aliases: []string{"prune"},
+	Short:   "Remove empty directories",
+	Long: `Remove every empty directory below a path, including directories that
+only contain other empty directories. The path itself is never removed.
+
+Excluded directories (--exclude-dir and the config file) and protected system
+paths are left alone. Removals are recorded so 'cleanup undo' recreates them.
+
+Examples:
+  cleanup prune-empty ~/Downloads
+  cleanup prune-empty ~/Projects --exclude-dir .git
+  cleanup prune-empty . --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPruneEmpty,
+}
+
+func init() {
+	pruneEmptyCmd.Flags().BoolVar(&pruneIncludeHidden, "hidden", false, "Also remove empty hidden directories")
+
+	rootCmd.AddCommand(pruneEmptyCmd)
+}
+
+func runPruneEmpty(cmd *cobra.Command, args []string) error {
+	absPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	if info, err := os.Stat(absPath); err != nil {
+		return fmt.Errorf("directory not found: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", absPath)
+	}
+
+	if !dryRun {
+		lock, err := lockTarget(absPath)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock()
+	}
+
+	ctx := context.Background()
+	scanOpts := buildScanOptions()
+
+	// Dry runs change nothing, so there is nothing to record
+	pruner := prune.NewPruner(txnMgr)
+	var tx *transaction.Transaction
+	if !dryRun {
+		tx = txnMgr.Begin()
+	}
+
+	result, err := pruner.PruneTree(ctx, tx, absPath, &prune.Options{
+		ExcludeDirs:   scanOpts.ExcludeDirs,
+		IncludeHidden: pruneIncludeHidden,
+		IsProtected:   cleaner.IsProtectedPath,
+		DryRun:        dryRun,
+		Traversal:     scanOpts.Traversal,
+	})
+	if err != nil {
+		if tx != nil {
+			if rbErr := txnMgr.Rollback(tx); rbErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to rollback: %v\n", rbErr)
+			}
+		}
+		return fmt.Errorf("failed to prune empty directories: %w", err)
+	}
+
+	if len(result.Removed) == 0 {
+		txnMgr.Discard(tx)
+		fmt.Println("No empty directories found")
+		return nil
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	for _, dir := range result.Removed {
+		relPath, _ := filepath.Rel(absPath, dir)
+		fmt.Printf("  %s: %s\n", verb, relPath)
+	}
+
+	for _, err := range result.Errors {
+		fmt.Fprintf(os.Stderr, "  ✗ %v\n", err)
+	}
+
+	if dryRun {
+		fmt.Printf("\n[DRY-RUN MODE] %d empty directories would be removed\n", len(result.Removed))
+		return nil
+	}
+
+	if err := txnMgr.Commit(tx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	fmt.Printf("\n✓ Removed %d empty directories\n", len(result.Removed))
+	fmt.Printf("  Transaction ID (for undo): %s\n", tx.ID)
+	return nil
+}
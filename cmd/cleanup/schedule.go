@@ -1,13 +1,15 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/xuanyiying/cleanup-cli/internal/jobs"
 	"github.com/xuanyiying/cleanup-cli/internal/scheduler"
 )
 
@@ -17,6 +19,32 @@ var (
 	scheduleInterval string
 	scheduleCommand  string
 	scheduleEnabled  bool
+	scheduleTimezone string
+	scheduleJitter   string
+	scheduleOverlap  string
+	scheduleCatchUp  string
+	scheduleRunLimit int
+	scheduleNextRuns int
+
+	// File system trigger
+	scheduleTrigger       string
+	scheduleTriggerPath   string
+	scheduleTriggerBelow  string
+	scheduleTriggerAbove  string
+	scheduleTriggerReset  string
+	scheduleTriggerPeriod string
+
+	// Built-in job parameters
+	scheduleJobs       []string
+	scheduleJobPath    string
+	scheduleRecursive  bool
+	scheduleUseAI      bool
+	schedulePrune      bool
+	scheduleCategories []string
+	scheduleKeep       string
+	scheduleMinSize    int64
+	scheduleOlderThan  string
+	scheduleReportOnly bool
 )
 
 // scheduleCmd represents the schedule command
@@ -26,18 +54,60 @@ var scheduleCmd = &cobra.Command{
 	Short:   "Manage scheduled cleanup tasks",
 	Long: `Schedule automatic cleanup tasks to run periodically.
 
-Supported intervals:
-  @hourly  - Run every hour
-  @daily   - Run every day
-  @weekly  - Run every week
-  @monthly - Run every month
-  1h       - Run every hour
-  30m      - Run every 30 minutes
-  2h30m    - Run every 2 hours and 30 minutes
+Supported schedules:
+  @hourly      - At the start of every hour
+  @daily       - Every day at midnight
+  @weekly      - Every Sunday at midnight
+  @monthly     - On the 1st of every month at midnight
+  @yearly      - On January 1st at midnight
+  0 3 * * SUN  - Cron expression (minute hour day-of-month month day-of-week)
+  1h           - Every hour after the previous run
+  30m          - Every 30 minutes after the previous run
+  2h30m        - Every 2 hours and 30 minutes after the previous run
+
+Calendar schedules use the local time zone unless --timezone is given.
+--jitter delays each run by a random amount up to the given duration.
+
+Instead of a schedule, a task can run when a file system condition is met
+(--trigger), checked every 5 minutes (--check-interval):
+  free-space   - Free space on the file system of --trigger-path drops --below
+                 a percentage or size, e.g. 10% or 5GB
+  dir-size     - Directory --trigger-path grows --above a size, e.g. 20GB
+After firing, a trigger waits until the level recovers past --reset (default
+5 points or 10% past the threshold) before it can fire again.
+
+--overlap decides what happens when a run is due while the previous one is
+still running: skip it (default), queue it, or replace the running one.
+--catch-up decides what happens to runs missed while the machine was asleep
+or the daemon was stopped: skip them (default) or run once right away.
+Jobs never run at the same time as a manual command on the same directory;
+such runs are recorded as skipped.
+
+A task runs either a shell command (--command) or built-in jobs (--job, run
+in the given order while they succeed) that run inside the daemon and record
+what they changed:
+  organize     - Organize --path by the configured rules
+  junk-clean   - Move junk files (--categories, default all) to the trash
+  dedup        - Move duplicate files in --path to the trash, keeping one copy
+  trash-empty  - Permanently delete trash entries (--older-than, e.g. 30d)
+With --report-only a job only reports what it would change.
+
+Tasks are stored in ~/.cleanup/schedule.yaml and run by 'cleanup daemon', or
+by systemd timers or cron after 'cleanup schedule export' / 'install'.
 
 Examples:
   cleanup schedule add --id daily-cleanup --name "Daily Cleanup" --interval @daily --command "cleanup organize ~/Downloads"
+  cleanup schedule add --id tidy-downloads --name "Tidy Downloads" --interval @daily --job organize --path ~/Downloads
+  cleanup schedule add --id dup-report --name "Duplicate Report" --interval @weekly --job dedup --path ~/Pictures --report-only
+  cleanup schedule add --id purge-trash --name "Purge Trash" --interval @monthly --job trash-empty --older-than 30d --catch-up run-once
+  cleanup schedule add --id weekly-junk --name "Weekly Junk" --interval "0 3 * * SUN" --timezone Europe/Berlin --jitter 15m --command "cleanup junk clean"
+  cleanup schedule add --id low-disk --name "Low Disk" --trigger free-space --trigger-path / --below 10% --job junk-clean,trash-empty --categories cache,temp --older-than 7d
+  cleanup schedule add --id big-downloads --name "Big Downloads" --trigger dir-size --trigger-path ~/Downloads --above 20GB --job dedup --path ~/Downloads
   cleanup schedule list
+  cleanup schedule next weekly-junk -n 5
+  cleanup schedule runs daily-cleanup
+  cleanup schedule export --format crontab
+  cleanup schedule install --systemd
   cleanup schedule enable daily-cleanup
   cleanup schedule disable daily-cleanup
   cleanup schedule remove daily-cleanup`,
@@ -78,6 +148,20 @@ var scheduleRemoveCmd = &cobra.Command{
 	RunE:    runScheduleRemove,
 }
 
+var scheduleRunsCmd = &cobra.Command{
+	Use:   "runs [task-id]",
+	Short: "Show recent runs of a scheduled task",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runScheduleRuns,
+}
+
+var scheduleNextCmd = &cobra.Command{
+	Use:   "next [task-id]",
+	Short: "Preview the upcoming run times of a scheduled task",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runScheduleNext,
+}
+
 func init() {
 	// Add subcommands
 	scheduleCmd.AddCommand(scheduleAddCmd)
@@ -85,94 +169,155 @@ func init() {
 	scheduleCmd.AddCommand(scheduleEnableCmd)
 	scheduleCmd.AddCommand(scheduleDisableCmd)
 	scheduleCmd.AddCommand(scheduleRemoveCmd)
+	scheduleCmd.AddCommand(scheduleRunsCmd)
+	scheduleCmd.AddCommand(scheduleNextCmd)
 
 	// Add flags
 	scheduleAddCmd.Flags().StringVar(&scheduleID, "id", "", "Task ID (required)")
 	scheduleAddCmd.Flags().StringVar(&scheduleName, "name", "", "Task name (required)")
-	scheduleAddCmd.Flags().StringVar(&scheduleInterval, "interval", "", "Schedule interval (required)")
-	scheduleAddCmd.Flags().StringVar(&scheduleCommand, "command", "", "Command to run (required)")
+	scheduleAddCmd.Flags().StringVar(&scheduleInterval, "interval", "", "Schedule interval, @descriptor or cron expression (or use --trigger)")
+	scheduleAddCmd.Flags().StringVar(&scheduleCommand, "command", "", "Shell command to run (or use --job)")
 	scheduleAddCmd.Flags().BoolVar(&scheduleEnabled, "enabled", true, "Enable task immediately")
+	scheduleAddCmd.Flags().StringVar(&scheduleTimezone, "timezone", "", "Time zone for calendar schedules, e.g. Europe/Berlin (default: local)")
+	scheduleAddCmd.Flags().StringVar(&scheduleJitter, "jitter", "", "Delay each run by a random amount up to this duration, e.g. 10m")
+
+	scheduleAddCmd.Flags().StringVar(&scheduleOverlap, "overlap", "skip", "When the previous run is still going: skip, queue, replace")
+	scheduleAddCmd.Flags().StringVar(&scheduleCatchUp, "catch-up", "skip", "Missed runs: skip, run-once")
+	scheduleAddCmd.Flags().StringSliceVar(&scheduleJobs, "job", nil, "Built-in jobs to run in order: organize, junk-clean, dedup, trash-empty")
+	scheduleAddCmd.Flags().StringVar(&scheduleTrigger, "trigger", "", "Run when a condition is met instead of on a schedule: free-space, dir-size")
+	scheduleAddCmd.Flags().StringVar(&scheduleTriggerPath, "trigger-path", "", "File system (free-space) or directory (dir-size) the trigger watches")
+	scheduleAddCmd.Flags().StringVar(&scheduleTriggerBelow, "below", "", "free-space trigger threshold, e.g. 10% or 5GB")
+	scheduleAddCmd.Flags().StringVar(&scheduleTriggerAbove, "above", "", "dir-size trigger limit, e.g. 20GB")
+	scheduleAddCmd.Flags().StringVar(&scheduleTriggerReset, "reset", "", "Level at which a fired trigger re-arms (default: 5 points or 10% past the threshold)")
+	scheduleAddCmd.Flags().StringVar(&scheduleTriggerPeriod, "check-interval", "", "How often the trigger condition is checked (default 5m)")
+	scheduleAddCmd.Flags().StringVar(&scheduleJobPath, "path", "", "Directory for organize and dedup jobs")
+	scheduleAddCmd.Flags().BoolVarP(&scheduleRecursive, "recursive", "r", false, "Organize subdirectories too")
+	scheduleAddCmd.Flags().BoolVar(&scheduleUseAI, "ai", false, "Let organize jobs ask the AI model for suggestions")
+	scheduleAddCmd.Flags().BoolVar(&schedulePrune, "prune-empty", false, "Remove directories emptied by organize and junk-clean jobs")
+	scheduleAddCmd.Flags().StringSliceVar(&scheduleCategories, "categories", nil, "Junk categories for junk-clean jobs (default: all)")
+	scheduleAddCmd.Flags().StringVar(&scheduleKeep, "keep", "newest", "Which duplicate dedup jobs keep: newest, oldest, first")
+	scheduleAddCmd.Flags().Int64Var(&scheduleMinSize, "min-size", 1024, "Minimum file size in bytes for dedup jobs")
+	scheduleAddCmd.Flags().StringVar(&scheduleOlderThan, "older-than", "", "Only empty trash entries older than this, e.g. 30d or 12h")
+	scheduleAddCmd.Flags().BoolVar(&scheduleReportOnly, "report-only", false, "Dry run: only report what the job would change")
+
+	scheduleRunsCmd.Flags().IntVarP(&scheduleRunLimit, "limit", "n", 10, "Number of runs to show")
+	scheduleNextCmd.Flags().IntVarP(&scheduleNextRuns, "count", "n", 5, "Number of run times to show")
 
 	scheduleAddCmd.MarkFlagRequired("id")
 	scheduleAddCmd.MarkFlagRequired("name")
-	scheduleAddCmd.MarkFlagRequired("interval")
-	scheduleAddCmd.MarkFlagRequired("command")
+	scheduleAddCmd.MarkFlagsOneRequired("interval", "trigger")
+	scheduleAddCmd.MarkFlagsMutuallyExclusive("interval", "trigger")
+	scheduleAddCmd.MarkFlagsMutuallyExclusive("command", "job")
 
 	rootCmd.AddCommand(scheduleCmd)
 }
 
 func runScheduleAdd(cmd *cobra.Command, args []string) error {
-	// Create scheduler
-	sched := scheduler.NewScheduler()
-	defer sched.Stop()
-
-	// Create task
-	task := &scheduler.Task{
+	task := &scheduler.TaskConfig{
 		ID:       scheduleID,
 		Name:     scheduleName,
 		Schedule: scheduleInterval,
+		Timezone: scheduleTimezone,
+		Jitter:   scheduleJitter,
+		Overlap:  scheduleOverlap,
+		CatchUp:  scheduleCatchUp,
 		Command:  scheduleCommand,
 		Enabled:  scheduleEnabled,
 	}
 
-	// Create task function
-	taskFn := func(ctx context.Context) error {
-		fmt.Printf("Running scheduled task: %s\n", task.Name)
-		
-		// Execute command
-		cmd := exec.CommandContext(ctx, "sh", "-c", task.Command)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		
-		return cmd.Run()
+	if scheduleTrigger != "" {
+		trigger, err := buildScheduleTrigger()
+		if err != nil {
+			return err
+		}
+		task.Trigger = trigger
+		// Catch-up only applies to schedules
+		if !cmd.Flags().Changed("catch-up") {
+			task.CatchUp = ""
+		}
+	}
+
+	for i, kind := range scheduleJobs {
+		job, err := buildScheduleJob(kind)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			task.Job = job
+		} else {
+			task.Then = append(task.Then, job)
+		}
 	}
 
-	// Add task
-	if err := sched.AddTask(task, taskFn); err != nil {
+	if err := scheduleStore.Add(task); err != nil {
 		return fmt.Errorf("failed to add task: %w", err)
 	}
 
 	fmt.Printf("✓ Task '%s' added successfully\n", task.Name)
 	fmt.Printf("  ID: %s\n", task.ID)
-	fmt.Printf("  Schedule: %s\n", task.Schedule)
-	fmt.Printf("  Command: %s\n", task.Command)
+	fmt.Printf("  Schedule: %s\n", describeSchedule(task))
+	if task.Job != nil {
+		fmt.Printf("  Job: %s\n", describeJob(task.Job))
+		for _, job := range task.Then {
+			fmt.Printf("  Then: %s\n", describeJob(job))
+		}
+	} else {
+		fmt.Printf("  Command: %s\n", task.Command)
+	}
 	fmt.Printf("  Enabled: %v\n", task.Enabled)
-	fmt.Printf("  Next run: %s\n", task.NextRun.Format("2006-01-02 15:04:05"))
+	if schedule, err := task.ParsedSchedule(); err == nil {
+		if nextRun := schedule.Next(time.Now()); !nextRun.IsZero() {
+			fmt.Printf("  Next run: %s (while 'cleanup daemon' is running)\n", nextRun.Format("2006-01-02 15:04:05 MST"))
+		}
+	}
 
 	return nil
 }
 
 func runScheduleList(cmd *cobra.Command, args []string) error {
-	// Create scheduler
-	sched := scheduler.NewScheduler()
-	defer sched.Stop()
-
-	// Get tasks
-	tasks := sched.ListTasks()
+	cfg, err := scheduleStore.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load scheduled tasks: %w", err)
+	}
 
-	if len(tasks) == 0 {
+	if len(cfg.Tasks) == 0 {
 		fmt.Println("No scheduled tasks found")
 		return nil
 	}
 
 	// Display tasks in table format
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tNAME\tSCHEDULE\tENABLED\tRUN COUNT\tNEXT RUN")
-	fmt.Fprintln(w, "---\t----\t--------\t-------\t---------\t--------")
+	fmt.Fprintln(w, "ID\tNAME\tSCHEDULE\tENABLED\tLAST RUN\tSTATUS\tNEXT RUN")
+	fmt.Fprintln(w, "---\t----\t--------\t-------\t--------\t------\t--------")
 
-	for _, task := range tasks {
+	now := time.Now()
+	for _, task := range cfg.Tasks {
 		enabled := "No"
 		if task.Enabled {
 			enabled = "Yes"
 		}
 
+		lastRun, status := "Never", "-"
+		var lastStart time.Time
+		if last, err := runHistory.LastRun(task.ID); err == nil && last != nil {
+			lastStart = last.StartedAt
+			lastRun = last.StartedAt.Format("2006-01-02 15:04")
+			status = string(last.Status)
+		}
+
 		nextRun := "N/A"
-		if !task.NextRun.IsZero() {
-			nextRun = task.NextRun.Format("2006-01-02 15:04")
+		if task.Enabled && task.Trigger != nil {
+			nextRun = "on trigger"
+		} else if task.Enabled {
+			if schedule, err := task.ParsedSchedule(); err == nil {
+				if next := scheduler.NextRunAfter(schedule, lastStart, now); !next.IsZero() {
+					nextRun = next.Format("2006-01-02 15:04")
+				}
+			}
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
-			task.ID, task.Name, task.Schedule, enabled, task.RunCount, nextRun)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			task.ID, task.Name, describeSchedule(task), enabled, lastRun, status, nextRun)
 	}
 
 	w.Flush()
@@ -182,11 +327,7 @@ func runScheduleList(cmd *cobra.Command, args []string) error {
 func runScheduleEnable(cmd *cobra.Command, args []string) error {
 	taskID := args[0]
 
-	// Create scheduler
-	sched := scheduler.NewScheduler()
-	defer sched.Stop()
-
-	if err := sched.EnableTask(taskID); err != nil {
+	if err := scheduleStore.SetEnabled(taskID, true); err != nil {
 		return fmt.Errorf("failed to enable task: %w", err)
 	}
 
@@ -197,11 +338,7 @@ func runScheduleEnable(cmd *cobra.Command, args []string) error {
 func runScheduleDisable(cmd *cobra.Command, args []string) error {
 	taskID := args[0]
 
-	// Create scheduler
-	sched := scheduler.NewScheduler()
-	defer sched.Stop()
-
-	if err := sched.DisableTask(taskID); err != nil {
+	if err := scheduleStore.SetEnabled(taskID, false); err != nil {
 		return fmt.Errorf("failed to disable task: %w", err)
 	}
 
@@ -212,6 +349,10 @@ func runScheduleDisable(cmd *cobra.Command, args []string) error {
 func runScheduleRemove(cmd *cobra.Command, args []string) error {
 	taskID := args[0]
 
+	if _, err := scheduleStore.Get(taskID); err != nil {
+		return fmt.Errorf("failed to remove task: %w", err)
+	}
+
 	// Confirm removal
 	fmt.Printf("Remove task '%s'? (y/n): ", taskID)
 	var response string
@@ -221,14 +362,230 @@ func runScheduleRemove(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Create scheduler
-	sched := scheduler.NewScheduler()
-	defer sched.Stop()
-
-	if err := sched.RemoveTask(taskID); err != nil {
+	if err := scheduleStore.Remove(taskID); err != nil {
 		return fmt.Errorf("failed to remove task: %w", err)
 	}
 
 	fmt.Printf("✓ Task '%s' removed\n", taskID)
 	return nil
 }
+
+func runScheduleRuns(cmd *cobra.Command, args []string) error {
+	taskID := args[0]
+
+	if _, err := scheduleStore.Get(taskID); err != nil {
+		return err
+	}
+
+	runs, err := runHistory.Runs(taskID, scheduleRunLimit)
+	if err != nil {
+		return fmt.Errorf("failed to load run history: %w", err)
+	}
+
+	if len(runs) == 0 {
+		fmt.Printf("Task '%s' has not run yet\n", taskID)
+		return nil
+	}
+
+	// Newest first
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+
+		icon := "✗"
+		switch run.Status {
+		case scheduler.RunSucceeded:
+			icon = "✓"
+		case scheduler.RunSkipped:
+			icon = "⏭"
+		}
+
+		fmt.Printf("%s %s  %s  %s  exit %d\n",
+			icon, run.StartedAt.Format("2006-01-02 15:04:05"), run.Status,
+			run.Duration.Round(time.Millisecond), run.ExitCode)
+		if run.Trigger != "" {
+			fmt.Printf("    Triggered: %s\n", run.Trigger)
+		}
+		if run.Error != "" {
+			label := "Error"
+			if run.Status == scheduler.RunSkipped {
+				label = "Reason"
+			}
+			fmt.Printf("    %s: %s\n", label, run.Error)
+		}
+		if run.Result != nil {
+			printJobResult(run.Result, len(run.Then) > 0)
+			for _, result := range run.Then {
+				printJobResult(result, true)
+			}
+		}
+		if output := strings.TrimSpace(run.Output); output != "" {
+			for _, line := range strings.Split(output, "\n") {
+				fmt.Printf("    │ %s\n", line)
+			}
+		}
+	}
+
+	return nil
+}
+
+func runScheduleNext(cmd *cobra.Command, args []string) error {
+	task, err := scheduleStore.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	if task.Trigger != nil {
+		fmt.Printf("Task '%s' runs %s, not on a schedule\n", task.ID, describeSchedule(task))
+		return nil
+	}
+
+	schedule, err := task.ParsedSchedule()
+	if err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+
+	// Interval schedules continue from the last recorded run
+	var lastStart time.Time
+	if last, err := runHistory.LastRun(task.ID); err == nil && last != nil {
+		lastStart = last.StartedAt
+	}
+
+	var runs []time.Time
+	if first := scheduler.NextRunAfter(schedule, lastStart, time.Now()); !first.IsZero() && scheduleNextRuns > 0 {
+		runs = append([]time.Time{first}, scheduler.UpcomingRuns(schedule, first, scheduleNextRuns-1)...)
+	}
+
+	if len(runs) == 0 {
+		fmt.Printf("Task '%s' has no upcoming runs\n", task.ID)
+		return nil
+	}
+
+	fmt.Printf("Upcoming runs of '%s' (%s):\n", task.ID, describeSchedule(task))
+	for _, run := range runs {
+		fmt.Printf("  %s\n", run.Format("Mon 2006-01-02 15:04 MST"))
+	}
+	if task.Jitter != "" {
+		fmt.Printf("\n  Each run may start up to %s later (jitter)\n", task.Jitter)
+	}
+	if !task.Enabled {
+		fmt.Println("\n  Note: task is disabled")
+	}
+
+	return nil
+}
+
+// describeSchedule formats a task's schedule with its time zone, or its trigger
+func describeSchedule(task *scheduler.TaskConfig) string {
+	if task.Trigger != nil {
+		return "when " + task.Trigger.String()
+	}
+	if task.Timezone == "" {
+		return task.Schedule
+	}
+	return fmt.Sprintf("%s %s", task.Schedule, task.Timezone)
+}
+
+// buildScheduleTrigger builds the file system trigger configured by the add flags
+func buildScheduleTrigger() (*scheduler.TriggerConfig, error) {
+	trigger := &scheduler.TriggerConfig{
+		Kind:     scheduler.TriggerKind(scheduleTrigger),
+		Below:    scheduleTriggerBelow,
+		Above:    scheduleTriggerAbove,
+		Reset:    scheduleTriggerReset,
+		Interval: scheduleTriggerPeriod,
+	}
+	if scheduleTriggerPath != "" {
+		// The daemon may run from any directory
+		absPath, err := filepath.Abs(scheduleTriggerPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve trigger path: %w", err)
+		}
+		trigger.Path = absPath
+	}
+
+	if err := trigger.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid trigger: %w", err)
+	}
+	return trigger, nil
+}
+
+// buildScheduleJob builds a built-in job of the given kind configured by the add flags
+func buildScheduleJob(kind string) (*scheduler.JobConfig, error) {
+	job := &scheduler.JobConfig{
+		Kind:       scheduler.JobKind(kind),
+		Policy:     scheduler.PolicyApply,
+		Recursive:  scheduleRecursive,
+		UseAI:      scheduleUseAI,
+		PruneEmpty: schedulePrune,
+		Categories: scheduleCategories,
+		OlderThan:  scheduleOlderThan,
+	}
+	if job.Kind != scheduler.JobJunkClean {
+		job.Categories = nil
+	}
+	if job.Kind != scheduler.JobTrashEmpty {
+		job.OlderThan = ""
+	}
+	if scheduleReportOnly {
+		job.Policy = scheduler.PolicyReport
+	}
+
+	switch job.Kind {
+	case scheduler.JobOrganize, scheduler.JobDedup:
+		if scheduleJobPath != "" {
+			// The daemon may run from any directory
+			absPath, err := filepath.Abs(scheduleJobPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve path: %w", err)
+			}
+			job.Path = absPath
+		}
+	}
+	if job.Kind == scheduler.JobDedup {
+		job.Keep = scheduleKeep
+		job.MinSize = scheduleMinSize
+	}
+
+	if err := jobs.Validate(job); err != nil {
+		return nil, fmt.Errorf("invalid job: %w", err)
+	}
+	return job, nil
+}
+
+// describeJob formats a built-in job and its parameters
+func describeJob(job *scheduler.JobConfig) string {
+	parts := []string{string(job.Kind)}
+	if job.Path != "" {
+		parts = append(parts, job.Path)
+	}
+	if len(job.Categories) > 0 {
+		parts = append(parts, "categories="+strings.Join(job.Categories, ","))
+	}
+	if job.OlderThan != "" {
+		parts = append(parts, "older-than="+job.OlderThan)
+	}
+	if job.ReportOnly() {
+		parts = append(parts, "(report only)")
+	}
+	return strings.Join(parts, " ")
+}
+
+// printJobResult prints what a built-in job of a run changed, prefixed with
+// the job kind if the run had several jobs
+func printJobResult(result *scheduler.JobResult, withKind bool) {
+	verb := "Touched"
+	if result.DryRun {
+		verb = "Would touch"
+	}
+	prefix := ""
+	if withKind {
+		prefix = string(result.Kind) + ": "
+	}
+	fmt.Printf("    %s%s %d files, freed %d bytes\n", prefix, verb, result.FilesTouched, result.BytesFreed)
+	if result.FilesSkipped > 0 {
+		fmt.Printf("    Skipped %d files for review\n", result.FilesSkipped)
+	}
+	for _, id := range result.TransactionIDs {
+		fmt.Printf("    Transaction: %s (undo with 'cleanup undo %s')\n", id, id)
+	}
+}
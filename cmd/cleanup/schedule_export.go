@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xuanyiying/cleanup-cli/internal/scheduler"
+	"github.com/xuanyiying/cleanup-cli/pkg/filelock"
+)
+
+var (
+	scheduleRunJitter     bool
+	scheduleExportFormat  string
+	scheduleInstallSystem bool
+	scheduleInstallEnable bool
+)
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run [task-id]",
+	Short: "Run a scheduled task once, now",
+	Long: `Run a scheduled task once in the foreground and record the run.
+
+This is what exported systemd timers and crontab entries call. A run is
+skipped if the same task is still running in another process. With --jitter
+the run first waits a random time up to the task's jitter.
+
+Examples:
+  cleanup schedule run tidy-downloads
+  cleanup schedule run --jitter weekly-junk`,
+	Args: cobra.ExactArgs(1),
+	RunE: runScheduleRun,
+}
+
+var scheduleExportCmd = &cobra.Command{
+	Use:   "export [task-id...]",
+	Short: "Export scheduled tasks as systemd units or crontab entries",
+	Long: `Print the scheduled tasks (all enabled tasks by default) as systemd user
+units or crontab entries that run them with 'cleanup schedule run'.
+
+Timers and crontab entries fire at the same times as the daemon would.
+Settings the target can't express are noted in comments: cron has no
+catch-up, and neither supports the queue or replace overlap policies.
+Interval schedules become clock-aligned in cron and must divide an hour or
+a day.
+
+Examples:
+  cleanup schedule export --format systemd
+  cleanup schedule export --format crontab >> mycrontab
+  cleanup schedule export --format crontab weekly-junk`,
+	RunE: runScheduleExport,
+}
+
+var scheduleInstallCmd = &cobra.Command{
+	Use:   "install [task-id...]",
+	Short: "Install scheduled tasks as systemd user timers",
+	Long: `Write a .service and .timer unit for each scheduled task (all enabled
+tasks by default) into ~/.config/systemd/user. With --enable the timers are
+started right away; otherwise the systemctl commands to do so are printed.
+
+Don't also run 'cleanup daemon' for installed tasks, or they run twice.
+
+Examples:
+  cleanup schedule install --systemd
+  cleanup schedule install --systemd --enable tidy-downloads`,
+	RunE: runScheduleInstall,
+}
+
+func init() {
+	scheduleCmd.AddCommand(scheduleRunCmd)
+	scheduleCmd.AddCommand(scheduleExportCmd)
+	scheduleCmd.AddCommand(scheduleInstallCmd)
+
+	scheduleRunCmd.Flags().BoolVar(&scheduleRunJitter, "jitter", false, "Wait a random time up to the task's jitter first")
+	scheduleExportCmd.Flags().StringVar(&scheduleExportFormat, "format", "systemd", "Output format: systemd, crontab")
+	scheduleInstallCmd.Flags().BoolVar(&scheduleInstallSystem, "systemd", false, "Install systemd user units (required)")
+	scheduleInstallCmd.Flags().BoolVar(&scheduleInstallEnable, "enable", false, "Reload systemd and start the timers")
+	scheduleInstallCmd.MarkFlagRequired("systemd")
+}
+
+func runScheduleRun(cmd *cobra.Command, args []string) error {
+	task, err := scheduleStore.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if scheduleRunJitter {
+		jitter, err := task.JitterDuration()
+		if err != nil {
+			return fmt.Errorf("invalid jitter: %w", err)
+		}
+		if jitter > 0 {
+			select {
+			case <-time.After(time.Duration(rand.Int63n(int64(jitter)))):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	var fn scheduler.TaskFunc
+	lock, err := filelock.TryLockProcess(lockDir, "task:"+task.ID)
+	switch {
+	case errors.Is(err, filelock.ErrLocked):
+		// Same as the skip overlap policy of the daemon
+		lockErr := err
+		fn = func(context.Context) error {
+			return fmt.Errorf("%w: previous run still in progress: %v", scheduler.ErrSkipRun, lockErr)
+		}
+	case err != nil:
+		return err
+	default:
+		defer lock.Unlock()
+		if fn, err = scheduler.TaskFuncFor(task, newJobRunner(), os.Stdout); err != nil {
+			return err
+		}
+	}
+
+	record := scheduler.RunNow(ctx, task.ID, fn, runHistory)
+	switch record.Status {
+	case scheduler.RunSucceeded:
+		fmt.Printf("✓ Task '%s' finished in %s\n", task.ID, record.Duration.Round(time.Millisecond))
+	case scheduler.RunSkipped:
+		fmt.Printf("⏭ Task '%s' skipped: %s\n", task.ID, record.Error)
+	default:
+		return fmt.Errorf("task %s %s: %s", task.ID, record.Status, record.Error)
+	}
+	return nil
+}
+
+func runScheduleExport(cmd *cobra.Command, args []string) error {
+	if scheduleExportFormat != "systemd" && scheduleExportFormat != "crontab" {
+		return fmt.Errorf("unknown format %q (use systemd or crontab)", scheduleExportFormat)
+	}
+
+	tasks, err := exportTasks(args)
+	if err != nil {
+		return err
+	}
+	executable, err := cleanupExecutable()
+	if err != nil {
+		return err
+	}
+
+	if scheduleExportFormat == "crontab" {
+		// CRON_TZ= applies to every following entry, so tasks in the local
+		// time zone go first
+		sort.SliceStable(tasks, func(i, j int) bool {
+			return tasks[i].Timezone == "" && tasks[j].Timezone != ""
+		})
+		for i, task := range tasks {
+			entry, err := scheduler.ExportCrontab(task, executable)
+			if err != nil {
+				return fmt.Errorf("failed to export task %s: %w", task.ID, err)
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Print(entry)
+		}
+		return nil
+	}
+
+	for i, task := range tasks {
+		units, err := scheduler.ExportSystemd(task, executable)
+		if err != nil {
+			return fmt.Errorf("failed to export task %s: %w", task.ID, err)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("### %s.service\n%s\n### %s.timer\n%s", units.Name, units.Service, units.Name, units.Timer)
+	}
+	return nil
+}
+
+func runScheduleInstall(cmd *cobra.Command, args []string) error {
+	tasks, err := exportTasks(args)
+	if err != nil {
+		return err
+	}
+	executable, err := cleanupExecutable()
+	if err != nil {
+		return err
+	}
+
+	unitDir, err := systemdUserDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(unitDir, 0755); err != nil {
+		return fmt.Errorf("failed to create unit directory: %w", err)
+	}
+
+	var timers []string
+	for _, task := range tasks {
+		units, err := scheduler.ExportSystemd(task, executable)
+		if err != nil {
+			return fmt.Errorf("failed to export task %s: %w", task.ID, err)
+		}
+		files := []struct{ name, content string }{
+			{units.Name + ".service", units.Service},
+			{units.Name + ".timer", units.Timer},
+		}
+		for _, file := range files {
+			if err := os.WriteFile(filepath.Join(unitDir, file.name), []byte(file.content), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", file.name, err)
+			}
+		}
+		timers = append(timers, units.Name+".timer")
+		fmt.Printf("✓ Installed %s (%s)\n", units.Name, describeSchedule(task))
+	}
+	fmt.Printf("\nUnits written to %s\n", unitDir)
+
+	commands := [][]string{
+		{"systemctl", "--user", "daemon-reload"},
+		append([]string{"systemctl", "--user", "enable", "--now"}, timers...),
+	}
+	if !scheduleInstallEnable {
+		fmt.Println("\nStart the timers with:")
+		for _, command := range commands {
+			fmt.Printf("  %s\n", strings.Join(command, " "))
+		}
+		return nil
+	}
+
+	for _, command := range commands {
+		c := exec.Command(command[0], command[1:]...)
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("failed to run %s: %w", strings.Join(command, " "), err)
+		}
+	}
+	fmt.Printf("✓ Started %d timer(s); see 'systemctl --user list-timers'\n", len(timers))
+	return nil
+}
+
+// exportTasks returns the named tasks, or all enabled tasks if none are named
+func exportTasks(ids []string) ([]*scheduler.TaskConfig, error) {
+	if len(ids) > 0 {
+		tasks := make([]*scheduler.TaskConfig, 0, len(ids))
+		for _, id := range ids {
+			task, err := scheduleStore.Get(id)
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, task)
+		}
+		return tasks, nil
+	}
+
+	cfg, err := scheduleStore.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scheduled tasks: %w", err)
+	}
+
+	var tasks []*scheduler.TaskConfig
+	for _, task := range cfg.Tasks {
+		if !task.Enabled {
+			fmt.Fprintf(os.Stderr, "Skipping disabled task '%s'\n", task.ID)
+			continue
+		}
+		if task.Trigger != nil {
+			fmt.Fprintf(os.Stderr, "Skipping task '%s': triggered tasks only run in 'cleanup daemon'\n", task.ID)
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("no enabled scheduled tasks to export")
+	}
+	return tasks, nil
+}
+
+// cleanupExecutable returns the absolute path of the running binary, which
+// the generated units and entries call
+func cleanupExecutable() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cleanup executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	return executable, nil
+}
+
+// systemdUserDir returns the directory for systemd user units
+func systemdUserDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "systemd", "user"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "systemd", "user"), nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/xuanyiying/cleanup-cli/internal/cleaner"
+	"github.com/xuanyiying/cleanup-cli/internal/organizer"
+	"github.com/xuanyiying/cleanup-cli/internal/prune"
+	"github.com/xuanyiying/cleanup-cli/internal/watcher"
+)
+
+var (
+	watchRecursive bool
+	watchSettle    time.Duration
+	watchBatch     time.Duration
+	watchNoAI      bool
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:     "watch <dir>",
+	Aliases: []string{"w"},
+	Short:   "Organize new files as they arrive",
+	Long: `Watch a directory and organize new or changed files with the configured rules.
+
+A file is only organized once it has settled: its size stays the same for the
+settle time, no download companion (.part, .crdownload, .tmp) exists next to
+it, and no process has it open for writing. Settled files are organized in
+batches, and every batch is recorded so 'cleanup undo' can revert it.
+
+Relative rule targets are created inside the watched directory. Press Ctrl+C
+to stop; a batch in progress is finished first.
+
+Examples:
+  cleanup watch ~/Downloads
+  cleanup watch ~/Downloads --recursive --settle 10s
+  cleanup watch ~/Desktop --no-ai --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().BoolVarP(&watchRecursive, "recursive", "r", false, "Also watch subdirectories")
+	watchCmd.Flags().DurationVar(&watchSettle, "settle", watcher.DefaultSettleTime, "How long a file must stay unchanged before it is organized")
+	watchCmd.Flags().DurationVar(&watchBatch, "batch", watcher.DefaultBatchWindow, "How long settled files are collected before organizing them")
+	watchCmd.Flags().BoolVar(&watchNoAI, "no-ai", false, "Don't ask the AI for names or categories")
+	watchCmd.Flags().BoolVar(&pruneEmpty, "prune-empty", false, "Remove directories left empty by each batch")
+
+	rootCmd.AddCommand(watchCmd)
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	absPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	if info, err := os.Stat(absPath); err != nil {
+		return fmt.Errorf("directory not found: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", absPath)
+	}
+
+	// Keep scheduled jobs and other commands out of the directory while
+	// files are organized as they arrive
+	if !dryRun {
+		lock, err := lockTarget(absPath)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	scanOpts := buildScanOptions()
+	scanOpts.Recursive = watchRecursive
+
+	strategy := &organizer.OrganizeStrategy{
+		UseAI:            !watchNoAI,
+		CreateFolders:    true,
+		ConflictStrategy: organizer.ConflictSuffix,
+		DryRun:           dryRun,
+		MaxConcurrency:   4,
+		PruneEmptyDirs:   pruneEmpty,
+		PruneRoot:        absPath,
+		PruneOptions: &prune.Options{
+			ExcludeDirs: scanOpts.ExcludeDirs,
+			IsProtected: cleaner.IsProtectedPath,
+		},
+		BaseDir: absPath,
+	}
+
+	w := watcher.NewWatcher(fileAnalyzer, fileOrganizer, &watcher.Options{
+		ScanOptions: scanOpts,
+		Strategy:    strategy,
+		SettleTime:  watchSettle,
+		BatchWindow: watchBatch,
+		OnBatch:     printWatchBatch,
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		},
+	})
+
+	fmt.Printf("👀 Watching %s (Ctrl+C to stop)\n", absPath)
+	if dryRun {
+		fmt.Println("[DRY-RUN MODE] Planned operations are shown but not executed")
+	}
+
+	if err := w.Run(ctx, absPath); err != nil {
+		return fmt.Errorf("failed to watch directory: %w", err)
+	}
+
+	fmt.Println("\nStopped watching")
+	return nil
+}
+
+// printWatchBatch prints the outcome of one watch batch
+func printWatchBatch(batch *watcher.Batch) {
+	fmt.Printf("\n[%s] %d settled file(s)\n", time.Now().Format("15:04:05"), len(batch.Files))
+
+	if batch.Err != nil {
+		fmt.Fprintf(os.Stderr, "  ✗ %v\n", batch.Err)
+	}
+	if batch.Plan == nil {
+		return
+	}
+
+	if dryRun {
+		for _, op := range batch.Plan.Operations {
+			fmt.Printf("  Would %s: %s → %s\n", op.Type, filepath.Base(op.Source), op.Target)
+		}
+		return
+	}
+
+	if batch.Result == nil {
+		return
+	}
+	if len(batch.Result.PrunedDirs) > 0 {
+		fmt.Printf("  🗑  Empty dirs: %d removed\n", len(batch.Result.PrunedDirs))
+	}
+	for _, txnID := range batch.Result.TransactionIDs {
+		fmt.Printf("  Transaction ID (for undo): %s\n", txnID)
+	}
+}
@@ -0,0 +1,192 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+// ErrMalformed is returned when a model's answer does not match
+// FileAnalysisSchema, even after asking again
+var ErrMalformed = errors.New("malformed AI answer")
+
+// maxAnalysisAttempts is how many times a file is sent before a malformed
+// answer is given up on
+const maxAnalysisAttempts = 3
+
+// maxReasonLen is the length of the longest reason kept, in characters
+const maxReasonLen = 160
+
+// FileAnalysis is the answer to a structured analysis of a file: a name
+// and a category with how sure the model is of them and why
+type FileAnalysis struct {
+	Name       string  `json:"name"`       // Suggested filename without extension
+	Category   string  `json:"category"`   // One of ScenarioCategories
+	Confidence float64 `json:"confidence"` // From 0 to 1
+	Language   string  `json:"language"`   // ISO 639-1 code of the content, "" if unknown
+	Reason     string  `json:"reason"`     // One line on why the name and category fit
+}
+
+// FileAnalysisSchema returns the JSON schema of a FileAnalysis answer. It
+// keeps to the subset of JSON Schema that OpenAI's strict mode accepts, so
+// ranges are checked by ParseFileAnalysis instead.
+func FileAnalysisSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Descriptive filename: lowercase letters, digits and hyphens, no extension",
+			},
+			"category": map[string]any{
+				"type": "string",
+				"enum": ScenarioCategories,
+			},
+			"confidence": map[string]any{
+				"type":        "number",
+				"description": "How sure you are of the name and category, from 0 to 1",
+			},
+			"language": map[string]any{
+				"type":        "string",
+				"description": "ISO 639-1 code of the content's language, such as en or zh; empty if there is no text",
+			},
+			"reason": map[string]any{
+				"type":        "string",
+				"description": "One short sentence on what the file is and why the name and category fit",
+			},
+		},
+		"required":             []string{"name", "category", "confidence", "language", "reason"},
+		"additionalProperties": false,
+	}
+}
+
+// GenerateFileAnalysisPrompt creates a prompt asking for the name, category,
+// confidence, language and reason of a file as one JSON object
+func GenerateFileAnalysisPrompt(file *analyzer.FileMetadata) string {
+	if file == nil {
+		return ""
+	}
+
+	var subject string
+	if len(file.ContentPreview) > 20 {
+		subject = fmt.Sprintf("Document content:\n%s\n\n", file.ContentPreview)
+	} else {
+		subject = fmt.Sprintf("File: %s (type: %s)\n\n", file.Name, file.MimeType)
+	}
+
+	return "You are a file naming and filing expert. Analyze this file.\n\n" + subject +
+		"Answer with ONE JSON object with these fields:\n" +
+		"- name: a clear, specific filename such as 'quarterly-sales-report-2024' or 'team-meeting-notes-jan'; " +
+		"lowercase letters, numbers and hyphens only, 15-50 characters, no extension\n" +
+		"- category: the file's purpose, one of " + strings.Join(ScenarioCategories, ", ") + "\n" +
+		"- confidence: how sure you are of the name and category, from 0 to 1\n" +
+		"- language: ISO 639-1 code of the content's language (en, zh, ...), or \"\" if there is no text\n" +
+		"- reason: one short sentence on what the file is and why the name and category fit\n\n" +
+		"Output ONLY the JSON object."
+}
+
+// ParseFileAnalysis decodes and validates a model's answer to the prompt of
+// GenerateFileAnalysisPrompt. The name is cleaned like CleanSuggestedName
+// and the reason cut to one line; anything else amiss fails with
+// ErrMalformed.
+func ParseFileAnalysis(answer string) (*FileAnalysis, error) {
+	answer = strings.TrimSpace(answer)
+
+	// Some models wrap JSON in a code block or a sentence
+	start, end := strings.IndexByte(answer, '{'), strings.LastIndexByte(answer, '}')
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("%w: no JSON object in %q", ErrMalformed, truncate(answer, 80))
+	}
+
+	var raw struct {
+		Name       *string  `json:"name"`
+		Category   *string  `json:"category"`
+		Confidence *float64 `json:"confidence"`
+		Language   *string  `json:"language"`
+		Reason     *string  `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	for _, f := range []struct {
+		name    string
+		missing bool
+	}{
+		{"name", raw.Name == nil},
+		{"category", raw.Category == nil},
+		{"confidence", raw.Confidence == nil},
+		{"reason", raw.Reason == nil},
+	} {
+		if f.missing {
+			return nil, fmt.Errorf("%w: missing field %q", ErrMalformed, f.name)
+		}
+	}
+
+	a := &FileAnalysis{
+		Name:       CleanSuggestedName(*raw.Name),
+		Category:   strings.ToLower(strings.TrimSpace(*raw.Category)),
+		Confidence: *raw.Confidence,
+	}
+	if a.Name == "" {
+		return nil, fmt.Errorf("%w: empty name", ErrMalformed)
+	}
+	valid := false
+	for _, c := range ScenarioCategories {
+		valid = valid || a.Category == c
+	}
+	if !valid {
+		return nil, fmt.Errorf("%w: category %q is not one of %s", ErrMalformed, a.Category, strings.Join(ScenarioCategories, ", "))
+	}
+	if a.Confidence < 0 || a.Confidence > 1 {
+		return nil, fmt.Errorf("%w: confidence %g is not between 0 and 1", ErrMalformed, a.Confidence)
+	}
+	if raw.Language != nil {
+		a.Language = strings.ToLower(strings.TrimSpace(*raw.Language))
+	}
+	reason, _, _ := strings.Cut(strings.TrimSpace(*raw.Reason), "\n")
+	a.Reason = truncate(strings.TrimSpace(reason), maxReasonLen)
+	if a.Reason == "" {
+		return nil, fmt.Errorf("%w: empty reason", ErrMalformed)
+	}
+	return a, nil
+}
+
+// RequestFileAnalysis sends the analysis prompt of a file with send and
+// parses the answer. A malformed answer is sent back with what was wrong
+// with it, up to maxAnalysisAttempts times in all; errors of send end the
+// request.
+func RequestFileAnalysis(ctx context.Context, file *analyzer.FileMetadata, send func(ctx context.Context, prompt string) (string, error)) (*FileAnalysis, error) {
+	if file == nil {
+		return nil, fmt.Errorf("file metadata cannot be nil")
+	}
+
+	base := GenerateFileAnalysisPrompt(file)
+	prompt := base
+	var lastErr error
+	for attempt := 0; attempt < maxAnalysisAttempts; attempt++ {
+		answer, err := send(ctx, prompt)
+		if err != nil {
+			return nil, err
+		}
+		analysis, err := ParseFileAnalysis(answer)
+		if err == nil {
+			return analysis, nil
+		}
+		lastErr = err
+		prompt = fmt.Sprintf("%s\n\nYour previous answer was rejected (%v). Reply again with only the JSON object.", base, err)
+	}
+	return nil, fmt.Errorf("no valid answer after %d attempts: %w", maxAnalysisAttempts, lastErr)
+}
+
+// truncate cuts s to at most n characters
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,78 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+func TestParseFileAnalysis(t *testing.T) {
+	got, err := ParseFileAnalysis("```json\n" + `{"name": "Q3 Sales Report.pdf", "category": " Report", "confidence": 0.9,
+		"language": "EN", "reason": "Quarterly sales figures by region.\nMore detail."}` + "\n```")
+	if err != nil {
+		t.Fatalf("ParseFileAnalysis() error = %v", err)
+	}
+	want := FileAnalysis{Name: "q3-sales-report", Category: "report", Confidence: 0.9, Language: "en", Reason: "Quarterly sales figures by region."}
+	if *got != want {
+		t.Errorf("ParseFileAnalysis() = %+v, want %+v", *got, want)
+	}
+
+	for _, answer := range []string{
+		"quarterly-report",
+		`{"name": "a", "category": "report", "confidence": 0.5`,
+		`{"category": "report", "confidence": 0.5, "language": "en", "reason": "r"}`,
+		`{"name": "", "category": "report", "confidence": 0.5, "language": "en", "reason": "r"}`,
+		`{"name": "a", "category": "recipe", "confidence": 0.5, "language": "en", "reason": "r"}`,
+		`{"name": "a", "category": "report", "confidence": 85, "language": "en", "reason": "r"}`,
+		`{"name": "a", "category": "report", "confidence": "high", "language": "en", "reason": "r"}`,
+		`{"name": "a", "category": "report", "confidence": 0.5, "language": "en", "reason": " "}`,
+	} {
+		if _, err := ParseFileAnalysis(answer); !errors.Is(err, ErrMalformed) {
+			t.Errorf("ParseFileAnalysis(%s) error = %v, want ErrMalformed", answer, err)
+		}
+	}
+}
+
+func TestRequestFileAnalysis(t *testing.T) {
+	file := &analyzer.FileMetadata{Name: "doc1.txt", ContentPreview: "Minutes of the weekly sync meeting"}
+	answers := []string{
+		`{"name": "weekly-sync", "category": "meeting notes", "confidence": 0.8, "language": "en", "reason": "Minutes"}`,
+		`{"name": "weekly-sync-minutes", "category": "meeting", "confidence": 0.8, "language": "en", "reason": "Minutes of a meeting"}`,
+	}
+	var prompts []string
+	send := func(ctx context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return answers[len(prompts)-1], nil
+	}
+
+	got, err := RequestFileAnalysis(context.Background(), file, send)
+	if err != nil || got.Name != "weekly-sync-minutes" {
+		t.Fatalf("RequestFileAnalysis() = %+v, %v", got, err)
+	}
+	if len(prompts) != 2 || !strings.Contains(prompts[1], `category "meeting notes" is not one of`) {
+		t.Errorf("retry prompt does not say what was wrong: %q", prompts[len(prompts)-1])
+	}
+
+	// Malformed answers are given up on after a few attempts
+	calls := 0
+	_, err = RequestFileAnalysis(context.Background(), file, func(ctx context.Context, prompt string) (string, error) {
+		calls++
+		return "I think it is a meeting.", nil
+	})
+	if !errors.Is(err, ErrMalformed) || calls != maxAnalysisAttempts {
+		t.Errorf("RequestFileAnalysis() error = %v after %d calls", err, calls)
+	}
+
+	// Errors of the provider are not retried
+	calls = 0
+	_, err = RequestFileAnalysis(context.Background(), file, func(ctx context.Context, prompt string) (string, error) {
+		calls++
+		return "", errors.New("connection refused")
+	})
+	if err == nil || errors.Is(err, ErrMalformed) || calls != 1 {
+		t.Errorf("RequestFileAnalysis() error = %v after %d calls", err, calls)
+	}
+}
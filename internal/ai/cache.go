@@ -1,96 +0,0 @@
-package ai
-
-import (
-	"crypto/md5"
-	"fmt"
-	"sync"
-	"time"
-)
-
-// CacheEntry represents a cached AI response
-type CacheEntry struct {
-	Response  []string
-	Timestamp time.Time
-}
-
-// Cache provides thread-safe caching for AI responses
-type Cache struct {
-	entries map[string]*CacheEntry
-	mu      sync.RWMutex
-	ttl     time.Duration
-}
-
-// NewCache creates a new AI response cache
-func NewCache(ttl time.Duration) *Cache {
-	return &Cache{
-		entries: make(map[string]*CacheEntry),
-		ttl:     ttl,
-	}
-}
-
-// Get retrieves a cached response if it exists and hasn't expired
-func (c *Cache) Get(key string) ([]string, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	entry, exists := c.entries[key]
-	if !exists {
-		return nil, false
-	}
-
-	// Check if entry has expired
-	if time.Since(entry.Timestamp) > c.ttl {
-		return nil, false
-	}
-
-	return entry.Response, true
-}
-
-// Set stores a response in the cache
-func (c *Cache) Set(key string, response []string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.entries[key] = &CacheEntry{
-		Response:  response,
-		Timestamp: time.Now(),
-	}
-}
-
-// GenerateKey creates a cache key from file metadata
-func GenerateKey(prefix, content string) string {
-	hash := md5.Sum([]byte(content))
-	return fmt.Sprintf("%s:%x", prefix, hash)
-}
-
-// Clear removes all entries from the cache
-func (c *Cache) Clear() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.entries = make(map[string]*CacheEntry)
-}
-
-// Size returns the number of entries in the cache
-func (c *Cache) Size() int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	return len(c.entries)
-}
-
-// CleanExpired removes expired entries from the cache
-func (c *Cache) CleanExpired() int {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	removed := 0
-	for key, entry := range c.entries {
-		if time.Since(entry.Timestamp) > c.ttl {
-			delete(c.entries, key)
-			removed++
-		}
-	}
-
-	return removed
-}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/xuanyiying/cleanup-cli/internal/ai"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+// Client is an ai.Client that answers name, category and analysis requests
+// from a Store when it can, and stores the answers of the client it wraps
+type Client struct {
+	client   ai.Client
+	store    *Store
+	provider string
+	model    string
+}
+
+// NewClient wraps the client of a provider's model with a cache. Answers
+// are kept apart by provider and model, so changing either asks anew.
+func NewClient(client ai.Client, store *Store, provider, model string) *Client {
+	return &Client{
+		client:   client,
+		store:    store,
+		provider: provider,
+		model:    model,
+	}
+}
+
+// CheckHealth checks the wrapped client
+func (c *Client) CheckHealth(ctx context.Context) error {
+	return c.client.CheckHealth(ctx)
+}
+
+// Analyze passes free-form prompts to the wrapped client uncached
+func (c *Client) Analyze(ctx context.Context, prompt string, contextContent string) (*ai.AnalysisResult, error) {
+	return c.client.Analyze(ctx, prompt, contextContent)
+}
+
+// SuggestName returns the cached name suggestions for the file, or asks the
+// wrapped client and caches its answer
+func (c *Client) SuggestName(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	return c.cached(ctx, "name", ai.GenerateNameSuggestionPrompt(file), func() ([]string, error) {
+		return c.client.SuggestName(ctx, file)
+	})
+}
+
+// SuggestCategory returns the cached categories for the file, or asks the
+// wrapped client and caches its answer
+func (c *Client) SuggestCategory(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	return c.cached(ctx, "category", ai.GenerateCategorySuggestionPrompt(file), func() ([]string, error) {
+		return c.client.SuggestCategory(ctx, file)
+	})
+}
+
+// AnalyzeFile returns the cached analysis of the file, or asks the wrapped
+// client and caches its answer
+func (c *Client) AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*ai.FileAnalysis, error) {
+	prompt := ai.GenerateFileAnalysisPrompt(file)
+	if prompt == "" {
+		return c.client.AnalyzeFile(ctx, file)
+	}
+
+	key := Key("analysis", c.provider, c.model, prompt)
+	if e, ok := c.store.Get(key); ok && e.Analysis != nil {
+		ai.AttributeCached(ctx)
+		return e.Analysis, nil
+	}
+
+	analysis, err := c.client.AnalyzeFile(ctx, file)
+	if err == nil && analysis != nil {
+		c.store.Set(key, &Entry{Kind: "analysis", Provider: c.provider, Model: c.model, Analysis: analysis})
+	}
+	return analysis, err
+}
+
+// cached answers a request from the store, or by calling ask and storing a
+// non-empty answer. Requests without a prompt are not sent to the model by
+// the providers and are not cached.
+func (c *Client) cached(ctx context.Context, kind, prompt string, ask func() ([]string, error)) ([]string, error) {
+	if prompt == "" {
+		return ask()
+	}
+
+	key := Key(kind, c.provider, c.model, prompt)
+	if e, ok := c.store.Get(key); ok {
+		ai.AttributeCached(ctx)
+		return e.Response, nil
+	}
+
+	response, err := ask()
+	if err == nil && len(response) > 0 && response[0] != "" {
+		c.store.Set(key, &Entry{Kind: kind, Provider: c.provider, Model: c.model, Response: response})
+	}
+	return response, err
+}
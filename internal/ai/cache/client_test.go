@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuanyiying/cleanup-cli/internal/ai"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+// countingClient answers with fixed suggestions and counts its calls
+type countingClient struct {
+	answer []string
+	err    error
+	calls  int
+}
+
+func (c *countingClient) CheckHealth(ctx context.Context) error {
+	return nil
+}
+
+func (c *countingClient) Analyze(ctx context.Context, prompt string, contextContent string) (*ai.AnalysisResult, error) {
+	c.calls++
+	return &ai.AnalysisResult{Success: true}, nil
+}
+
+func (c *countingClient) SuggestName(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	c.calls++
+	return c.answer, c.err
+}
+
+func (c *countingClient) SuggestCategory(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	c.calls++
+	return c.answer, c.err
+}
+
+func (c *countingClient) AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*ai.FileAnalysis, error) {
+	c.calls++
+	if c.err != nil || len(c.answer) == 0 {
+		return nil, c.err
+	}
+	return &ai.FileAnalysis{Name: c.answer[0], Category: "notes", Confidence: 0.8, Language: "en", Reason: "a plan"}, nil
+}
+
+func TestClientCachesAnswers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ai-cache.json.gz")
+	model := &countingClient{answer: []string{"team-offsite-plan"}}
+	store := NewStore(path, 0)
+	client := NewClient(model, store, "ollama", "llama3.2")
+	file := &analyzer.FileMetadata{
+		Name:           "doc1.txt",
+		MimeType:       "text/plain",
+		ContentPreview: strings.Repeat("Plan for the team offsite in May. ", 3),
+	}
+
+	ctx, by := ai.WithAttribution(context.Background())
+	if _, err := client.SuggestName(ctx, file); err != nil || by.Cached {
+		t.Fatalf("SuggestName() error = %v, cached = %v", err, by.Cached)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later run answers from the file
+	client = NewClient(model, NewStore(path, 0), "ollama", "llama3.2")
+	ctx, by = ai.WithAttribution(context.Background())
+	names, err := client.SuggestName(ctx, file)
+	if err != nil || len(names) != 1 || names[0] != "team-offsite-plan" || !by.Cached {
+		t.Errorf("SuggestName() = %v, %v, cached = %v", names, err, by.Cached)
+	}
+	if model.calls != 1 {
+		t.Errorf("model called %d times, want 1", model.calls)
+	}
+
+	// Categories and other models are cached apart
+	if _, err := client.SuggestCategory(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+	client = NewClient(model, NewStore(path, 0), "ollama", "qwen2.5")
+	if _, err := client.SuggestName(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+	if model.calls != 3 {
+		t.Errorf("model called %d times, want 3", model.calls)
+	}
+}
+
+func TestClientSkipsFailures(t *testing.T) {
+	model := &countingClient{err: errors.New("timeout")}
+	client := NewClient(model, NewStore(filepath.Join(t.TempDir(), "ai-cache.json.gz"), 0), "ollama", "llama3.2")
+	file := &analyzer.FileMetadata{Name: "scan001.pdf", MimeType: "application/pdf"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.SuggestName(context.Background(), file); err == nil {
+			t.Error("SuggestName() succeeded with a failing model")
+		}
+	}
+
+	// Empty answers are not cached either
+	model.err = nil
+	model.answer = []string{}
+	for i := 0; i < 2; i++ {
+		if _, err := client.SuggestName(context.Background(), file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if model.calls != 4 {
+		t.Errorf("model called %d times, want 4", model.calls)
+	}
+}
+
+func TestClientCachesAnalyses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ai-cache.json.gz")
+	model := &countingClient{answer: []string{"team-offsite-plan"}}
+	store := NewStore(path, 0)
+	file := &analyzer.FileMetadata{Name: "doc1.txt", MimeType: "text/plain", ContentPreview: "Plan for the team offsite in May."}
+
+	if _, err := NewClient(model, store, "ollama", "llama3.2").AnalyzeFile(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, by := ai.WithAttribution(context.Background())
+	analysis, err := NewClient(model, NewStore(path, 0), "ollama", "llama3.2").AnalyzeFile(ctx, file)
+	if err != nil || !by.Cached || model.calls != 1 {
+		t.Fatalf("AnalyzeFile() error = %v, cached = %v, model called %d times", err, by.Cached, model.calls)
+	}
+	if analysis.Name != "team-offsite-plan" || analysis.Reason != "a plan" || analysis.Confidence != 0.8 {
+		t.Errorf("AnalyzeFile() = %+v", analysis)
+	}
+}
@@ -0,0 +1,406 @@
+package cache
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/ai"
+)
+
+// formatVersion is bumped whenever the meaning of stored entries changes;
+// cache files of another version are discarded on load
+const formatVersion = 1
+
+// DefaultMaxSize is the size limit of a store created without one
+const DefaultMaxSize = 32 << 20
+
+// flushInterval is how often a store that keeps changing is written to
+// disk, so that an interrupted run loses few answers
+const flushInterval = 30 * time.Second
+
+// entryOverhead approximates the bytes an entry takes besides its strings
+const entryOverhead = 96
+
+// Entry is one cached answer of an AI provider
+type Entry struct {
+	Kind      string           `json:"kind"` // "name", "category" or "analysis"
+	Provider  string           `json:"provider"`
+	Model     string           `json:"model,omitempty"`
+	Response  []string         `json:"response,omitempty"` // Suggested names or categories
+	Analysis  *ai.FileAnalysis `json:"analysis,omitempty"` // Structured analysis
+	CreatedAt time.Time        `json:"created_at"`
+	UsedAt    time.Time        `json:"used_at"`
+}
+
+// size approximates the bytes the entry of key takes
+func (e *Entry) size(key string) int64 {
+	n := len(key) + len(e.Kind) + len(e.Provider) + len(e.Model) + entryOverhead
+	for _, r := range e.Response {
+		n += len(r)
+	}
+	if a := e.Analysis; a != nil {
+		n += len(a.Name) + len(a.Category) + len(a.Language) + len(a.Reason) + entryOverhead
+	}
+	return int64(n)
+}
+
+// clone returns a copy of the entry that shares nothing with it
+func (e *Entry) clone() *Entry {
+	c := *e
+	c.Response = append([]string(nil), e.Response...)
+	if e.Analysis != nil {
+		analysis := *e.Analysis
+		c.Analysis = &analysis
+	}
+	return &c
+}
+
+// cacheFile is the on-disk layout of a store
+type cacheFile struct {
+	Version int               `json:"version"`
+	SavedAt time.Time         `json:"saved_at"`
+	Entries map[string]*Entry `json:"entries"`
+}
+
+// Store is a persistent cache of AI answers, so that files analyzed in an
+// earlier run are not sent to the model again. It holds at most maxSize
+// bytes of answers and drops the least recently used ones beyond that.
+type Store struct {
+	path    string
+	maxSize int64
+	now     func() time.Time
+
+	mu        sync.Mutex
+	loaded    bool
+	entries   map[string]*Entry
+	size      int64
+	savedAt   time.Time
+	flushedAt time.Time
+	dirty     bool
+	hits      int
+	misses    int
+}
+
+// NewStore creates a store kept at path and holding at most maxSize bytes
+// of answers, or DefaultMaxSize if maxSize is not positive. The file is
+// read on first use, so creating a store costs nothing for commands that
+// never ask the model.
+func NewStore(path string, maxSize int64) *Store {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return &Store{
+		path:    path,
+		maxSize: maxSize,
+		now:     time.Now,
+		entries: make(map[string]*Entry),
+	}
+}
+
+// Open loads the store kept at path. A missing file gives an empty store.
+func Open(path string, maxSize int64) (*Store, error) {
+	s := NewStore(path, maxSize)
+	if err := s.Load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Key returns the cache key of a request of the given kind made to a
+// provider's model. The prompt holds the file content as it was sent, and
+// ai.PromptVersion retires answers when the prompts change.
+func Key(kind, provider, model, prompt string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\x00", ai.PromptVersion, kind, provider, model)
+	h.Write([]byte(prompt))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Path returns the file the store is kept in
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load reads the entries from disk, replacing those in memory
+func (s *Store) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// load reads the entries from disk. The caller holds the lock.
+func (s *Store) load() error {
+	s.loaded = true
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open AI cache: %w", err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read AI cache: %w", err)
+	}
+	var file cacheFile
+	if err := json.NewDecoder(zr).Decode(&file); err != nil {
+		return fmt.Errorf("failed to unmarshal AI cache: %w", err)
+	}
+
+	s.entries = make(map[string]*Entry)
+	s.size = 0
+	if file.Version == formatVersion {
+		for key, e := range file.Entries {
+			if e == nil {
+				continue
+			}
+			s.entries[key] = e
+			s.size += e.size(key)
+		}
+		s.savedAt = file.SavedAt
+	}
+	s.evict()
+	return nil
+}
+
+// ensureLoaded reads the entries on first use. An unreadable file gives an
+// empty store that replaces it on save. The caller holds the lock.
+func (s *Store) ensureLoaded() {
+	if !s.loaded {
+		_ = s.load()
+	}
+}
+
+// Get returns a copy of the answer cached under key and marks it as
+// recently used
+func (s *Store) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+
+	e, ok := s.entries[key]
+	if !ok {
+		s.misses++
+		return nil, false
+	}
+	s.hits++
+	e.UsedAt = s.now()
+	s.dirty = true
+	return e.clone(), true
+}
+
+// Set caches an answer under key, dropping the least recently used answers
+// if the store grows past its limit. A store that has changed for a while
+// is written to disk; errors are left for the final Save to report.
+func (s *Store) Set(key string, e *Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+
+	now := s.now()
+	entry := e.clone()
+	entry.CreatedAt = now
+	entry.UsedAt = now
+	if old, ok := s.entries[key]; ok {
+		s.size -= old.size(key)
+	}
+	s.entries[key] = entry
+	s.size += entry.size(key)
+	s.dirty = true
+	s.evict()
+
+	if s.flushedAt.IsZero() {
+		s.flushedAt = now
+	} else if now.Sub(s.flushedAt) >= flushInterval {
+		_ = s.save()
+	}
+}
+
+// evict drops the least recently used entries once the store holds more
+// than maxSize bytes, down to a tenth below the limit so that it does not
+// have to evict again on the next answer. It returns how many it dropped.
+// The caller holds the lock.
+func (s *Store) evict() int {
+	if s.size <= s.maxSize {
+		return 0
+	}
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s.entries[keys[i]].UsedAt.Before(s.entries[keys[j]].UsedAt)
+	})
+
+	target := s.maxSize - s.maxSize/10
+	removed := 0
+	for _, key := range keys {
+		if s.size <= target {
+			break
+		}
+		s.size -= s.entries[key].size(key)
+		delete(s.entries, key)
+		removed++
+	}
+	s.dirty = true
+	return removed
+}
+
+// Save writes the store to disk if it changed since it was loaded. The file
+// is replaced atomically so that an interrupted save keeps the old cache.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save()
+}
+
+// save writes the store to disk. The caller holds the lock.
+func (s *Store) save() error {
+	if !s.dirty {
+		return nil
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create AI cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create AI cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	now := s.now()
+	zw := gzip.NewWriter(tmp)
+	err = json.NewEncoder(zw).Encode(&cacheFile{Version: formatVersion, SavedAt: now, Entries: s.entries})
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write AI cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write AI cache: %w", err)
+	}
+
+	s.savedAt = now
+	s.flushedAt = now
+	s.dirty = false
+	return nil
+}
+
+// Len returns the number of cached answers
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	return len(s.entries)
+}
+
+// Hits returns how many lookups since the store was created were answered
+// from it and how many were not
+func (s *Store) Hits() (hits, misses int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits, s.misses
+}
+
+// Clear removes every cached answer and returns how many there were
+func (s *Store) Clear() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+
+	removed := len(s.entries)
+	s.entries = make(map[string]*Entry)
+	s.size = 0
+	s.dirty = true
+	return removed
+}
+
+// Prune removes the answers not used for longer than maxAge, then the
+// least recently used ones beyond the size limit, and returns how many it
+// removed. A maxAge of zero keeps answers of any age.
+func (s *Store) Prune(maxAge time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+
+	removed := 0
+	if maxAge > 0 {
+		cutoff := s.now().Add(-maxAge)
+		for key, e := range s.entries {
+			if e.UsedAt.Before(cutoff) {
+				s.size -= e.size(key)
+				delete(s.entries, key)
+				removed++
+			}
+		}
+		if removed > 0 {
+			s.dirty = true
+		}
+	}
+	return removed + s.evict()
+}
+
+// Stats summarizes a store
+type Stats struct {
+	Path     string
+	Entries  int
+	Size     int64          // Approximate bytes of cached answers
+	MaxSize  int64          // Size limit
+	FileSize int64          // Bytes of the compressed file on disk
+	SavedAt  time.Time      // When the file was last written, zero if never
+	Oldest   time.Time      // When the least recently used answer was last used
+	Hits     int            // Lookups answered since the store was created
+	Misses   int            // Lookups not answered since the store was created
+	Models   map[string]int // Answers by "provider/model"
+	Kinds    map[string]int // Answers by kind
+}
+
+// Stats returns a summary of the store
+func (s *Store) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+
+	stats := Stats{
+		Path:    s.path,
+		Entries: len(s.entries),
+		Size:    s.size,
+		MaxSize: s.maxSize,
+		SavedAt: s.savedAt,
+		Hits:    s.hits,
+		Misses:  s.misses,
+		Models:  make(map[string]int),
+		Kinds:   make(map[string]int),
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		stats.FileSize = info.Size()
+	}
+	for _, e := range s.entries {
+		model := e.Provider
+		if e.Model != "" {
+			model += "/" + e.Model
+		}
+		stats.Models[model]++
+		stats.Kinds[e.Kind]++
+		if stats.Oldest.IsZero() || e.UsedAt.Before(stats.Oldest) {
+			stats.Oldest = e.UsedAt
+		}
+	}
+	return stats
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	key := Key("name", "ollama", "llama3.2", "prompt")
+	if key != Key("name", "ollama", "llama3.2", "prompt") {
+		t.Error("Key() differs for the same request")
+	}
+	for _, other := range []string{
+		Key("category", "ollama", "llama3.2", "prompt"),
+		Key("name", "openai", "llama3.2", "prompt"),
+		Key("name", "ollama", "qwen2.5", "prompt"),
+		Key("name", "ollama", "llama3.2", "other prompt"),
+	} {
+		if other == key {
+			t.Errorf("Key() = %s for different requests", key)
+		}
+	}
+}
+
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ai-cache.json.gz")
+	s := NewStore(path, 0)
+	s.Set("k1", &Entry{Kind: "name", Provider: "ollama", Model: "llama3.2", Response: []string{"q3-budget"}})
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	s, err := Open(path, 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	e, ok := s.Get("k1")
+	if !ok || len(e.Response) != 1 || e.Response[0] != "q3-budget" {
+		t.Errorf("Get() = %+v, %v after reopening", e, ok)
+	}
+	if _, ok := s.Get("k2"); ok {
+		t.Error("Get() found a key that was never set")
+	}
+	if hits, misses := s.Hits(); hits != 1 || misses != 1 {
+		t.Errorf("Hits() = %d, %d, want 1, 1", hits, misses)
+	}
+
+	stats := s.Stats()
+	if stats.Entries != 1 || stats.Models["ollama/llama3.2"] != 1 || stats.Kinds["name"] != 1 || stats.FileSize == 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestStoreLoadsLazily(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ai-cache.json.gz")
+	s := NewStore(path, 0)
+	s.Set("k1", &Entry{Kind: "name", Provider: "ollama", Response: []string{"a"}})
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// A new store adds to the answers on disk instead of replacing them
+	s = NewStore(path, 0)
+	s.Set("k2", &Entry{Kind: "name", Provider: "ollama", Response: []string{"b"}})
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if s, _ := Open(path, 0); s.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", s.Len())
+	}
+}
+
+func TestStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	now := time.Now()
+	e := &Entry{Kind: "name", Provider: "ollama", Response: []string{"name"}}
+	s := NewStore(filepath.Join(t.TempDir(), "ai-cache.json.gz"), 3*e.size("k1"))
+	s.now = func() time.Time { return now }
+
+	for _, key := range []string{"k1", "k2", "k3"} {
+		now = now.Add(time.Second)
+		s.Set(key, e)
+	}
+	now = now.Add(time.Second)
+	s.Get("k1")
+	now = now.Add(time.Second)
+	s.Set("k4", e)
+
+	if _, ok := s.Get("k2"); ok {
+		t.Error("least recently used answer was kept")
+	}
+	for _, key := range []string{"k1", "k4"} {
+		if _, ok := s.Get(key); !ok {
+			t.Errorf("answer %s was dropped", key)
+		}
+	}
+	if stats := s.Stats(); stats.Size > stats.MaxSize {
+		t.Errorf("Size = %d, over the limit of %d", stats.Size, stats.MaxSize)
+	}
+}
+
+func TestStorePrune(t *testing.T) {
+	now := time.Now()
+	s := NewStore(filepath.Join(t.TempDir(), "ai-cache.json.gz"), 0)
+	s.now = func() time.Time { return now }
+
+	s.Set("old", &Entry{Kind: "name", Provider: "ollama", Response: []string{"a"}})
+	now = now.Add(100 * 24 * time.Hour)
+	s.Set("new", &Entry{Kind: "name", Provider: "ollama", Response: []string{"b"}})
+
+	if removed := s.Prune(90 * 24 * time.Hour); removed != 1 {
+		t.Errorf("Prune() = %d, want 1", removed)
+	}
+	if _, ok := s.Get("new"); !ok {
+		t.Error("Prune() removed a recent answer")
+	}
+	if removed := s.Clear(); removed != 1 || s.Len() != 0 {
+		t.Errorf("Clear() = %d, leaving %d", removed, s.Len())
+	}
+}
+
+func TestStoreFlushesPeriodically(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "ai-cache.json.gz")
+	s := NewStore(path, 0)
+	s.now = func() time.Time { return now }
+
+	s.Set("k1", &Entry{Kind: "name", Provider: "ollama", Response: []string{"a"}})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("store written after the first answer: %v", err)
+	}
+	now = now.Add(flushInterval)
+	s.Set("k2", &Entry{Kind: "name", Provider: "ollama", Response: []string{"b"}})
+	if s, err := Open(path, 0); err != nil || s.Len() != 2 {
+		t.Errorf("Open() error = %v after the flush interval", err)
+	}
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ai-cache.json.gz")
+	if err := os.WriteFile(path, []byte("not gzip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path, 0); err == nil {
+		t.Error("Open() succeeded on a corrupt file")
+	}
+
+	// Used without Open, the store starts empty and replaces the file
+	s := NewStore(path, 0)
+	if s.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", s.Len())
+	}
+}
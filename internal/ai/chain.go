@@ -0,0 +1,334 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+// ErrUnavailable is returned by a Chain when no provider could answer
+var ErrUnavailable = errors.New("no AI provider available")
+
+// Provider is a named client in a Chain
+type Provider struct {
+	Name   string
+	Client Client
+}
+
+// BreakerState is the state of a provider's circuit breaker
+type BreakerState string
+
+const (
+	// BreakerClosed lets requests through
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen skips the provider until the cooldown has passed
+	BreakerOpen BreakerState = "open"
+)
+
+// ChainOptions configures how a Chain falls back between providers
+type ChainOptions struct {
+	FailureThreshold int           // Consecutive failures that open a provider's breaker (default 3)
+	Cooldown         time.Duration // How long an open breaker skips the provider before probing it again (default 1m)
+	ProbeTimeout     time.Duration // Timeout of a health probe (default 5s)
+}
+
+// ProviderStats reports what a provider did during a run
+type ProviderStats struct {
+	Name      string
+	Answered  int          // Requests the provider answered
+	Failed    int          // Requests that failed, including failed health probes
+	Skipped   int          // Requests passed on while the breaker was open
+	State     BreakerState // State of the breaker
+	LastError string       // The last failure, empty if there was none
+}
+
+// ChainStats reports what a chain did during a run
+type ChainStats struct {
+	Providers  []ProviderStats
+	Unanswered int // Requests no provider answered
+}
+
+// Degraded reports whether any provider failed or requests went unanswered
+func (s ChainStats) Degraded() bool {
+	if s.Unanswered > 0 {
+		return true
+	}
+	for _, p := range s.Providers {
+		if p.Failed > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// chainProvider is a provider with its circuit breaker
+type chainProvider struct {
+	Provider
+
+	mu       sync.Mutex // Held during health probes so that only one runs
+	probed   bool       // Health has been checked since the breaker last opened
+	failures int        // Consecutive failures
+	openedAt time.Time  // When the breaker opened, zero while closed
+	stats    ProviderStats
+}
+
+// Chain is a Client that tries providers in order, falling back to the next
+// when one fails. Each provider has a circuit breaker: its health is probed
+// before first use, and after FailureThreshold consecutive failures it is
+// skipped until Cooldown has passed and a new probe succeeds.
+type Chain struct {
+	providers []*chainProvider
+	opts      ChainOptions
+	now       func() time.Time
+
+	mu         sync.Mutex
+	unanswered int
+}
+
+// NewChain creates a chain of providers, tried in the order given
+func NewChain(providers []Provider, opts ChainOptions) *Chain {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 3
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = time.Minute
+	}
+	if opts.ProbeTimeout <= 0 {
+		opts.ProbeTimeout = 5 * time.Second
+	}
+	c := &Chain{opts: opts, now: time.Now}
+	for _, p := range providers {
+		c.providers = append(c.providers, &chainProvider{
+			Provider: p,
+			stats:    ProviderStats{Name: p.Name, State: BreakerClosed},
+		})
+	}
+	return c
+}
+
+// CheckHealth probes every provider and succeeds if any of them is healthy
+func (c *Chain) CheckHealth(ctx context.Context) error {
+	var errs []error
+	for _, p := range c.providers {
+		p.mu.Lock()
+		err := c.probe(ctx, p)
+		p.mu.Unlock()
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+	}
+	return fmt.Errorf("%w: %w", ErrUnavailable, errors.Join(errs...))
+}
+
+// Analyze sends a prompt to the first provider that answers
+func (c *Chain) Analyze(ctx context.Context, prompt string, contextContent string) (*AnalysisResult, error) {
+	var result *AnalysisResult
+	err := c.try(ctx, func(client Client) error {
+		var err error
+		result, err = client.Analyze(ctx, prompt, contextContent)
+		return err
+	})
+	return result, err
+}
+
+// SuggestName asks the first provider that answers for names
+func (c *Chain) SuggestName(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	var names []string
+	err := c.try(ctx, func(client Client) error {
+		var err error
+		names, err = client.SuggestName(ctx, file)
+		return err
+	})
+	return names, err
+}
+
+// SuggestCategory asks the first provider that answers for categories
+func (c *Chain) SuggestCategory(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	var categories []string
+	err := c.try(ctx, func(client Client) error {
+		var err error
+		categories, err = client.SuggestCategory(ctx, file)
+		return err
+	})
+	return categories, err
+}
+
+// AnalyzeFile asks the first provider that answers for a structured
+// analysis of a file
+func (c *Chain) AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*FileAnalysis, error) {
+	var analysis *FileAnalysis
+	err := c.try(ctx, func(client Client) error {
+		var err error
+		analysis, err = client.AnalyzeFile(ctx, file)
+		return err
+	})
+	return analysis, err
+}
+
+// Stats returns what each provider did so far
+func (c *Chain) Stats() ChainStats {
+	var stats ChainStats
+	for _, p := range c.providers {
+		p.mu.Lock()
+		stats.Providers = append(stats.Providers, p.stats)
+		p.mu.Unlock()
+	}
+	c.mu.Lock()
+	stats.Unanswered = c.unanswered
+	c.mu.Unlock()
+	return stats
+}
+
+// try calls the providers in order until one succeeds. Providers that
+// withhold the request under a policy or do not support it are passed over
+// without counting as failures; if no provider answers, the error says why.
+func (c *Chain) try(ctx context.Context, call func(Client) error) error {
+	var errs []error
+	var withheld error
+	skipped := false
+	for _, p := range c.providers {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !c.allow(ctx, p) {
+			skipped = true
+			continue
+		}
+
+		err := call(p.Client)
+		switch {
+		case err == nil:
+			c.succeeded(p)
+			Attribute(ctx, p.Name)
+			return nil
+		case errors.Is(err, ErrWithheld):
+			withheld = err
+			continue
+		case errors.Is(err, ErrUnsupported):
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+			continue
+		case ctx.Err() != nil:
+			return ctx.Err()
+		}
+		c.failed(p, err)
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+	}
+
+	// Requests withheld by every provider are not missing an answer
+	if withheld != nil && len(errs) == 0 && !skipped {
+		return withheld
+	}
+	c.mu.Lock()
+	c.unanswered++
+	c.mu.Unlock()
+	if withheld != nil {
+		errs = append(errs, withheld)
+	}
+	if len(errs) == 0 {
+		return ErrUnavailable
+	}
+	return fmt.Errorf("%w: %w", ErrUnavailable, errors.Join(errs...))
+}
+
+// allow reports whether a provider may be called. Its health is probed
+// before first use and before it is used again after its breaker opened.
+func (c *Chain) allow(ctx context.Context, p *chainProvider) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.openedAt.IsZero() {
+		if c.now().Sub(p.openedAt) < c.opts.Cooldown {
+			p.stats.Skipped++
+			return false
+		}
+		p.probed = false
+	}
+	if !p.probed {
+		if err := c.probe(ctx, p); err != nil {
+			p.stats.Skipped++
+			return false
+		}
+	}
+	return true
+}
+
+// probe checks a provider's health, closing its breaker if it is healthy
+// and opening it otherwise. The caller holds p.mu.
+func (c *Chain) probe(ctx context.Context, p *chainProvider) error {
+	probeCtx, cancel := context.WithTimeout(ctx, c.opts.ProbeTimeout)
+	defer cancel()
+
+	p.probed = true
+	err := p.Client.CheckHealth(probeCtx)
+	if err != nil {
+		p.stats.Failed++
+		p.stats.LastError = err.Error()
+		p.stats.State = BreakerOpen
+		p.openedAt = c.now()
+		return err
+	}
+	p.failures = 0
+	p.openedAt = time.Time{}
+	p.stats.State = BreakerClosed
+	return nil
+}
+
+// succeeded records an answer from a provider
+func (c *Chain) succeeded(p *chainProvider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures = 0
+	p.stats.Answered++
+}
+
+// failed records a failure of a provider and opens its breaker once the
+// failures reach the threshold
+func (c *Chain) failed(p *chainProvider, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures++
+	p.stats.Failed++
+	p.stats.LastError = err.Error()
+	if p.failures >= c.opts.FailureThreshold && p.openedAt.IsZero() {
+		p.openedAt = c.now()
+		p.stats.State = BreakerOpen
+	}
+}
+
+// Attribution records which provider answered a request, and whether the
+// answer came from the cache of an earlier request
+type Attribution struct {
+	Provider string
+	Cached   bool
+}
+
+// attributionKey is the context key of an *Attribution
+type attributionKey struct{}
+
+// WithAttribution returns a context that records which provider answers
+// requests made with it
+func WithAttribution(ctx context.Context) (context.Context, *Attribution) {
+	a := &Attribution{}
+	return context.WithValue(ctx, attributionKey{}, a), a
+}
+
+// Attribute records the provider that answered a request made with ctx. It
+// does nothing if ctx does not come from WithAttribution.
+func Attribute(ctx context.Context, provider string) {
+	if a, ok := ctx.Value(attributionKey{}).(*Attribution); ok {
+		a.Provider = provider
+	}
+}
+
+// AttributeCached records that a request made with ctx was answered from a
+// cache. It does nothing if ctx does not come from WithAttribution.
+func AttributeCached(ctx context.Context) {
+	if a, ok := ctx.Value(attributionKey{}).(*Attribution); ok {
+		a.Cached = true
+	}
+}
@@ -0,0 +1,207 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+// fakeClient answers with its name, or fails with err
+type fakeClient struct {
+	name      string
+	healthErr error
+	err       error
+	calls     int
+	probes    int
+}
+
+func (c *fakeClient) CheckHealth(ctx context.Context) error {
+	c.probes++
+	return c.healthErr
+}
+
+func (c *fakeClient) Analyze(ctx context.Context, prompt string, contextContent string) (*AnalysisResult, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &AnalysisResult{Success: true, Content: c.name}, nil
+}
+
+func (c *fakeClient) SuggestName(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return []string{c.name}, nil
+}
+
+func (c *fakeClient) SuggestCategory(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return []string{c.name}, nil
+}
+
+func (c *fakeClient) AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*FileAnalysis, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &FileAnalysis{Name: c.name, Category: "other", Reason: c.name}, nil
+}
+
+func TestChainFallsBack(t *testing.T) {
+	primary := &fakeClient{name: "primary", err: errors.New("model not loaded")}
+	secondary := &fakeClient{name: "secondary"}
+	chain := NewChain([]Provider{{"primary", primary}, {"secondary", secondary}}, ChainOptions{})
+
+	ctx, by := WithAttribution(context.Background())
+	names, err := chain.SuggestName(ctx, &analyzer.FileMetadata{Name: "a.txt"})
+	if err != nil {
+		t.Fatalf("SuggestName() error = %v", err)
+	}
+	if names[0] != "secondary" || by.Provider != "secondary" {
+		t.Errorf("SuggestName() = %v from %q, want the secondary provider", names, by.Provider)
+	}
+
+	analysis, err := chain.AnalyzeFile(ctx, &analyzer.FileMetadata{Name: "a.txt"})
+	if err != nil || analysis.Name != "secondary" {
+		t.Errorf("AnalyzeFile() = %+v, %v, want the secondary provider", analysis, err)
+	}
+
+	stats := chain.Stats()
+	if stats.Providers[0].Failed != 2 || stats.Providers[1].Answered != 2 || stats.Unanswered != 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+	if !stats.Degraded() {
+		t.Error("Degraded() = false after a provider failed")
+	}
+}
+
+func TestChainBreaker(t *testing.T) {
+	primary := &fakeClient{name: "primary", err: errors.New("timeout")}
+	secondary := &fakeClient{name: "secondary"}
+	chain := NewChain([]Provider{{"primary", primary}, {"secondary", secondary}},
+		ChainOptions{FailureThreshold: 2, Cooldown: time.Minute})
+	now := time.Now()
+	chain.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	file := &analyzer.FileMetadata{Name: "a.txt"}
+	for i := 0; i < 5; i++ {
+		if _, err := chain.SuggestCategory(ctx, file); err != nil {
+			t.Fatalf("SuggestCategory() error = %v", err)
+		}
+	}
+	if primary.calls != 2 {
+		t.Errorf("primary called %d times, want 2 before its breaker opened", primary.calls)
+	}
+	if s := chain.Stats().Providers[0]; s.State != BreakerOpen || s.Skipped != 3 || s.LastError != "timeout" {
+		t.Errorf("primary stats = %+v", s)
+	}
+
+	// After the cooldown the provider is probed and tried again
+	primary.err = nil
+	now = now.Add(2 * time.Minute)
+	names, err := chain.SuggestCategory(ctx, file)
+	if err != nil || names[0] != "primary" {
+		t.Errorf("SuggestCategory() = %v, %v after the cooldown", names, err)
+	}
+	if primary.probes != 2 {
+		t.Errorf("primary probed %d times, want 2", primary.probes)
+	}
+	if s := chain.Stats().Providers[0]; s.State != BreakerClosed {
+		t.Errorf("primary breaker = %s after a healthy probe", s.State)
+	}
+}
+
+func TestChainSkipsUnhealthyProvider(t *testing.T) {
+	down := &fakeClient{name: "down", healthErr: errors.New("connection refused")}
+	up := &fakeClient{name: "up"}
+	chain := NewChain([]Provider{{"down", down}, {"up", up}}, ChainOptions{})
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := chain.Analyze(ctx, "prompt", ""); err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+	}
+	if down.calls != 0 || down.probes != 1 {
+		t.Errorf("unhealthy provider called %d times and probed %d times, want 0 and 1", down.calls, down.probes)
+	}
+	if err := chain.CheckHealth(ctx); err != nil {
+		t.Errorf("CheckHealth() error = %v with a healthy provider", err)
+	}
+}
+
+func TestChainUnavailable(t *testing.T) {
+	down := &fakeClient{name: "down", healthErr: errors.New("connection refused")}
+	failing := &fakeClient{name: "failing", err: errors.New("500 internal error")}
+	chain := NewChain([]Provider{{"down", down}, {"failing", failing}}, ChainOptions{})
+
+	ctx := context.Background()
+	_, err := chain.SuggestName(ctx, &analyzer.FileMetadata{Name: "a.txt"})
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("SuggestName() error = %v, want ErrUnavailable", err)
+	}
+	if stats := chain.Stats(); stats.Unanswered != 1 {
+		t.Errorf("Unanswered = %d, want 1", stats.Unanswered)
+	}
+
+	failing.healthErr = errors.New("connection refused")
+	if err := chain.CheckHealth(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("CheckHealth() error = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestChainWithheld(t *testing.T) {
+	strict := &fakeClient{name: "remote", err: fmt.Errorf("%w: secret.txt holds an api-key", ErrWithheld)}
+	local := &fakeClient{name: "local"}
+
+	// A provider that withholds a file passes it on without failing
+	chain := NewChain([]Provider{{"remote", strict}, {"local", local}}, ChainOptions{FailureThreshold: 1})
+	ctx := context.Background()
+	file := &analyzer.FileMetadata{Name: "secret.txt"}
+	if names, err := chain.SuggestName(ctx, file); err != nil || names[0] != "local" {
+		t.Errorf("SuggestName() = %v, %v", names, err)
+	}
+	if s := chain.Stats().Providers[0]; s.Failed != 0 || s.State != BreakerClosed {
+		t.Errorf("withholding provider stats = %+v", s)
+	}
+
+	// A file withheld by every provider is not an outage
+	chain = NewChain([]Provider{{"remote", strict}}, ChainOptions{})
+	_, err := chain.SuggestName(ctx, file)
+	if !errors.Is(err, ErrWithheld) || errors.Is(err, ErrUnavailable) {
+		t.Errorf("SuggestName() error = %v, want ErrWithheld", err)
+	}
+	if stats := chain.Stats(); stats.Degraded() {
+		t.Errorf("Stats() = %+v, want no degradation", stats)
+	}
+}
+
+func TestChainUnsupported(t *testing.T) {
+	offline := &fakeClient{name: "offline", err: fmt.Errorf("%w: free-form prompts", ErrUnsupported)}
+	chain := NewChain([]Provider{{"offline", offline}}, ChainOptions{FailureThreshold: 1})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := chain.Analyze(ctx, "prompt", ""); !errors.Is(err, ErrUnavailable) || !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Analyze() error = %v, want ErrUnavailable and ErrUnsupported", err)
+		}
+	}
+
+	// The provider stays in use for the requests it supports
+	if offline.calls != 2 {
+		t.Errorf("provider called %d times, want 2", offline.calls)
+	}
+	if s := chain.Stats().Providers[0]; s.Failed != 0 || s.State != BreakerClosed {
+		t.Errorf("provider stats = %+v", s)
+	}
+}
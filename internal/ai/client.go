@@ -2,10 +2,20 @@ package ai
 
 import (
 	"context"
+	"errors"
 
 	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
 )
 
+// ErrWithheld is returned by clients that refuse to send a file or prompt,
+// such as under a privacy policy. It is not a failure of the provider.
+var ErrWithheld = errors.New("withheld from AI by privacy policy")
+
+// ErrUnsupported is returned by clients that cannot handle a kind of
+// request, such as free-form prompts without a language model. The request
+// is passed on without counting as a failure of the provider.
+var ErrUnsupported = errors.New("request not supported by AI provider")
+
 // AnalysisResult represents the result of an analysis request
 type AnalysisResult struct {
 	Success bool
@@ -19,4 +29,5 @@ type Client interface {
 	Analyze(ctx context.Context, prompt string, context string) (*AnalysisResult, error)
 	SuggestName(ctx context.Context, file *analyzer.FileMetadata) ([]string, error)
 	SuggestCategory(ctx context.Context, file *analyzer.FileMetadata) ([]string, error)
+	AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*FileAnalysis, error)
 }
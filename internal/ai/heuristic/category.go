@@ -0,0 +1,174 @@
+package heuristic
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// minCategoryScore is the score a category needs to be suggested: two
+// distinct keywords, one keyword used three times or one in the title
+const minCategoryScore = 4.0
+
+// titleWeight multiplies the score of keywords in the filename or title
+const titleWeight = 3.0
+
+// categoryKeywords are the English and Chinese words of each scenario
+// category. Words listed for several categories count less for each.
+var categoryKeywords = map[string][]string{
+	"resume": {
+		"resume", "curriculum vitae", "cv", "work experience", "professional experience",
+		"employment history", "education", "skills", "career objective", "references", "linkedin",
+		"简历", "个人简历", "工作经历", "工作经验", "教育背景", "求职意向", "专业技能", "自我评价", "项目经验",
+	},
+	"interview": {
+		"interview", "interviewer", "interviewee", "candidate", "interview questions", "behavioral",
+		"onsite", "phone screen", "leetcode", "coding challenge",
+		"面试", "面试题", "面经", "笔试", "候选人", "面试官", "一面", "二面",
+	},
+	"meeting": {
+		"meeting", "minutes", "agenda", "attendees", "participants", "action items",
+		"next steps", "discussed", "standup", "stand-up", "retrospective",
+		"会议", "会议纪要", "会议记录", "议程", "参会", "出席", "与会", "讨论", "待办",
+	},
+	"report": {
+		"report", "analysis", "findings", "quarterly", "annual", "results", "metrics",
+		"conclusion", "executive summary", "kpi", "revenue", "statistics",
+		"报告", "分析", "总结", "季度", "年度", "结论", "数据", "指标", "汇报",
+	},
+	"proposal": {
+		"proposal", "proposed", "propose", "scope", "deliverables", "milestones",
+		"timeline", "budget", "rfp", "objectives", "business case",
+		"提案", "建议书", "方案", "立项", "项目计划", "预算", "可行性",
+	},
+	"contract": {
+		"contract", "agreement", "party", "parties", "hereby", "hereinafter", "clause",
+		"terms and conditions", "liability", "termination", "governing law",
+		"signature", "witness", "indemnify",
+		"合同", "协议", "甲方", "乙方", "条款", "违约", "签字", "盖章", "签订",
+	},
+	"invoice": {
+		"invoice", "receipt", "bill to", "amount due", "subtotal", "total due",
+		"vat", "tax", "payment", "paid", "due date", "invoice number",
+		"发票", "收据", "账单", "金额", "合计", "税额", "价税合计", "付款", "开票",
+	},
+	"guide": {
+		"guide", "tutorial", "how to", "step", "install", "installation", "manual",
+		"instructions", "getting started", "usage", "configuration", "troubleshooting",
+		"指南", "教程", "手册", "说明书", "步骤", "安装", "使用说明", "入门", "配置",
+	},
+	"notes": {
+		"notes", "note", "todo", "to-do", "draft", "memo", "ideas", "reminder", "journal",
+		"笔记", "备忘", "备忘录", "草稿", "随笔", "待办事项", "日记", "心得",
+	},
+}
+
+// keyword is a category keyword with its weight across categories
+type keyword struct {
+	word     string
+	category string
+	idf      float64
+	match    *regexp.Regexp // Whole-word match of Latin keywords, nil for CJK
+}
+
+// keywords are all category keywords, built once
+var keywords = buildKeywords()
+
+// buildKeywords weighs each keyword by the inverse of the number of
+// categories listing it, treating categories as the documents of TF-IDF
+func buildKeywords() []keyword {
+	df := make(map[string]int)
+	for _, words := range categoryKeywords {
+		for _, w := range words {
+			df[w]++
+		}
+	}
+
+	var list []keyword
+	n := float64(len(categoryKeywords))
+	for category, words := range categoryKeywords {
+		for _, w := range words {
+			k := keyword{word: w, category: category, idf: math.Log(1 + n/float64(df[w]))}
+			if !hasCJK(w) {
+				k.match = regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(w) + `\b`)
+			}
+			list = append(list, k)
+		}
+	}
+	return list
+}
+
+// maxReasonKeywords is how many matched keywords a category reports
+const maxReasonKeywords = 3
+
+// categoryScore is the score of a category for a text, with its keywords
+// that were found, most telling first
+type categoryScore struct {
+	category string
+	score    float64
+	keywords []string
+}
+
+// categorize scores the categories for a document whose title (or
+// filename) and body are given, best first. Categories scoring below
+// minCategoryScore are left out.
+func categorize(title, body string) []categoryScore {
+	type match struct {
+		word  string
+		score float64
+	}
+	scores := make(map[string]float64)
+	matches := make(map[string][]match)
+	for _, k := range keywords {
+		tf := weightedCount(k, body) + titleWeight*weightedCount(k, title)
+		if tf > 0 {
+			scores[k.category] += tf * k.idf
+			matches[k.category] = append(matches[k.category], match{k.word, tf * k.idf})
+		}
+	}
+
+	var ranked []categoryScore
+	for category, score := range scores {
+		if score < minCategoryScore {
+			continue
+		}
+		found := matches[category]
+		sort.Slice(found, func(i, j int) bool {
+			if found[i].score != found[j].score {
+				return found[i].score > found[j].score
+			}
+			return found[i].word < found[j].word
+		})
+		s := categoryScore{category: category, score: score}
+		for i := 0; i < len(found) && i < maxReasonKeywords; i++ {
+			s.keywords = append(s.keywords, found[i].word)
+		}
+		ranked = append(ranked, s)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].category < ranked[j].category
+	})
+	return ranked
+}
+
+// weightedCount returns the dampened term frequency of a keyword in text,
+// 1 + ln(count), so that a word repeated many times does not dominate
+func weightedCount(k keyword, text string) float64 {
+	if text == "" {
+		return 0
+	}
+	var count int
+	if k.match != nil {
+		count = len(k.match.FindAllStringIndex(text, -1))
+	} else {
+		count = strings.Count(text, k.word)
+	}
+	if count == 0 {
+		return 0
+	}
+	return 1 + math.Log(float64(count))
+}
@@ -0,0 +1,37 @@
+package heuristic
+
+import "testing"
+
+func TestCategorize(t *testing.T) {
+	tests := []struct {
+		title string
+		body  string
+		want  string
+	}{
+		{"scan 0042", "INVOICE\nInvoice number 1001\nBill to: ACME\nSubtotal 90.00\nVAT 10.00\nAmount due 100.00", "invoice"},
+		{"notes", "Weekly sync. Attendees: Ann, Bob. Agenda: roadmap. Action items: Bob to send the plan.", "meeting"},
+		{"jane doe cv", "Jane Doe, engineer", "resume"},
+		{"", "本协议由甲方与乙方签订。双方同意以下条款，如有违约，需承担责任。", "contract"},
+		{"", "个人简历\n求职意向：前端工程师\n工作经历：2019-2023 某公司\n教育背景：某大学", "resume"},
+		{"", "第一季度数据分析报告：结论是指标稳步增长。", "report"},
+		{"", "Getting started: to install the tool, follow each step in this guide.", "guide"},
+	}
+	for _, tt := range tests {
+		ranked := categorize(tt.title, tt.body)
+		if len(ranked) == 0 || ranked[0].category != tt.want {
+			t.Errorf("categorize(%q, %q) = %v, want %s first", tt.title, tt.body, ranked, tt.want)
+		}
+	}
+}
+
+func TestCategorize_TooLittleEvidence(t *testing.T) {
+	for _, body := range []string{
+		"",
+		"Lunch was great, we should go again.",
+		"The payment went through.", // A single keyword
+	} {
+		if ranked := categorize("", body); len(ranked) != 0 {
+			t.Errorf("categorize(%q) = %v, want none", body, ranked)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package heuristic
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/xuanyiying/cleanup-cli/internal/ai"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+// Client is an ai.Client that needs no language model. It names files by
+// the titles in their metadata and content, or by their most frequent
+// words, and categorizes them by scoring English and Chinese keywords of
+// each scenario category. It works offline and is always available.
+type Client struct {
+	names *analyzer.NameScorer
+}
+
+// NewClient creates a heuristic client. Suggested names must score at
+// least the threshold of names; nil uses the default filename scorer.
+func NewClient(names *analyzer.NameScorer) *Client {
+	if names == nil {
+		names = analyzer.DefaultNameScorer()
+	}
+	return &Client{names: names}
+}
+
+// CheckHealth always succeeds, as the client needs no service
+func (c *Client) CheckHealth(ctx context.Context) error {
+	return nil
+}
+
+// Analyze fails with ai.ErrUnsupported, as free-form prompts need a
+// language model
+func (c *Client) Analyze(ctx context.Context, prompt string, contextContent string) (*ai.AnalysisResult, error) {
+	return nil, fmt.Errorf("%w: free-form prompts need a language model", ai.ErrUnsupported)
+}
+
+// nameCandidate is a suggested name, what it was taken from and how much
+// that source can be trusted, from 0 to 1
+type nameCandidate struct {
+	name       string
+	source     string
+	confidence float64
+}
+
+// SuggestName suggests names from the title in the file's metadata, the
+// subject or heading of its content and its most frequent words, in that
+// order. Names that would not score above the threshold are left out, so
+// the result is empty when the file gives nothing better to go by.
+func (c *Client) SuggestName(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	if file == nil {
+		return nil, fmt.Errorf("file metadata cannot be nil")
+	}
+
+	names := []string{}
+	for _, candidate := range c.nameCandidates(ctx, file) {
+		names = append(names, candidate.name)
+	}
+	return names, nil
+}
+
+// SuggestCategory suggests the scenario categories whose keywords score
+// highest in the file's name, title and content, best first. The result is
+// empty when no category scores high enough.
+func (c *Client) SuggestCategory(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	if file == nil {
+		return nil, fmt.Errorf("file metadata cannot be nil")
+	}
+
+	categories := []string{}
+	for _, s := range c.categories(ctx, file) {
+		categories = append(categories, s.category)
+	}
+	return categories, nil
+}
+
+// AnalyzeFile combines the best name and category. Its confidence is that
+// of the name's source averaged with how clearly the category scored, and
+// its reason says where both came from. Name or category are empty when
+// the file gives nothing to go by.
+func (c *Client) AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*ai.FileAnalysis, error) {
+	if file == nil {
+		return nil, fmt.Errorf("file metadata cannot be nil")
+	}
+
+	analysis := &ai.FileAnalysis{Language: file.Language}
+	var reasons []string
+	var confidence []float64
+	if candidates := c.nameCandidates(ctx, file); len(candidates) > 0 {
+		best := candidates[0]
+		analysis.Name = best.name
+		reasons = append(reasons, "named after "+best.source)
+		confidence = append(confidence, best.confidence)
+	}
+	if scores := c.categories(ctx, file); len(scores) > 0 {
+		best := scores[0]
+		analysis.Category = best.category
+		reasons = append(reasons, fmt.Sprintf("%s keywords: %s", best.category, strings.Join(best.keywords, ", ")))
+		confidence = append(confidence, math.Min(0.9, best.score/(3*minCategoryScore)))
+	}
+
+	if len(reasons) == 0 {
+		analysis.Reason = "nothing in the file to name or categorize it by"
+		return analysis, nil
+	}
+	for _, v := range confidence {
+		analysis.Confidence += v / float64(len(confidence))
+	}
+	analysis.Reason = strings.Join(reasons, "; ")
+	return analysis, nil
+}
+
+// nameCandidates returns the names the file's titles and words give, most
+// reliable first, leaving out its current name and names that would not
+// score above the threshold
+func (c *Client) nameCandidates(ctx context.Context, file *analyzer.FileMetadata) []nameCandidate {
+	ext := filepath.Ext(file.Name)
+	current := strings.ToLower(strings.TrimSuffix(file.Name, ext))
+	sources := []struct {
+		title      string
+		source     string
+		confidence float64
+	}{
+		{documentTitle(ctx, file), "the document title", 0.8},
+		{mediaTitle(file), "the media title", 0.8},
+		{contentTitle(file.ContentPreview), "the subject or heading", 0.6},
+		{keywordTitle(file.ContentPreview, file.Language), "the most frequent words", 0.4},
+	}
+
+	var candidates []nameCandidate
+	seen := map[string]bool{current: true}
+	for _, s := range sources {
+		name := slug(s.title)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if c.names.Score(name+ext).Score < c.names.Threshold() {
+			continue
+		}
+		candidates = append(candidates, nameCandidate{name: name, source: s.source, confidence: s.confidence})
+	}
+	return candidates
+}
+
+// categories scores the scenario categories by the keywords in the file's
+// name, title and content, best first
+func (c *Client) categories(ctx context.Context, file *analyzer.FileMetadata) []categoryScore {
+	// Separators within filenames would hide words from whole-word matching
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, strings.TrimSuffix(file.Name, filepath.Ext(file.Name)))
+	title := strings.Join([]string{name, documentTitle(ctx, file), contentTitle(file.ContentPreview)}, "\n")
+	return categorize(title, file.ContentPreview)
+}
+
+// documentTitle returns the title in a document's metadata. Files inside
+// archives cannot be read on their own and have none.
+func documentTitle(ctx context.Context, file *analyzer.FileMetadata) string {
+	if file.ArchivePath != "" || file.Path == "" {
+		return ""
+	}
+	return analyzer.DocumentTitle(ctx, file.Path, file.MimeType)
+}
+
+// mediaTitle returns the title of a song or video, led by its artist
+func mediaTitle(file *analyzer.FileMetadata) string {
+	title := file.MediaData[analyzer.MediaTitle]
+	if title == "" {
+		return ""
+	}
+	if artist := file.MediaData[analyzer.MediaArtist]; artist != "" {
+		return artist + " " + title
+	}
+	return title
+}
@@ -0,0 +1,119 @@
+package heuristic
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xuanyiying/cleanup-cli/internal/ai"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+func TestClient_SuggestName(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(nil)
+
+	dir := t.TempDir()
+	docx := filepath.Join(dir, "Document1.docx")
+	f, _ := os.Create(docx)
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("docProps/core.xml")
+	w.Write([]byte(`<cp:coreProperties xmlns:cp="cp" xmlns:dc="dc"><dc:title>Q3 Budget Review</dc:title></cp:coreProperties>`))
+	zw.Close()
+	f.Close()
+
+	tests := []struct {
+		file *analyzer.FileMetadata
+		want []string
+	}{
+		{
+			&analyzer.FileMetadata{Path: docx, Name: "Document1.docx", MimeType: analyzer.MimeDOCX, ContentPreview: "# Budget\nNumbers"},
+			[]string{"q3-budget-review", "budget"},
+		},
+		{
+			&analyzer.FileMetadata{Name: "message.eml", ContentPreview: "From: ann@example.com\nSubject: Re: Offsite venue options\n\nHi"},
+			[]string{"offsite-venue-options"},
+		},
+		{
+			&analyzer.FileMetadata{Name: "untitled.txt", Language: "en", ContentPreview: "The telescope mirror arrived. We polished the mirror and aligned the telescope."},
+			[]string{"telescope-mirror"},
+		},
+		{
+			&analyzer.FileMetadata{Name: "IMG_2041.mp3", MediaData: map[string]string{analyzer.MediaTitle: "Clair de Lune", analyzer.MediaArtist: "Debussy"}},
+			[]string{"debussy-clair-de-lune"},
+		},
+		{
+			// Nothing better than a generic word to go by
+			&analyzer.FileMetadata{Name: "scan.txt", ContentPreview: "Data"},
+			[]string{},
+		},
+	}
+	for _, tt := range tests {
+		got, err := client.SuggestName(ctx, tt.file)
+		if err != nil {
+			t.Fatalf("SuggestName(%s) error = %v", tt.file.Name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SuggestName(%s) = %q, want %q", tt.file.Name, got, tt.want)
+		}
+	}
+}
+
+func TestClient_SuggestCategory(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(nil)
+
+	got, err := client.SuggestCategory(ctx, &analyzer.FileMetadata{Name: "2024_invoice-0042.pdf", ContentPreview: "ACME Ltd"})
+	if err != nil || len(got) == 0 || got[0] != "invoice" {
+		t.Errorf("SuggestCategory() = %v, %v, want invoice", got, err)
+	}
+	got, err = client.SuggestCategory(ctx, &analyzer.FileMetadata{Name: "a.txt", ContentPreview: "Hello there"})
+	if err != nil || len(got) != 0 {
+		t.Errorf("SuggestCategory() = %v, %v, want none", got, err)
+	}
+}
+
+func TestClient_AnalyzeFile(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(nil)
+
+	got, err := client.AnalyzeFile(ctx, &analyzer.FileMetadata{
+		Name:           "doc1.txt",
+		Language:       "en",
+		ContentPreview: "Subject: Weekly sync minutes\n\nAgenda: budget. Action items: book the venue.",
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+	want := &ai.FileAnalysis{
+		Name:     "weekly-sync-minutes",
+		Category: "meeting",
+		Language: "en",
+		Reason:   "named after the subject or heading; meeting keywords: minutes, action items, agenda",
+	}
+	if got.Name != want.Name || got.Category != want.Category || got.Language != want.Language || got.Reason != want.Reason {
+		t.Errorf("AnalyzeFile() = %+v, want %+v", got, want)
+	}
+	if got.Confidence <= 0 || got.Confidence > 1 {
+		t.Errorf("Confidence = %g, want between 0 and 1", got.Confidence)
+	}
+
+	got, err = client.AnalyzeFile(ctx, &analyzer.FileMetadata{Name: "scan.txt", ContentPreview: "Data"})
+	if err != nil || got.Name != "" || got.Category != "" || got.Confidence != 0 {
+		t.Errorf("AnalyzeFile() = %+v, %v, want no suggestion", got, err)
+	}
+}
+
+func TestClient_Analyze(t *testing.T) {
+	client := NewClient(nil)
+	if err := client.CheckHealth(context.Background()); err != nil {
+		t.Errorf("CheckHealth() error = %v", err)
+	}
+	if _, err := client.Analyze(context.Background(), "organize ~/Downloads", ""); !errors.Is(err, ai.ErrUnsupported) {
+		t.Errorf("Analyze() error = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,246 @@
+package heuristic
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxNameLen is the length of the longest suggested name, in characters
+const maxNameLen = 50
+
+var (
+	subjectLine      = regexp.MustCompile(`(?mi)^(?:subject|主题)\s*[:：]\s*(.+)$`)
+	replyPrefix      = regexp.MustCompile(`(?i)^(?:(?:re|fwd?|aw|wg|回复|答复|转发)\s*[:：]\s*)+`)
+	frontMatterTitle = regexp.MustCompile(`(?m)^title:\s*["']?(.+?)["']?\s*$`)
+	atxHeading       = regexp.MustCompile(`(?m)^#{1,6}\s+(.+?)[\s#]*$`)
+	setextHeading    = regexp.MustCompile(`(?m)^(\S[^\n]*)\n(?:=+|-{2,})[ \t]*$`)
+	fieldLine        = regexp.MustCompile(`^[\p{L}-]+\s*[:：]\s`)
+)
+
+// stopwords are English words too common to name a file by
+var stopwords = toSet(
+	"the", "and", "for", "are", "but", "not", "you", "all", "any", "can", "had", "her", "was",
+	"one", "our", "out", "has", "have", "him", "his", "how", "its", "may", "new", "now", "own",
+	"see", "two", "way", "who", "did", "get", "let", "say", "she", "too", "use", "with", "this",
+	"that", "from", "they", "will", "would", "there", "their", "what", "about", "which", "when",
+	"make", "like", "time", "just", "know", "take", "into", "your", "some", "could", "them",
+	"than", "then", "other", "only", "also", "after", "first", "well", "even", "want", "because",
+	"these", "most", "very", "been", "were", "more", "should", "each", "such", "here", "where",
+	"over", "under", "between", "through", "while", "being", "does", "done", "must", "shall",
+	"page", "file", "document", "untitled", "copy", "draft", "version", "http", "https", "www",
+)
+
+// cjkStopChars are Chinese characters that rarely belong to a key term
+var cjkStopChars = toSet(
+	"的", "了", "是", "在", "和", "有", "与", "及", "等", "为", "这", "那", "个", "们", "我",
+	"你", "他", "她", "它", "也", "就", "都", "而", "但", "被", "把", "对", "从", "将", "于",
+	"之", "以", "或", "并", "其", "中", "上", "下", "不", "一", "到", "说", "要", "会",
+)
+
+// contentTitle returns the title of a text: the subject of an email, the
+// title of its front matter, its first Markdown heading, or its first line
+// if that reads like a title. It returns "" if there is none.
+func contentTitle(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if m := subjectLine.FindStringSubmatch(text); m != nil {
+		if subject := replyPrefix.ReplaceAllString(strings.TrimSpace(m[1]), ""); subject != "" {
+			return subject
+		}
+	}
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		if end := strings.Index(rest, "\n---"); end >= 0 {
+			if m := frontMatterTitle.FindStringSubmatch(rest[:end]); m != nil {
+				return m[1]
+			}
+			text = rest[end+4:]
+		}
+	}
+	if m := atxHeading.FindStringSubmatch(text); m != nil && titleLike(m[1]) {
+		return m[1]
+	}
+	if m := setextHeading.FindStringSubmatch(text); m != nil && titleLike(m[1]) {
+		return m[1]
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if titleLike(line) {
+			return line
+		}
+		break
+	}
+	return ""
+}
+
+// titleLike reports whether a line reads like a title: short, mostly
+// letters and not a sentence, a field such as "From: ..." or a link
+func titleLike(line string) bool {
+	line = strings.TrimSpace(line)
+	n := utf8.RuneCountInString(line)
+	if n < 2 || n > 80 || strings.Contains(line, "://") || fieldLine.MatchString(line) {
+		return false
+	}
+	if len(strings.Fields(line)) > 12 {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(line)
+	if strings.ContainsRune(".。!！?？;；,，、", last) {
+		return false
+	}
+
+	var letters, other int
+	for _, r := range line {
+		switch {
+		case unicode.IsLetter(r):
+			letters++
+		case !unicode.IsSpace(r):
+			other++
+		}
+	}
+	return letters >= 2 && letters >= other
+}
+
+// keywordTitle joins the terms that occur most often in a text, or returns
+// "" if no term occurs more than once. Chinese is not separated into words
+// by spaces, so its terms are runs of two to four characters, and longer
+// terms are preferred over the shorter ones they contain.
+func keywordTitle(text, language string) string {
+	type term struct {
+		text  string
+		count int
+		first int
+		score int
+	}
+	terms := make(map[string]*term)
+	add := func(t string) {
+		if tt, ok := terms[t]; ok {
+			tt.count++
+			return
+		}
+		terms[t] = &term{text: t, count: 1, first: len(terms)}
+	}
+
+	limit := 3
+	cjk := language == "zh"
+	if cjk {
+		limit = 2
+		for _, run := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.Is(unicode.Han, r) }) {
+			chars := []rune(run)
+			for i := range chars {
+				for n := 2; n <= 4 && i+n <= len(chars); n++ {
+					if cjkStopChars[string(chars[i+n-1])] || cjkStopChars[string(chars[i])] {
+						break
+					}
+					add(string(chars[i : i+n]))
+				}
+			}
+		}
+	} else {
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, w := range words {
+			if utf8.RuneCountInString(w) < 3 || stopwords[w] || strings.IndexFunc(w, unicode.IsLetter) < 0 {
+				continue
+			}
+			add(w)
+		}
+	}
+
+	var ranked []*term
+	for _, t := range terms {
+		if t.count < 2 {
+			continue
+		}
+		t.score = t.count
+		if cjk {
+			t.score *= utf8.RuneCountInString(t.text)
+		}
+		ranked = append(ranked, t)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].first < ranked[j].first
+	})
+
+	// Terms overlapping a better one add nothing
+	var chosen []*term
+	for _, t := range ranked {
+		if len(chosen) == limit {
+			break
+		}
+		overlaps := false
+		for _, c := range chosen {
+			if strings.Contains(c.text, t.text) || strings.Contains(t.text, c.text) {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			chosen = append(chosen, t)
+		}
+	}
+
+	// Keep the terms in the order they appear in
+	sort.Slice(chosen, func(i, j int) bool { return chosen[i].first < chosen[j].first })
+	parts := make([]string, len(chosen))
+	for i, t := range chosen {
+		parts[i] = t.text
+	}
+	return strings.Join(parts, " ")
+}
+
+// slug turns a title into a filename: lowercase letters and digits of any
+// script joined by hyphens, cut at a word boundary after maxNameLen
+// characters
+func slug(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		hyphen = true
+	}
+
+	name := b.String()
+	if utf8.RuneCountInString(name) <= maxNameLen {
+		return name
+	}
+	runes := []rune(name)[:maxNameLen]
+	cut := string(runes)
+	if i := strings.LastIndexByte(cut, '-'); i > 0 {
+		cut = cut[:i]
+	}
+	return cut
+}
+
+// hasCJK reports whether s holds Chinese, Japanese or Korean characters
+func hasCJK(s string) bool {
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			return true
+		}
+	}
+	return false
+}
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
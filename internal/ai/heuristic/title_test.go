@@ -0,0 +1,55 @@
+package heuristic
+
+import "testing"
+
+func TestContentTitle(t *testing.T) {
+	tests := map[string]string{
+		"From: ann@example.com\nSubject: Re: Fwd: Q3 budget review\n\nHi all":  "Q3 budget review",
+		"主题：回复：年度预算\n\n各位好":                                                    "年度预算",
+		"---\ntitle: \"Trip to Kyoto\"\ndate: 2024-05-01\n---\nWe left early.": "Trip to Kyoto",
+		"Some intro line that is a sentence.\n\n## Install steps ##\nRun it":   "Install steps",
+		"Project Apollo\n==============\nNotes follow":                         "Project Apollo",
+		"Weekly Sync Agenda\n\n1. Status":                                      "Weekly Sync Agenda",
+		"项目启动会议纪要\n时间：周一":                                                      "项目启动会议纪要",
+		"This is just the first sentence of a long letter.\nMore text":         "",
+		"https://example.com/download":                                         "",
+		"From: bob@example.com\nTo: ann@example.com":                           "",
+		"1234 5678 9012": "",
+	}
+	for text, want := range tests {
+		if got := contentTitle(text); got != want {
+			t.Errorf("contentTitle(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestKeywordTitle(t *testing.T) {
+	text := "The telescope mirror arrived. We polished the mirror and aligned the telescope. " +
+		"Mirror coating is next; the telescope needs a mount."
+	if got := keywordTitle(text, "en"); got != "telescope mirror" {
+		t.Errorf("keywordTitle() = %q, want %q", got, "telescope mirror")
+	}
+	if got := keywordTitle("All words differ in this short text", "en"); got != "" {
+		t.Errorf("keywordTitle() = %q for words used once", got)
+	}
+	zh := "本次团建活动安排在周六。团建活动包括徒步和烧烤，团建预算已批准。"
+	if got := keywordTitle(zh, "zh"); got != "团建活动" {
+		t.Errorf("keywordTitle() = %q", got)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"Q3 Budget Review (Final)": "q3-budget-review-final",
+		"  Trip to Kyoto!  ":       "trip-to-kyoto",
+		"项目启动会议纪要":                 "项目启动会议纪要",
+		"Über Straße – Notizen":    "über-straße-notizen",
+		"!!!":                      "",
+		"a very long title that keeps going well past the fifty character limit": "a-very-long-title-that-keeps-going-well-past-the",
+	}
+	for title, want := range tests {
+		if got := slug(title); got != want {
+			t.Errorf("slug(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
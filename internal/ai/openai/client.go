@@ -6,6 +6,7 @@ import (
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/shared"
 	"github.com/xuanyiying/cleanup-cli/internal/ai"
 	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
 	"github.com/xuanyiying/cleanup-cli/internal/config"
@@ -111,3 +112,33 @@ func (c *OpenAIClient) SuggestCategory(ctx context.Context, file *analyzer.FileM
 
 	return []string{ai.CleanSuggestedCategory(result.Content)}, nil
 }
+
+// AnalyzeFile asks for the name, category, confidence, language and reason
+// of a file in one request, constraining the response to
+// ai.FileAnalysisSchema and asking again if it is malformed
+func (c *OpenAIClient) AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*ai.FileAnalysis, error) {
+	return ai.RequestFileAnalysis(ctx, file, func(ctx context.Context, prompt string) (string, error) {
+		resp, err := c.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.UserMessage(prompt),
+			},
+			Model: openai.ChatModel(c.config.Model),
+			ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+				OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+					JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+						Name:   "file_analysis",
+						Schema: ai.FileAnalysisSchema(),
+						Strict: openai.Bool(true),
+					},
+				},
+			},
+		})
+		if err != nil {
+			return "", fmt.Errorf("openai analysis failed: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return "", fmt.Errorf("analysis failed")
+		}
+		return resp.Choices[0].Message.Content, nil
+	})
+}
@@ -214,3 +214,47 @@ func TestOpenAIClient_CheckHealth_Error(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "openai health check failed")
 }
+
+func TestOpenAIClient_AnalyzeFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		format, _ := req["response_format"].(map[string]any)
+		assert.Equal(t, "json_schema", format["type"])
+		schema, _ := format["json_schema"].(map[string]any)
+		assert.Equal(t, "file_analysis", schema["name"])
+		assert.Equal(t, true, schema["strict"])
+
+		resp := chatCompletionResponse{
+			ID:      "chatcmpl-123",
+			Object:  "chat.completion",
+			Created: time.Now().Unix(),
+			Model:   "gpt-4",
+			Choices: []choice{
+				{
+					Index: 0,
+					Message: message{
+						Role:    "assistant",
+						Content: `{"name": "team-offsite-plan", "category": "notes", "confidence": 0.7, "language": "en", "reason": "Plans for the offsite"}`,
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.OpenAIConfig{APIKey: "test-key", BaseURL: server.URL, Model: "gpt-4", Timeout: 10 * time.Second})
+	analysis, err := client.AnalyzeFile(context.Background(), &analyzer.FileMetadata{
+		Name:           "test.txt",
+		MimeType:       "text/plain",
+		ContentPreview: "Agenda and travel plans for the team offsite.",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "team-offsite-plan", analysis.Name)
+	assert.Equal(t, "notes", analysis.Category)
+	assert.Equal(t, 0.7, analysis.Confidence)
+	assert.Equal(t, "Plans for the offsite", analysis.Reason)
+}
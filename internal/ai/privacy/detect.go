@@ -0,0 +1,183 @@
+// Package privacy keeps secrets and personal data out of what the CLI sends
+// to AI providers. It detects them in file previews and prompts, redacts or
+// withholds them under a policy, and wraps an ai.Client so that every call
+// goes through that policy.
+package privacy
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kind is a category of sensitive content
+type Kind string
+
+// Kinds of sensitive content that are detected
+const (
+	KindPrivateKey Kind = "private-key"
+	KindAPIKey     Kind = "api-key"
+	KindPassword   Kind = "password"
+	KindCreditCard Kind = "credit-card"
+	KindIDNumber   Kind = "id-number"
+	KindEmail      Kind = "email"
+	KindPhone      Kind = "phone"
+)
+
+// Kinds lists every kind, from the most to the least sensitive
+var Kinds = []Kind{KindPrivateKey, KindAPIKey, KindPassword, KindCreditCard, KindIDNumber, KindEmail, KindPhone}
+
+// Finding is sensitive content found in a text, as a byte range
+type Finding struct {
+	Kind  Kind
+	Start int
+	End   int
+}
+
+// detector finds one kind of content. If the pattern has a group, only the
+// group is sensitive, such as the value of a password assignment. valid
+// rejects matches that only look like the kind, such as numbers failing a
+// checksum.
+type detector struct {
+	kind    Kind
+	pattern *regexp.Regexp
+	valid   func(match string) bool
+}
+
+// detectors are listed by priority: where matches overlap, the earlier
+// detector's match is kept
+var detectors = []detector{
+	{KindPrivateKey, regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----.*?(?:-----END [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----|\z)`), nil},
+	{KindAPIKey, regexp.MustCompile(`\b(?:` +
+		`sk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}|` + // OpenAI, Anthropic
+		`(?:AKIA|ASIA)[0-9A-Z]{16}|` + // AWS access key ID
+		`gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,}|` + // GitHub
+		`glpat-[A-Za-z0-9_-]{20,}|` + // GitLab
+		`xox[abposr]-[A-Za-z0-9-]{10,}|` + // Slack
+		`AIza[0-9A-Za-z_-]{35}|` + // Google
+		`(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{16,}|` + // Stripe
+		`eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}` + // JSON web token
+		`)`), nil},
+	{KindAPIKey, regexp.MustCompile(`(?i)(?:api[_-]?key|api[_-]?secret|secret[_-]?key|client[_-]?secret|access[_-]?key|access[_-]?token|auth[_-]?token|token|secret)["']?\s*[:=]\s*["']?([A-Za-z0-9_\-+/.=]{12,})`), nil},
+	{KindPassword, regexp.MustCompile(`(?i)(?:password|passwd|passphrase|pwd|密码|口令)["']?\s*[:=：]\s*["']?([^\s"',;]{4,})`), nil},
+	{KindPassword, regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:([^\s@/]+)@`), nil},
+	{KindIDNumber, regexp.MustCompile(`\b[1-9]\d{5}(?:18|19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]\b`), validResidentID},
+	{KindIDNumber, regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), validSSN},
+	{KindCreditCard, regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), validCardNumber},
+	{KindEmail, regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`), nil},
+	{KindPhone, regexp.MustCompile(`(?:\+?86[ -]?)?\b1[3-9]\d(?:[ -]?\d{4}){2}\b`), nil},
+	{KindPhone, regexp.MustCompile(`\+\d{1,3}[ .-]?(?:\(\d{1,4}\)|\d{1,4})(?:[ .-]?\d{2,4}){2,3}\b`), nil},
+	{KindPhone, regexp.MustCompile(`(?:\(\d{3}\) ?|\b\d{3}[.-])\d{3}[.-]\d{4}\b`), nil},
+}
+
+// Detect returns the sensitive content in a text, ordered by position and
+// without overlaps
+func Detect(text string) []Finding {
+	type candidate struct {
+		Finding
+		priority int
+	}
+	var candidates []candidate
+	for priority, d := range detectors {
+		for _, m := range d.pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if len(m) > 2 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			if d.valid != nil && !d.valid(text[start:end]) {
+				continue
+			}
+			candidates = append(candidates, candidate{Finding{d.kind, start, end}, priority})
+		}
+	}
+
+	// Earlier detectors claim their ranges first
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].priority < candidates[j].priority
+	})
+	var findings []Finding
+	for _, c := range candidates {
+		overlaps := false
+		for _, f := range findings {
+			if c.Start < f.End && f.Start < c.End {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			findings = append(findings, c.Finding)
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+	return findings
+}
+
+// Placeholder returns what content of a kind is replaced with
+func Placeholder(kind Kind) string {
+	return "[REDACTED " + strings.ToUpper(strings.ReplaceAll(string(kind), "-", " ")) + "]"
+}
+
+// Redact replaces the findings of the given kinds in a text with their
+// placeholders. findings must come from Detect on the same text.
+func Redact(text string, findings []Finding, kinds map[Kind]bool) string {
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		if !kinds[f.Kind] {
+			continue
+		}
+		b.WriteString(text[last:f.Start])
+		b.WriteString(Placeholder(f.Kind))
+		last = f.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// digits returns the decimal digits of s
+func digits(s string) []int {
+	var d []int
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			d = append(d, int(r-'0'))
+		}
+	}
+	return d
+}
+
+// validCardNumber checks a payment card number's length and Luhn checksum
+func validCardNumber(s string) bool {
+	d := digits(s)
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range d {
+		n := d[len(d)-1-i]
+		if i%2 == 1 {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+	}
+	return sum%10 == 0
+}
+
+// validResidentID checks the check digit of an 18-digit Chinese resident
+// identity card number (GB 11643)
+func validResidentID(s string) bool {
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, w := range weights {
+		sum += int(s[i]-'0') * w
+	}
+	return "10X98765432"[sum%11] == strings.ToUpper(s)[17]
+}
+
+// validSSN rejects US social security numbers that are never issued
+func validSSN(s string) bool {
+	area, group, serial := s[:3], s[4:6], s[7:]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
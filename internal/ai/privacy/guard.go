@@ -0,0 +1,184 @@
+package privacy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/xuanyiying/cleanup-cli/internal/ai"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+// ErrBlocked is returned for files and prompts the policy does not let
+// through
+var ErrBlocked = ai.ErrWithheld
+
+// Stats counts the files a guard changed or withheld
+type Stats struct {
+	Redacted int          // Files sent with content redacted
+	Blocked  int          // Files not sent
+	Kinds    map[Kind]int // Files holding each kind of sensitive content
+}
+
+// Guard is an ai.Client that applies a policy to everything before passing
+// it on to another client
+type Guard struct {
+	client ai.Client
+	policy *Policy
+
+	mu    sync.Mutex
+	files map[string]*outcome // What the policy did with each file
+}
+
+// outcome is what a guard did with a file
+type outcome struct {
+	blocked  bool
+	redacted bool
+	kinds    map[Kind]bool
+}
+
+// NewGuard wraps a client so that it only receives what a policy allows
+func NewGuard(client ai.Client, policy *Policy) *Guard {
+	return &Guard{
+		client: client,
+		policy: policy,
+		files:  make(map[string]*outcome),
+	}
+}
+
+// Policy returns the policy the guard applies
+func (g *Guard) Policy() *Policy {
+	return g.policy
+}
+
+// CheckHealth checks the wrapped client
+func (g *Guard) CheckHealth(ctx context.Context) error {
+	return g.client.CheckHealth(ctx)
+}
+
+// Analyze sends a prompt and its context with sensitive content redacted,
+// or fails with ErrBlocked
+func (g *Guard) Analyze(ctx context.Context, prompt string, contextContent string) (*ai.AnalysisResult, error) {
+	prompt, d := g.policy.CheckText(prompt)
+	if d.Blocked {
+		return nil, fmt.Errorf("%w: prompt %s", ErrBlocked, d.Reason)
+	}
+	contextContent, d = g.policy.CheckText(contextContent)
+	if d.Blocked {
+		return nil, fmt.Errorf("%w: context %s", ErrBlocked, d.Reason)
+	}
+	return g.client.Analyze(ctx, prompt, contextContent)
+}
+
+// SuggestName asks for names with the file's preview redacted, or fails
+// with ErrBlocked
+func (g *Guard) SuggestName(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	safe, err := g.check(file)
+	if err != nil {
+		return nil, err
+	}
+	return g.client.SuggestName(ctx, safe)
+}
+
+// SuggestCategory asks for categories with the file's preview redacted, or
+// fails with ErrBlocked
+func (g *Guard) SuggestCategory(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	safe, err := g.check(file)
+	if err != nil {
+		return nil, err
+	}
+	return g.client.SuggestCategory(ctx, safe)
+}
+
+// AnalyzeFile asks for a structured analysis with the file's preview
+// redacted, or fails with ErrBlocked
+func (g *Guard) AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*ai.FileAnalysis, error) {
+	safe, err := g.check(file)
+	if err != nil {
+		return nil, err
+	}
+	return g.client.AnalyzeFile(ctx, safe)
+}
+
+// Stats returns what the guard has redacted and withheld so far
+func (g *Guard) Stats() Stats {
+	return MergeStats(g)
+}
+
+// MergeStats adds up the stats of guards in front of different providers,
+// counting each file once: as redacted if any guard let it through
+// redacted, as withheld if every guard that saw it withheld it
+func MergeStats(guards ...*Guard) Stats {
+	merged := make(map[string]*outcome)
+	for _, g := range guards {
+		g.mu.Lock()
+		for path, o := range g.files {
+			m, ok := merged[path]
+			if !ok {
+				m = &outcome{blocked: true, kinds: make(map[Kind]bool)}
+				merged[path] = m
+			}
+			m.blocked = m.blocked && o.blocked
+			m.redacted = m.redacted || o.redacted
+			for k := range o.kinds {
+				m.kinds[k] = true
+			}
+		}
+		g.mu.Unlock()
+	}
+
+	stats := Stats{Kinds: make(map[Kind]int)}
+	for _, o := range merged {
+		switch {
+		case o.redacted:
+			stats.Redacted++
+		case o.blocked:
+			stats.Blocked++
+		}
+		for k := range o.kinds {
+			stats.Kinds[k]++
+		}
+	}
+	return stats
+}
+
+// check applies the policy to a file and counts the outcome once per file
+func (g *Guard) check(file *analyzer.FileMetadata) (*analyzer.FileMetadata, error) {
+	if file == nil {
+		return nil, fmt.Errorf("file metadata cannot be nil")
+	}
+	safe, d := g.policy.CheckFile(file)
+
+	g.mu.Lock()
+	if _, seen := g.files[file.Path]; !seen {
+		o := &outcome{blocked: d.Blocked, redacted: !d.Blocked && d.Redacted > 0, kinds: make(map[Kind]bool)}
+		for _, f := range d.Findings {
+			o.kinds[f.Kind] = true
+		}
+		g.files[file.Path] = o
+	}
+	g.mu.Unlock()
+
+	if d.Blocked {
+		return nil, fmt.Errorf("%w: %s %s", ErrBlocked, file.Name, d.Reason)
+	}
+	return safe, nil
+}
+
+// IsLocal reports whether a provider's base URL points at this machine.
+// Providers elsewhere get the stricter RemotePolicy.
+func IsLocal(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,135 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xuanyiying/cleanup-cli/internal/ai"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+// recordingClient records what reaches the provider
+type recordingClient struct {
+	prompts  []string
+	previews []string
+}
+
+func (c *recordingClient) CheckHealth(ctx context.Context) error { return nil }
+
+func (c *recordingClient) Analyze(ctx context.Context, prompt string, contextContent string) (*ai.AnalysisResult, error) {
+	c.prompts = append(c.prompts, prompt+"|"+contextContent)
+	return &ai.AnalysisResult{Success: true}, nil
+}
+
+func (c *recordingClient) SuggestName(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	c.previews = append(c.previews, file.ContentPreview)
+	return []string{"name"}, nil
+}
+
+func (c *recordingClient) SuggestCategory(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	c.previews = append(c.previews, file.ContentPreview)
+	return []string{"category"}, nil
+}
+
+func (c *recordingClient) AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*ai.FileAnalysis, error) {
+	c.previews = append(c.previews, file.ContentPreview)
+	return &ai.FileAnalysis{Name: "name", Category: "other", Reason: "reason"}, nil
+}
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	client := &recordingClient{}
+	guard := NewGuard(client, RemotePolicy())
+
+	files := []*analyzer.FileMetadata{
+		{Path: "/d/contact.txt", Name: "contact.txt", ContentPreview: "Call 13812345678 or mail bob@example.com"},
+		{Path: "/d/deploy.txt", Name: "deploy.txt", ContentPreview: "token: 9f8e7d6c5b4a39281706"},
+		{Path: "/d/id_rsa", Name: "id_rsa", ContentPreview: "whatever"},
+		{Path: "/d/plain.txt", Name: "plain.txt", ContentPreview: "Shopping list"},
+	}
+	for _, file := range files {
+		_, nameErr := guard.SuggestName(ctx, file)
+		_, categoryErr := guard.SuggestCategory(ctx, file)
+		_, analysisErr := guard.AnalyzeFile(ctx, file)
+		blocked := file.Name == "deploy.txt" || file.Name == "id_rsa"
+		if blocked != errors.Is(nameErr, ErrBlocked) || blocked != errors.Is(categoryErr, ErrBlocked) || blocked != errors.Is(analysisErr, ErrBlocked) {
+			t.Errorf("%s: errors = %v, %v, %v, blocked %v", file.Name, nameErr, categoryErr, analysisErr, blocked)
+		}
+	}
+
+	redacted := "Call [REDACTED PHONE] or mail [REDACTED EMAIL]"
+	want := []string{redacted, redacted, redacted, "Shopping list", "Shopping list", "Shopping list"}
+	if len(client.previews) != len(want) {
+		t.Fatalf("provider received %q, want %q", client.previews, want)
+	}
+	for i := range want {
+		if client.previews[i] != want[i] {
+			t.Errorf("provider received %q, want %q", client.previews[i], want[i])
+		}
+	}
+
+	// Files asked about several times are counted once
+	stats := guard.Stats()
+	if stats.Redacted != 1 || stats.Blocked != 2 || stats.Kinds[KindPhone] != 1 || stats.Kinds[KindAPIKey] != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestGuard_Analyze(t *testing.T) {
+	ctx := context.Background()
+	client := &recordingClient{}
+	guard := NewGuard(client, RemotePolicy())
+
+	if _, err := guard.Analyze(ctx, "organize files for bob@example.com", ""); err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if client.prompts[0] != "organize files for [REDACTED EMAIL]|" {
+		t.Errorf("provider received %q", client.prompts[0])
+	}
+	if _, err := guard.Analyze(ctx, "summarize", "password: hunter22"); !errors.Is(err, ErrBlocked) {
+		t.Errorf("Analyze() error = %v, want ErrBlocked", err)
+	}
+	if len(client.prompts) != 1 {
+		t.Errorf("provider received a blocked prompt: %q", client.prompts)
+	}
+}
+
+func TestMergeStats(t *testing.T) {
+	ctx := context.Background()
+	local := NewGuard(&recordingClient{}, LocalPolicy())
+	remote := NewGuard(&recordingClient{}, RemotePolicy())
+
+	files := []*analyzer.FileMetadata{
+		{Path: "/d/contact.txt", Name: "contact.txt", ContentPreview: "mail bob@example.com"},
+		{Path: "/d/deploy.txt", Name: "deploy.txt", ContentPreview: "token: 9f8e7d6c5b4a39281706"},
+		{Path: "/d/id_rsa", Name: "id_rsa", ContentPreview: "whatever"},
+	}
+	for _, file := range files {
+		local.SuggestName(ctx, file)
+		remote.SuggestName(ctx, file)
+	}
+
+	// The token reached the local provider redacted, so only the key file
+	// was withheld from both providers
+	stats := MergeStats(local, remote)
+	if stats.Redacted != 2 || stats.Blocked != 1 || stats.Kinds[KindEmail] != 1 || stats.Kinds[KindAPIKey] != 1 {
+		t.Errorf("MergeStats() = %+v", stats)
+	}
+}
+
+func TestIsLocal(t *testing.T) {
+	tests := map[string]bool{
+		"http://localhost:11434":    true,
+		"http://127.0.0.1:1234/v1":  true,
+		"http://[::1]:8080":         true,
+		"https://api.openai.com/v1": false,
+		"http://192.168.1.20:11434": false,
+		"":                          false,
+	}
+	for url, want := range tests {
+		if got := IsLocal(url); got != want {
+			t.Errorf("IsLocal(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package privacy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/config"
+)
+
+// Action is what a policy does with a kind of sensitive content
+type Action string
+
+const (
+	// ActionAllow sends the content as it is
+	ActionAllow Action = "allow"
+	// ActionRedact replaces the content with a placeholder
+	ActionRedact Action = "redact"
+	// ActionBlock sends nothing about a file whose preview holds the content
+	ActionBlock Action = "block"
+)
+
+// DefaultNeverSend are files whose content is never sent to AI: keys,
+// certificates, credentials and environment files
+var DefaultNeverSend = []string{
+	".env", ".env.*", "*.key", "*.pem", "*.p12", "*.pfx", "*.keystore", "*.jks",
+	"id_rsa*", "id_dsa*", "id_ecdsa*", "id_ed25519*", ".netrc", ".pgpass", ".npmrc", ".pypirc",
+	"credentials", "credentials.*", "*.kdbx", ".ssh", ".gnupg", ".aws",
+}
+
+// Policy decides what of a file may be sent to an AI provider
+type Policy struct {
+	Actions   map[Kind]Action // Kinds missing from the map are allowed
+	NeverSend []string        // Patterns of files never sent, see Policy.NeverSends
+}
+
+// LocalPolicy returns the default policy for providers on this machine:
+// secrets and identity numbers are redacted, contact details are sent
+func LocalPolicy() *Policy {
+	return &Policy{
+		Actions: map[Kind]Action{
+			KindPrivateKey: ActionRedact,
+			KindAPIKey:     ActionRedact,
+			KindPassword:   ActionRedact,
+			KindCreditCard: ActionRedact,
+			KindIDNumber:   ActionRedact,
+			KindEmail:      ActionAllow,
+			KindPhone:      ActionAllow,
+		},
+		NeverSend: append([]string(nil), DefaultNeverSend...),
+	}
+}
+
+// RemotePolicy returns the default policy for providers reached over the
+// network: files holding secrets are not sent at all and all other
+// personal data is redacted
+func RemotePolicy() *Policy {
+	return &Policy{
+		Actions: map[Kind]Action{
+			KindPrivateKey: ActionBlock,
+			KindAPIKey:     ActionBlock,
+			KindPassword:   ActionBlock,
+			KindCreditCard: ActionRedact,
+			KindIDNumber:   ActionRedact,
+			KindEmail:      ActionRedact,
+			KindPhone:      ActionRedact,
+		},
+		NeverSend: append([]string(nil), DefaultNeverSend...),
+	}
+}
+
+// NewPolicy builds the policy for a provider from the configuration. Remote
+// providers start from RemotePolicy, local ones from LocalPolicy unless the
+// configuration asks for strict mode; configured actions then override the
+// defaults and configured patterns are added to DefaultNeverSend.
+func NewPolicy(cfg *config.PrivacyConfig, remote bool) (*Policy, error) {
+	if cfg != nil && cfg.Strict {
+		remote = true
+	}
+	p := LocalPolicy()
+	if remote {
+		p = RemotePolicy()
+	}
+	if cfg == nil {
+		return p, nil
+	}
+
+	for name, value := range cfg.Actions {
+		kind := Kind(strings.ToLower(name))
+		if _, ok := p.Actions[kind]; !ok {
+			return nil, fmt.Errorf("unknown sensitive content kind %q", name)
+		}
+		action := Action(strings.ToLower(value))
+		switch action {
+		case ActionAllow, ActionRedact, ActionBlock:
+			p.Actions[kind] = action
+		default:
+			return nil, fmt.Errorf("invalid privacy action %q for %s", value, name)
+		}
+	}
+	for _, pattern := range cfg.NeverSend {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid neverSend pattern %q: %w", pattern, err)
+		}
+		p.NeverSend = append(p.NeverSend, pattern)
+	}
+	return p, nil
+}
+
+// NeverSends reports whether a file matches a never-send pattern. Patterns
+// with a path separator match the full path or a directory above it, with
+// "~" standing for the home directory; other patterns match the file name
+// or the name of any directory above it.
+func (p *Policy) NeverSends(path string) bool {
+	path = filepath.Clean(path)
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for _, pattern := range p.NeverSend {
+		if strings.ContainsAny(pattern, `/\`) {
+			pattern = expandHome(pattern)
+			for dir := path; ; dir = filepath.Dir(dir) {
+				if ok, _ := filepath.Match(pattern, dir); ok {
+					return true
+				}
+				if filepath.Dir(dir) == dir {
+					break
+				}
+			}
+			continue
+		}
+		for _, part := range parts {
+			if ok, _ := filepath.Match(pattern, part); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expandHome replaces a leading "~" with the home directory
+func expandHome(pattern string) string {
+	if pattern != "~" && !strings.HasPrefix(pattern, "~/") {
+		return filepath.Clean(pattern)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Clean(pattern)
+	}
+	return filepath.Join(home, pattern[1:])
+}
+
+// Decision is the outcome of checking a file or text against a policy
+type Decision struct {
+	Blocked  bool      // Nothing may be sent
+	Reason   string    // Why it is blocked
+	Findings []Finding // Sensitive content found
+	Redacted int       // Findings replaced with placeholders
+}
+
+// CheckText applies the policy to a text, returning it with content to
+// redact replaced. If content to block is found, the text is empty.
+func (p *Policy) CheckText(text string) (string, Decision) {
+	return p.apply(text, Detect(text))
+}
+
+// checkName applies the policy to a file name. The name is also read with
+// underscores as spaces, so that "tax_123-45-6789.pdf" is checked like
+// words in a text while secrets holding underscores are still found.
+func (p *Policy) checkName(name string) (string, Decision) {
+	findings := Detect(name)
+	for _, f := range Detect(strings.ReplaceAll(name, "_", " ")) {
+		overlaps := false
+		for _, g := range findings {
+			if f.Start < g.End && g.Start < f.End {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			findings = append(findings, f)
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+	return p.apply(name, findings)
+}
+
+// apply decides what to do with the sensitive content found in a text
+func (p *Policy) apply(text string, findings []Finding) (string, Decision) {
+	d := Decision{Findings: findings}
+	redact := make(map[Kind]bool)
+	for _, f := range findings {
+		switch p.Actions[f.Kind] {
+		case ActionBlock:
+			return "", Decision{Blocked: true, Reason: fmt.Sprintf("contains %s", f.Kind), Findings: findings}
+		case ActionRedact:
+			redact[f.Kind] = true
+			d.Redacted++
+		}
+	}
+	if d.Redacted == 0 {
+		return text, d
+	}
+	return Redact(text, findings, redact), d
+}
+
+// CheckFile applies the policy to a file's metadata. It returns a copy of
+// the metadata whose name and preview are safe to send, or a blocked
+// decision for files matching a never-send pattern or holding content to
+// block in either.
+func (p *Policy) CheckFile(file *analyzer.FileMetadata) (*analyzer.FileMetadata, Decision) {
+	if p.NeverSends(file.Path) {
+		return nil, Decision{Blocked: true, Reason: "matches a neverSend pattern"}
+	}
+
+	name, d := p.checkName(file.Name)
+	if d.Blocked {
+		d.Reason = "name " + d.Reason
+		return nil, d
+	}
+	preview := file.ContentPreview
+	if preview != "" {
+		var pd Decision
+		preview, pd = p.CheckText(preview)
+		pd.Findings = append(d.Findings, pd.Findings...)
+		if pd.Blocked {
+			return nil, pd
+		}
+		pd.Redacted += d.Redacted
+		d = pd
+	}
+	if d.Redacted == 0 {
+		return file, d
+	}
+
+	safe := *file
+	safe.Name = name
+	safe.ContentPreview = preview
+	return &safe, d
+}
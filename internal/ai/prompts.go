@@ -8,6 +8,17 @@ import (
 	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
 )
 
+// PromptVersion identifies the wording of the prompts. Bump it whenever
+// the prompts or the cleanup of their answers change, so that answers
+// cached for the old prompts are not reused.
+const PromptVersion = 1
+
+// ScenarioCategories are the document scenarios files are categorized into
+var ScenarioCategories = []string{
+	"resume", "interview", "meeting", "report", "proposal",
+	"contract", "invoice", "guide", "notes", "other",
+}
+
 // GenerateNameSuggestionPrompt creates a prompt for file name suggestion
 func GenerateNameSuggestionPrompt(file *analyzer.FileMetadata) string {
 	if file == nil {
@@ -150,22 +161,10 @@ func CleanSuggestedCategory(category string) string {
 	category = strings.Trim(category, "\"'`")
 
 	// Validate category
-	validCategories := map[string]bool{
-		"resume":    true,
-		"interview": true,
-		"meeting":   true,
-		"report":    true,
-		"proposal":  true,
-		"contract":  true,
-		"invoice":   true,
-		"guide":     true,
-		"notes":     true,
-		"other":     true,
-	}
-
-	if !validCategories[category] {
-		return "other"
+	for _, valid := range ScenarioCategories {
+		if category == valid {
+			return category
+		}
 	}
-
-	return category
+	return "other"
 }
@@ -1,8 +1,10 @@
 package analyzer
 
 import (
+	"archive/zip"
 	"context"
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -11,6 +13,9 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
+
+	"github.com/xuanyiying/cleanup-cli/internal/walk"
 )
 
 // FileMetadata represents complete metadata for a file
@@ -20,16 +25,29 @@ type FileMetadata struct {
 	Extension         string
 	Size              int64
 	MimeType          string
-	CreatedAt         time.Time
+	CreatedAt         time.Time // 创建时间；文件系统不记录时为修改时间
 	ModifiedAt        time.Time
+	AccessedAt        time.Time // 最后访问时间，未知时为零值
 	ContentPreview    string
-	ExifData          map[string]string
+	Encoding          string // 文本文件的编码，如 utf-8、gb18030、shift_jis；其他文件为空
+	Language          string // 内容预览的语言（ISO 639-1，如 zh、en、ja），无法判断时为空
+	ExifData          map[string]string // EXIF/XMP 元数据，键见 Exif* 常量
+	TakenAt           time.Time         // 拍摄时间（来自 EXIF/XMP），未知时为零值
+	MediaData         map[string]string // 音视频元数据，键见 Media* 常量
 	Hash              string
 	FileNameQuality   FileNameQuality // 文件名质量评估
+	FileNameScore     int             // 文件名评分（0-100），低于阈值时需要智能重命名
 	NeedsSmarterName  bool            // 是否需要智能重命名
 	SuggestedName     string          // AI 建议的文件名
+	SuggestedBy       string          // 给出 AI 建议的服务（如 ollama、openai），建议来自缓存时为 cache
+	SuggestionReason  string          // AI 对建议的一句话说明
+	SuggestionConfidence float64      // AI 对建议的把握（0-1），未知时为 0
 	ScenarioCategory  string          // 文档场景分类（简历、面试、会议等）
 	NeedsScenarioAnalysis bool        // 是否需要场景分析
+	ArchivePath       string          // 压缩包内的文件所在的压缩包，磁盘上的文件为空
+	ArchiveEntries    []string        // 压缩包内的文件（扫描时开启 Archives 才列出）
+	ArchiveHash       string          // 压缩包内文件内容的 SHA-256
+	FileID            walk.FileID     `json:"-"` // 设备号与 inode，硬链接相同；未知时为零值
 }
 
 // FileNameQuality represents the quality assessment of a filename
@@ -47,11 +65,16 @@ type ScanOptions struct {
 	Recursive         bool
 	IncludeHidden     bool
 	Filter            *FileFilter
-	ExcludeExtensions []string // 要排除的文件扩展名 (不带点，如 "txt", "log")
-	ExcludePatterns   []string // 要排除的文件名模式 (支持通配符)
-	ExcludeDirs       []string // 要排除的目录名
-	CalculateHash     bool     // 是否计算文件哈希 (默认 true)
-	Workers           int      // 并发工作线程数 (默认 4)
+	ExcludeExtensions []string       // 要排除的文件扩展名 (不带点，如 "txt", "log")
+	ExcludePatterns   []string       // 要排除的文件名模式 (支持通配符)
+	ExcludeDirs       []string       // 要排除的目录名
+	CalculateHash     bool           // 是否计算文件哈希 (默认 true)
+	SkipContent       bool           // 只读取文件系统信息，不检测类型、不读取内容
+	Archives          bool           // 列出压缩包内的文件并作为虚拟文件输出 (不解压)；CalculateHash 时计算其 SHA-256
+	Traversal         walk.Options   // 符号链接、挂载点和伪文件系统的遍历策略
+	Workers           int            // 并发工作线程数 (默认 4)
+	Buffer            int            // 流式分析时缓冲的文件数 (默认 Workers 的两倍)
+	OnProgress        func(Progress) // 流式分析的进度回调，依次调用，不会并发
 }
 
 // FileFilter defines criteria for filtering files
@@ -67,16 +90,74 @@ type FileFilter struct {
 type Analyzer interface {
 	Analyze(ctx context.Context, path string) (*FileMetadata, error)
 	AnalyzeDirectory(ctx context.Context, path string, opts *ScanOptions) ([]*FileMetadata, error)
+	AnalyzeStream(ctx context.Context, path string, opts *ScanOptions) <-chan Result
 	DetectType(path string) (string, error)
 	AssessFileNameQuality(filename string) FileNameQuality
 }
 
+// previewChars is the length of a file's content preview
+const previewChars = 500
+
+// sharedExtractors serves analyzers created without NewAnalyzer
+var sharedExtractors = sync.OnceValue(DefaultExtractors)
+
+// sharedSignatures serves analyzers created without NewAnalyzer
+var sharedSignatures = sync.OnceValue(DefaultSignatures)
+
+// Cache remembers the analysis of files between runs so that directory scans
+// only read the files that changed
+type Cache interface {
+	// Lookup returns the metadata stored for path if the file has not
+	// changed since it was stored
+	Lookup(path string, info os.FileInfo) (*FileMetadata, bool)
+	// Store records the metadata of a file
+	Store(path string, info os.FileInfo, metadata *FileMetadata)
+}
+
 // FileAnalyzer implements the Analyzer interface
-type FileAnalyzer struct{}
+type FileAnalyzer struct {
+	extractors *ExtractorRegistry
+	signatures *SignatureDB
+	cache      Cache
+	names      *NameScorer
+}
 
-// NewAnalyzer creates a new file analyzer
+// NewAnalyzer creates a new file analyzer with the default content extractors
+// and file signatures
 func NewAnalyzer() *FileAnalyzer {
-	return &FileAnalyzer{}
+	return &FileAnalyzer{extractors: DefaultExtractors(), signatures: DefaultSignatures()}
+}
+
+// SetExtractors replaces the registry used to extract content previews
+func (fa *FileAnalyzer) SetExtractors(r *ExtractorRegistry) {
+	fa.extractors = r
+}
+
+// Extractors returns the registry used to extract content previews
+func (fa *FileAnalyzer) Extractors() *ExtractorRegistry {
+	if fa.extractors == nil {
+		return sharedExtractors()
+	}
+	return fa.extractors
+}
+
+// SetSignatures replaces the database used to detect file types
+func (fa *FileAnalyzer) SetSignatures(db *SignatureDB) {
+	fa.signatures = db
+}
+
+// Signatures returns the database used to detect file types
+func (fa *FileAnalyzer) Signatures() *SignatureDB {
+	if fa.signatures == nil {
+		return sharedSignatures()
+	}
+	return fa.signatures
+}
+
+// SetCache sets the cache consulted by AnalyzeDirectory; nil analyzes every
+// file
+func (fa *FileAnalyzer) SetCache(c Cache) {
+	fa.cache = c
 }
 
 // Analyze extracts complete metadata for a single file
@@ -97,61 +178,10 @@ func (fa *FileAnalyzer) Analyze(ctx context.Context, path string) (*FileMetadata
 		return nil, fmt.Errorf("path is a directory, not a file")
 	}
 
-	// Extract basic metadata
-	name := info.Name()
-	ext := filepath.Ext(name)
-	if ext != "" {
-		ext = ext[1:] // Remove leading dot
-	}
-
-	// Detect MIME type
-	mimeType, err := fa.DetectType(path)
-	if err != nil {
-		mimeType = "application/octet-stream"
-	}
-
-	// Calculate file hash (can be skipped for performance)
-	hash := ""
-
-	// Extract content preview for text files
-	preview := ""
-	if strings.HasPrefix(mimeType, "text/") {
-		preview = fa.extractTextPreview(path, 500) // Increased from 200 to 500
-	}
-
-	metadata := &FileMetadata{
-		Path:             path,
-		Name:             name,
-		Extension:        ext,
-		Size:             info.Size(),
-		MimeType:         mimeType,
-		CreatedAt:        info.ModTime(), // Go doesn't have creation time on all platforms
-		ModifiedAt:       info.ModTime(),
-		ContentPreview:   preview,
-		ExifData:         make(map[string]string),
-		Hash:             hash,
-		FileNameQuality:  fa.AssessFileNameQuality(name),
-		NeedsSmarterName: false,
-		SuggestedName:    "",
-		ScenarioCategory: "",
-		NeedsScenarioAnalysis: false,
-	}
-
-	// 判断是否需要智能重命名
-	if metadata.FileNameQuality == FileNameMeaningless || metadata.FileNameQuality == FileNameGeneric {
-		metadata.NeedsSmarterName = true
-	}
-
-	// 判断是否需要场景分析（文档类型）
-	if strings.HasPrefix(mimeType, "text/") || 
-		strings.Contains(mimeType, "pdf") ||
-		strings.Contains(mimeType, "document") ||
-		strings.Contains(mimeType, "word") ||
-		strings.Contains(mimeType, "excel") {
-		metadata.NeedsScenarioAnalysis = true
-	}
+	// Before reading the file, which may update its access time
+	created, accessed := statTimes(path, info)
 
-	return metadata, nil
+	return fa.readMetadata(ctx, path, info, created, accessed, false), nil
 }
 
 // AnalyzeDirectory scans a directory and returns metadata for all matching files
@@ -163,23 +193,30 @@ func (fa *FileAnalyzer) AnalyzeDirectory(ctx context.Context, path string, opts
 	default:
 	}
 
-	if opts == nil {
-		opts = &ScanOptions{
-			Recursive:     true,
-			IncludeHidden: false,
-			CalculateHash: true,
-			Workers:       4,
+	results := []*FileMetadata{}
+	var scanErr error
+	for result := range fa.AnalyzeStream(ctx, path, opts) {
+		if result.Err != nil {
+			if result.Path == "" {
+				scanErr = result.Err
+			}
+			continue // Skip files that can't be analyzed
 		}
+		results = append(results, result.Metadata)
 	}
 
-	// Set defaults
-	if opts.Workers <= 0 {
-		opts.Workers = 4
+	if scanErr != nil {
+		return nil, scanErr
 	}
+	return results, ctx.Err()
+}
 
-	// Phase 1: Collect file paths (fast, sequential)
-	var filePaths []string
-	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+// walkFiles walks a directory under the scan's traversal policy and calls
+// visit for every file that passes the scan options. Entries that can't be
+// accessed are skipped, as are symbolic links that are not followed; broken
+// links and link loops are passed to visit with their error.
+func (fa *FileAnalyzer) walkFiles(ctx context.Context, path string, opts *ScanOptions, visit func(filePath string, err error) error) error {
+	return walk.Walk(path, &opts.Traversal, func(filePath string, info os.FileInfo, err error) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -187,6 +224,12 @@ func (fa *FileAnalyzer) AnalyzeDirectory(ctx context.Context, path string, opts
 		}
 
 		if err != nil {
+			if errors.Is(err, walk.ErrBrokenLink) || errors.Is(err, walk.ErrLinkLoop) {
+				if fa.shouldSkipFile(info.Name(), opts) {
+					return nil
+				}
+				return visit(filePath, err)
+			}
 			return nil // Skip files we can't access
 		}
 
@@ -196,7 +239,7 @@ func (fa *FileAnalyzer) AnalyzeDirectory(ctx context.Context, path string, opts
 			if fa.shouldExcludeDir(info.Name(), opts) {
 				return filepath.SkipDir
 			}
-			
+
 			// If not recursive, skip subdirectories
 			if !opts.Recursive && filePath != path {
 				return filepath.SkipDir
@@ -204,13 +247,12 @@ func (fa *FileAnalyzer) AnalyzeDirectory(ctx context.Context, path string, opts
 			return nil
 		}
 
-		// Skip hidden files if requested
-		if !opts.IncludeHidden && strings.HasPrefix(info.Name(), ".") {
+		// Links are only analyzed when they are followed
+		if info.Mode()&os.ModeSymlink != 0 {
 			return nil
 		}
 
-		// Check if file should be excluded
-		if fa.shouldExcludeFile(info.Name(), opts) {
+		if fa.shouldSkipFile(info.Name(), opts) {
 			return nil
 		}
 
@@ -221,84 +263,20 @@ func (fa *FileAnalyzer) AnalyzeDirectory(ctx context.Context, path string, opts
 			}
 		}
 
-		filePaths = append(filePaths, filePath)
-		return nil
+		return visit(filePath, nil)
 	})
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to scan directory: %w", err)
-	}
-
-	// Phase 2: Analyze files concurrently (slow, parallel)
-	return fa.analyzeFilesConcurrently(ctx, filePaths, opts)
 }
 
-// analyzeFilesConcurrently analyzes multiple files using a worker pool
-func (fa *FileAnalyzer) analyzeFilesConcurrently(ctx context.Context, filePaths []string, opts *ScanOptions) ([]*FileMetadata, error) {
-	if len(filePaths) == 0 {
-		return []*FileMetadata{}, nil
-	}
-
-	// Create channels
-	fileChan := make(chan string, len(filePaths))
-	resultChan := make(chan *FileMetadata, len(filePaths))
-	errorChan := make(chan error, opts.Workers)
-
-	// Start worker pool
-	var wg sync.WaitGroup
-	for i := 0; i < opts.Workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for filePath := range fileChan {
-				select {
-				case <-ctx.Done():
-					errorChan <- ctx.Err()
-					return
-				default:
-				}
-
-				metadata, err := fa.analyzeWithOptions(ctx, filePath, opts)
-				if err != nil {
-					// Skip files that can't be analyzed
-					continue
-				}
-				resultChan <- metadata
-			}
-		}()
-	}
-
-	// Send files to workers
-	go func() {
-		for _, filePath := range filePaths {
-			fileChan <- filePath
-		}
-		close(fileChan)
-	}()
-
-	// Wait for workers to finish
-	go func() {
-		wg.Wait()
-		close(resultChan)
-		close(errorChan)
-	}()
-
-	// Collect results
-	var results []*FileMetadata
-	for metadata := range resultChan {
-		results = append(results, metadata)
-	}
-
-	// Check for errors
-	select {
-	case err := <-errorChan:
-		if err != nil {
-			return results, err
-		}
-	default:
+// shouldSkipFile reports whether a file is hidden and hidden files are not
+// scanned, or is excluded
+func (fa *FileAnalyzer) shouldSkipFile(name string, opts *ScanOptions) bool {
+	// Skip hidden files if requested
+	if !opts.IncludeHidden && strings.HasPrefix(name, ".") {
+		return true
 	}
 
-	return results, nil
+	// Check if file should be excluded
+	return fa.shouldExcludeFile(name, opts)
 }
 
 // analyzeWithOptions analyzes a file with specific options (hash calculation, etc.)
@@ -319,6 +297,41 @@ func (fa *FileAnalyzer) analyzeWithOptions(ctx context.Context, path string, opt
 		return nil, fmt.Errorf("path is a directory, not a file")
 	}
 
+	// Before reading the file, which may update its access time
+	created, accessed := statTimes(path, info)
+
+	if opts.SkipContent {
+		return fa.statMetadata(path, info, created, accessed), nil
+	}
+
+	// Unchanged files are taken from the cache; times other than the
+	// modification time change without the content changing
+	if fa.cache != nil {
+		if cached, ok := fa.cache.Lookup(path, info); ok {
+			cached.CreatedAt, cached.AccessedAt = created, accessed
+			cached.FileID, _ = walk.ID(info)
+			fa.assessName(cached) // The scoring rules may have changed
+			if opts.CalculateHash && cached.Hash == "" {
+				if cached.Hash, err = fa.calculateHash(path); err == nil {
+					fa.cache.Store(path, info, cached)
+				}
+			}
+			return cached, nil
+		}
+	}
+
+	metadata := fa.readMetadata(ctx, path, info, created, accessed, opts.CalculateHash)
+
+	if fa.cache != nil {
+		fa.cache.Store(path, info, metadata)
+	}
+
+	return metadata, nil
+}
+
+// readMetadata reads the type, content preview and embedded metadata of a
+// file. The hash is calculated only if withHash is set.
+func (fa *FileAnalyzer) readMetadata(ctx context.Context, path string, info os.FileInfo, created, accessed time.Time, withHash bool) *FileMetadata {
 	// Extract basic metadata
 	name := info.Name()
 	ext := filepath.Ext(name)
@@ -334,15 +347,12 @@ func (fa *FileAnalyzer) analyzeWithOptions(ctx context.Context, path string, opt
 
 	// Calculate file hash only if requested
 	hash := ""
-	if opts.CalculateHash {
+	if withHash {
 		hash, _ = fa.calculateHash(path)
 	}
 
-	// Extract content preview for text files
-	preview := ""
-	if strings.HasPrefix(mimeType, "text/") {
-		preview = fa.extractTextPreview(path, 500)
-	}
+	// Extract content preview for text files and documents
+	preview, encoding := fa.extractPreview(ctx, path, mimeType)
 
 	metadata := &FileMetadata{
 		Path:             path,
@@ -350,33 +360,80 @@ func (fa *FileAnalyzer) analyzeWithOptions(ctx context.Context, path string, opt
 		Extension:        ext,
 		Size:             info.Size(),
 		MimeType:         mimeType,
-		CreatedAt:        info.ModTime(),
+		CreatedAt:        created,
 		ModifiedAt:       info.ModTime(),
+		AccessedAt:       accessed,
 		ContentPreview:   preview,
+		Encoding:         encoding,
+		Language:         DetectLanguage(preview),
 		ExifData:         make(map[string]string),
+		MediaData:        make(map[string]string),
 		Hash:             hash,
-		FileNameQuality:  fa.AssessFileNameQuality(name),
 		NeedsSmarterName: false,
 		SuggestedName:    "",
 		ScenarioCategory: "",
 		NeedsScenarioAnalysis: false,
 	}
 
+	metadata.FileID, _ = walk.ID(info)
+
+	// Read embedded metadata such as EXIF
+	fa.readEmbeddedMetadata(metadata)
+
 	// 判断是否需要智能重命名
-	if metadata.FileNameQuality == FileNameMeaningless || metadata.FileNameQuality == FileNameGeneric {
-		metadata.NeedsSmarterName = true
-	}
+	fa.assessName(metadata)
 
 	// 判断是否需要场景分析（文档类型）
-	if strings.HasPrefix(mimeType, "text/") || 
-		strings.Contains(mimeType, "pdf") ||
-		strings.Contains(mimeType, "document") ||
-		strings.Contains(mimeType, "word") ||
-		strings.Contains(mimeType, "excel") {
+	if fa.needsScenarioAnalysis(mimeType) {
 		metadata.NeedsScenarioAnalysis = true
 	}
 
-	return metadata, nil
+	return metadata
+}
+
+// statMetadata returns the metadata of a file known without reading it. The
+// MIME type is guessed from the extension.
+func (fa *FileAnalyzer) statMetadata(path string, info os.FileInfo, created, accessed time.Time) *FileMetadata {
+	name := info.Name()
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+	metadata := &FileMetadata{
+		Path:             path,
+		Name:             name,
+		Extension:        ext,
+		Size:             info.Size(),
+		MimeType:         mime.TypeByExtension(filepath.Ext(name)),
+		CreatedAt:        created,
+		ModifiedAt:       info.ModTime(),
+		AccessedAt:       accessed,
+		ExifData:         make(map[string]string),
+		MediaData:        make(map[string]string),
+	}
+	metadata.FileID, _ = walk.ID(info)
+	fa.assessName(metadata)
+	return metadata
+}
+
+// statTimes returns the creation and access times of a file. Go's FileInfo
+// only carries the modification time, which stands in for the creation
+// time where the platform or the filesystem does not record it.
+func statTimes(path string, info os.FileInfo) (time.Time, time.Time) {
+	created, accessed := fileTimes(path, info)
+	if created.IsZero() {
+		created = info.ModTime()
+	}
+	return created, accessed
+}
+
+// readEmbeddedMetadata fills the metadata a file carries about itself, such
+// as the EXIF data of photos and the tags of music. Damaged files keep
+// whatever could be read.
+func (fa *FileAnalyzer) readEmbeddedMetadata(metadata *FileMetadata) {
+	exif, _ := readImageMetadata(metadata.Path, metadata.MimeType)
+	metadata.ExifData = exif
+	if taken, ok := exif[ExifDateTimeOriginal]; ok {
+		metadata.TakenAt = parseTakenAt(taken)
+	}
+	metadata.MediaData, _ = readMediaMetadata(metadata.Path, metadata.MimeType)
 }
 
 // DetectType detects the MIME type of a file using magic bytes and extension
@@ -401,90 +458,131 @@ func (fa *FileAnalyzer) DetectType(path string) (string, error) {
 
 // detectByMagicBytes detects MIME type by reading file magic bytes
 func (fa *FileAnalyzer) detectByMagicBytes(path string) (string, error) {
-	file, err := os.Open(path)
+	file, err := openForRead(path)
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
 
-	// Read first 512 bytes for magic byte detection
+	mimeType, err := fa.Signatures().Detect(file, path)
+	if err != nil || mimeType != "" {
+		return mimeType, err
+	}
+
+	// Read first 512 bytes for text detection
 	header := make([]byte, 512)
-	n, err := file.Read(header)
+	n, err := file.ReadAt(header, 0)
 	if err != nil && err != io.EOF {
 		return "", err
 	}
 
 	header = header[:n]
 
-	// Common magic bytes
-	if len(header) >= 4 {
-		// JPEG
-		if header[0] == 0xFF && header[1] == 0xD8 && header[2] == 0xFF {
-			return "image/jpeg", nil
-		}
-		// PNG
-		if header[0] == 0x89 && header[1] == 0x50 && header[2] == 0x4E && header[3] == 0x47 {
-			return "image/png", nil
-		}
-		// GIF
-		if header[0] == 0x47 && header[1] == 0x49 && header[2] == 0x46 {
-			return "image/gif", nil
-		}
-		// PDF
-		if header[0] == 0x25 && header[1] == 0x50 && header[2] == 0x44 && header[3] == 0x46 {
-			return "application/pdf", nil
-		}
-		// ZIP (including docx, xlsx, etc.)
-		if header[0] == 0x50 && header[1] == 0x4B && header[2] == 0x03 && header[3] == 0x04 {
-			return "application/zip", nil
-		}
-	}
-
-	if len(header) >= 2 {
-		// UTF-16 BOM
-		if header[0] == 0xFF && header[1] == 0xFE {
-			return "text/plain; charset=utf-16", nil
-		}
-		if header[0] == 0xFE && header[1] == 0xFF {
-			return "text/plain; charset=utf-16", nil
-		}
-	}
-
 	// Check if it's text
 	if fa.isTextFile(header) {
+		if looksLikeHTML(path, header) {
+			return MimeHTML, nil
+		}
 		return "text/plain", nil
 	}
 
 	return "", nil
 }
 
-// isTextFile checks if content appears to be text
-func (fa *FileAnalyzer) isTextFile(data []byte) bool {
-	if len(data) == 0 {
-		return true
+// isMPEGFrame reports whether b starts with a valid MPEG audio frame header
+func isMPEGFrame(b []byte) bool {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return false
 	}
+	versionBits, layerBits := b[1]>>3&3, b[1]>>1&3
+	bitrateIndex, rateIndex := b[2]>>4, b[2]>>2&3
+	return versionBits != 1 && layerBits != 0 && bitrateIndex != 0 && bitrateIndex != 15 && rateIndex != 3
+}
 
-	// Check for null bytes (common in binary files)
-	for _, b := range data {
-		if b == 0 {
-			return false
-		}
+// heifBrands map the major brands of HEIF files to their MIME types
+var heifBrands = map[string]string{
+	"heic": MimeHEIC, "heix": MimeHEIC, "heim": MimeHEIC, "heis": MimeHEIC,
+	"mif1": MimeHEIF, "msf1": MimeHEIF,
+	"avif": MimeAVIF, "avis": MimeAVIF,
+}
+
+// zipDocumentEntries identify Office Open XML documents and Android
+// packages by their main part
+var zipDocumentEntries = map[string]string{
+	"word/document.xml":    MimeDOCX,
+	"xl/workbook.xml":      MimeXLSX,
+	"ppt/presentation.xml": MimePPTX,
+	"AndroidManifest.xml":  MimeAPK,
+}
+
+// detectZipDocument tells ZIP-based documents and packages apart from plain
+// archives. OpenDocument and EPUB files store their type in a "mimetype"
+// entry; Office Open XML files and Android packages are recognized by their
+// main part, and Java archives by their manifest.
+func detectZipDocument(path string) string {
+	zr, err := openZip(path)
+	if err != nil {
+		return MimeZip
 	}
+	defer zr.Close()
 
-	// If mostly printable ASCII, consider it text
-	printable := 0
-	for _, b := range data {
-		if (b >= 32 && b <= 126) || b == '\n' || b == '\r' || b == '\t' {
-			printable++
+	mimeType := MimeZip
+	for _, f := range zr.File {
+		if f.Name == "mimetype" {
+			if documentType := readZipMimetype(f); documentType != "" {
+				return documentType
+			}
+			continue
+		}
+		if documentType, ok := zipDocumentEntries[f.Name]; ok {
+			return documentType
 		}
+		// Android packages carry a manifest too
+		if f.Name == "META-INF/MANIFEST.MF" {
+			mimeType = MimeJAR
+		}
+	}
+	return mimeType
+}
+
+// readZipMimetype returns the document type named by a "mimetype" entry
+// if it is a document format known to the analyzer
+func readZipMimetype(f *zip.File) string {
+	rc, err := f.Open()
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(rc, 128))
+	switch mimeType := strings.TrimSpace(string(data)); mimeType {
+	case MimeODT, MimeODS, MimeODP, MimeEPUB:
+		return mimeType
+	}
+	return ""
+}
+
+// looksLikeHTML reports whether a text file is an HTML document, by its
+// extension or its leading markup
+func looksLikeHTML(path string, header []byte) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm", ".xhtml":
+		return true
 	}
+	start := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(string(header), "\ufeff")))
+	return strings.HasPrefix(start, "<!doctype html") || strings.HasPrefix(start, "<html")
+}
 
-	return float64(printable)/float64(len(data)) > 0.75
+// isTextFile checks if content appears to be text, in any of the encodings
+// recognized by DetectEncoding
+func (fa *FileAnalyzer) isTextFile(data []byte) bool {
+	_, ok := DetectEncoding(data)
+	return ok
 }
 
 // calculateHash computes MD5 hash of file content
 func (fa *FileAnalyzer) calculateHash(path string) (string, error) {
-	file, err := os.Open(path)
+	file, err := openForRead(path)
 	if err != nil {
 		return "", err
 	}
@@ -498,30 +596,30 @@ func (fa *FileAnalyzer) calculateHash(path string) (string, error) {
 	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
-// extractTextPreview reads first N characters of a text file
-func (fa *FileAnalyzer) extractTextPreview(path string, maxChars int) string {
-	file, err := os.Open(path)
+// extractTextPreview reads the first N characters of a text file, decoded
+// to UTF-8 from the encoding it detects, and returns them with the encoding
+func (fa *FileAnalyzer) extractTextPreview(path string, maxChars int) (string, string) {
+	file, err := openForRead(path)
 	if err != nil {
-		return ""
+		return "", ""
 	}
 	defer file.Close()
 
-	// For text files, try to read more content for better analysis
-	readSize := maxChars
-	if strings.HasSuffix(strings.ToLower(path), ".txt") ||
-		strings.HasSuffix(strings.ToLower(path), ".md") ||
-		strings.HasSuffix(strings.ToLower(path), ".doc") {
-		readSize = maxChars * 3 // Read more for documents
+	// Characters take up to four bytes; the extra also helps detection
+	data := make([]byte, max(maxChars*4, encodingSampleSize))
+	n, err := io.ReadFull(file, data)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", ""
 	}
+	data = data[:n]
 
-	data := make([]byte, readSize)
-	n, err := file.Read(data)
-	if err != nil && err != io.EOF {
-		return ""
+	encoding, ok := DetectEncoding(data)
+	if !ok {
+		return "", ""
 	}
 
 	// Clean up preview: remove null bytes and control characters
-	preview := string(data[:n])
+	preview := DecodeText(data, encoding)
 	preview = strings.Map(func(r rune) rune {
 		if r == 0 || (r < 32 && r != '\n' && r != '\r' && r != '\t') {
 			return -1
@@ -529,19 +627,59 @@ func (fa *FileAnalyzer) extractTextPreview(path string, maxChars int) string {
 		return r
 	}, preview)
 
-	preview = strings.TrimSpace(preview)
-	
-	// If preview is too long, truncate to maxChars but try to end at word boundary
-	if len(preview) > maxChars {
-		preview = preview[:maxChars]
-		// Find last space to avoid cutting words
-		if lastSpace := strings.LastIndex(preview, " "); lastSpace > maxChars/2 {
-			preview = preview[:lastSpace]
+	return truncatePreview(strings.TrimSpace(preview), maxChars), encoding
+}
+
+// extractPreview returns the content preview of a file: the text of a
+// document with a registered extractor, or the start of a text file along
+// with its encoding
+func (fa *FileAnalyzer) extractPreview(ctx context.Context, path, mimeType string) (string, string) {
+	extractors := fa.Extractors()
+	if extractors.Supports(mimeType) {
+		text, err := extractors.Extract(ctx, path, mimeType)
+		if err != nil {
+			return "", ""
 		}
-		preview = preview + "..."
+		return truncatePreview(strings.TrimSpace(text), previewChars), ""
 	}
+	if strings.HasPrefix(mimeType, "text/") {
+		return fa.extractTextPreview(path, previewChars)
+	}
+	return "", ""
+}
 
-	return preview
+// needsScenarioAnalysis reports whether files of a MIME type are documents
+// whose content can be analyzed
+func (fa *FileAnalyzer) needsScenarioAnalysis(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") ||
+		strings.Contains(mimeType, "pdf") ||
+		strings.Contains(mimeType, "document") ||
+		strings.Contains(mimeType, "word") ||
+		strings.Contains(mimeType, "excel") ||
+		fa.Extractors().Supports(mimeType)
+}
+
+// truncatePreview shortens a preview to maxChars characters, ending at a
+// word boundary where possible
+func truncatePreview(preview string, maxChars int) string {
+	if utf8.RuneCountInString(preview) <= maxChars {
+		return preview
+	}
+	cut, chars := 0, 0
+	for i := range preview {
+		if chars == maxChars {
+			cut = i
+			break
+		}
+		chars++
+	}
+	preview = preview[:cut]
+	// Find last space to avoid cutting words; text without spaces, such as
+	// Chinese, is cut at the limit
+	if lastSpace := strings.LastIndex(preview, " "); lastSpace > cut/2 {
+		preview = preview[:lastSpace]
+	}
+	return preview + "..."
 }
 
 // matchesFilter checks if a file matches the given filter criteria
@@ -587,74 +725,64 @@ func (fa *FileAnalyzer) matchesFilter(filePath string, info os.FileInfo, filter
 // AssessFileNameQuality evaluates the quality of a filename
 // Returns whether the filename is meaningful, generic, or meaningless
 func (fa *FileAnalyzer) AssessFileNameQuality(filename string) FileNameQuality {
-	// Remove extension for analysis
-	nameWithoutExt := strings.TrimSuffix(filename, filepath.Ext(filename))
-	nameWithoutExt = strings.TrimSpace(nameWithoutExt)
-
-	if nameWithoutExt == "" {
-		return FileNameMeaningless
-	}
-
-	// Convert to lowercase for comparison
-	nameLower := strings.ToLower(nameWithoutExt)
+	return fa.NameScorer().Score(filename).Quality
+}
 
-	// Meaningless patterns: random characters, timestamps, generic names
-	meaninglessPatterns := []string{
-		"untitled", "新建", "新建文档", "无标题", "new file", "document",
-		"image", "photo", "picture", "download",
-		"screenshot", "屏幕截图", "截图", "img", "pic",
-		"temp", "tmp", "test", "copy", "副本",
-	}
+// ScoreFileName scores a filename and gives the reasons for the score
+func (fa *FileAnalyzer) ScoreFileName(filename string) NameScore {
+	return fa.NameScorer().Score(filename)
+}
 
-	for _, pattern := range meaninglessPatterns {
-		if nameLower == pattern || strings.HasPrefix(nameLower, pattern+" ") || strings.HasPrefix(nameLower, pattern) {
-			return FileNameMeaningless
-		}
-	}
+// SetNameScorer replaces the scorer that decides which files need a
+// smarter name
+func (fa *FileAnalyzer) SetNameScorer(s *NameScorer) {
+	fa.names = s
+}
 
-	// Check for timestamp-only names (e.g., "20240101_123456", "IMG_1234")
-	if isTimestampLikeName(nameLower) {
-		return FileNameMeaningless
+// NameScorer returns the scorer the analyzer assesses filenames with
+func (fa *FileAnalyzer) NameScorer() *NameScorer {
+	if fa.names != nil {
+		return fa.names
 	}
+	return sharedNameScorer()
+}
 
-	// Check for very short names (1-2 characters)
-	if len(nameWithoutExt) <= 2 {
-		return FileNameMeaningless
-	}
+// assessName scores a file's name and flags it for a smarter name if the
+// score is below the threshold
+func (fa *FileAnalyzer) assessName(metadata *FileMetadata) {
+	score := fa.NameScorer().Score(metadata.Name)
+	metadata.FileNameScore = score.Score
+	metadata.FileNameQuality = score.Quality
+	metadata.NeedsSmarterName = score.Quality != FileNameGood
+}
 
-	// Generic patterns: common prefixes without meaningful content
-	genericPatterns := []string{
-		"doc", "data", "report", "notes",
+// IsExcluded reports whether AnalyzeDirectory(root, opts) would skip the file
+// at path because of its location, visibility or exclusion rules
+func IsExcluded(root, path string, opts *ScanOptions) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return true
 	}
-
-	for _, pattern := range genericPatterns {
-		if nameLower == pattern {
-			return FileNameGeneric
-		}
+	if opts == nil {
+		return false
 	}
 
-	// Special case: "file" is meaningless
-	if nameLower == "file" {
-		return FileNameMeaningless
+	fa := &FileAnalyzer{}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if !opts.Recursive && len(parts) > 1 {
+		return true
 	}
-
-	// Check if name is mostly numbers
-	digitCount := 0
-	for _, r := range nameWithoutExt {
-		if r >= '0' && r <= '9' {
-			digitCount++
+	for _, dir := range parts[:len(parts)-1] {
+		if fa.shouldExcludeDir(dir, opts) {
+			return true
 		}
 	}
-	if float64(digitCount)/float64(len(nameWithoutExt)) > 0.7 {
-		return FileNameMeaningless
-	}
 
-	// If name has meaningful length and content, consider it good
-	if len(nameWithoutExt) >= 3 {
-		return FileNameGood
+	name := parts[len(parts)-1]
+	if !opts.IncludeHidden && strings.HasPrefix(name, ".") {
+		return true
 	}
-
-	return FileNameUnknown
+	return fa.shouldExcludeFile(name, opts)
 }
 
 // shouldExcludeFile checks if a file should be excluded based on options
@@ -713,42 +841,3 @@ func (fa *FileAnalyzer) shouldExcludeDir(dirname string, opts *ScanOptions) bool
 	return false
 }
 
-// isTimestampLikeName checks if a filename looks like a timestamp or auto-generated ID
-func isTimestampLikeName(name string) bool {
-	// Common timestamp patterns
-	timestampPatterns := []string{
-		"img_", "dsc_", "pic_", "photo_", "screenshot_",
-		"wechat", "mmexport", "wx_camera_",
-	}
-
-	for _, pattern := range timestampPatterns {
-		if strings.HasPrefix(name, pattern) {
-			// Check if followed by mostly numbers
-			suffix := strings.TrimPrefix(name, pattern)
-			digitCount := 0
-			for _, r := range suffix {
-				if r >= '0' && r <= '9' {
-					digitCount++
-				}
-			}
-			if float64(digitCount)/float64(len(suffix)) > 0.6 {
-				return true
-			}
-		}
-	}
-
-	// Check for pure numeric or date-like patterns
-	// e.g., "20240101", "123456", "2024-01-01"
-	cleaned := strings.ReplaceAll(name, "-", "")
-	cleaned = strings.ReplaceAll(cleaned, "_", "")
-	cleaned = strings.ReplaceAll(cleaned, " ", "")
-
-	digitCount := 0
-	for _, r := range cleaned {
-		if r >= '0' && r <= '9' {
-			digitCount++
-		}
-	}
-
-	return float64(digitCount)/float64(len(cleaned)) > 0.8
-}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -266,3 +267,73 @@ func TestFileMetadataWithQualityAssessment(t *testing.T) {
 		})
 	}
 }
+
+// mapCache is a Cache keyed by path, size and modification time
+type mapCache struct {
+	mu      sync.Mutex
+	entries map[string]FileMetadata
+	stores  int
+}
+
+func (c *mapCache) Lookup(path string, info os.FileInfo) (*FileMetadata, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.entries[path]
+	if !ok || m.Size != info.Size() || !m.ModifiedAt.Equal(info.ModTime()) {
+		return nil, false
+	}
+	return &m, true
+}
+
+func (c *mapCache) Store(path string, info os.FileInfo, metadata *FileMetadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[path] = *metadata
+	c.stores++
+}
+
+func TestAnalyzeDirectory_Cache(t *testing.T) {
+	tmpDir := t.TempDir()
+	unchanged := filepath.Join(tmpDir, "unchanged.txt")
+	changed := filepath.Join(tmpDir, "changed.txt")
+	os.WriteFile(unchanged, []byte("first"), 0644)
+	os.WriteFile(changed, []byte("first"), 0644)
+
+	cache := &mapCache{entries: make(map[string]FileMetadata)}
+	fa := NewAnalyzer()
+	fa.SetCache(cache)
+	ctx := context.Background()
+
+	if _, err := fa.AnalyzeDirectory(ctx, tmpDir, &ScanOptions{Recursive: true}); err != nil {
+		t.Fatal(err)
+	}
+	if cache.stores != 2 {
+		t.Fatalf("stored %d files, want 2", cache.stores)
+	}
+
+	// Mark the cached entry so we can tell it from a fresh analysis
+	m := cache.entries[unchanged]
+	m.MimeType = "application/x-cached"
+	cache.entries[unchanged] = m
+	os.WriteFile(changed, []byte("second run"), 0644)
+
+	files, err := fa.AnalyzeDirectory(ctx, tmpDir, &ScanOptions{Recursive: true, CalculateHash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]*FileMetadata)
+	for _, f := range files {
+		byName[f.Name] = f
+	}
+	if got := byName["unchanged.txt"]; got == nil || got.MimeType != "application/x-cached" {
+		t.Errorf("unchanged file = %+v, want it taken from the cache", got)
+	}
+	if got := byName["changed.txt"]; got == nil || got.MimeType != "text/plain" || got.Size != 10 {
+		t.Errorf("changed file = %+v, want it analyzed again", got)
+	}
+
+	// Hashes missing from cached entries are filled in
+	if byName["unchanged.txt"].Hash == "" || cache.entries[unchanged].Hash == "" {
+		t.Error("hash of a cached file was not calculated when requested")
+	}
+}
@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/xuanyiying/cleanup-cli/internal/archive"
+)
+
+// IsArchived reports whether the metadata describes a file inside an
+// archive rather than a file on disk
+func (m *FileMetadata) IsArchived() bool {
+	return m.ArchivePath != ""
+}
+
+// analyzeArchive lists the files inside an archive without extracting it.
+// It records their names on the archive's metadata and returns them as
+// virtual files, hashed with SHA-256 if opts asks for hashes. Archives
+// with more entries than listed keep the entries read so far.
+func (fa *FileAnalyzer) analyzeArchive(ctx context.Context, metadata *FileMetadata, opts *ScanOptions) ([]*FileMetadata, error) {
+	entries, err := archive.List(ctx, metadata.Path, &archive.Options{Hash: opts.CalculateHash})
+	if err != nil && !errors.Is(err, archive.ErrTooManyEntries) {
+		return nil, fmt.Errorf("failed to list archive: %w", err)
+	}
+
+	files := make([]*FileMetadata, 0, len(entries))
+	metadata.ArchiveEntries = make([]string, 0, len(entries))
+	for _, e := range entries {
+		metadata.ArchiveEntries = append(metadata.ArchiveEntries, e.Name)
+		files = append(files, fa.archiveEntryMetadata(metadata.Path, e))
+	}
+	return files, nil
+}
+
+// archiveEntryMetadata returns the metadata of a file inside an archive.
+// The MIME type is guessed from the extension; such files are never
+// renamed, so they need no smarter name.
+func (fa *FileAnalyzer) archiveEntryMetadata(archivePath string, e *archive.Entry) *FileMetadata {
+	name := path.Base(e.Name)
+	ext := path.Ext(name)
+	score := fa.ScoreFileName(name)
+	return &FileMetadata{
+		Path:            archive.EntryPath(archivePath, e.Name),
+		Name:            name,
+		Extension:       strings.TrimPrefix(ext, "."),
+		Size:            e.Size,
+		MimeType:        mime.TypeByExtension(ext),
+		CreatedAt:       e.ModTime,
+		ModifiedAt:      e.ModTime,
+		ExifData:        make(map[string]string),
+		MediaData:       make(map[string]string),
+		FileNameQuality: score.Quality,
+		FileNameScore:   score.Score,
+		ArchivePath:     archivePath,
+		ArchiveHash:     e.SHA256,
+	}
+}
@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func writeTestZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnalyzeStream_Archives(t *testing.T) {
+	tmpDir := t.TempDir()
+	backup := filepath.Join(tmpDir, "backup.zip")
+	writeTestZip(t, backup, map[string]string{
+		"notes.txt":        "hello",
+		"photos/beach.jpg": "not really a jpeg",
+	})
+	os.WriteFile(filepath.Join(tmpDir, "broken.zip"), []byte("not a zip"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("hello"), 0644)
+
+	opts := &ScanOptions{Recursive: true, CalculateHash: true, Archives: true}
+	results := make(map[string]Result)
+	var order []string
+	for result := range NewAnalyzer().AnalyzeStream(context.Background(), tmpDir, opts) {
+		results[result.Path] = result
+		order = append(order, result.Path)
+	}
+
+	archived := results[backup+"!/photos/beach.jpg"].Metadata
+	if archived == nil || !archived.IsArchived() || archived.ArchivePath != backup {
+		t.Fatalf("archived file = %+v", archived)
+	}
+	if archived.Name != "beach.jpg" || archived.Extension != "jpg" || archived.MimeType != "image/jpeg" || archived.ArchiveHash == "" {
+		t.Errorf("archived file = %+v", archived)
+	}
+
+	m := results[backup].Metadata
+	if m == nil || m.IsArchived() {
+		t.Fatalf("archive = %+v", m)
+	}
+	entries := append([]string(nil), m.ArchiveEntries...)
+	sort.Strings(entries)
+	if len(entries) != 2 || entries[0] != "notes.txt" || entries[1] != "photos/beach.jpg" {
+		t.Errorf("ArchiveEntries = %v", m.ArchiveEntries)
+	}
+	fields := m.Fields()
+	if fields[FieldArchiveFormat] != "zip" || fields[FieldArchiveEntries] != "2" {
+		t.Errorf("archive fields = %q, %q", fields[FieldArchiveFormat], fields[FieldArchiveEntries])
+	}
+	if fields := archived.Fields(); fields[FieldArchive] != backup || fields[FieldArchiveFormat] != "" {
+		t.Errorf("archived file fields = %q, %q", fields[FieldArchive], fields[FieldArchiveFormat])
+	}
+
+	// The archive is delivered before its entries
+	position := make(map[string]int)
+	for i, p := range order {
+		position[p] = i
+	}
+	if position[backup] > position[backup+"!/notes.txt"] {
+		t.Error("an archived file was delivered before its archive")
+	}
+
+	// Damaged archives are analyzed as files and fail to list
+	broken := filepath.Join(tmpDir, "broken.zip")
+	if results[broken].Err != nil || results[broken+"!/"].Err == nil {
+		t.Errorf("broken archive results = %+v, %+v", results[broken], results[broken+"!/"])
+	}
+}
+
+func TestAnalyzeStream_ArchivesOff(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestZip(t, filepath.Join(tmpDir, "backup.zip"), map[string]string{"notes.txt": "hello"})
+
+	count := 0
+	for result := range NewAnalyzer().AnalyzeStream(context.Background(), tmpDir, nil) {
+		count++
+		if result.Metadata.ArchiveEntries != nil {
+			t.Error("archive was listed without ScanOptions.Archives")
+		}
+	}
+	if count != 1 {
+		t.Errorf("streamed %d files, want only the archive", count)
+	}
+}
@@ -0,0 +1,335 @@
+package analyzer
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	textunicode "golang.org/x/text/encoding/unicode"
+)
+
+// Text encodings reported in FileMetadata.Encoding
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingGB18030     = "gb18030"
+	EncodingBig5        = "big5"
+	EncodingShiftJIS    = "shift_jis"
+	EncodingWindows1252 = "windows-1252"
+)
+
+// encodingSampleSize is how much of a file encoding detection looks at
+const encodingSampleSize = 4096
+
+// encodings map encoding names to their decoders
+var encodings = map[string]encoding.Encoding{
+	EncodingUTF16LE:     textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM),
+	EncodingUTF16BE:     textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM),
+	EncodingGB18030:     simplifiedchinese.GB18030,
+	EncodingBig5:        traditionalchinese.Big5,
+	EncodingShiftJIS:    japanese.ShiftJIS,
+	EncodingWindows1252: charmap.Windows1252,
+}
+
+// legacyCharset is a multibyte East Asian encoding that detection weighs
+// against the others by how common the characters it decodes are
+type legacyCharset struct {
+	name   string
+	common func(r rune, encoded []byte) bool // A frequent character of the charset
+}
+
+// legacyCharsets are tried in order; earlier ones win ties
+var legacyCharsets = []legacyCharset{
+	{EncodingGB18030, func(r rune, b []byte) bool {
+		// GB2312 level 1: the 3755 most frequent simplified characters
+		return len(b) == 2 && b[0] >= 0xB0 && b[0] <= 0xD7 && b[1] >= 0xA1
+	}},
+	{EncodingBig5, func(r rune, b []byte) bool {
+		// Big5 frequently used characters
+		return len(b) == 2 && b[0] >= 0xA4 && b[0] <= 0xC6
+	}},
+	{EncodingShiftJIS, func(r rune, b []byte) bool {
+		// Kana and JIS level 1 kanji
+		return isKana(r) || len(b) == 2 && b[0] >= 0x88 && b[0] <= 0x98
+	}},
+}
+
+// DetectEncoding guesses the encoding of text from a sample of its start.
+// It returns false if the sample does not look like text.
+func DetectEncoding(sample []byte) (string, bool) {
+	switch {
+	case len(sample) == 0:
+		return EncodingUTF8, true
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8, true
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE, true
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE, true
+	}
+	if enc := detectUTF16(sample); enc != "" {
+		return enc, true
+	}
+
+	// Control characters other than blank space mark binary data
+	controls := 0
+	for _, b := range sample {
+		if b == 0 {
+			return "", false
+		}
+		if (b < 0x20 && !isTextControl(b)) || b == 0x7F {
+			controls++
+		}
+	}
+	if controls*10 > len(sample) {
+		return "", false
+	}
+
+	if validUTF8Prefix(sample) {
+		return EncodingUTF8, true
+	}
+
+	best, bestScore := "", 0
+	for _, cs := range legacyCharsets {
+		if score, ok := scoreCharset(sample, cs); ok && score > bestScore {
+			best, bestScore = cs.name, score
+		}
+	}
+	if best != "" {
+		return best, true
+	}
+
+	// Mostly ASCII with a few single-byte accented letters
+	ascii := 0
+	for _, b := range sample {
+		if b >= 0x20 && b < 0x7F || isTextControl(b) {
+			ascii++
+		}
+	}
+	if ascii*4 > len(sample)*3 {
+		return EncodingWindows1252, true
+	}
+	return "", false
+}
+
+// isTextControl reports whether a control byte is blank space or an
+// escape that text files contain
+func isTextControl(b byte) bool {
+	switch b {
+	case '\t', '\n', '\r', '\f', '\v', 0x1B:
+		return true
+	}
+	return false
+}
+
+// validUTF8Prefix reports whether a sample is UTF-8, allowing its end to
+// cut a character in half
+func validUTF8Prefix(sample []byte) bool {
+	if utf8.Valid(sample) {
+		return true
+	}
+	for cut := 1; cut < utf8.UTFMax && cut < len(sample); cut++ {
+		if utf8.Valid(sample[:len(sample)-cut]) {
+			return !utf8.FullRune(sample[len(sample)-cut:])
+		}
+	}
+	return false
+}
+
+// detectUTF16 recognizes UTF-16 without a byte order mark by the zero
+// bytes of its ASCII characters, which fall on every other byte
+func detectUTF16(sample []byte) string {
+	if len(sample) < 4 {
+		return ""
+	}
+	var zeros [2]int
+	for i, b := range sample {
+		if b == 0 {
+			zeros[i%2]++
+		}
+	}
+	half := len(sample) / 2
+	enc := ""
+	switch {
+	case zeros[1]*10 > half*3 && zeros[0]*20 < half:
+		enc = EncodingUTF16LE
+	case zeros[0]*10 > half*3 && zeros[1]*20 < half:
+		enc = EncodingUTF16BE
+	default:
+		return ""
+	}
+
+	// Binary data with zero padding decodes to control characters
+	controls, runes := 0, 0
+	for _, r := range DecodeText(sample, enc) {
+		runes++
+		if r < 0x20 && !isTextControl(byte(r)) {
+			controls++
+		}
+	}
+	if controls*10 > runes {
+		return ""
+	}
+	return enc
+}
+
+// scoreCharset decodes a sample with a charset and scores how plausible
+// the text is: frequent characters count for it, rare ones and halfwidth
+// katakana (what Chinese text looks like as Shift-JIS) against. A sample
+// that does not decode cleanly is rejected.
+func scoreCharset(sample []byte, cs legacyCharset) (int, bool) {
+	enc := encodings[cs.name]
+	text, err := enc.NewDecoder().Bytes(sample)
+	if err != nil {
+		return 0, false
+	}
+	encoder := enc.NewEncoder()
+
+	// The sample may end in the middle of a character
+	decoded := strings.TrimSuffix(string(text), string(utf8.RuneError))
+
+	score, invalid, runes := 0, 0, 0
+	for _, r := range decoded {
+		runes++
+		switch {
+		case r == utf8.RuneError:
+			invalid++
+		case r < utf8.RuneSelf:
+		case r >= 0xFF61 && r <= 0xFF9F:
+			score--
+		case r >= 0x3000 && r <= 0x303F, r >= 0xFF01 && r <= 0xFF5E:
+			score++ // CJK punctuation and fullwidth forms
+		default:
+			if b, err := encoder.String(string(r)); err == nil && cs.common(r, []byte(b)) {
+				score += 2
+			} else {
+				score--
+			}
+		}
+	}
+	return score, invalid*100 <= runes
+}
+
+// DecodeText converts text in an encoding to UTF-8, dropping a byte order
+// mark and characters that do not decode. Unknown encodings are treated as
+// UTF-8.
+func DecodeText(data []byte, encodingName string) string {
+	if enc, ok := encodings[encodingName]; ok {
+		if decoded, err := enc.NewDecoder().Bytes(data); err == nil {
+			data = decoded
+		}
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	return strings.ToValidUTF8(strings.ReplaceAll(text, "\ufffd", ""), "")
+}
+
+// isKana reports whether r is a fullwidth hiragana or katakana letter
+func isKana(r rune) bool {
+	return r >= 0x3041 && r <= 0x30FF && r != 0x30FB
+}
+
+// stopwords are frequent words that tell languages written in the Latin
+// alphabet apart
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "for", "with", "this", "are", "was"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "ein", "eine", "auf", "sich"},
+	"fr": {"le", "la", "les", "et", "est", "des", "une", "pour", "dans", "que", "pas", "sur"},
+	"es": {"el", "los", "las", "y", "es", "del", "una", "para", "con", "que", "por", "como"},
+	"it": {"il", "di", "che", "non", "per", "una", "sono", "della", "con", "gli", "questo", "anche"},
+	"pt": {"o", "os", "e", "do", "da", "em", "um", "uma", "para", "com", "não", "que"},
+	"nl": {"de", "het", "een", "en", "van", "is", "niet", "dat", "op", "met", "voor", "zijn"},
+}
+
+// DetectLanguage guesses the language of text as an ISO 639-1 code by its
+// script, and for Latin script by frequent words. It returns "" if the text
+// is too short or the language unclear.
+func DetectLanguage(text string) string {
+	var han, kana, hangul, latin, letters int
+	scripts := map[string]int{}
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case isKana(r):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["ru"]++
+		case unicode.Is(unicode.Greek, r):
+			scripts["el"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			scripts["he"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["th"]++
+		}
+	}
+	if letters < 10 {
+		return ""
+	}
+
+	// CJK text often mixes in Latin words, so a fifth is enough
+	switch {
+	case kana*20 >= letters:
+		return "ja"
+	case hangul*5 >= letters:
+		return "ko"
+	case han*5 >= letters:
+		return "zh"
+	}
+	for lang, n := range scripts {
+		if n*2 > letters {
+			return lang
+		}
+	}
+	if latin*2 > letters {
+		return latinLanguage(text)
+	}
+	return ""
+}
+
+// latinLanguage picks the language whose frequent words occur most often
+func latinLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	counts := make(map[string]int)
+	for _, word := range words {
+		for lang, list := range stopwords {
+			for _, w := range list {
+				if w == word {
+					counts[lang]++
+				}
+			}
+		}
+	}
+
+	best, bestCount, second := "", 0, 0
+	for lang, n := range counts {
+		switch {
+		case n > bestCount:
+			best, bestCount, second = lang, n, bestCount
+		case n > second:
+			second = n
+		}
+	}
+	if bestCount < 2 || bestCount == second {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,171 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	textunicode "golang.org/x/text/encoding/unicode"
+)
+
+const (
+	chineseText     = "这是一份关于项目进度的会议记录，包含了所有参会人员的发言内容和下一步的工作安排。"
+	traditionalText = "這是一份關於專案進度的會議紀錄，包含所有與會人員的發言內容和下一步的工作安排。"
+	japaneseText    = "これはプロジェクトの進捗に関する会議の議事録です。参加者全員の発言が含まれています。"
+	englishText     = "This is the summary of the meeting and the notes that were taken for the project."
+	frenchText      = "Voici le compte rendu de la réunion et les notes prises pour le projet dans les délais."
+)
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDetectEncoding(t *testing.T) {
+	utf16le := textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM)
+	utf16be := textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM)
+
+	mixed := "会议记录 Meeting notes 2024-03-01\n" + chineseText
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		text string // The text decoded
+	}{
+		{"ascii", []byte(englishText), EncodingUTF8, englishText},
+		{"utf-8 chinese", []byte(chineseText), EncodingUTF8, chineseText},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, chineseText...), EncodingUTF8, chineseText},
+		{"gbk", encode(t, simplifiedchinese.GBK, chineseText), EncodingGB18030, chineseText},
+		{"gbk with ascii", encode(t, simplifiedchinese.GBK, mixed), EncodingGB18030, mixed},
+		{"big5", encode(t, traditionalchinese.Big5, traditionalText), EncodingBig5, traditionalText},
+		{"shift-jis", encode(t, japanese.ShiftJIS, japaneseText), EncodingShiftJIS, japaneseText},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, encode(t, utf16le, englishText)...), EncodingUTF16LE, englishText},
+		{"utf-16le", encode(t, utf16le, chineseText+englishText), EncodingUTF16LE, chineseText + englishText},
+		{"utf-16be", encode(t, utf16be, englishText), EncodingUTF16BE, englishText},
+		{"windows-1252", encode(t, charmap.Windows1252, frenchText), EncodingWindows1252, frenchText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DetectEncoding(tt.data)
+			if !ok || got != tt.want {
+				t.Errorf("DetectEncoding() = %q, %v, want %q", got, ok, tt.want)
+			}
+			if text := DecodeText(tt.data, got); text != tt.text {
+				t.Errorf("DecodeText() = %q, want %q", text, tt.text)
+			}
+		})
+	}
+}
+
+func TestDetectEncoding_CutCharacter(t *testing.T) {
+	// Samples end wherever the read stopped
+	data := []byte(chineseText)
+	if got, ok := DetectEncoding(data[:len(data)-1]); !ok || got != EncodingUTF8 {
+		t.Errorf("DetectEncoding() of cut UTF-8 = %q, %v", got, ok)
+	}
+	gbk := encode(t, simplifiedchinese.GBK, chineseText)
+	if got, ok := DetectEncoding(gbk[:len(gbk)-1]); !ok || got != EncodingGB18030 {
+		t.Errorf("DetectEncoding() of cut GBK = %q, %v", got, ok)
+	}
+}
+
+func TestDetectEncoding_Binary(t *testing.T) {
+	binary := [][]byte{
+		{0x00, 0x01, 0x02, 0x03, 0x04, 0x05},
+		{0x7F, 'E', 'L', 'F', 0x02, 0x01, 0x01, 0x00},
+		[]byte("\x01\x02\x03\x04\x05\x06\x07\x08abc"),
+	}
+	for _, data := range binary {
+		if got, ok := DetectEncoding(data); ok {
+			t.Errorf("DetectEncoding(%q) = %q, want binary", data, got)
+		}
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	gbk := encode(t, simplifiedchinese.GBK, chineseText)
+	if got := DecodeText(gbk, EncodingGB18030); got != chineseText {
+		t.Errorf("DecodeText(gbk) = %q", got)
+	}
+	sjis := encode(t, japanese.ShiftJIS, japaneseText)
+	if got := DecodeText(sjis, EncodingShiftJIS); got != japaneseText {
+		t.Errorf("DecodeText(shift-jis) = %q", got)
+	}
+	bom := append([]byte{0xFF, 0xFE}, encode(t, textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM), "héllo")...)
+	if got := DecodeText(bom, EncodingUTF16LE); got != "héllo" {
+		t.Errorf("DecodeText(utf-16le) = %q", got)
+	}
+	if got := DecodeText([]byte("a\xffb"), EncodingUTF8); got != "ab" {
+		t.Errorf("DecodeText(invalid utf-8) = %q", got)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]string{
+		chineseText:     "zh",
+		traditionalText: "zh",
+		japaneseText:    "ja",
+		englishText:     "en",
+		frenchText:      "fr",
+		"Das ist nicht die Lösung, die wir mit dem Team auf der Sitzung besprochen haben.": "de",
+		"이것은 프로젝트 진행 상황에 관한 회의록입니다.":                                                       "ko",
+		"Это протокол совещания о ходе работы над проектом.":                               "ru",
+		"项目 Roadmap 2024：Q1 完成 API 设计，Q2 上线":                                               "zh",
+		"hello":           "",
+		"12345 67890 !!!": "",
+	}
+	for text, want := range tests {
+		if got := DetectLanguage(text); got != want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestAnalyze_EncodedText(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string][]byte{
+		"gbk.txt":    encode(t, simplifiedchinese.GBK, chineseText),
+		"utf16.txt":  append([]byte{0xFF, 0xFE}, encode(t, textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM), japaneseText)...),
+		"english.md": []byte(englishText),
+	}
+	want := map[string][3]string{
+		"gbk.txt":    {chineseText, EncodingGB18030, "zh"},
+		"utf16.txt":  {japaneseText, EncodingUTF16LE, "ja"},
+		"english.md": {englishText, EncodingUTF8, "en"},
+	}
+	for name, data := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		m, err := NewAnalyzer().Analyze(context.Background(), path)
+		if err != nil {
+			t.Fatalf("Analyze(%s) error = %v", name, err)
+		}
+		if got := [3]string{m.ContentPreview, m.Encoding, m.Language}; got != want[name] {
+			t.Errorf("Analyze(%s) preview, encoding, language = %q, want %q", name, got, want[name])
+		}
+	}
+}
+
+func TestTruncatePreview(t *testing.T) {
+	if got := truncatePreview(chineseText, 10); got != string([]rune(chineseText)[:10])+"..." {
+		t.Errorf("truncatePreview(chinese) = %q", got)
+	}
+	if got := truncatePreview("hello wonderful world", 16); got != "hello wonderful..." {
+		t.Errorf("truncatePreview(english) = %q", got)
+	}
+	if got := truncatePreview("短文本", 10); got != "短文本" {
+		t.Errorf("truncatePreview(short) = %q", got)
+	}
+}
@@ -0,0 +1,119 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	pdfInfoRef  = regexp.MustCompile(`/Info\s+(\d+)\s+\d+\s+R`)
+	pdfTitleKey = regexp.MustCompile(`/Title\s*[(<]`)
+)
+
+// officeCore is docProps/core.xml of Office Open XML documents
+type officeCore struct {
+	Title string `xml:"title"`
+}
+
+// odfMeta is meta.xml of OpenDocument files
+type odfMeta struct {
+	Title string `xml:"meta>title"`
+}
+
+// DocumentTitle returns the title a document declares in its metadata: the
+// document information of a PDF, the core properties of an Office Open XML
+// file or the metadata of an OpenDocument file or EPUB book. It returns ""
+// if the document declares none or cannot be read.
+func DocumentTitle(ctx context.Context, path, mimeType string) string {
+	if err := ctx.Err(); err != nil {
+		return ""
+	}
+
+	var title string
+	switch baseMimeType(mimeType) {
+	case MimePDF:
+		title = pdfTitle(path)
+	case MimeDOCX, MimeXLSX, MimePPTX:
+		var core officeCore
+		if zipXML(path, "docProps/core.xml", &core) {
+			title = core.Title
+		}
+	case MimeODT, MimeODS, MimeODP:
+		var meta odfMeta
+		if zipXML(path, "meta.xml", &meta) {
+			title = meta.Title
+		}
+	case MimeEPUB:
+		title = epubTitle(path)
+	}
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// pdfTitle returns the /Title of a PDF's document information dictionary.
+// Encrypted documents encrypt their titles too, so they yield "".
+func pdfTitle(path string) string {
+	if info, err := os.Stat(path); err != nil || info.Size() > DefaultExtractLimits.MaxFileSize {
+		return ""
+	}
+	data, err := readFile(path)
+	if err != nil || !bytes.HasPrefix(data, []byte("%PDF")) || bytes.Contains(data, []byte("/Encrypt")) {
+		return ""
+	}
+
+	// The last trailer belongs to the latest revision
+	refs := pdfInfoRef.FindAllSubmatch(data, -1)
+	if len(refs) == 0 {
+		return ""
+	}
+	num, _ := strconv.Atoi(string(refs[len(refs)-1][1]))
+	objects, _ := parsePDFObjects(data, DefaultExtractLimits.MaxFileSize*maxEntryRatio)
+	info, ok := objects[num]
+	if !ok {
+		return ""
+	}
+
+	loc := pdfTitleKey.FindIndex(info.dict)
+	if loc == nil {
+		return ""
+	}
+	lex := &pdfLexer{data: info.dict, pos: loc[1] - 1}
+	tok, ok := lex.next()
+	if !ok || tok.kind != pdfString {
+		return ""
+	}
+	return decodePDFString(tok.str)
+}
+
+// epubTitle returns the title in the package document of an EPUB book
+func epubTitle(path string) string {
+	zr, err := openZip(path)
+	if err != nil {
+		return ""
+	}
+	defer zr.Close()
+
+	var container epubContainer
+	if err := decodeZipXML(zr.Reader, "META-INF/container.xml", DefaultExtractLimits, &container); err != nil || len(container.Rootfiles) == 0 {
+		return ""
+	}
+	var pkg epubPackage
+	if err := decodeZipXML(zr.Reader, container.Rootfiles[0].FullPath, DefaultExtractLimits, &pkg); err != nil {
+		return ""
+	}
+	return pkg.Title
+}
+
+// zipXML unmarshals an XML entry of a ZIP-based document into v, reporting
+// whether it succeeded
+func zipXML(path, name string, v any) bool {
+	zr, err := openZip(path)
+	if err != nil {
+		return false
+	}
+	defer zr.Close()
+	return decodeZipXML(zr.Reader, name, DefaultExtractLimits, v) == nil
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDocumentTitle(t *testing.T) {
+	dir := t.TempDir()
+
+	pdf := filepath.Join(dir, "scan.pdf")
+	writePDF(t, pdf, "BT /F1 12 Tf (Body) Tj ET\n")
+	data, _ := os.ReadFile(pdf)
+	data = []byte(strings.Replace(string(data), "trailer\n<< /Root 1 0 R >>",
+		"8 0 obj\n<< /Producer (Scanner) /Title <FEFF00520065006E0074002000610067007200650065006D0065006E0074> >>\nendobj\n"+
+			"trailer\n<< /Root 1 0 R /Info 8 0 R >>", 1))
+	os.WriteFile(pdf, data, 0644)
+
+	untitledPDF := filepath.Join(dir, "untitled.pdf")
+	writePDF(t, untitledPDF, "BT /F1 12 Tf (Body) Tj ET\n")
+
+	docx := filepath.Join(dir, "doc1.docx")
+	writeZip(t, docx, [][2]string{
+		{"word/document.xml", `<w:document xmlns:w="w"/>`},
+		{"docProps/core.xml", `<cp:coreProperties xmlns:cp="cp" xmlns:dc="dc"><dc:title>Q3  Budget
+			Review</dc:title><dc:creator>Ann</dc:creator></cp:coreProperties>`},
+	})
+
+	odt := filepath.Join(dir, "doc2.odt")
+	writeZip(t, odt, [][2]string{
+		{"mimetype", MimeODT},
+		{"meta.xml", `<office:document-meta xmlns:office="o" xmlns:dc="dc"><office:meta><dc:title>会议纪要</dc:title></office:meta></office:document-meta>`},
+	})
+
+	epub := filepath.Join(dir, "book.epub")
+	writeZip(t, epub, [][2]string{
+		{"mimetype", MimeEPUB},
+		{"META-INF/container.xml", `<container><rootfiles><rootfile full-path="content.opf"/></rootfiles></container>`},
+		{"content.opf", `<package><metadata><dc:title xmlns:dc="dc">A Tale</dc:title></metadata></package>`},
+	})
+
+	tests := []struct {
+		path     string
+		mimeType string
+		want     string
+	}{
+		{pdf, MimePDF, "Rent agreement"},
+		{untitledPDF, MimePDF, ""},
+		{docx, MimeDOCX, "Q3 Budget Review"},
+		{odt, MimeODT, "会议纪要"},
+		{epub, MimeEPUB, "A Tale"},
+		{docx, "text/plain", ""},
+		{filepath.Join(dir, "missing.pdf"), MimePDF, ""},
+	}
+	for _, tt := range tests {
+		if got := DocumentTitle(context.Background(), tt.path, tt.mimeType); got != tt.want {
+			t.Errorf("DocumentTitle(%s, %s) = %q, want %q", filepath.Base(tt.path), tt.mimeType, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"path/filepath"
 	"testing"
 )
 
@@ -149,3 +150,59 @@ func TestShouldExcludeDir(t *testing.T) {
 		})
 	}
 }
+
+func TestIsExcluded(t *testing.T) {
+	root := filepath.Join("tmp", "root")
+
+	tests := []struct {
+		name string
+		path string
+		opts *ScanOptions
+		want bool
+	}{
+		{
+			name: "top-level file",
+			path: filepath.Join(root, "report.pdf"),
+			opts: &ScanOptions{},
+			want: false,
+		},
+		{
+			name: "outside root",
+			path: filepath.Join("tmp", "other", "report.pdf"),
+			opts: &ScanOptions{Recursive: true},
+			want: true,
+		},
+		{
+			name: "nested file when not recursive",
+			path: filepath.Join(root, "sub", "report.pdf"),
+			opts: &ScanOptions{},
+			want: true,
+		},
+		{
+			name: "inside excluded directory",
+			path: filepath.Join(root, "node_modules", "pkg", "index.js"),
+			opts: &ScanOptions{Recursive: true, ExcludeDirs: []string{"node_modules"}},
+			want: true,
+		},
+		{
+			name: "hidden file",
+			path: filepath.Join(root, ".DS_Store"),
+			opts: &ScanOptions{},
+			want: true,
+		},
+		{
+			name: "excluded extension",
+			path: filepath.Join(root, "debug.log"),
+			opts: &ScanOptions{ExcludeExtensions: []string{"log"}},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsExcluded(root, tt.path, tt.opts); got != tt.want {
+				t.Errorf("IsExcluded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys of FileMetadata.ExifData, named after their EXIF tags
+const (
+	ExifMake             = "Make"
+	ExifModel            = "Model"
+	ExifLensModel        = "LensModel"
+	ExifOrientation      = "Orientation"
+	ExifDateTimeOriginal = "DateTimeOriginal" // 2006-01-02T15:04:05, with a zone offset if known
+	ExifGPSLatitude      = "GPSLatitude"      // Decimal degrees, negative south of the equator
+	ExifGPSLongitude     = "GPSLongitude"     // Decimal degrees, negative west of Greenwich
+	ExifGPSAltitude      = "GPSAltitude"      // Meters, negative below sea level
+	ExifImageWidth       = "ImageWidth"
+	ExifImageHeight      = "ImageHeight"
+)
+
+const (
+	// maxIFDEntries bounds the entries read from one image file directory
+	maxIFDEntries = 1024
+	// maxMetadataSegment bounds the EXIF and XMP payloads read from a file
+	maxMetadataSegment = 4 << 20
+)
+
+// TIFF tags read from image file directories
+const (
+	tagImageWidth        = 0x0100
+	tagImageLength       = 0x0101
+	tagMake              = 0x010F
+	tagModel             = 0x0110
+	tagOrientation       = 0x0112
+	tagDateTime          = 0x0132
+	tagXMP               = 0x02BC
+	tagExifIFD           = 0x8769
+	tagGPSIFD            = 0x8825
+	tagDateTimeOriginal  = 0x9003
+	tagDateTimeDigitized = 0x9004
+	tagOffsetTimeOrig    = 0x9011
+	tagPixelXDimension   = 0xA002
+	tagPixelYDimension   = 0xA003
+	tagLensModel         = 0xA434
+
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+	tagGPSAltitudeRef  = 0x0005
+	tagGPSAltitude     = 0x0006
+)
+
+// tiffTypeSizes are the sizes in bytes of TIFF field types
+var tiffTypeSizes = map[uint16]int64{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8,
+}
+
+// ifdEntry is a field of an image file directory
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// tiffReader reads image file directories of a TIFF structure, as found in
+// TIFF files and the EXIF payloads of other formats
+type tiffReader struct {
+	r     io.ReaderAt
+	size  int64
+	order binary.ByteOrder
+}
+
+// parseTIFF reads the EXIF tags of a TIFF structure into data
+func parseTIFF(r io.ReaderAt, size int64, data map[string]string) error {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("failed to read TIFF header: %w", err)
+	}
+
+	t := &tiffReader{r: r, size: size}
+	switch string(header[:4]) {
+	case "II*\x00":
+		t.order = binary.LittleEndian
+	case "MM\x00*":
+		t.order = binary.BigEndian
+	default:
+		return fmt.Errorf("invalid TIFF header")
+	}
+
+	ifd0, err := t.readIFD(t.order.Uint32(header[4:]))
+	if err != nil {
+		return err
+	}
+	setExif(data, ExifMake, t.ascii(ifd0[tagMake]))
+	setExif(data, ExifModel, t.ascii(ifd0[tagModel]))
+	setExif(data, ExifOrientation, t.number(ifd0[tagOrientation]))
+	setExif(data, ExifImageWidth, t.number(ifd0[tagImageWidth]))
+	setExif(data, ExifImageHeight, t.number(ifd0[tagImageLength]))
+
+	taken := t.ascii(ifd0[tagDateTime])
+	if e, ok := ifd0[tagExifIFD]; ok {
+		exif, err := t.readIFD(t.uint32(e))
+		if err != nil {
+			return err
+		}
+		if s := t.ascii(exif[tagDateTimeOriginal]); s != "" && !strings.HasPrefix(s, "0000") {
+			taken = s + t.ascii(exif[tagOffsetTimeOrig])
+		} else if s := t.ascii(exif[tagDateTimeDigitized]); s != "" && !strings.HasPrefix(s, "0000") {
+			taken = s
+		}
+		setExif(data, ExifLensModel, t.ascii(exif[tagLensModel]))
+		setExif(data, ExifImageWidth, t.number(exif[tagPixelXDimension]))
+		setExif(data, ExifImageHeight, t.number(exif[tagPixelYDimension]))
+	}
+	setExif(data, ExifDateTimeOriginal, normalizeExifTime(taken))
+
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		gps, err := t.readIFD(t.uint32(e))
+		if err != nil {
+			return err
+		}
+		setExif(data, ExifGPSLatitude, formatCoordinate(t.rationals(gps[tagGPSLatitude]), t.ascii(gps[tagGPSLatitudeRef]), "S"))
+		setExif(data, ExifGPSLongitude, formatCoordinate(t.rationals(gps[tagGPSLongitude]), t.ascii(gps[tagGPSLongitudeRef]), "W"))
+		if alt := t.rationals(gps[tagGPSAltitude]); len(alt) > 0 {
+			if ref := gps[tagGPSAltitudeRef]; len(ref.value) > 0 && ref.value[0] == 1 {
+				alt[0] = -alt[0]
+			}
+			setExif(data, ExifGPSAltitude, strconv.FormatFloat(alt[0], 'f', 1, 64))
+		}
+	}
+
+	if e, ok := ifd0[tagXMP]; ok {
+		parseXMP(e.value, data)
+	}
+	return nil
+}
+
+// readIFD reads the fields of the image file directory at offset
+func (t *tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, error) {
+	var countBuf [2]byte
+	if _, err := t.r.ReadAt(countBuf[:], int64(offset)); err != nil {
+		return nil, fmt.Errorf("failed to read image file directory: %w", err)
+	}
+	count := int(t.order.Uint16(countBuf[:]))
+	if count > maxIFDEntries {
+		return nil, fmt.Errorf("image file directory has too many entries: %d", count)
+	}
+
+	raw := make([]byte, count*12)
+	if _, err := t.r.ReadAt(raw, int64(offset)+2); err != nil {
+		return nil, fmt.Errorf("failed to read image file directory: %w", err)
+	}
+
+	entries := make(map[uint16]ifdEntry, count)
+	for i := 0; i < count; i++ {
+		field := raw[i*12 : i*12+12]
+		tag, typ, n := t.order.Uint16(field), t.order.Uint16(field[2:]), t.order.Uint32(field[4:])
+		size, ok := tiffTypeSizes[typ]
+		if !ok {
+			continue
+		}
+		length := size * int64(n)
+		if length <= 4 {
+			entries[tag] = ifdEntry{typ: typ, count: n, value: field[8 : 8+length]}
+			continue
+		}
+
+		valueOffset := int64(t.order.Uint32(field[8:]))
+		if length > maxMetadataSegment || valueOffset+length > t.size {
+			continue
+		}
+		value := make([]byte, length)
+		if _, err := t.r.ReadAt(value, valueOffset); err != nil {
+			continue
+		}
+		entries[tag] = ifdEntry{typ: typ, count: n, value: value}
+	}
+	return entries, nil
+}
+
+// ascii returns a text field without padding
+func (t *tiffReader) ascii(e ifdEntry) string {
+	if e.typ != 2 && e.typ != 7 {
+		return ""
+	}
+	if i := bytes.IndexByte(e.value, 0); i >= 0 {
+		e.value = e.value[:i]
+	}
+	return strings.TrimSpace(string(e.value))
+}
+
+// uint32 returns the first value of an integer field
+func (t *tiffReader) uint32(e ifdEntry) uint32 {
+	switch {
+	case e.typ == 3 && len(e.value) >= 2:
+		return uint32(t.order.Uint16(e.value))
+	case e.typ == 4 && len(e.value) >= 4:
+		return t.order.Uint32(e.value)
+	case e.typ == 1 && len(e.value) >= 1:
+		return uint32(e.value[0])
+	}
+	return 0
+}
+
+// number formats the first value of an integer field, or returns "" if
+// the field is missing
+func (t *tiffReader) number(e ifdEntry) string {
+	if len(e.value) == 0 || (e.typ != 1 && e.typ != 3 && e.typ != 4) {
+		return ""
+	}
+	return strconv.FormatUint(uint64(t.uint32(e)), 10)
+}
+
+// rationals returns the values of a rational field
+func (t *tiffReader) rationals(e ifdEntry) []float64 {
+	if e.typ != 5 && e.typ != 10 {
+		return nil
+	}
+	values := make([]float64, 0, len(e.value)/8)
+	for i := 0; i+8 <= len(e.value); i += 8 {
+		num, den := t.order.Uint32(e.value[i:]), t.order.Uint32(e.value[i+4:])
+		if den == 0 {
+			return nil
+		}
+		if e.typ == 10 {
+			values = append(values, float64(int32(num))/float64(int32(den)))
+		} else {
+			values = append(values, float64(num)/float64(den))
+		}
+	}
+	return values
+}
+
+// setExif sets a key of data unless the value is empty or the key is set
+func setExif(data map[string]string, key, value string) {
+	if value == "" {
+		return
+	}
+	if _, ok := data[key]; !ok {
+		data[key] = value
+	}
+}
+
+// formatCoordinate converts degrees, minutes and seconds to decimal
+// degrees, negated when ref is the negative hemisphere
+func formatCoordinate(dms []float64, ref, negative string) string {
+	if len(dms) == 0 {
+		return ""
+	}
+	deg := dms[0]
+	if len(dms) > 1 {
+		deg += dms[1] / 60
+	}
+	if len(dms) > 2 {
+		deg += dms[2] / 3600
+	}
+	if strings.EqualFold(ref, negative) {
+		deg = -deg
+	}
+	return strconv.FormatFloat(deg, 'f', 6, 64)
+}
+
+// exifTimeLayouts are the date formats of EXIF and XMP, most specific first
+var exifTimeLayouts = []struct {
+	layout string
+	zone   bool
+}{
+	{"2006:01:02 15:04:05-07:00", true},
+	{"2006:01:02 15:04:05", false},
+	{"2006-01-02T15:04:05.999999999Z07:00", true},
+	{"2006-01-02T15:04:05.999999999", false},
+	{"2006-01-02T15:04Z07:00", true},
+	{"2006-01-02T15:04", false},
+	{"2006-01-02", false},
+}
+
+// normalizeExifTime rewrites an EXIF or XMP date as 2006-01-02T15:04:05,
+// followed by the zone offset if the date has one. Dates that cannot be
+// parsed yield "".
+func normalizeExifTime(s string) string {
+	s = strings.TrimSpace(s)
+	for _, l := range exifTimeLayouts {
+		t, err := time.Parse(l.layout, s)
+		if err != nil {
+			continue
+		}
+		if l.zone {
+			return t.Format("2006-01-02T15:04:05-07:00")
+		}
+		return t.Format("2006-01-02T15:04:05")
+	}
+	return ""
+}
+
+// parseTakenAt parses a normalized date; dates without a zone offset are
+// in local time
+func parseTakenAt(s string) time.Time {
+	if t, err := time.Parse("2006-01-02T15:04:05-07:00", s); err == nil {
+		return t
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", s, time.Local); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+var (
+	xmpAttribute = regexp.MustCompile(`\s([A-Za-z]+):([A-Za-z]+)\s*=\s*"([^"]*)"`)
+	xmpElement   = regexp.MustCompile(`<([A-Za-z]+):([A-Za-z]+)>([^<]*)</`)
+	xmpGPS       = regexp.MustCompile(`^(\d+),(\d+(?:\.\d+)?)(?:,(\d+(?:\.\d+)?))?([NSEW])$`)
+)
+
+// xmpProperties map XMP properties to ExifData keys; earlier properties
+// win over later ones for the same key
+var xmpProperties = []struct {
+	property string
+	key      string
+}{
+	{"tiff:Make", ExifMake},
+	{"tiff:Model", ExifModel},
+	{"exifEX:LensModel", ExifLensModel},
+	{"aux:Lens", ExifLensModel},
+	{"tiff:Orientation", ExifOrientation},
+	{"exif:DateTimeOriginal", ExifDateTimeOriginal},
+	{"photoshop:DateCreated", ExifDateTimeOriginal},
+	{"xmp:CreateDate", ExifDateTimeOriginal},
+	{"exif:GPSLatitude", ExifGPSLatitude},
+	{"exif:GPSLongitude", ExifGPSLongitude},
+	{"exif:PixelXDimension", ExifImageWidth},
+	{"tiff:ImageWidth", ExifImageWidth},
+	{"exif:PixelYDimension", ExifImageHeight},
+	{"tiff:ImageLength", ExifImageHeight},
+}
+
+// parseXMP reads properties of an XMP packet into data, leaving keys that
+// are already set alone
+func parseXMP(packet []byte, data map[string]string) {
+	found := make(map[string]string)
+	for _, re := range []*regexp.Regexp{xmpAttribute, xmpElement} {
+		for _, m := range re.FindAllSubmatch(packet, -1) {
+			property := string(m[1]) + ":" + string(m[2])
+			if _, ok := found[property]; !ok {
+				found[property] = strings.TrimSpace(string(m[3]))
+			}
+		}
+	}
+
+	for _, p := range xmpProperties {
+		value, ok := found[p.property]
+		if !ok {
+			continue
+		}
+		switch p.key {
+		case ExifDateTimeOriginal:
+			value = normalizeExifTime(value)
+		case ExifGPSLatitude, ExifGPSLongitude:
+			value = parseXMPCoordinate(value)
+		}
+		setExif(data, p.key, value)
+	}
+}
+
+// parseXMPCoordinate converts an XMP GPS coordinate such as "51,30.5N" or
+// "0,7,39W" to decimal degrees
+func parseXMPCoordinate(s string) string {
+	m := xmpGPS.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return ""
+	}
+	var dms []float64
+	for _, part := range m[1:4] {
+		if part == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return ""
+		}
+		dms = append(dms, v)
+	}
+	negative := "S"
+	if m[4] == "E" || m[4] == "W" {
+		negative = "W"
+	}
+	return formatCoordinate(dms, m[4], negative)
+}
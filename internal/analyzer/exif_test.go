@@ -0,0 +1,402 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tiffField is a field of a test TIFF structure. values is a string
+// (ASCII), []byte (BYTE), []uint16 (SHORT), []uint32 (LONG) or
+// [][2]uint32 (RATIONAL).
+type tiffField struct {
+	tag    uint16
+	values interface{}
+}
+
+// byteOrder is binary.LittleEndian or binary.BigEndian
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// buildTIFF lays out a TIFF structure with IFD0 and, if not empty, an EXIF
+// and a GPS directory
+func buildTIFF(order byteOrder, ifd0, exif, gps []tiffField) []byte {
+	dirs := [][]tiffField{ifd0, exif, gps}
+	// Pointer fields of IFD0, filled in once the layout is known
+	if len(exif) > 0 {
+		dirs[0] = append(dirs[0], tiffField{tagExifIFD, []uint32{0}})
+	}
+	if len(gps) > 0 {
+		dirs[0] = append(dirs[0], tiffField{tagGPSIFD, []uint32{0}})
+	}
+
+	offsets := make([]uint32, len(dirs))
+	next := uint32(8)
+	for i, d := range dirs {
+		if len(d) == 0 {
+			continue
+		}
+		offsets[i] = next
+		next += uint32(2 + 12*len(d) + 4)
+	}
+	for i := range dirs[0] {
+		switch dirs[0][i].tag {
+		case tagExifIFD:
+			dirs[0][i].values = []uint32{offsets[1]}
+		case tagGPSIFD:
+			dirs[0][i].values = []uint32{offsets[2]}
+		}
+	}
+
+	out := make([]byte, next)
+	if order == binary.LittleEndian {
+		copy(out, "II*\x00")
+	} else {
+		copy(out, "MM\x00*")
+	}
+	order.PutUint32(out[4:], 8)
+
+	for i, d := range dirs {
+		if len(d) == 0 {
+			continue
+		}
+		pos := offsets[i]
+		order.PutUint16(out[pos:], uint16(len(d)))
+		for j, f := range d {
+			var typ uint16
+			var count uint32
+			var value []byte
+			switch v := f.values.(type) {
+			case string:
+				typ, count, value = 2, uint32(len(v)+1), append([]byte(v), 0)
+			case []byte:
+				typ, count, value = 1, uint32(len(v)), v
+			case []uint16:
+				typ, count = 3, uint32(len(v))
+				for _, x := range v {
+					value = order.AppendUint16(value, x)
+				}
+			case []uint32:
+				typ, count = 4, uint32(len(v))
+				for _, x := range v {
+					value = order.AppendUint32(value, x)
+				}
+			case [][2]uint32:
+				typ, count = 5, uint32(len(v))
+				for _, x := range v {
+					value = order.AppendUint32(order.AppendUint32(value, x[0]), x[1])
+				}
+			}
+
+			entry := out[pos+2+uint32(12*j):]
+			order.PutUint16(entry, f.tag)
+			order.PutUint16(entry[2:], typ)
+			order.PutUint32(entry[4:], count)
+			if len(value) <= 4 {
+				copy(entry[8:12], value)
+			} else {
+				order.PutUint32(entry[8:], uint32(len(out)))
+				out = append(out, value...)
+			}
+		}
+	}
+	return out
+}
+
+// testExif is a camera's EXIF payload with date, lens and GPS position
+func testExif(order byteOrder) []byte {
+	return buildTIFF(order,
+		[]tiffField{
+			{tagMake, "Canon"},
+			{tagModel, "Canon EOS R5"},
+			{tagOrientation, []uint16{6}},
+			{tagDateTime, "2023:08:01 10:00:00"},
+		},
+		[]tiffField{
+			{tagDateTimeOriginal, "2023:07:14 18:22:05"},
+			{tagOffsetTimeOrig, "+02:00"},
+			{tagLensModel, "RF24-105mm F4 L IS USM"},
+			{tagPixelXDimension, []uint32{8192}},
+			{tagPixelYDimension, []uint32{5464}},
+		},
+		[]tiffField{
+			{tagGPSLatitudeRef, "N"},
+			{tagGPSLatitude, [][2]uint32{{51, 1}, {30, 1}, {2646, 100}}},
+			{tagGPSLongitudeRef, "W"},
+			{tagGPSLongitude, [][2]uint32{{0, 1}, {7, 1}, {3960, 100}}},
+			{tagGPSAltitudeRef, []byte{0}},
+			{tagGPSAltitude, [][2]uint32{{355, 10}}},
+		})
+}
+
+// testExifWant is what testExif reads as
+var testExifWant = map[string]string{
+	ExifMake:             "Canon",
+	ExifModel:            "Canon EOS R5",
+	ExifOrientation:      "6",
+	ExifDateTimeOriginal: "2023-07-14T18:22:05+02:00",
+	ExifLensModel:        "RF24-105mm F4 L IS USM",
+	ExifGPSLatitude:      "51.507350",
+	ExifGPSLongitude:     "-0.127667",
+	ExifGPSAltitude:      "35.5",
+}
+
+// jpegSegment builds a JPEG marker segment
+func jpegSegment(marker byte, payload []byte) []byte {
+	seg := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// jpegFrame builds a baseline frame header of the given size
+func jpegFrame(width, height uint16) []byte {
+	frame := []byte{8, 0, 0, 0, 0, 3}
+	binary.BigEndian.PutUint16(frame[1:], height)
+	binary.BigEndian.PutUint16(frame[3:], width)
+	return jpegSegment(0xC0, frame)
+}
+
+// pngChunk builds a PNG chunk with a zero CRC
+func pngChunk(typ string, payload []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	chunk = append(chunk, typ...)
+	chunk = append(chunk, payload...)
+	return append(chunk, 0, 0, 0, 0)
+}
+
+// riffChunk builds a RIFF chunk, padded to an even size
+func riffChunk(typ string, payload []byte) []byte {
+	chunk := append([]byte(typ), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// isoBoxBytes builds an ISO base media box
+func isoBoxBytes(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(box, typ...), body...)
+}
+
+// buildHEIC builds a HEIC file with a grid of tiles and an Exif item
+func buildHEIC(exif []byte) []byte {
+	ftyp := isoBoxBytes("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+	infe := func(id uint16, typ string) []byte {
+		return isoBoxBytes("infe", []byte{2, 0, 0, 0}, binary.BigEndian.AppendUint16(nil, id), []byte{0, 0}, []byte(typ), []byte{0})
+	}
+	ispe := func(w, h uint32) []byte {
+		return isoBoxBytes("ispe", []byte{0, 0, 0, 0}, binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, w), h))
+	}
+	item := append([]byte{0, 0, 0, 6}, exifHeader...)
+	item = append(item, exif...)
+
+	meta := func(itemOffset uint32) []byte {
+		iloc := []byte{0, 0, 0, 0, 0x44, 0x00, 0, 1, 0, 2, 0, 0, 0, 1}
+		iloc = binary.BigEndian.AppendUint32(iloc, itemOffset)
+		iloc = binary.BigEndian.AppendUint32(iloc, uint32(len(item)))
+		return isoBoxBytes("meta", []byte{0, 0, 0, 0},
+			isoBoxBytes("hdlr", make([]byte, 24)),
+			isoBoxBytes("iinf", []byte{0, 0, 0, 0, 0, 2}, infe(1, "grid"), infe(2, "Exif")),
+			isoBoxBytes("iloc", iloc),
+			isoBoxBytes("iprp", isoBoxBytes("ipco", ispe(512, 512), ispe(4032, 3024))),
+		)
+	}
+
+	offset := uint32(len(ftyp) + len(meta(0)) + 8)
+	return bytes.Join([][]byte{ftyp, meta(offset), isoBoxBytes("mdat", item)}, nil)
+}
+
+func TestReadImageMetadata(t *testing.T) {
+	dir := t.TempDir()
+	le, be := testExif(binary.LittleEndian), testExif(binary.BigEndian)
+
+	xmp := []byte(`<x:xmpmeta><rdf:RDF><rdf:Description tiff:Model="Ignored" xmp:CreateDate="2020-01-01T00:00:00"/></rdf:RDF></x:xmpmeta>`)
+	jpeg := bytes.Join([][]byte{
+		{0xFF, 0xD8},
+		jpegSegment(0xE0, []byte("JFIF\x00\x01\x01")),
+		jpegSegment(0xE1, append(append([]byte{}, exifHeader...), le...)),
+		jpegSegment(0xE1, append(append([]byte{}, xmpHeader...), xmp...)),
+		jpegSegment(0xDB, make([]byte, 65)),
+		jpegFrame(4000, 3000),
+		jpegSegment(0xDA, []byte{1, 1, 0, 0, 0x3F, 0}),
+		{0x12, 0x34, 0xFF, 0xD9},
+	}, nil)
+
+	png := bytes.Join([][]byte{
+		[]byte("\x89PNG\r\n\x1a\n"),
+		pngChunk("IHDR", []byte{0, 0, 2, 0x80, 0, 0, 1, 0xE0, 8, 2, 0, 0, 0}),
+		pngChunk("eXIf", be),
+		pngChunk("IDAT", make([]byte, 16)),
+		pngChunk("IEND", nil),
+	}, nil)
+
+	vp8x := []byte{0x08, 0, 0, 0, 0x7F, 0x07, 0, 0x37, 0x04, 0} // 1920x1080
+	webpBody := bytes.Join([][]byte{[]byte("WEBP"), riffChunk("VP8X", vp8x), riffChunk("VP8 ", make([]byte, 11)), riffChunk("EXIF", le)}, nil)
+	webp := append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(webpBody)))...), webpBody...)
+
+	tiff := buildTIFF(binary.BigEndian,
+		[]tiffField{{tagImageWidth, []uint32{6000}}, {tagImageLength, []uint16{4000}}, {tagMake, "NIKON CORPORATION"}},
+		[]tiffField{{tagDateTimeOriginal, "2019:12:24 20:15:00"}}, nil)
+
+	withDims := func(width, height string) map[string]string {
+		want := map[string]string{ExifImageWidth: width, ExifImageHeight: height}
+		for k, v := range testExifWant {
+			want[k] = v
+		}
+		return want
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		mimeType string
+		want     map[string]string
+	}{
+		{"photo.jpg", jpeg, "image/jpeg", withDims("4000", "3000")},
+		{"photo.png", png, "image/png", withDims("640", "480")},
+		{"photo.webp", webp, MimeWebP, withDims("1920", "1080")},
+		{"photo.heic", buildHEIC(be), MimeHEIC, withDims("4032", "3024")},
+		{"scan.tif", tiff, MimeTIFF, map[string]string{
+			ExifImageWidth: "6000", ExifImageHeight: "4000", ExifMake: "NIKON CORPORATION",
+			ExifDateTimeOriginal: "2019-12-24T20:15:00",
+		}},
+		{"notes.txt", []byte("hello"), "text/plain", map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readImageMetadata(path, tt.mimeType)
+			if err != nil {
+				t.Fatalf("readImageMetadata() error = %v", err)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("readImageMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadImageMetadata_Damaged(t *testing.T) {
+	dir := t.TempDir()
+	// The GPS coordinates are cut off
+	exif := testExif(binary.LittleEndian)
+	exif = exif[:len(exif)-80]
+
+	jpeg := bytes.Join([][]byte{
+		{0xFF, 0xD8},
+		jpegFrame(800, 600),
+		jpegSegment(0xE1, append(append([]byte{}, exifHeader...), exif...)),
+	}, nil)
+	path := filepath.Join(dir, "broken.jpg")
+	os.WriteFile(path, jpeg, 0644)
+
+	got, _ := readImageMetadata(path, "image/jpeg")
+	if got[ExifImageWidth] != "800" || got[ExifMake] != "Canon" || got[ExifGPSLatitude] != "" {
+		t.Errorf("readImageMetadata() = %v, want what could be read", got)
+	}
+}
+
+func TestParseXMP(t *testing.T) {
+	data := map[string]string{ExifMake: "Canon"}
+	parseXMP([]byte(`<rdf:Description tiff:Make="Apple" tiff:Model="iPhone 15"
+		exif:DateTimeOriginal="2021-05-01T09:30:00.25+01:00">
+		<exif:GPSLatitude>48,51.4N</exif:GPSLatitude>
+		<exif:GPSLongitude>2,17,40.2E</exif:GPSLongitude>
+		<aux:Lens>iPhone 15 back camera</aux:Lens>
+	</rdf:Description>`), data)
+
+	want := map[string]string{
+		ExifMake:             "Canon",
+		ExifModel:            "iPhone 15",
+		ExifDateTimeOriginal: "2021-05-01T09:30:00+01:00",
+		ExifGPSLatitude:      "48.856667",
+		ExifGPSLongitude:     "2.294500",
+		ExifLensModel:        "iPhone 15 back camera",
+	}
+	for k, v := range want {
+		if data[k] != v {
+			t.Errorf("%s = %q, want %q", k, data[k], v)
+		}
+	}
+}
+
+func TestDetectType_Images(t *testing.T) {
+	dir := t.TempDir()
+	tiff := testExif(binary.LittleEndian)
+	files := map[string][]byte{
+		"a.tif":  tiff,
+		"a.dng":  tiff,
+		"a.webp": append([]byte("RIFF\x00\x00\x00\x00WEBP"), riffChunk("VP8X", make([]byte, 10))...),
+		"a.heic": buildHEIC(tiff),
+		"a.avif": isoBoxBytes("ftyp", []byte("avif\x00\x00\x00\x00")),
+	}
+	want := map[string]string{
+		"a.tif":  MimeTIFF,
+		"a.dng":  "image/x-adobe-dng",
+		"a.webp": MimeWebP,
+		"a.heic": MimeHEIC,
+		"a.avif": MimeAVIF,
+	}
+
+	fa := NewAnalyzer()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, data, 0644)
+		got, err := fa.DetectType(path)
+		if err != nil || got != want[name] {
+			t.Errorf("DetectType(%s) = %q, %v, want %q", name, got, err, want[name])
+		}
+	}
+}
+
+func TestAnalyze_ImageMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "IMG_0001.dng")
+	os.WriteFile(path, testExif(binary.LittleEndian), 0644)
+
+	metadata, err := NewAnalyzer().Analyze(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if metadata.ExifData[ExifModel] != "Canon EOS R5" {
+		t.Errorf("ExifData = %v, want the camera model", metadata.ExifData)
+	}
+	wantTaken := time.Date(2023, 7, 14, 18, 22, 5, 0, time.FixedZone("", 2*3600))
+	if !metadata.TakenAt.Equal(wantTaken) {
+		t.Errorf("TakenAt = %v, want %v", metadata.TakenAt, wantTaken)
+	}
+
+	fields := metadata.Fields()
+	for name, want := range map[string]string{
+		FieldCameraModel: "Canon EOS R5",
+		FieldTakenYear:   "2023",
+		FieldTakenMonth:  "07",
+		FieldTakenDay:    "14",
+		FieldGPSLat:      "51.507350",
+		FieldWidth:       "8192",
+		FieldCreatedYear: "2023",
+	} {
+		if fields[name] != want {
+			t.Errorf("Fields()[%s] = %q, want %q", name, fields[name], want)
+		}
+	}
+	if len(fields) != len(MetadataFields) {
+		t.Errorf("Fields() has %d fields, want %d", len(fields), len(MetadataFields))
+	}
+}
@@ -0,0 +1,263 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Document MIME types with built-in extractors
+const (
+	MimePDF  = "application/pdf"
+	MimeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MimeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	MimePPTX = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	MimeODT  = "application/vnd.oasis.opendocument.text"
+	MimeODS  = "application/vnd.oasis.opendocument.spreadsheet"
+	MimeODP  = "application/vnd.oasis.opendocument.presentation"
+	MimeRTF  = "application/rtf"
+	MimeEPUB = "application/epub+zip"
+	MimeHTML = "text/html"
+)
+
+// ErrNoExtractor is returned when no extractor is registered for a MIME type
+var ErrNoExtractor = errors.New("no extractor for MIME type")
+
+// errTextLimit stops an extractor once it has collected enough text
+var errTextLimit = errors.New("text limit reached")
+
+// ExtractLimits bounds the work an extractor does for a single file
+type ExtractLimits struct {
+	MaxFileSize int64         // Larger files are skipped
+	MaxChars    int           // Characters of text to extract at most
+	Timeout     time.Duration // Time allowed per file
+}
+
+// DefaultExtractLimits are the limits of the built-in extractors
+var DefaultExtractLimits = ExtractLimits{
+	MaxFileSize: 50 * 1024 * 1024,
+	MaxChars:    4000,
+	Timeout:     5 * time.Second,
+}
+
+// Extractor extracts plain text from a document
+type Extractor interface {
+	// Extract returns up to limits.MaxChars characters of the document's text
+	Extract(ctx context.Context, path string, limits ExtractLimits) (string, error)
+}
+
+// ExtractorFunc adapts a function to the Extractor interface
+type ExtractorFunc func(ctx context.Context, path string, limits ExtractLimits) (string, error)
+
+// Extract calls f
+func (f ExtractorFunc) Extract(ctx context.Context, path string, limits ExtractLimits) (string, error) {
+	return f(ctx, path, limits)
+}
+
+type registeredExtractor struct {
+	extractor Extractor
+	limits    ExtractLimits
+}
+
+// ExtractorRegistry selects content extractors by MIME type
+type ExtractorRegistry struct {
+	mu         sync.RWMutex
+	extractors map[string]registeredExtractor
+}
+
+// NewExtractorRegistry creates an empty extractor registry
+func NewExtractorRegistry() *ExtractorRegistry {
+	return &ExtractorRegistry{extractors: make(map[string]registeredExtractor)}
+}
+
+// DefaultExtractors creates a registry with the built-in extractors for PDF,
+// Office Open XML, OpenDocument, RTF, EPUB and HTML documents
+func DefaultExtractors() *ExtractorRegistry {
+	r := NewExtractorRegistry()
+	r.Register(MimePDF, ExtractorFunc(extractPDF), DefaultExtractLimits)
+	r.Register(MimeDOCX, ExtractorFunc(extractDOCX), DefaultExtractLimits)
+	r.Register(MimeXLSX, ExtractorFunc(extractXLSX), DefaultExtractLimits)
+	r.Register(MimePPTX, ExtractorFunc(extractPPTX), DefaultExtractLimits)
+	r.Register(MimeODT, ExtractorFunc(extractODF), DefaultExtractLimits)
+	r.Register(MimeODS, ExtractorFunc(extractODF), DefaultExtractLimits)
+	r.Register(MimeODP, ExtractorFunc(extractODF), DefaultExtractLimits)
+	r.Register(MimeRTF, ExtractorFunc(extractRTF), DefaultExtractLimits)
+	r.Register(MimeEPUB, ExtractorFunc(extractEPUB), DefaultExtractLimits)
+	r.Register(MimeHTML, ExtractorFunc(extractHTML), DefaultExtractLimits)
+	return r
+}
+
+// Register sets the extractor and its limits for a MIME type, replacing any
+// extractor registered before
+func (r *ExtractorRegistry) Register(mimeType string, extractor Extractor, limits ExtractLimits) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.extractors[baseMimeType(mimeType)] = registeredExtractor{extractor: extractor, limits: limits}
+}
+
+// Unregister removes the extractor of a MIME type
+func (r *ExtractorRegistry) Unregister(mimeType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.extractors, baseMimeType(mimeType))
+}
+
+// Supports reports whether an extractor is registered for a MIME type
+func (r *ExtractorRegistry) Supports(mimeType string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.extractors[baseMimeType(mimeType)]
+	return ok
+}
+
+// Extract extracts the text of the file at path with the extractor registered
+// for mimeType, enforcing its size and time limits
+func (r *ExtractorRegistry) Extract(ctx context.Context, path, mimeType string) (string, error) {
+	r.mu.RLock()
+	registered, ok := r.extractors[baseMimeType(mimeType)]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w %s", ErrNoExtractor, mimeType)
+	}
+	limits := registered.limits
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	if limits.MaxFileSize > 0 && info.Size() > limits.MaxFileSize {
+		return "", fmt.Errorf("file too large for extraction: %d bytes (limit %d)", info.Size(), limits.MaxFileSize)
+	}
+
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+
+	// Extractors check ctx between steps, but a single step may still block,
+	// so the result is abandoned once the deadline passes
+	type result struct {
+		text string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		text, err := registered.extractor.Extract(ctx, path, limits)
+		done <- result{text, err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return "", fmt.Errorf("failed to extract text: %w", res.err)
+		}
+		return res.text, nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("failed to extract text: %w", ctx.Err())
+	}
+}
+
+// baseMimeType strips parameters such as "; charset=utf-8" from a MIME type
+func baseMimeType(mimeType string) string {
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// textBuilder collects extracted text up to a number of characters,
+// collapsing runs of blank space
+type textBuilder struct {
+	b        strings.Builder
+	max      int
+	chars    int
+	pendingS bool // A space is due before the next text
+	pendingN bool // A line break is due before the next text
+}
+
+func newTextBuilder(maxChars int) *textBuilder {
+	return &textBuilder{max: maxChars}
+}
+
+// WriteText appends text. It returns errTextLimit once the limit is reached.
+func (t *textBuilder) WriteText(s string) error {
+	for _, r := range s {
+		if t.full() {
+			return errTextLimit
+		}
+		switch r {
+		case '\n', '\r', '\f', '\v':
+			t.Break()
+		case ' ', '\t', '\u00a0':
+			t.Space()
+		default:
+			if r < 0x20 || r == utf8.RuneError {
+				continue
+			}
+			if t.chars > 0 {
+				switch {
+				case t.pendingN:
+					t.b.WriteByte('\n')
+					t.chars++
+				case t.pendingS:
+					t.b.WriteByte(' ')
+					t.chars++
+				}
+			}
+			t.pendingN, t.pendingS = false, false
+			t.b.WriteRune(r)
+			t.chars++
+		}
+	}
+	if t.full() {
+		return errTextLimit
+	}
+	return nil
+}
+
+// Space separates the next text with a space
+func (t *textBuilder) Space() {
+	t.pendingS = true
+}
+
+// Break separates the next text with a line break
+func (t *textBuilder) Break() {
+	t.pendingN = true
+}
+
+func (t *textBuilder) full() bool {
+	return t.max > 0 && t.chars >= t.max
+}
+
+func (t *textBuilder) String() string {
+	return t.b.String()
+}
+
+// finishText returns the collected text, treating a reached limit as success
+func finishText(t *textBuilder, err error) (string, error) {
+	if err != nil && !errors.Is(err, errTextLimit) {
+		return "", err
+	}
+	return t.String(), nil
+}
+
+// cp1252High maps the bytes 0x80-0x9F of Windows-1252 to runes; the other
+// bytes of the code page equal their Latin-1 code points
+var cp1252High = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// decodeCP1252 decodes a Windows-1252 byte
+func decodeCP1252(b byte) rune {
+	if b >= 0x80 && b < 0xA0 {
+		return cp1252High[b-0x80]
+	}
+	return rune(b)
+}
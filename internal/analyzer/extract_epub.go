@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+)
+
+// epubContainer is META-INF/container.xml, which points to the package document
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackage is the part of the package document listing the content files
+type epubPackage struct {
+	Title    string `xml:"metadata>title"`
+	Manifest []struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// extractEPUB extracts the title and the text of an EPUB book in reading order
+func extractEPUB(ctx context.Context, filePath string, limits ExtractLimits) (string, error) {
+	zr, err := openZip(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer zr.Close()
+
+	var container epubContainer
+	if err := decodeZipXML(zr.Reader, "META-INF/container.xml", limits, &container); err != nil {
+		return "", err
+	}
+	if len(container.Rootfiles) == 0 {
+		return "", fmt.Errorf("EPUB has no package document")
+	}
+	opfPath := container.Rootfiles[0].FullPath
+
+	var pkg epubPackage
+	if err := decodeZipXML(zr.Reader, opfPath, limits, &pkg); err != nil {
+		return "", err
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		hrefs[item.ID] = item.Href
+	}
+
+	t := newTextBuilder(limits.MaxChars)
+	if err := t.WriteText(pkg.Title); err != nil {
+		return finishText(t, err)
+	}
+	t.Break()
+
+	for _, ref := range pkg.Spine {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+
+		rc, err := openZipEntry(zr.Reader, path.Join(zipEntryDir(opfPath), href), limits)
+		if err != nil {
+			continue // Books with missing chapters still have text
+		}
+		err = writeHTMLText(ctx, rc, t)
+		rc.Close()
+		if err != nil {
+			return finishText(t, err)
+		}
+		t.Break()
+	}
+	return t.String(), nil
+}
+
+// decodeZipXML unmarshals an XML entry of an archive into v
+func decodeZipXML(zr *zip.Reader, name string, limits ExtractLimits, v any) error {
+	rc, err := openZipEntry(zr, name, limits)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	d := xml.NewDecoder(rc)
+	d.Strict = false
+	if err := d.Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+var (
+	// htmlSkipElements hold no readable text
+	htmlSkipElements = map[string]bool{
+		"script": true, "style": true, "noscript": true, "template": true, "svg": true,
+	}
+	// htmlBlockElements start on a new line
+	htmlBlockElements = map[string]bool{
+		"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+		"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true, "footer": true,
+		"form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"header": true, "hr": true, "li": true, "main": true, "nav": true, "ol": true,
+		"p": true, "pre": true, "section": true, "table": true, "title": true, "tr": true, "ul": true,
+	}
+	// htmlCellElements are separated by a space
+	htmlCellElements = map[string]bool{"td": true, "th": true}
+)
+
+// extractHTML extracts the visible text of an HTML document
+func extractHTML(ctx context.Context, filePath string, limits ExtractLimits) (string, error) {
+	f, err := openForRead(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to read HTML: %w", err)
+	}
+	// Pages saved in legacy encodings such as GBK are decoded first
+	if encoding, ok := DetectEncoding(data[:min(len(data), encodingSampleSize)]); ok && encoding != EncodingUTF8 {
+		data = []byte(DecodeText(data, encoding))
+	}
+
+	t := newTextBuilder(limits.MaxChars)
+	return finishText(t, writeHTMLText(ctx, bytes.NewReader(data), t))
+}
+
+// writeHTMLText writes the visible text of an HTML document to t. It is a
+// lenient tokenizer: tags are dropped, entities decoded and block elements
+// put on their own lines.
+func writeHTMLText(ctx context.Context, r io.Reader, t *textBuilder) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read HTML: %w", err)
+	}
+
+	skip := "" // Element whose content is being skipped
+	for i, steps := 0, 0; i < len(data); steps++ {
+		if steps%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		if data[i] != '<' {
+			end := bytes.IndexByte(data[i:], '<')
+			if end < 0 {
+				end = len(data) - i
+			}
+			if skip == "" {
+				if err := t.WriteText(html.UnescapeString(string(data[i : i+end]))); err != nil {
+					return err
+				}
+			}
+			i += end
+			continue
+		}
+
+		// Comments, CDATA and declarations
+		if bytes.HasPrefix(data[i:], []byte("<!--")) {
+			end := bytes.Index(data[i+4:], []byte("-->"))
+			if end < 0 {
+				return nil
+			}
+			i += 4 + end + 3
+			continue
+		}
+
+		end := bytes.IndexByte(data[i:], '>')
+		if end < 0 {
+			return nil
+		}
+		name, closing := htmlTagName(data[i+1 : i+end])
+		selfClosing := bytes.HasSuffix(data[i+1:i+end], []byte("/"))
+		i += end + 1
+
+		if skip != "" {
+			if closing && name == skip {
+				skip = ""
+			}
+			continue
+		}
+		switch {
+		case htmlSkipElements[name] && !closing && !selfClosing:
+			skip = name
+		case htmlBlockElements[name]:
+			t.Break()
+		case htmlCellElements[name]:
+			t.Space()
+		}
+	}
+	return nil
+}
+
+// htmlTagName returns the lower-case element name of a tag's content and
+// whether it is a closing tag
+func htmlTagName(tag []byte) (string, bool) {
+	closing := len(tag) > 0 && tag[0] == '/'
+	if closing {
+		tag = tag[1:]
+	}
+	end := bytes.IndexAny(tag, " \t\r\n/>")
+	if end >= 0 {
+		tag = tag[:end]
+	}
+	return strings.ToLower(string(tag)), closing
+}
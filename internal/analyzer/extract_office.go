@@ -0,0 +1,209 @@
+package analyzer
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// maxEntryRatio bounds how much a single archive entry may decompress to,
+// relative to the file size limit, to stop decompression bombs
+const maxEntryRatio = 10
+
+// xmlTextRules describe where the text of an XML document is
+type xmlTextRules struct {
+	text   map[string]bool // Character data counts only inside these elements (nil: everywhere)
+	skip   map[string]bool // Content of these elements is ignored
+	breaks map[string]bool // A line break follows these elements
+	spaces map[string]bool // These elements stand for blank space
+}
+
+var (
+	// WordprocessingML: text runs, paragraphs, breaks and tabs
+	docxRules = xmlTextRules{
+		text:   map[string]bool{"t": true},
+		breaks: map[string]bool{"p": true, "br": true, "cr": true},
+		spaces: map[string]bool{"tab": true},
+	}
+	// Shared strings of SpreadsheetML: one string item per cell value
+	xlsxRules = xmlTextRules{
+		text:   map[string]bool{"t": true},
+		breaks: map[string]bool{"si": true},
+	}
+	// DrawingML text in PresentationML slides
+	pptxRules = xmlTextRules{
+		text:   map[string]bool{"t": true},
+		breaks: map[string]bool{"p": true, "br": true},
+	}
+	// OpenDocument content: paragraphs, headings and spacing elements
+	odfRules = xmlTextRules{
+		skip:   map[string]bool{"automatic-styles": true, "font-face-decls": true, "scripts": true},
+		breaks: map[string]bool{"p": true, "h": true, "line-break": true, "list-item": true},
+		spaces: map[string]bool{"s": true, "tab": true, "table-cell": true},
+	}
+
+	slideName = regexp.MustCompile(`^ppt/slides/slide(\d+)\.xml$`)
+)
+
+// extractDOCX extracts the body text of a Word document
+func extractDOCX(ctx context.Context, filePath string, limits ExtractLimits) (string, error) {
+	return extractZipXML(ctx, filePath, limits, []string{"word/document.xml"}, docxRules)
+}
+
+// extractXLSX extracts the cell strings of an Excel workbook
+func extractXLSX(ctx context.Context, filePath string, limits ExtractLimits) (string, error) {
+	return extractZipXML(ctx, filePath, limits, []string{"xl/sharedStrings.xml"}, xlsxRules)
+}
+
+// extractPPTX extracts the text of a PowerPoint presentation, slide by slide
+func extractPPTX(ctx context.Context, filePath string, limits ExtractLimits) (string, error) {
+	zr, err := openZip(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer zr.Close()
+
+	type slide struct {
+		number int
+		name   string
+	}
+	var slides []slide
+	for _, f := range zr.File {
+		if m := slideName.FindStringSubmatch(f.Name); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			slides = append(slides, slide{n, f.Name})
+		}
+	}
+	sort.Slice(slides, func(i, j int) bool { return slides[i].number < slides[j].number })
+
+	names := make([]string, len(slides))
+	for i, s := range slides {
+		names[i] = s.name
+	}
+	return extractZipXML(ctx, filePath, limits, names, pptxRules)
+}
+
+// extractODF extracts the text of an OpenDocument text, spreadsheet or presentation
+func extractODF(ctx context.Context, filePath string, limits ExtractLimits) (string, error) {
+	return extractZipXML(ctx, filePath, limits, []string{"content.xml"}, odfRules)
+}
+
+// extractZipXML extracts the text of XML entries of a ZIP-based document in order
+func extractZipXML(ctx context.Context, filePath string, limits ExtractLimits, entries []string, rules xmlTextRules) (string, error) {
+	zr, err := openZip(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer zr.Close()
+
+	t := newTextBuilder(limits.MaxChars)
+	for _, name := range entries {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		rc, err := openZipEntry(zr.Reader, name, limits)
+		if err != nil {
+			return "", err
+		}
+		err = writeXMLText(ctx, rc, t, rules)
+		rc.Close()
+		if err != nil {
+			return finishText(t, err)
+		}
+		t.Break()
+	}
+	return t.String(), nil
+}
+
+// openZipEntry opens an archive entry, limiting how much it may decompress to
+func openZipEntry(zr *zip.Reader, name string, limits ExtractLimits) (io.ReadCloser, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		if limits.MaxFileSize <= 0 {
+			return rc, nil
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(rc, limits.MaxFileSize*maxEntryRatio), rc}, nil
+	}
+	return nil, fmt.Errorf("archive has no %s", name)
+}
+
+// writeXMLText writes the text of an XML document to t following rules
+func writeXMLText(ctx context.Context, r io.Reader, t *textBuilder, rules xmlTextRules) error {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	inText, skipping := 0, 0
+	for tokens := 0; ; tokens++ {
+		if tokens%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse XML: %w", err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			name := tok.Name.Local
+			switch {
+			case skipping > 0 || rules.skip[name]:
+				skipping++
+			case rules.text[name]:
+				inText++
+			case rules.spaces[name]:
+				t.Space()
+			}
+		case xml.EndElement:
+			name := tok.Name.Local
+			switch {
+			case skipping > 0:
+				skipping--
+			case rules.text[name]:
+				inText--
+			case rules.breaks[name]:
+				t.Break()
+			}
+		case xml.CharData:
+			if skipping == 0 && (rules.text == nil || inText > 0) {
+				if err := t.WriteText(string(tok)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// zipEntryDir returns the directory of an archive entry, for resolving
+// relative references
+func zipEntryDir(name string) string {
+	dir := path.Dir(name)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,659 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+)
+
+var (
+	pdfObjectStart = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	pdfStreamStart = regexp.MustCompile(`>>\s*stream(\r\n|\n|\r)`)
+	pdfFilter      = regexp.MustCompile(`/Filter\s*(/\w+|\[[^\]]*\])`)
+	pdfToUnicode   = regexp.MustCompile(`/ToUnicode\s+(\d+)\s+\d+\s+R`)
+	pdfFontDict    = regexp.MustCompile(`/Font\s*<<([^>]*)>>`)
+	pdfFontRef     = regexp.MustCompile(`/Font\s+(\d+)\s+\d+\s+R`)
+	pdfNamedRef    = regexp.MustCompile(`/([^\s/<>\[\]()]+)\s+(\d+)\s+\d+\s+R`)
+	pdfObjStmN     = regexp.MustCompile(`/N\s+(\d+)`)
+	pdfObjStmFirst = regexp.MustCompile(`/First\s+(\d+)`)
+	pdfSkipStream  = regexp.MustCompile(`/Subtype\s*/(Image|Type1C|CIDFontType0C|OpenType|XML)|/Length[123]\b|/Type\s*/(XRef|Metadata|EmbeddedFile)`)
+)
+
+// pdfObject is an indirect object of a PDF file
+type pdfObject struct {
+	dict   []byte // Object body up to its stream, if any
+	stream []byte // Raw stream data (nil without a stream)
+}
+
+// pdfCMap maps character codes of a font to Unicode text
+type pdfCMap struct {
+	codeLen int // Bytes per character code
+	chars   map[uint32]string
+}
+
+// extractPDF extracts the text of a PDF document. It decodes uncompressed
+// and Flate-compressed content streams, resolves fonts' ToUnicode maps and
+// reads text in file order. Encrypted documents and text drawn as images
+// yield no text.
+func extractPDF(ctx context.Context, filePath string, limits ExtractLimits) (string, error) {
+	data, err := readFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+
+	maxStream := limits.MaxFileSize * maxEntryRatio
+	objects, order := parsePDFObjects(data, maxStream)
+
+	// Font resource names to the character maps of their fonts. Resource
+	// names are looked up document-wide, which holds for most producers.
+	fonts := make(map[string]*pdfCMap)
+	cmaps := make(map[int]*pdfCMap)
+	cmapFor := func(num int) *pdfCMap {
+		if cmap, ok := cmaps[num]; ok {
+			return cmap
+		}
+		var cmap *pdfCMap
+		if font, ok := objects[num]; ok {
+			if m := pdfToUnicode.FindSubmatch(font.dict); m != nil {
+				ref, _ := strconv.Atoi(string(m[1]))
+				if obj, ok := objects[ref]; ok {
+					if decoded := decodePDFStream(obj, maxStream); decoded != nil {
+						cmap = parsePDFCMap(decoded)
+					}
+				}
+			}
+		}
+		cmaps[num] = cmap
+		return cmap
+	}
+	addFonts := func(dict []byte) {
+		for _, m := range pdfNamedRef.FindAllSubmatch(dict, -1) {
+			num, _ := strconv.Atoi(string(m[2]))
+			if cmap := cmapFor(num); cmap != nil {
+				fonts[string(m[1])] = cmap
+			}
+		}
+	}
+	for _, num := range order {
+		dict := objects[num].dict
+		for _, m := range pdfFontDict.FindAllSubmatch(dict, -1) {
+			addFonts(m[1])
+		}
+		for _, m := range pdfFontRef.FindAllSubmatch(dict, -1) {
+			ref, _ := strconv.Atoi(string(m[1]))
+			if obj, ok := objects[ref]; ok {
+				addFonts(obj.dict)
+			}
+		}
+	}
+
+	t := newTextBuilder(limits.MaxChars)
+	for _, num := range order {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		obj := objects[num]
+		if obj.stream == nil || pdfSkipStream.Match(obj.dict) {
+			continue
+		}
+		content := decodePDFStream(obj, maxStream)
+		if content == nil || !bytes.Contains(content, []byte("BT")) {
+			continue
+		}
+		if err := writePDFContentText(ctx, content, fonts, t); err != nil {
+			return finishText(t, err)
+		}
+		t.Break()
+	}
+	return t.String(), nil
+}
+
+// parsePDFObjects indexes the indirect objects of a PDF file, including
+// those stored in object streams, in file order
+func parsePDFObjects(data []byte, maxStream int64) (map[int]*pdfObject, []int) {
+	objects := make(map[int]*pdfObject)
+	var order []int
+
+	matches := pdfObjectStart.FindAllSubmatchIndex(data, -1)
+	for i, m := range matches {
+		num, err := strconv.Atoi(string(data[m[2]:m[3]]))
+		if err != nil {
+			continue
+		}
+		end := len(data)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		body := data[m[1]:end]
+
+		obj := &pdfObject{dict: body}
+		if s := pdfStreamStart.FindIndex(body); s != nil {
+			obj.dict = body[:s[0]+2]
+			stream := body[s[1]:]
+			if e := bytes.LastIndex(stream, []byte("endstream")); e >= 0 {
+				stream = bytes.TrimRight(stream[:e], "\r\n")
+			}
+			obj.stream = stream
+		}
+		if _, seen := objects[num]; !seen {
+			order = append(order, num)
+		}
+		objects[num] = obj // Later revisions replace earlier ones
+	}
+
+	// Objects of PDF 1.5 object streams
+	for _, num := range order {
+		obj := objects[num]
+		if obj.stream == nil || !bytes.Contains(obj.dict, []byte("/ObjStm")) {
+			continue
+		}
+		n, first := pdfInt(pdfObjStmN, obj.dict), pdfInt(pdfObjStmFirst, obj.dict)
+		decoded := decodePDFStream(obj, maxStream)
+		if decoded == nil || first <= 0 || first > len(decoded) {
+			continue
+		}
+
+		header := bytes.Fields(decoded[:first])
+		for i := 0; i+1 < len(header) && i/2 < n; i += 2 {
+			child, err1 := strconv.Atoi(string(header[i]))
+			offset, err2 := strconv.Atoi(string(header[i+1]))
+			if err1 != nil || err2 != nil || first+offset > len(decoded) {
+				continue
+			}
+			end := len(decoded)
+			if i+3 < len(header) {
+				if next, err := strconv.Atoi(string(header[i+3])); err == nil && first+next <= end && next >= offset {
+					end = first + next
+				}
+			}
+			if _, seen := objects[child]; !seen {
+				objects[child] = &pdfObject{dict: decoded[first+offset : end]}
+				order = append(order, child)
+			}
+		}
+	}
+
+	return objects, order
+}
+
+// pdfInt returns the integer captured by re in dict, or 0
+func pdfInt(re *regexp.Regexp, dict []byte) int {
+	if m := re.FindSubmatch(dict); m != nil {
+		n, _ := strconv.Atoi(string(m[1]))
+		return n
+	}
+	return 0
+}
+
+// decodePDFStream returns the decoded data of a stream, or nil if it uses a
+// filter other than FlateDecode
+func decodePDFStream(obj *pdfObject, maxSize int64) []byte {
+	m := pdfFilter.FindSubmatch(obj.dict)
+	if m == nil {
+		return obj.stream
+	}
+
+	filters := bytes.Fields(bytes.Trim(m[1], "[]"))
+	if len(filters) != 1 || (string(filters[0]) != "/FlateDecode" && string(filters[0]) != "/Fl") {
+		return nil
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(obj.stream))
+	if err != nil {
+		return nil
+	}
+	defer zr.Close()
+
+	var r io.Reader = zr
+	if maxSize > 0 {
+		r = io.LimitReader(zr, maxSize)
+	}
+	// Truncated streams still hold useful text
+	decoded, _ := io.ReadAll(r)
+	if len(decoded) == 0 {
+		return nil
+	}
+	return decoded
+}
+
+// parsePDFCMap parses the bfchar and bfrange mappings of a ToUnicode CMap
+func parsePDFCMap(data []byte) *pdfCMap {
+	cmap := &pdfCMap{codeLen: 1, chars: make(map[uint32]string)}
+	lex := &pdfLexer{data: data}
+
+	var operands []pdfToken
+	mode := ""
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		if tok.kind != pdfOperator {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch tok.text {
+		case "begincodespacerange", "beginbfchar", "beginbfrange":
+			mode = tok.text
+			operands = operands[:0]
+		case "endcodespacerange":
+			if len(operands) > 0 && len(operands[0].str) > 0 {
+				cmap.codeLen = len(operands[0].str)
+			}
+			mode = ""
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				cmap.chars[pdfCode(operands[i].str)] = decodeUTF16BE(operands[i+1].str)
+			}
+			mode = ""
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, hi := pdfCode(operands[i].str), pdfCode(operands[i+1].str)
+				if hi < lo || hi-lo > 0xFFFF {
+					continue
+				}
+				dst := operands[i+2]
+				if dst.kind == pdfArray {
+					for j, s := range dst.items {
+						if uint32(j) > hi-lo {
+							break
+						}
+						cmap.chars[lo+uint32(j)] = decodeUTF16BE(s)
+					}
+					continue
+				}
+				base := []rune(decodeUTF16BE(dst.str))
+				if len(base) == 0 {
+					continue
+				}
+				for code := lo; code <= hi; code++ {
+					chars := append([]rune{}, base...)
+					chars[len(chars)-1] += rune(code - lo)
+					cmap.chars[code] = string(chars)
+				}
+			}
+			mode = ""
+		default:
+			if mode == "" {
+				operands = operands[:0]
+			}
+		}
+	}
+
+	if len(cmap.chars) == 0 {
+		return nil
+	}
+	return cmap
+}
+
+// decode maps the character codes of a string to text
+func (c *pdfCMap) decode(s []byte) string {
+	var out []rune
+	for i := 0; i+c.codeLen <= len(s); i += c.codeLen {
+		if text, ok := c.chars[pdfCode(s[i:i+c.codeLen])]; ok {
+			out = append(out, []rune(text)...)
+		}
+	}
+	return string(out)
+}
+
+// pdfCode returns the big-endian character code of s
+func pdfCode(s []byte) uint32 {
+	var code uint32
+	for _, b := range s {
+		code = code<<8 | uint32(b)
+	}
+	return code
+}
+
+// decodeUTF16BE decodes UTF-16BE text
+func decodeUTF16BE(s []byte) string {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodePDFString decodes a string shown without a ToUnicode map: UTF-16
+// with a byte order mark, otherwise single bytes as Windows-1252
+func decodePDFString(s []byte) string {
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		return decodeUTF16BE(s[2:])
+	}
+	out := make([]rune, len(s))
+	for i, b := range s {
+		out[i] = decodeCP1252(b)
+	}
+	return string(out)
+}
+
+// writePDFContentText writes the text shown by a content stream to t
+func writePDFContentText(ctx context.Context, content []byte, fonts map[string]*pdfCMap, t *textBuilder) error {
+	lex := &pdfLexer{data: content}
+
+	var operands []pdfToken
+	var font *pdfCMap
+	lastY, haveY := 0.0, false
+
+	show := func(s []byte) error {
+		if font != nil {
+			return t.WriteText(font.decode(s))
+		}
+		return t.WriteText(decodePDFString(s))
+	}
+
+	for steps := 0; ; steps++ {
+		if steps%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		tok, ok := lex.next()
+		if !ok {
+			return nil
+		}
+		if tok.kind != pdfOperator {
+			operands = append(operands, tok)
+			continue
+		}
+
+		var err error
+		switch tok.text {
+		case "Tf":
+			if len(operands) >= 2 {
+				font = fonts[operands[len(operands)-2].text]
+			}
+		case "Tj":
+			if len(operands) >= 1 {
+				err = show(operands[len(operands)-1].str)
+			}
+		case "'", "\"":
+			t.Break()
+			if len(operands) >= 1 {
+				err = show(operands[len(operands)-1].str)
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				for _, item := range operands[len(operands)-1].array {
+					if item.kind == pdfNumber {
+						// Large negative adjustments separate words
+						if item.num < -200 {
+							t.Space()
+						}
+						continue
+					}
+					if err = show(item.str); err != nil {
+						break
+					}
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 && operands[len(operands)-1].num != 0 {
+				t.Break()
+			} else {
+				t.Space()
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				y := operands[len(operands)-1].num
+				if !haveY || y != lastY {
+					t.Break()
+				} else {
+					t.Space()
+				}
+				lastY, haveY = y, true
+			}
+		case "T*":
+			t.Break()
+		case "ET":
+			t.Space()
+		case "BI":
+			lex.skipInlineImage()
+		}
+		if err != nil {
+			return err
+		}
+		operands = operands[:0]
+	}
+}
+
+// pdfTokenKind classifies the tokens of PDF content
+type pdfTokenKind int
+
+const (
+	pdfOperator pdfTokenKind = iota
+	pdfNumber
+	pdfString
+	pdfName
+	pdfArray
+	pdfOther
+)
+
+// pdfToken is a lexical token of PDF content. Arrays carry their elements.
+type pdfToken struct {
+	kind  pdfTokenKind
+	text  string     // Operator or name (without the slash)
+	num   float64    // Number value
+	str   []byte     // String bytes
+	array []pdfToken // Array elements
+	items [][]byte   // Strings of an array, for CMaps
+}
+
+// pdfLexer splits PDF content into tokens
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next token, or false at the end of the data
+func (l *pdfLexer) next() (pdfToken, bool) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return pdfToken{}, false
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		return pdfToken{kind: pdfString, str: l.literalString()}, true
+	case c == '<' && l.peek(1) == '<', c == '>' && l.peek(1) == '>':
+		l.pos += 2
+		return pdfToken{kind: pdfOther}, true
+	case c == '<':
+		return pdfToken{kind: pdfString, str: l.hexString()}, true
+	case c == '[':
+		l.pos++
+		tok := pdfToken{kind: pdfArray}
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return tok, true
+			}
+			if l.data[l.pos] == ']' {
+				l.pos++
+				return tok, true
+			}
+			item, ok := l.next()
+			if !ok {
+				return tok, true
+			}
+			tok.array = append(tok.array, item)
+			if item.kind == pdfString {
+				tok.items = append(tok.items, item.str)
+			}
+		}
+	case c == ']' || c == '{' || c == '}' || c == ')' || c == '>':
+		l.pos++
+		return pdfToken{kind: pdfOther}, true
+	case c == '/':
+		l.pos++
+		return pdfToken{kind: pdfName, text: string(l.regular())}, true
+	}
+
+	word := l.regular()
+	if len(word) == 0 {
+		l.pos++ // Stray delimiter
+		return pdfToken{kind: pdfOther}, true
+	}
+	if num, err := strconv.ParseFloat(string(word), 64); err == nil {
+		return pdfToken{kind: pdfNumber, num: num}, true
+	}
+	return pdfToken{kind: pdfOperator, text: string(word)}, true
+}
+
+func (l *pdfLexer) peek(offset int) byte {
+	if l.pos+offset < len(l.data) {
+		return l.data[l.pos+offset]
+	}
+	return 0
+}
+
+// skipSpace skips white space and comments
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		switch c := l.data[l.pos]; {
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case isPDFSpace(c):
+			l.pos++
+		default:
+			return
+		}
+	}
+}
+
+// regular reads a run of regular characters
+func (l *pdfLexer) regular() []byte {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return l.data[start:l.pos]
+}
+
+// literalString reads a (string) with nested parentheses and escapes
+func (l *pdfLexer) literalString() []byte {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+			out = append(out, c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for n := 1; n < 3 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; n++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// hexString reads a <hex string>
+func (l *pdfLexer) hexString() []byte {
+	l.pos++ // <
+	var out []byte
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if v, ok := hexValue(l.data[l.pos]); ok {
+			digits = append(digits, v)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, 0)
+	}
+	for i := 0; i < len(digits); i += 2 {
+		out = append(out, digits[i]<<4|digits[i+1])
+	}
+	return out
+}
+
+// skipInlineImage skips the data of an inline image up to its EI operator
+func (l *pdfLexer) skipInlineImage() {
+	if end := bytes.Index(l.data[l.pos:], []byte("EI")); end >= 0 {
+		l.pos += end + 2
+	} else {
+		l.pos = len(l.data)
+	}
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
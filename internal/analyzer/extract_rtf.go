@@ -0,0 +1,192 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// rtfSkipDestinations are groups that hold no document text
+var rtfSkipDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true, "pict": true,
+	"object": true, "header": true, "headerl": true, "headerr": true, "headerf": true,
+	"footer": true, "footerl": true, "footerr": true, "footerf": true, "listtable": true,
+	"listoverridetable": true, "rsidtbl": true, "generator": true, "themedata": true,
+	"colorschememapping": true, "datastore": true, "latentstyles": true, "xmlnstbl": true,
+	"filetbl": true, "revtbl": true, "fldinst": true,
+}
+
+// rtfSymbols are control words that stand for a character
+var rtfSymbols = map[string]string{
+	"emdash": "—", "endash": "–", "bullet": "•", "lquote": "‘", "rquote": "’",
+	"ldblquote": "“", "rdblquote": "”", "emspace": " ", "enspace": " ",
+}
+
+// rtfGroup is the state of an RTF group
+type rtfGroup struct {
+	skip bool // Text of the group is ignored
+	uc   int  // Fallback characters that follow a \u character
+}
+
+// extractRTF extracts the text of an RTF document
+func extractRTF(ctx context.Context, filePath string, limits ExtractLimits) (string, error) {
+	data, err := readFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	t := newTextBuilder(limits.MaxChars)
+	return finishText(t, writeRTFText(ctx, data, t))
+}
+
+// writeRTFText writes the text of an RTF document to t. \'hh bytes are
+// decoded as Windows-1252 and \uN as Unicode.
+func writeRTFText(ctx context.Context, data []byte, t *textBuilder) error {
+	group := rtfGroup{uc: 1}
+	var stack []rtfGroup
+	skipFallback := 0 // Fallback characters still to drop after \uN
+
+	write := func(s string) error {
+		if group.skip {
+			return nil
+		}
+		if skipFallback > 0 {
+			skipFallback--
+			return nil
+		}
+		return t.WriteText(s)
+	}
+
+	for i, steps := 0, 0; i < len(data); steps++ {
+		if steps%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		c := data[i]
+		switch c {
+		case '{':
+			stack = append(stack, group)
+			skipFallback = 0
+			i++
+			// An ignorable destination: {\* \name ...}
+			if i+1 < len(data) && data[i] == '\\' && data[i+1] == '*' {
+				group.skip = true
+				i += 2
+			}
+		case '}':
+			if len(stack) > 0 {
+				group = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+			skipFallback = 0
+			i++
+		case '\r', '\n':
+			i++
+		case '\\':
+			if i+1 >= len(data) {
+				return nil
+			}
+			next := data[i+1]
+			switch {
+			case isASCIILetter(next):
+				j := i + 1
+				for j < len(data) && isASCIILetter(data[j]) {
+					j++
+				}
+				word := string(data[i+1 : j])
+				k := j
+				if k < len(data) && (data[k] == '-' || isASCIIDigit(data[k])) {
+					k++
+					for k < len(data) && isASCIIDigit(data[k]) {
+						k++
+					}
+				}
+				param, hasParam := 0, k > j
+				if hasParam {
+					param, _ = strconv.Atoi(string(data[j:k]))
+				}
+				if k < len(data) && data[k] == ' ' {
+					k++ // The delimiting space belongs to the control word
+				}
+				i = k
+
+				switch {
+				case rtfSkipDestinations[word]:
+					group.skip = true
+				case word == "par" || word == "line" || word == "sect" || word == "page" || word == "row":
+					if !group.skip {
+						t.Break()
+					}
+				case word == "tab" || word == "cell":
+					if !group.skip {
+						t.Space()
+					}
+				case word == "uc" && hasParam:
+					group.uc = param
+				case word == "u" && hasParam:
+					if param < 0 {
+						param += 65536
+					}
+					if err := write(string(rune(param))); err != nil {
+						return err
+					}
+					skipFallback = group.uc
+				case rtfSymbols[word] != "":
+					if err := write(rtfSymbols[word]); err != nil {
+						return err
+					}
+				}
+			case next == '\'':
+				if i+3 >= len(data) {
+					return nil
+				}
+				b, err := strconv.ParseUint(string(data[i+2:i+4]), 16, 8)
+				i += 4
+				if err != nil {
+					continue
+				}
+				if err := write(string(decodeCP1252(byte(b)))); err != nil {
+					return err
+				}
+			case next == '\r' || next == '\n':
+				if !group.skip {
+					t.Break()
+				}
+				i += 2
+			case next == '~':
+				if err := write(" "); err != nil {
+					return err
+				}
+				i += 2
+			case next == '_':
+				if err := write("-"); err != nil {
+					return err
+				}
+				i += 2
+			case next == '\\' || next == '{' || next == '}':
+				if err := write(string(next)); err != nil {
+					return err
+				}
+				i += 2
+			default:
+				i += 2 // Other control symbols, e.g. \- (optional hyphen)
+			}
+		default:
+			if err := write(string(decodeCP1252(c))); err != nil {
+				return err
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+func isASCIILetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func isASCIIDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,307 @@
+package analyzer
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeZip creates an archive with the given entries, in order
+func writeZip(t *testing.T, path string, entries [][2]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		method := zip.Deflate
+		if e[0] == "mimetype" {
+			method = zip.Store
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e[0], Method: method})
+		if err != nil {
+			t.Fatalf("failed to create entry: %v", err)
+		}
+		if _, err := w.Write([]byte(e[1])); err != nil {
+			t.Fatalf("failed to write entry: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+}
+
+// writePDF creates a one-page PDF whose content stream is Flate-compressed
+// and whose font F2 has a ToUnicode map
+func writePDF(t *testing.T, path, content string) {
+	t.Helper()
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write([]byte(content))
+	zw.Close()
+
+	cmap := "/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n" +
+		"1 beginbfchar\n<0001> <4E2D>\nendbfchar\n" +
+		"1 beginbfrange\n<0002> <0003> <5B57>\nendbfrange\n" +
+		"endcmap\nend\nend\n"
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	b.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	b.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n")
+	b.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>\nendobj\n")
+	b.WriteString("4 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>\nendobj\n")
+	b.WriteString("5 0 obj\n<< /Type /Font /Subtype /Type0 /BaseFont /Song /ToUnicode 7 0 R >>\nendobj\n")
+	fmt.Fprintf(&b, "6 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	b.Write(compressed.Bytes())
+	b.WriteString("\nendstream\nendobj\n")
+	fmt.Fprintf(&b, "7 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(cmap), cmap)
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write PDF: %v", err)
+	}
+}
+
+func TestExtractors(t *testing.T) {
+	dir := t.TempDir()
+
+	docx := filepath.Join(dir, "report.docx")
+	writeZip(t, docx, [][2]string{
+		{"[Content_Types].xml", `<Types/>`},
+		{"word/document.xml", `<w:document xmlns:w="w"><w:body>` +
+			`<w:p><w:r><w:t>Quarterly</w:t></w:r><w:r><w:t xml:space="preserve"> report</w:t></w:r></w:p>` +
+			`<w:p><w:r><w:instrText>PAGE</w:instrText><w:t>Revenue</w:t><w:tab/><w:t>grew</w:t></w:r></w:p>` +
+			`</w:body></w:document>`},
+	})
+
+	xlsx := filepath.Join(dir, "budget.xlsx")
+	writeZip(t, xlsx, [][2]string{
+		{"xl/workbook.xml", `<workbook/>`},
+		{"xl/sharedStrings.xml", `<sst><si><t>Rent</t></si><si><r><t>Office</t></r><r><t> supplies</t></r></si></sst>`},
+	})
+
+	pptx := filepath.Join(dir, "deck.pptx")
+	writeZip(t, pptx, [][2]string{
+		{"ppt/presentation.xml", `<p:presentation xmlns:p="p"/>`},
+		{"ppt/slides/slide10.xml", `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>Last slide</a:t></a:r></a:p></p:sld>`},
+		{"ppt/slides/slide2.xml", `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>Second slide</a:t></a:r></a:p></p:sld>`},
+		{"ppt/slides/slide1.xml", `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>Title slide</a:t></a:r></a:p></p:sld>`},
+	})
+
+	odt := filepath.Join(dir, "letter.odt")
+	writeZip(t, odt, [][2]string{
+		{"mimetype", MimeODT},
+		{"content.xml", `<office:document-content xmlns:office="o" xmlns:text="t" xmlns:style="s">` +
+			`<office:automatic-styles><style:style>hidden</style:style></office:automatic-styles>` +
+			`<office:body><office:text><text:h>Dear reader</text:h><text:p>Thanks<text:s/>for<text:tab/>writing</text:p></office:text></office:body>` +
+			`</office:document-content>`},
+	})
+
+	epub := filepath.Join(dir, "book.epub")
+	writeZip(t, epub, [][2]string{
+		{"mimetype", MimeEPUB},
+		{"META-INF/container.xml", `<container><rootfiles><rootfile full-path="OEBPS/content.opf"/></rootfiles></container>`},
+		{"OEBPS/content.opf", `<package><metadata><dc:title xmlns:dc="dc">A Tale</dc:title></metadata>` +
+			`<manifest><item id="c2" href="text/chapter%202.xhtml"/><item id="c1" href="text/chapter1.xhtml"/></manifest>` +
+			`<spine><itemref idref="c1"/><itemref idref="c2"/></spine></package>`},
+		{"OEBPS/text/chapter1.xhtml", `<html><head><title>One</title><style>p{}</style></head><body><p>It was the best of times.</p></body></html>`},
+		{"OEBPS/text/chapter 2.xhtml", `<html><body><p>It was the worst of times.</p></body></html>`},
+	})
+
+	rtf := filepath.Join(dir, "memo.rtf")
+	os.WriteFile(rtf, []byte(`{\rtf1\ansi\ansicpg1252\deff0{\fonttbl{\f0 Times;}}{\*\generator Writer;}`+
+		`\f0\fs24 Caf\'e9 menu\par Price\tab 5\'80\par {\b Bold} \u8364? and \{braces\}}`), 0644)
+
+	html := filepath.Join(dir, "page.html")
+	os.WriteFile(html, []byte(`<!DOCTYPE html><html><head><title>Home</title><script>var x = "<p>no</p>";</script></head>`+
+		`<body><!-- hidden --><h1>Welcome</h1><p>Fish &amp; chips<br>daily</p><table><tr><td>A</td><td>B</td></tr></table></body></html>`), 0644)
+
+	pdf := filepath.Join(dir, "invoice.pdf")
+	writePDF(t, pdf, "BT /F1 12 Tf 72 720 Td (Invoice \\(paid\\)) Tj 0 -14 Td [(Tot) 10 (al) -300 (due)] TJ ET\n"+
+		"BT /F2 12 Tf 1 0 0 1 72 600 Tm <00010002> Tj <0003> Tj ET\n")
+
+	registry := DefaultExtractors()
+	tests := []struct {
+		path     string
+		mimeType string
+		want     string
+	}{
+		{docx, MimeDOCX, "Quarterly report\nRevenue grew"},
+		{xlsx, MimeXLSX, "Rent\nOffice supplies"},
+		{pptx, MimePPTX, "Title slide\nSecond slide\nLast slide"},
+		{odt, MimeODT, "Dear reader\nThanks for writing"},
+		{epub, MimeEPUB, "A Tale\nOne\nIt was the best of times.\nIt was the worst of times."},
+		{rtf, MimeRTF, "Café menu\nPrice 5€\nBold € and {braces}"},
+		{html, MimeHTML + "; charset=utf-8", "Home\nWelcome\nFish & chips\ndaily\nA B"},
+		{pdf, MimePDF, "Invoice (paid)\nTotal due\n中字存"},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.path), func(t *testing.T) {
+			got, err := registry.Extract(context.Background(), tt.path, tt.mimeType)
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Extract() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractorRegistry_Limits(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "page.html")
+	os.WriteFile(path, []byte("<p>"+strings.Repeat("word ", 100)+"</p>"), 0644)
+
+	t.Run("max chars", func(t *testing.T) {
+		r := NewExtractorRegistry()
+		r.Register(MimeHTML, ExtractorFunc(extractHTML), ExtractLimits{MaxChars: 12})
+		got, err := r.Extract(context.Background(), path, MimeHTML)
+		if err != nil {
+			t.Fatalf("Extract() error = %v", err)
+		}
+		if got != "word word wo" {
+			t.Errorf("Extract() = %q, want %q", got, "word word wo")
+		}
+	})
+
+	t.Run("max file size", func(t *testing.T) {
+		r := NewExtractorRegistry()
+		r.Register(MimeHTML, ExtractorFunc(extractHTML), ExtractLimits{MaxFileSize: 100})
+		if _, err := r.Extract(context.Background(), path, MimeHTML); err == nil {
+			t.Error("Extract() should reject a file over the size limit")
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		r := NewExtractorRegistry()
+		block := make(chan struct{})
+		defer close(block)
+		r.Register(MimeHTML, ExtractorFunc(func(ctx context.Context, path string, limits ExtractLimits) (string, error) {
+			<-block // Ignores ctx, like an extractor stuck in a single step
+			return "late", nil
+		}), ExtractLimits{Timeout: 20 * time.Millisecond})
+
+		start := time.Now()
+		_, err := r.Extract(context.Background(), path, MimeHTML)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Extract() error = %v, want deadline exceeded", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Extract() took %v after the timeout", elapsed)
+		}
+	})
+
+	t.Run("no extractor", func(t *testing.T) {
+		r := NewExtractorRegistry()
+		if _, err := r.Extract(context.Background(), path, MimeHTML); !errors.Is(err, ErrNoExtractor) {
+			t.Errorf("Extract() error = %v, want ErrNoExtractor", err)
+		}
+	})
+}
+
+func TestExtractorRegistry_Selection(t *testing.T) {
+	r := DefaultExtractors()
+	if !r.Supports("Application/PDF") || !r.Supports("text/html; charset=utf-8") {
+		t.Error("Supports() should ignore case and parameters")
+	}
+	if r.Supports("text/plain") || r.Supports("application/zip") {
+		t.Error("Supports() should be false for types without an extractor")
+	}
+
+	r.Register("text/plain", ExtractorFunc(func(ctx context.Context, path string, limits ExtractLimits) (string, error) {
+		return "custom", nil
+	}), DefaultExtractLimits)
+	r.Unregister(MimePDF)
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(path, []byte("notes"), 0644)
+	if got, _ := r.Extract(context.Background(), path, "text/plain; charset=utf-8"); got != "custom" {
+		t.Errorf("Extract() = %q, want the registered extractor's text", got)
+	}
+	if r.Supports(MimePDF) {
+		t.Error("Supports() should be false after Unregister")
+	}
+}
+
+func TestDetectType_Documents(t *testing.T) {
+	dir := t.TempDir()
+	fa := NewAnalyzer()
+
+	files := map[string]string{
+		"noext_docx": MimeDOCX,
+		"noext_odt":  MimeODS,
+		"noext_epub": MimeEPUB,
+		"plain.zip":  "application/zip",
+		"memo.dat":   MimeRTF,
+		"page.txt":   MimeHTML,
+		"index.htm":  MimeHTML,
+		"notes.txt":  "text/plain",
+	}
+	writeZip(t, filepath.Join(dir, "noext_docx"), [][2]string{{"[Content_Types].xml", "<Types/>"}, {"word/document.xml", "<w:document/>"}})
+	writeZip(t, filepath.Join(dir, "noext_odt"), [][2]string{{"mimetype", MimeODS}, {"content.xml", "<c/>"}})
+	writeZip(t, filepath.Join(dir, "noext_epub"), [][2]string{{"mimetype", MimeEPUB}, {"META-INF/container.xml", "<c/>"}})
+	writeZip(t, filepath.Join(dir, "plain.zip"), [][2]string{{"readme.txt", "hello"}})
+	os.WriteFile(filepath.Join(dir, "memo.dat"), []byte(`{\rtf1\ansi hello}`), 0644)
+	os.WriteFile(filepath.Join(dir, "page.txt"), []byte("  <!DOCTYPE html><html><body>hi</body></html>"), 0644)
+	os.WriteFile(filepath.Join(dir, "index.htm"), []byte("hello <b>world</b>"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("shopping list: milk <2 liters>"), 0644)
+
+	for name, want := range files {
+		got, err := fa.DetectType(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("DetectType(%s) error = %v", name, err)
+		}
+		if got != want {
+			t.Errorf("DetectType(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestAnalyze_DocumentPreview(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scan001.docx")
+	writeZip(t, path, [][2]string{
+		{"word/document.xml", `<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>Lease agreement ` +
+			strings.Repeat("clause ", 200) + `</w:t></w:r></w:p></w:body></w:document>`},
+	})
+
+	fa := NewAnalyzer()
+	metadata, err := fa.Analyze(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if metadata.MimeType != MimeDOCX {
+		t.Errorf("MimeType = %q, want %q", metadata.MimeType, MimeDOCX)
+	}
+	if !strings.HasPrefix(metadata.ContentPreview, "Lease agreement clause") {
+		t.Errorf("ContentPreview = %q, want the document text", metadata.ContentPreview)
+	}
+	if len(metadata.ContentPreview) > previewChars+3 || !strings.HasSuffix(metadata.ContentPreview, "...") {
+		t.Errorf("ContentPreview should be truncated to %d characters, got %d", previewChars, len(metadata.ContentPreview))
+	}
+	if !metadata.NeedsScenarioAnalysis {
+		t.Error("NeedsScenarioAnalysis should be true for documents")
+	}
+
+	// Without an extractor the document has no preview
+	fa.SetExtractors(NewExtractorRegistry())
+	metadata, err = fa.Analyze(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if metadata.ContentPreview != "" {
+		t.Errorf("ContentPreview = %q, want none without an extractor", metadata.ContentPreview)
+	}
+}
@@ -0,0 +1,174 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/archive"
+)
+
+// Metadata fields of a file, named as in rule conditions and path templates
+const (
+	FieldCameraMake   = "camera.make"
+	FieldCameraModel  = "camera.model"
+	FieldLens         = "lens"
+	FieldOrientation  = "orientation"
+	FieldWidth        = "width"
+	FieldHeight       = "height"
+	FieldGPSLat       = "gps.lat"
+	FieldGPSLon       = "gps.lon"
+	FieldGPSAlt       = "gps.alt"
+	FieldTaken        = "taken"
+	FieldTakenYear    = "taken.year"
+	FieldTakenMonth   = "taken.month"
+	FieldTakenDay     = "taken.day"
+	FieldTitle        = "title"
+	FieldArtist       = "artist"
+	FieldAlbum        = "album"
+	FieldTrack        = "track"
+	FieldGenre        = "genre"
+	FieldDuration     = "duration"
+	FieldResolution   = "resolution"
+	FieldCreated      = "created"
+	FieldCreatedYear  = "created.year"
+	FieldCreatedMonth = "created.month"
+	FieldCreatedDay   = "created.day"
+
+	FieldFileCreated      = "file.created"
+	FieldFileCreatedYear  = "file.created.year"
+	FieldFileCreatedMonth = "file.created.month"
+	FieldFileCreatedDay   = "file.created.day"
+	FieldFileModified     = "file.modified"
+	FieldFileAccessed     = "file.accessed"
+
+	FieldEncoding = "encoding" // Encoding of a text file, e.g. utf-8 or gb18030
+	FieldLanguage = "language" // Language of the content, e.g. zh or en
+
+	FieldArchive        = "archive"         // Archive holding the file
+	FieldArchiveFormat  = "archive.format"  // Format of an archive, e.g. zip or tar.gz
+	FieldArchiveEntries = "archive.entries" // Number of files listed in an archive
+)
+
+// MetadataFields lists the names of all metadata fields
+var MetadataFields = []string{
+	FieldCameraMake, FieldCameraModel, FieldLens, FieldOrientation,
+	FieldWidth, FieldHeight, FieldGPSLat, FieldGPSLon, FieldGPSAlt,
+	FieldTaken, FieldTakenYear, FieldTakenMonth, FieldTakenDay,
+	FieldTitle, FieldArtist, FieldAlbum, FieldTrack, FieldGenre,
+	FieldDuration, FieldResolution,
+	FieldCreated, FieldCreatedYear, FieldCreatedMonth, FieldCreatedDay,
+	FieldFileCreated, FieldFileCreatedYear, FieldFileCreatedMonth, FieldFileCreatedDay,
+	FieldFileModified, FieldFileAccessed,
+	FieldEncoding, FieldLanguage,
+	FieldArchive, FieldArchiveFormat, FieldArchiveEntries,
+}
+
+// exifFields map metadata fields to the ExifData keys they show
+var exifFields = map[string]string{
+	FieldCameraMake:  ExifMake,
+	FieldCameraModel: ExifModel,
+	FieldLens:        ExifLensModel,
+	FieldOrientation: ExifOrientation,
+	FieldWidth:       ExifImageWidth,
+	FieldHeight:      ExifImageHeight,
+	FieldGPSLat:      ExifGPSLatitude,
+	FieldGPSLon:      ExifGPSLongitude,
+	FieldGPSAlt:      ExifGPSAltitude,
+	FieldTaken:       ExifDateTimeOriginal,
+}
+
+// mediaFields map metadata fields to the MediaData keys they show
+var mediaFields = map[string]string{
+	FieldTitle:    MediaTitle,
+	FieldArtist:   MediaArtist,
+	FieldAlbum:    MediaAlbum,
+	FieldTrack:    MediaTrack,
+	FieldGenre:    MediaGenre,
+	FieldDuration: MediaDuration,
+	FieldCreated:  MediaCreated,
+}
+
+// Fields returns the embedded metadata of the file by field name. Every
+// field in MetadataFields is present; values the file does not carry are "".
+func (m *FileMetadata) Fields() map[string]string {
+	fields := make(map[string]string, len(MetadataFields))
+	for _, name := range MetadataFields {
+		fields[name] = ""
+	}
+	for field, key := range exifFields {
+		fields[field] = m.ExifData[key]
+	}
+	for field, key := range mediaFields {
+		fields[field] = m.MediaData[key]
+	}
+
+	// Videos carry their picture size and photos their creation date in
+	// the other map
+	if fields[FieldWidth] == "" && fields[FieldHeight] == "" {
+		fields[FieldWidth] = m.MediaData[MediaWidth]
+		fields[FieldHeight] = m.MediaData[MediaHeight]
+	}
+	if fields[FieldWidth] != "" && fields[FieldHeight] != "" {
+		fields[FieldResolution] = fields[FieldWidth] + "x" + fields[FieldHeight]
+	}
+	if fields[FieldCreated] == "" {
+		fields[FieldCreated] = fields[FieldTaken]
+	}
+	created := fields[FieldCreated]
+	for _, part := range []struct {
+		field      string
+		start, end int
+	}{
+		{FieldCreatedYear, 0, 4},
+		{FieldCreatedMonth, 5, 7},
+		{FieldCreatedDay, 8, 10},
+	} {
+		if len(created) < part.end || !isDigits(created[part.start:part.end]) {
+			break
+		}
+		fields[part.field] = created[part.start:part.end]
+	}
+
+	// Times the filesystem keeps, in local time
+	if !m.CreatedAt.IsZero() {
+		created := m.CreatedAt.Local()
+		fields[FieldFileCreated] = created.Format(time.RFC3339)
+		fields[FieldFileCreatedYear] = fmt.Sprintf("%04d", created.Year())
+		fields[FieldFileCreatedMonth] = fmt.Sprintf("%02d", created.Month())
+		fields[FieldFileCreatedDay] = fmt.Sprintf("%02d", created.Day())
+	}
+	if !m.ModifiedAt.IsZero() {
+		fields[FieldFileModified] = m.ModifiedAt.Local().Format(time.RFC3339)
+	}
+	if !m.AccessedAt.IsZero() {
+		fields[FieldFileAccessed] = m.AccessedAt.Local().Format(time.RFC3339)
+	}
+
+	fields[FieldEncoding] = m.Encoding
+	fields[FieldLanguage] = m.Language
+	fields[FieldArchive] = m.ArchivePath
+	if !m.IsArchived() {
+		fields[FieldArchiveFormat] = string(archive.DetectFormat(m.Name))
+	}
+	if m.ArchiveEntries != nil {
+		fields[FieldArchiveEntries] = strconv.Itoa(len(m.ArchiveEntries))
+	}
+
+	if !m.TakenAt.IsZero() {
+		fields[FieldTakenYear] = fmt.Sprintf("%04d", m.TakenAt.Year())
+		fields[FieldTakenMonth] = fmt.Sprintf("%02d", m.TakenAt.Month())
+		fields[FieldTakenDay] = fmt.Sprintf("%02d", m.TakenAt.Day())
+	}
+	return fields
+}
+
+// isDigits reports whether s consists of ASCII digits
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// zipFile is a ZIP archive opened with openZip
+type zipFile struct {
+	*zip.Reader
+	f *os.File
+}
+
+// Close closes the archive
+func (z *zipFile) Close() error {
+	return z.f.Close()
+}
+
+// openZip opens a ZIP archive like zip.OpenReader, without updating the
+// file's access time
+func openZip(path string) (*zipFile, error) {
+	f, err := openForRead(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	zr, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &zipFile{Reader: zr, f: f}, nil
+}
+
+// readFile reads a whole file like os.ReadFile, without updating its
+// access time
+func readFile(path string) ([]byte, error) {
+	f, err := openForRead(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
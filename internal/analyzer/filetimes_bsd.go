@@ -0,0 +1,29 @@
+//go:build darwin || freebsd || netbsd
+
+package analyzer
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileTimes returns the birth and access times of a file. The birth time is
+// zero when the filesystem does not record it.
+func fileTimes(_ string, info os.FileInfo) (time.Time, time.Time) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, time.Time{}
+	}
+	var birth time.Time
+	// Filesystems without birth times report 0 or -1
+	if st.Birthtimespec.Sec > 0 {
+		birth = time.Unix(st.Birthtimespec.Unix())
+	}
+	return birth, time.Unix(st.Atimespec.Unix())
+}
+
+// openForRead opens a file for reading
+func openForRead(path string) (*os.File, error) {
+	return os.Open(path)
+}
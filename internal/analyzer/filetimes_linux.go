@@ -0,0 +1,50 @@
+//go:build linux
+
+package analyzer
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileTimes returns the birth and access times of a file. The birth time is
+// zero when the kernel or the filesystem does not record it.
+func fileTimes(path string, info os.FileInfo) (time.Time, time.Time) {
+	var stx unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, path, 0, unix.STATX_BTIME|unix.STATX_ATIME, &stx); err != nil {
+		// Kernels before 4.11 have no statx
+		return time.Time{}, statAccessTime(info)
+	}
+
+	var birth time.Time
+	if stx.Mask&unix.STATX_BTIME != 0 {
+		birth = time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec))
+	}
+	if stx.Mask&unix.STATX_ATIME == 0 {
+		return birth, statAccessTime(info)
+	}
+	return birth, time.Unix(stx.Atime.Sec, int64(stx.Atime.Nsec))
+}
+
+// statAccessTime returns the access time recorded in the result of stat
+func statAccessTime(info os.FileInfo) time.Time {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Atim.Unix())
+	}
+	return time.Time{}
+}
+
+// openForRead opens a file for reading without updating its access time,
+// so that analyzing files does not make them look recently opened. Only
+// owners of a file may do so; other files are opened normally.
+func openForRead(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOATIME, 0)
+	if errors.Is(err, syscall.EPERM) {
+		return os.Open(path)
+	}
+	return f, err
+}
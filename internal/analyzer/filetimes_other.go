@@ -0,0 +1,19 @@
+//go:build !(linux || darwin || freebsd || netbsd || windows)
+
+package analyzer
+
+import (
+	"os"
+	"time"
+)
+
+// fileTimes returns zero birth and access times on platforms where they
+// are not read
+func fileTimes(_ string, _ os.FileInfo) (time.Time, time.Time) {
+	return time.Time{}, time.Time{}
+}
+
+// openForRead opens a file for reading
+func openForRead(path string) (*os.File, error) {
+	return os.Open(path)
+}
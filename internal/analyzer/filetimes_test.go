@@ -0,0 +1,73 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestAnalyze_FileTimes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	start := time.Now().Add(-time.Second)
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	accessed := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	modified := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, accessed, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := NewAnalyzer().Analyze(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if !metadata.ModifiedAt.Equal(modified) {
+		t.Errorf("ModifiedAt = %v, want %v", metadata.ModifiedAt, modified)
+	}
+	// The birth time is now where the filesystem records it, and the
+	// modification time otherwise
+	if !metadata.CreatedAt.Equal(modified) && metadata.CreatedAt.Before(start) {
+		t.Errorf("CreatedAt = %v, want the birth time or %v", metadata.CreatedAt, modified)
+	}
+
+	switch runtime.GOOS {
+	case "linux", "darwin", "freebsd", "netbsd", "windows":
+		if !metadata.AccessedAt.Equal(accessed) {
+			t.Errorf("AccessedAt = %v, want %v", metadata.AccessedAt, accessed)
+		}
+	}
+
+	// Analyzing a file does not count as opening it
+	if runtime.GOOS == "linux" {
+		again, err := NewAnalyzer().Analyze(context.Background(), path)
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		if !again.AccessedAt.Equal(accessed) {
+			t.Errorf("AccessedAt after analysis = %v, want %v", again.AccessedAt, accessed)
+		}
+	}
+}
+
+func TestFields_FileTimes(t *testing.T) {
+	created := time.Date(2023, 3, 14, 9, 26, 53, 0, time.Local)
+	m := &FileMetadata{CreatedAt: created, ModifiedAt: created.AddDate(0, 1, 0)}
+
+	fields := m.Fields()
+	for name, want := range map[string]string{
+		FieldFileCreated:      created.Format(time.RFC3339),
+		FieldFileCreatedYear:  "2023",
+		FieldFileCreatedMonth: "03",
+		FieldFileCreatedDay:   "14",
+		FieldFileModified:     created.AddDate(0, 1, 0).Format(time.RFC3339),
+		FieldFileAccessed:     "",
+	} {
+		if fields[name] != want {
+			t.Errorf("Fields()[%s] = %q, want %q", name, fields[name], want)
+		}
+	}
+}
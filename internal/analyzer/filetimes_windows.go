@@ -0,0 +1,23 @@
+//go:build windows
+
+package analyzer
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileTimes returns the creation and access times of a file
+func fileTimes(_ string, info os.FileInfo) (time.Time, time.Time) {
+	attrs, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, time.Time{}
+	}
+	return time.Unix(0, attrs.CreationTime.Nanoseconds()), time.Unix(0, attrs.LastAccessTime.Nanoseconds())
+}
+
+// openForRead opens a file for reading
+func openForRead(path string) (*os.File, error) {
+	return os.Open(path)
+}
@@ -0,0 +1,280 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// id3Frames map ID3v2.3/2.4 and ID3v2.2 frame IDs to MediaData keys
+var id3Frames = map[string]string{
+	"TIT2": MediaTitle, "TT2": MediaTitle,
+	"TPE1": MediaArtist, "TP1": MediaArtist,
+	"TALB": MediaAlbum, "TAL": MediaAlbum,
+	"TRCK": MediaTrack, "TRK": MediaTrack,
+	"TCON": MediaGenre, "TCO": MediaGenre,
+	"TDRC": MediaCreated, "TYER": MediaCreated, "TYE": MediaCreated,
+}
+
+// id3Genre matches ID3v1 genre references such as "(17)" in genre frames
+var id3Genre = regexp.MustCompile(`^\((\d+)\)`)
+
+// mpegBitrates are the bitrates in kbit/s by MPEG version (1 or 2/2.5),
+// layer and bitrate index
+var mpegBitrates = [2][3][15]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+// mpegSampleRates are the sample rates by version bits (2.5, -, 2, 1) and index
+var mpegSampleRates = [4][3]int{
+	{11025, 12000, 8000},
+	{},
+	{22050, 24000, 16000},
+	{44100, 48000, 32000},
+}
+
+// maxFrameSearch bounds how far past the ID3 tag the first MPEG frame is sought
+const maxFrameSearch = 64 * 1024
+
+// readMP3Metadata reads the ID3 tags and the duration of an MP3 file
+func readMP3Metadata(f *os.File, size int64, data map[string]string) error {
+	tagEnd := id3v2Size(f)
+	if tagEnd > 0 {
+		payload, err := readISOPayload(f, isoBox{typ: "ID3", offset: 0, size: min(tagEnd, size, maxMetadataSegment)}, maxMetadataSegment)
+		if err != nil {
+			return err
+		}
+		readID3v2(payload, data)
+	}
+
+	audioEnd := size
+	var v1 [128]byte
+	if size >= 128 {
+		if _, err := f.ReadAt(v1[:], size-128); err == nil && string(v1[:3]) == "TAG" {
+			readID3v1(v1[:], data)
+			audioEnd -= 128
+		}
+	}
+
+	if tagEnd < audioEnd {
+		window := make([]byte, min(audioEnd-tagEnd, maxFrameSearch))
+		n, _ := f.ReadAt(window, tagEnd)
+		setDuration(data, mpegDuration(window[:n], audioEnd-tagEnd))
+	}
+	return nil
+}
+
+// id3v2Size returns the size of the ID3v2 tag at the start of a file,
+// including its header and footer, or 0 if there is none
+func id3v2Size(f *os.File) int64 {
+	var h [10]byte
+	if _, err := f.ReadAt(h[:], 0); err != nil || string(h[:3]) != "ID3" {
+		return 0
+	}
+	size := 10 + syncsafe(h[6:10])
+	if h[5]&0x10 != 0 {
+		size += 10 // Footer
+	}
+	return size
+}
+
+// syncsafe decodes an ID3v2 integer that uses 7 bits per byte
+func syncsafe(b []byte) int64 {
+	var v int64
+	for _, c := range b {
+		v = v<<7 | int64(c&0x7F)
+	}
+	return v
+}
+
+// readID3v2 reads the text frames of an ID3v2.2, 2.3 or 2.4 tag
+func readID3v2(tag []byte, data map[string]string) {
+	if len(tag) < 10 {
+		return
+	}
+	version, flags := tag[3], tag[5]
+	pos := 10
+	if flags&0x40 != 0 && version >= 3 { // Extended header
+		if len(tag) < 14 {
+			return
+		}
+		if version == 3 {
+			pos += 4 + int(binary.BigEndian.Uint32(tag[10:14]))
+		} else {
+			pos += int(syncsafe(tag[10:14]))
+		}
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+	for pos+headerLen <= len(tag) {
+		h := tag[pos : pos+headerLen]
+		if h[0] == 0 {
+			return // Padding
+		}
+		id := string(h[:idLen])
+		var size int
+		switch version {
+		case 2:
+			size = int(h[3])<<16 | int(h[4])<<8 | int(h[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(h[4:8]))
+		default:
+			size = int(syncsafe(h[4:8]))
+		}
+		pos += headerLen
+		if size < 0 || pos+size > len(tag) {
+			return
+		}
+		frame := tag[pos : pos+size]
+		pos += size
+
+		if id == "TLEN" || id == "TLE" {
+			if ms, err := strconv.ParseFloat(decodeID3Text(frame), 64); err == nil {
+				setDuration(data, ms/1000)
+			}
+			continue
+		}
+		key, ok := id3Frames[id]
+		if !ok {
+			continue
+		}
+		value := decodeID3Text(frame)
+		if key == MediaGenre {
+			// "(17)Rock" names the genre after the reference
+			if m := id3Genre.FindStringIndex(value); m != nil && m[1] < len(value) {
+				value = value[m[1]:]
+			}
+		}
+		setMedia(data, key, value)
+	}
+}
+
+// decodeID3Text decodes the first string of an ID3v2 text frame
+func decodeID3Text(frame []byte) string {
+	if len(frame) == 0 {
+		return ""
+	}
+	text := frame[1:]
+	switch frame[0] {
+	case 1, 2: // UTF-16 with a byte order mark, or big-endian UTF-16
+		order := binary.ByteOrder(binary.BigEndian)
+		if len(text) >= 2 && text[0] == 0xFF && text[1] == 0xFE {
+			order, text = binary.LittleEndian, text[2:]
+		} else if len(text) >= 2 && text[0] == 0xFE && text[1] == 0xFF {
+			text = text[2:]
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			u := order.Uint16(text[i:])
+			if u == 0 {
+				break
+			}
+			units = append(units, u)
+		}
+		return string(utf16.Decode(units))
+	case 3: // UTF-8
+		if i := bytes.IndexByte(text, 0); i >= 0 {
+			text = text[:i]
+		}
+		return string(text)
+	default: // ISO-8859-1
+		return decodeLatin1(text)
+	}
+}
+
+// decodeLatin1 decodes ISO-8859-1 text up to the first NUL
+func decodeLatin1(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	var sb strings.Builder
+	for _, c := range b {
+		sb.WriteRune(rune(c))
+	}
+	return sb.String()
+}
+
+// readID3v1 reads the 128-byte ID3v1 tag at the end of a file; ID3v2
+// values take precedence
+func readID3v1(tag []byte, data map[string]string) {
+	setMedia(data, MediaTitle, decodeLatin1(tag[3:33]))
+	setMedia(data, MediaArtist, decodeLatin1(tag[33:63]))
+	setMedia(data, MediaAlbum, decodeLatin1(tag[63:93]))
+	setMedia(data, MediaCreated, decodeLatin1(tag[93:97]))
+	if tag[125] == 0 && tag[126] != 0 { // ID3v1.1 track number
+		setMedia(data, MediaTrack, strconv.Itoa(int(tag[126])))
+	}
+}
+
+// mpegDuration returns the duration in seconds of an MPEG audio stream of
+// audioSize bytes whose start is in window. It uses the frame count of a
+// Xing, Info or VBRI header if there is one, and the first frame's bitrate
+// otherwise.
+func mpegDuration(window []byte, audioSize int64) float64 {
+	for i := 0; i+4 <= len(window); i++ {
+		if !isMPEGFrame(window[i:]) {
+			continue
+		}
+		versionBits, layerBits := window[i+1]>>3&3, window[i+1]>>1&3
+		bitrateIndex, rateIndex := int(window[i+2]>>4), int(window[i+2]>>2&3)
+
+		mpeg1 := versionBits == 3
+		layer := 4 - int(layerBits) // 1, 2 or 3
+		table := 1
+		if mpeg1 {
+			table = 0
+		}
+		bitrate := mpegBitrates[table][layer-1][bitrateIndex] * 1000
+		sampleRate := mpegSampleRates[versionBits][rateIndex]
+		samplesPerFrame := 1152
+		switch {
+		case layer == 1:
+			samplesPerFrame = 384
+		case layer == 3 && !mpeg1:
+			samplesPerFrame = 576
+		}
+
+		// VBR files count their frames in the first frame
+		mono := window[i+3]>>6 == 3
+		sideInfo := 32
+		switch {
+		case mpeg1 && mono:
+			sideInfo = 17
+		case !mpeg1 && !mono:
+			sideInfo = 17
+		case !mpeg1 && mono:
+			sideInfo = 9
+		}
+		frame := window[i:]
+		if x := 4 + sideInfo; len(frame) >= x+12 {
+			tag := string(frame[x : x+4])
+			if (tag == "Xing" || tag == "Info") && binary.BigEndian.Uint32(frame[x+4:])&1 != 0 {
+				frames := binary.BigEndian.Uint32(frame[x+8:])
+				return float64(frames) * float64(samplesPerFrame) / float64(sampleRate)
+			}
+		}
+		if len(frame) >= 36+18 && string(frame[36:40]) == "VBRI" {
+			frames := binary.BigEndian.Uint32(frame[36+14:])
+			return float64(frames) * float64(samplesPerFrame) / float64(sampleRate)
+		}
+
+		return float64(audioSize-int64(i)) * 8 / float64(bitrate)
+	}
+	return 0
+}
@@ -0,0 +1,501 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Image MIME types whose metadata the analyzer reads, besides JPEG and PNG
+const (
+	MimeTIFF = "image/tiff"
+	MimeWebP = "image/webp"
+	MimeHEIC = "image/heic"
+	MimeHEIF = "image/heif"
+	MimeAVIF = "image/avif"
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	xmpHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+)
+
+// rawImageTypes are the MIME types of camera raw formats built on TIFF,
+// by file extension
+var rawImageTypes = map[string]string{
+	".cr2": "image/x-canon-cr2",
+	".nef": "image/x-nikon-nef",
+	".arw": "image/x-sony-arw",
+	".dng": "image/x-adobe-dng",
+	".orf": "image/x-olympus-orf",
+	".rw2": "image/x-panasonic-rw2",
+	".pef": "image/x-pentax-pef",
+	".srw": "image/x-samsung-srw",
+}
+
+// readImageMetadata reads the EXIF and XMP metadata and the dimensions of
+// an image, keyed as in FileMetadata.ExifData. Formats without metadata
+// support yield an empty map; damaged files yield what could be read
+// along with the error.
+func readImageMetadata(path, mimeType string) (map[string]string, error) {
+	data := make(map[string]string)
+
+	mimeType = baseMimeType(mimeType)
+	var read func(f *os.File, size int64, data map[string]string) error
+	switch mimeType {
+	case "image/jpeg":
+		read = readJPEGMetadata
+	case "image/png":
+		read = readPNGMetadata
+	case MimeWebP:
+		read = readWebPMetadata
+	case MimeHEIC, MimeHEIF, MimeAVIF:
+		read = readHEIFMetadata
+	case MimeTIFF:
+		read = readTIFFMetadata
+	default:
+		for _, raw := range rawImageTypes {
+			if mimeType == raw {
+				read = readTIFFMetadata
+				break
+			}
+		}
+	}
+	if read == nil {
+		return data, nil
+	}
+
+	f, err := openForRead(path)
+	if err != nil {
+		return data, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return data, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	err = read(f, info.Size(), data)
+	return data, err
+}
+
+// readTIFFMetadata reads the metadata of a TIFF file or a raw format built on it
+func readTIFFMetadata(f *os.File, size int64, data map[string]string) error {
+	return parseTIFF(f, size, data)
+}
+
+// parseExifPayload reads an EXIF payload, with or without its "Exif"
+// header, into data
+func parseExifPayload(payload []byte, data map[string]string) error {
+	payload = bytes.TrimPrefix(payload, exifHeader)
+	return parseTIFF(bytes.NewReader(payload), int64(len(payload)), data)
+}
+
+// setDimensions records the pixel size of an image. Dimensions of the
+// image itself take precedence over those stored in its metadata.
+func setDimensions(data map[string]string, width, height uint64) {
+	if width == 0 || height == 0 {
+		return
+	}
+	data[ExifImageWidth] = strconv.FormatUint(width, 10)
+	data[ExifImageHeight] = strconv.FormatUint(height, 10)
+}
+
+// readJPEGMetadata reads the APP1 segments and frame size of a JPEG file
+func readJPEGMetadata(f *os.File, size int64, data map[string]string) error {
+	br := bufio.NewReader(f)
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return fmt.Errorf("invalid JPEG header")
+	}
+
+	haveFrame := false
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil
+		}
+		if b != 0xFF {
+			continue
+		}
+		marker, err := br.ReadByte()
+		for err == nil && marker == 0xFF {
+			marker, err = br.ReadByte()
+		}
+		if err != nil {
+			return nil
+		}
+
+		switch {
+		case marker == 0x01 || marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7):
+			continue // Markers without a segment
+		case marker == 0xD9 || marker == 0xDA:
+			return nil // Metadata precedes the scan data
+		}
+
+		var lenBuf [2]byte
+		if _, err := io.ReadFull(br, lenBuf[:]); err != nil {
+			return fmt.Errorf("failed to read JPEG segment: %w", err)
+		}
+		n := int(binary.BigEndian.Uint16(lenBuf[:])) - 2
+		if n < 0 {
+			return fmt.Errorf("invalid JPEG segment length")
+		}
+
+		isFrame := marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC
+		if marker != 0xE1 && (!isFrame || haveFrame) {
+			if _, err := br.Discard(n); err != nil {
+				return nil
+			}
+			continue
+		}
+
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return fmt.Errorf("failed to read JPEG segment: %w", err)
+		}
+		switch {
+		case isFrame && len(payload) >= 5:
+			haveFrame = true
+			height, width := binary.BigEndian.Uint16(payload[1:]), binary.BigEndian.Uint16(payload[3:])
+			setDimensions(data, uint64(width), uint64(height))
+		case bytes.HasPrefix(payload, exifHeader):
+			if err := parseExifPayload(payload, data); err != nil {
+				return err
+			}
+		case bytes.HasPrefix(payload, xmpHeader):
+			parseXMP(payload[len(xmpHeader):], data)
+		}
+	}
+}
+
+// readPNGMetadata reads the header, eXIf and XMP chunks of a PNG file
+func readPNGMetadata(f *os.File, size int64, data map[string]string) error {
+	var sig [8]byte
+	if _, err := io.ReadFull(f, sig[:]); err != nil || string(sig[:]) != "\x89PNG\r\n\x1a\n" {
+		return fmt.Errorf("invalid PNG header")
+	}
+
+	for off := int64(8); off+8 <= size; {
+		var h [8]byte
+		if _, err := f.ReadAt(h[:], off); err != nil {
+			return fmt.Errorf("failed to read PNG chunk: %w", err)
+		}
+		length, typ := int64(binary.BigEndian.Uint32(h[:4])), string(h[4:8])
+		chunk := isoBox{typ: typ, offset: off + 8, size: length}
+		off += 12 + length // Header, data and CRC
+
+		switch typ {
+		case "IHDR", "eXIf", "iTXt":
+		case "IEND":
+			return nil
+		default:
+			continue
+		}
+		payload, err := readISOPayload(f, chunk, maxMetadataSegment)
+		if err != nil {
+			continue
+		}
+
+		switch typ {
+		case "IHDR":
+			if len(payload) >= 8 {
+				setDimensions(data, uint64(binary.BigEndian.Uint32(payload)), uint64(binary.BigEndian.Uint32(payload[4:])))
+			}
+		case "eXIf":
+			if err := parseExifPayload(payload, data); err != nil {
+				return err
+			}
+		case "iTXt":
+			if xmp := pngXMP(payload); xmp != nil {
+				parseXMP(xmp, data)
+			}
+		}
+	}
+	return nil
+}
+
+// pngXMP returns the XMP packet of an iTXt chunk, or nil if the chunk holds
+// other text
+func pngXMP(payload []byte) []byte {
+	r := &beReader{b: payload}
+	if r.cstring() != "XML:com.adobe.xmp" {
+		return nil
+	}
+	compressed := r.uint(1) == 1
+	r.uint(1)   // Compression method
+	r.cstring() // Language tag
+	r.cstring() // Translated keyword
+	text := r.b[r.pos:]
+	if !compressed {
+		return text
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(text))
+	if err != nil {
+		return nil
+	}
+	defer zr.Close()
+	xmp, err := io.ReadAll(io.LimitReader(zr, maxMetadataSegment))
+	if err != nil {
+		return nil
+	}
+	return xmp
+}
+
+// readWebPMetadata reads the canvas size and the EXIF and XMP chunks of a
+// WebP file
+func readWebPMetadata(f *os.File, size int64, data map[string]string) error {
+	var h [12]byte
+	if _, err := f.ReadAt(h[:], 0); err != nil || string(h[:4]) != "RIFF" || string(h[8:]) != "WEBP" {
+		return fmt.Errorf("invalid WebP header")
+	}
+
+	for off := int64(12); off+8 <= size; {
+		var ch [8]byte
+		if _, err := f.ReadAt(ch[:], off); err != nil {
+			return fmt.Errorf("failed to read WebP chunk: %w", err)
+		}
+		length := int64(binary.LittleEndian.Uint32(ch[4:]))
+		chunk := isoBox{typ: string(ch[:4]), offset: off + 8, size: length}
+		off += 8 + length + length%2 // Chunks are padded to an even size
+
+		switch chunk.typ {
+		case "VP8X", "VP8 ", "VP8L":
+			chunk.size = min(chunk.size, 10) // The size is in the first bytes
+		case "EXIF", "XMP ":
+		default:
+			continue
+		}
+		payload, err := readISOPayload(f, chunk, maxMetadataSegment)
+		if err != nil {
+			continue
+		}
+
+		switch chunk.typ {
+		case "VP8X":
+			if len(payload) >= 10 {
+				width := uint64(payload[4]) | uint64(payload[5])<<8 | uint64(payload[6])<<16
+				height := uint64(payload[7]) | uint64(payload[8])<<8 | uint64(payload[9])<<16
+				setDimensions(data, width+1, height+1)
+			}
+		case "VP8 ":
+			if _, ok := data[ExifImageWidth]; !ok && len(payload) >= 10 && bytes.Equal(payload[3:6], []byte{0x9D, 0x01, 0x2A}) {
+				width := binary.LittleEndian.Uint16(payload[6:]) & 0x3FFF
+				height := binary.LittleEndian.Uint16(payload[8:]) & 0x3FFF
+				setDimensions(data, uint64(width), uint64(height))
+			}
+		case "VP8L":
+			if _, ok := data[ExifImageWidth]; !ok && len(payload) >= 5 && payload[0] == 0x2F {
+				bits := binary.LittleEndian.Uint32(payload[1:])
+				setDimensions(data, uint64(bits&0x3FFF)+1, uint64(bits>>14&0x3FFF)+1)
+			}
+		case "EXIF":
+			if err := parseExifPayload(payload, data); err != nil {
+				return err
+			}
+		case "XMP ":
+			parseXMP(payload, data)
+		}
+	}
+	return nil
+}
+
+// heifItem is an item of a HEIF file's meta box
+type heifItem struct {
+	typ         string
+	contentType string
+	offset      int64
+	length      int64
+}
+
+// readHEIFMetadata reads the EXIF and XMP items and the image size of a
+// HEIF file (HEIC, AVIF)
+func readHEIFMetadata(f *os.File, size int64, data map[string]string) error {
+	boxes, err := readISOBoxes(f, 0, size)
+	meta, ok := findISOBox(boxes, "meta")
+	if !ok {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("HEIF file has no meta box")
+	}
+	children, err := readISOChildren(f, meta, 4)
+	if err != nil {
+		return err
+	}
+
+	items := make(map[uint64]*heifItem)
+	if iinf, ok := findISOBox(children, "iinf"); ok {
+		if err := readHEIFItemInfo(f, iinf, items); err != nil {
+			return err
+		}
+	}
+	if iloc, ok := findISOBox(children, "iloc"); ok {
+		if err := readHEIFItemLocations(f, iloc, items); err != nil {
+			return err
+		}
+	}
+
+	// Grid images have a property for each tile; the largest size is the
+	// whole image's
+	if iprp, ok := findISOBox(children, "iprp"); ok {
+		if props, err := readISOChildren(f, iprp, 0); err == nil {
+			if ipco, ok := findISOBox(props, "ipco"); ok {
+				var width, height uint64
+				properties, _ := readISOChildren(f, ipco, 0)
+				for _, p := range properties {
+					if p.typ != "ispe" {
+						continue
+					}
+					payload, err := readISOPayload(f, p, 64)
+					if err != nil {
+						continue
+					}
+					r := &beReader{b: payload}
+					r.uint(4) // Version and flags
+					w, h := r.uint(4), r.uint(4)
+					if !r.bad && w*h > width*height {
+						width, height = w, h
+					}
+				}
+				setDimensions(data, width, height)
+			}
+		}
+	}
+
+	for _, item := range items {
+		if item.offset < 0 || item.length <= 0 || item.length > maxMetadataSegment || item.offset+item.length > size {
+			continue
+		}
+		isXMP := item.typ == "mime" && item.contentType == "application/rdf+xml"
+		if item.typ != "Exif" && !isXMP {
+			continue
+		}
+
+		payload := make([]byte, item.length)
+		if _, err := f.ReadAt(payload, item.offset); err != nil {
+			return fmt.Errorf("failed to read HEIF item: %w", err)
+		}
+		if isXMP {
+			parseXMP(payload, data)
+			continue
+		}
+		// Exif items start with the offset of the TIFF header
+		r := &beReader{b: payload}
+		r.bytes(int(r.uint(4)))
+		if r.bad {
+			continue
+		}
+		if err := parseExifPayload(payload[r.pos:], data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readHEIFItemInfo reads the item types of an iinf box
+func readHEIFItemInfo(f *os.File, iinf isoBox, items map[uint64]*heifItem) error {
+	header, err := readISOPayload(f, isoBox{typ: iinf.typ, offset: iinf.offset, size: min(iinf.size, 8)}, 8)
+	if err != nil {
+		return err
+	}
+	if len(header) < 6 {
+		return fmt.Errorf("iinf box is truncated")
+	}
+	skip := int64(6) // Version, flags and a 16-bit entry count
+	if header[0] != 0 {
+		skip = 8
+	}
+	entries, err := readISOChildren(f, iinf, skip)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.typ != "infe" {
+			continue
+		}
+		payload, err := readISOPayload(f, e, 4096)
+		if err != nil {
+			continue
+		}
+		r := &beReader{b: payload}
+		version := r.uint(1)
+		r.uint(3) // Flags
+		if version < 2 {
+			continue
+		}
+		idSize := 2
+		if version >= 3 {
+			idSize = 4
+		}
+		item := &heifItem{}
+		id := r.uint(idSize)
+		r.uint(2) // Protection index
+		item.typ = string(r.bytes(4))
+		r.cstring() // Item name
+		if item.typ == "mime" {
+			item.contentType = r.cstring()
+		}
+		if !r.bad {
+			items[id] = item
+		}
+	}
+	return nil
+}
+
+// readHEIFItemLocations reads where items stored in the file are, from an
+// iloc box. Only the first extent of an item is used.
+func readHEIFItemLocations(f *os.File, iloc isoBox, items map[uint64]*heifItem) error {
+	payload, err := readISOPayload(f, iloc, maxMetadataSegment)
+	if err != nil {
+		return err
+	}
+	r := &beReader{b: payload}
+	version := r.uint(1)
+	r.uint(3) // Flags
+	sizes := r.uint(1)
+	offsetSize, lengthSize := int(sizes>>4), int(sizes&0xF)
+	sizes = r.uint(1)
+	baseOffsetSize, indexSize := int(sizes>>4), 0
+	if version == 1 || version == 2 {
+		indexSize = int(sizes & 0xF)
+	}
+	idSize := 2
+	if version == 2 {
+		idSize = 4
+	}
+	count := r.uint(idSize)
+
+	for i := uint64(0); i < count && !r.bad; i++ {
+		id := r.uint(idSize)
+		method := uint64(0)
+		if version == 1 || version == 2 {
+			method = r.uint(2) & 0xF
+		}
+		r.uint(2) // Data reference index
+		base := r.uint(baseOffsetSize)
+		extents := r.uint(2)
+
+		var offset, length uint64
+		for j := uint64(0); j < extents && !r.bad; j++ {
+			r.uint(indexSize)
+			o, l := r.uint(offsetSize), r.uint(lengthSize)
+			if j == 0 {
+				offset, length = o, l
+			}
+		}
+		// Construction method 0 addresses the file; others the idat box or
+		// other items, which do not hold EXIF data in practice
+		if item, ok := items[id]; ok && method == 0 && !r.bad {
+			item.offset, item.length = int64(base+offset), int64(length)
+		}
+	}
+	return nil
+}
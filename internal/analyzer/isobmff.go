@@ -0,0 +1,137 @@
+package analyzer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// maxISOBoxes bounds how many sibling boxes are read from one container
+const maxISOBoxes = 4096
+
+// isoBox is a box of an ISO base media file (HEIF, MP4, MOV)
+type isoBox struct {
+	typ    string
+	offset int64 // Start of the payload
+	size   int64 // Payload size
+}
+
+// end returns the offset just past the box
+func (b isoBox) end() int64 {
+	return b.offset + b.size
+}
+
+// readISOBoxes lists the boxes between start and end
+func readISOBoxes(r io.ReaderAt, start, end int64) ([]isoBox, error) {
+	var boxes []isoBox
+	for off := start; off+8 <= end; {
+		if len(boxes) >= maxISOBoxes {
+			return boxes, fmt.Errorf("too many boxes")
+		}
+
+		var h [16]byte
+		if _, err := r.ReadAt(h[:8], off); err != nil {
+			return boxes, fmt.Errorf("failed to read box header: %w", err)
+		}
+		size, typ, header := int64(binary.BigEndian.Uint32(h[:4])), string(h[4:8]), int64(8)
+		switch size {
+		case 0: // The box extends to the end of its container
+			size = end - off
+		case 1: // A 64-bit size follows the type
+			if _, err := r.ReadAt(h[8:16], off+8); err != nil {
+				return boxes, fmt.Errorf("failed to read box header: %w", err)
+			}
+			size, header = int64(binary.BigEndian.Uint64(h[8:16])), 16
+		}
+		if size < header || size > end-off {
+			return boxes, fmt.Errorf("invalid size of box %q", typ)
+		}
+
+		boxes = append(boxes, isoBox{typ: typ, offset: off + header, size: size - header})
+		off += size
+	}
+	return boxes, nil
+}
+
+// findISOBox returns the first box of a type
+func findISOBox(boxes []isoBox, typ string) (isoBox, bool) {
+	for _, b := range boxes {
+		if b.typ == typ {
+			return b, true
+		}
+	}
+	return isoBox{}, false
+}
+
+// readISOChildren lists the boxes inside a box, skipping skip bytes of
+// fields that precede them (e.g. 4 for the version and flags of a full box)
+func readISOChildren(r io.ReaderAt, box isoBox, skip int64) ([]isoBox, error) {
+	if skip > box.size {
+		return nil, fmt.Errorf("box %q is truncated", box.typ)
+	}
+	return readISOBoxes(r, box.offset+skip, box.end())
+}
+
+// readISOPayload reads the payload of a box of at most max bytes
+func readISOPayload(r io.ReaderAt, box isoBox, max int64) ([]byte, error) {
+	if box.size > max {
+		return nil, fmt.Errorf("box %q too large: %d bytes", box.typ, box.size)
+	}
+	data := make([]byte, box.size)
+	if _, err := r.ReadAt(data, box.offset); err != nil {
+		return nil, fmt.Errorf("failed to read box %q: %w", box.typ, err)
+	}
+	return data, nil
+}
+
+// beReader reads big-endian fields from a byte slice. Reads past the end
+// return zero values and set a sticky error flag.
+type beReader struct {
+	b   []byte
+	pos int
+	bad bool
+}
+
+// uint reads an unsigned integer of n bytes; n may be 0, 1, 2, 4 or 8
+func (r *beReader) uint(n int) uint64 {
+	if n == 0 {
+		return 0
+	}
+	if r.pos+n > len(r.b) {
+		r.bad = true
+		r.pos = len(r.b)
+		return 0
+	}
+	var v uint64
+	for _, c := range r.b[r.pos : r.pos+n] {
+		v = v<<8 | uint64(c)
+	}
+	r.pos += n
+	return v
+}
+
+// bytes reads n bytes
+func (r *beReader) bytes(n int) []byte {
+	if n < 0 || r.pos+n > len(r.b) {
+		r.bad = true
+		r.pos = len(r.b)
+		return nil
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// cstring reads a NUL-terminated string
+func (r *beReader) cstring() string {
+	for i := r.pos; i < len(r.b); i++ {
+		if r.b[i] == 0 {
+			s := string(r.b[r.pos:i])
+			r.pos = i + 1
+			return s
+		}
+	}
+	s := string(r.b[r.pos:])
+	r.pos = len(r.b)
+	return s
+}
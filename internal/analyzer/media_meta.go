@@ -0,0 +1,305 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys of FileMetadata.MediaData
+const (
+	MediaTitle    = "Title"
+	MediaArtist   = "Artist"
+	MediaAlbum    = "Album"
+	MediaTrack    = "Track" // Track number, without the track count
+	MediaGenre    = "Genre"
+	MediaDuration = "Duration" // Whole seconds
+	MediaCreated  = "Created"  // 2006-01-02T15:04:05 with a zone offset if known, or a partial date such as 2006
+	MediaWidth    = "Width"
+	MediaHeight   = "Height"
+)
+
+// Audio and video MIME types whose metadata the analyzer reads
+const (
+	MimeMP3       = "audio/mpeg"
+	MimeFLAC      = "audio/flac"
+	MimeOgg       = "audio/ogg"
+	MimeM4A       = "audio/mp4"
+	MimeMP4       = "video/mp4"
+	MimeQuickTime = "video/quicktime"
+	Mime3GPP      = "video/3gpp"
+)
+
+// mp4Brands map the major brands of ISO base media files to their MIME types
+var mp4Brands = map[string]string{
+	"M4A ": MimeM4A, "M4B ": MimeM4A, "M4P ": MimeM4A,
+	"isom": MimeMP4, "iso2": MimeMP4, "iso4": MimeMP4, "iso5": MimeMP4, "iso6": MimeMP4,
+	"mp41": MimeMP4, "mp42": MimeMP4, "avc1": MimeMP4, "dash": MimeMP4, "M4V ": MimeMP4,
+	"MSNV": MimeMP4, "XAVC": MimeMP4,
+	"qt  ": MimeQuickTime,
+	"3gp4": Mime3GPP, "3gp5": Mime3GPP, "3gp6": Mime3GPP, "3gp7": Mime3GPP,
+}
+
+// partialDate matches dates given as a year or a year and month
+var partialDate = regexp.MustCompile(`^\d{4}(-\d{2})?$`)
+
+// readMediaMetadata reads the tags, duration and picture size of an audio
+// or video file, keyed as in FileMetadata.MediaData. Formats without
+// metadata support yield an empty map; damaged files yield what could be
+// read along with the error.
+func readMediaMetadata(path, mimeType string) (map[string]string, error) {
+	data := make(map[string]string)
+
+	var read func(f *os.File, size int64, data map[string]string) error
+	switch baseMimeType(mimeType) {
+	case MimeMP3:
+		read = readMP3Metadata
+	case MimeFLAC, "audio/x-flac":
+		read = readFLACMetadata
+	case MimeOgg, "audio/opus", "audio/vorbis", "application/ogg":
+		read = readOggMetadata
+	case MimeM4A, "audio/x-m4a", MimeMP4, "video/x-m4v", MimeQuickTime, Mime3GPP:
+		read = readMP4Metadata
+	default:
+		return data, nil
+	}
+
+	f, err := openForRead(path)
+	if err != nil {
+		return data, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return data, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	err = read(f, info.Size(), data)
+	return data, err
+}
+
+// setMedia sets a key of data unless the value is empty or the key is set.
+// Track numbers lose their track count and dates are normalized.
+func setMedia(data map[string]string, key, value string) {
+	value = strings.TrimSpace(strings.TrimRight(value, "\x00"))
+	switch key {
+	case MediaTrack:
+		value, _, _ = strings.Cut(value, "/")
+		if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && n > 0 {
+			value = strconv.Itoa(n)
+		} else {
+			value = ""
+		}
+	case MediaCreated:
+		value = normalizeMediaDate(value)
+	}
+	setExif(data, key, value)
+}
+
+// setDuration records a duration in seconds
+func setDuration(data map[string]string, seconds float64) {
+	if seconds <= 0 || math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+		return
+	}
+	setExif(data, MediaDuration, strconv.FormatInt(int64(math.Round(seconds)), 10))
+}
+
+// normalizeMediaDate rewrites a tag date like normalizeExifTime, keeping
+// dates that only give a year or a month as they are
+func normalizeMediaDate(s string) string {
+	s = strings.TrimSpace(s)
+	if partialDate.MatchString(s) {
+		return s
+	}
+	if t := normalizeExifTime(strings.Replace(s, " ", "T", 1)); t != "" {
+		return t
+	}
+	// QuickTime writes zone offsets without a colon
+	for _, layout := range []string{"2006-01-02T15:04:05-0700", "2006-01-02T15:04:05.999999999-0700"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02T15:04:05-07:00")
+		}
+	}
+	return ""
+}
+
+// readVorbisComments reads the comments of a FLAC or Ogg file, in the
+// little-endian format shared by both
+func readVorbisComments(b []byte, data map[string]string) {
+	r := &leReader{beReader{b: b}}
+	r.bytes(int(r.uint32())) // Vendor string
+	count := r.uint32()
+	for i := uint32(0); i < count && !r.bad; i++ {
+		comment := string(r.bytes(int(r.uint32())))
+		key, value, ok := strings.Cut(comment, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			setMedia(data, MediaTitle, value)
+		case "ARTIST":
+			setMedia(data, MediaArtist, value)
+		case "ALBUM":
+			setMedia(data, MediaAlbum, value)
+		case "TRACKNUMBER":
+			setMedia(data, MediaTrack, value)
+		case "GENRE":
+			setMedia(data, MediaGenre, value)
+		case "DATE", "YEAR":
+			setMedia(data, MediaCreated, value)
+		}
+	}
+}
+
+// leReader reads little-endian fields
+type leReader struct {
+	beReader
+}
+
+// uint32 reads a little-endian 32-bit integer
+func (r *leReader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+// readFLACMetadata reads the stream info and Vorbis comments of a FLAC file
+func readFLACMetadata(f *os.File, size int64, data map[string]string) error {
+	off := id3v2Size(f)
+	var magic [4]byte
+	if _, err := f.ReadAt(magic[:], off); err != nil || string(magic[:]) != "fLaC" {
+		return fmt.Errorf("invalid FLAC header")
+	}
+
+	for off += 4; off+4 <= size; {
+		var h [4]byte
+		if _, err := f.ReadAt(h[:], off); err != nil {
+			return fmt.Errorf("failed to read FLAC metadata block: %w", err)
+		}
+		last, typ := h[0]&0x80 != 0, h[0]&0x7F
+		length := int64(h[1])<<16 | int64(h[2])<<8 | int64(h[3])
+		block := isoBox{typ: "flac", offset: off + 4, size: length}
+		off += 4 + length
+
+		switch typ {
+		case 0: // STREAMINFO
+			payload, err := readISOPayload(f, block, 64)
+			if err == nil && len(payload) >= 18 {
+				v := binary.BigEndian.Uint64(payload[10:18])
+				rate, samples := v>>44, v&(1<<36-1)
+				if rate > 0 {
+					setDuration(data, float64(samples)/float64(rate))
+				}
+			}
+		case 4: // VORBIS_COMMENT
+			if payload, err := readISOPayload(f, block, maxMetadataSegment); err == nil {
+				readVorbisComments(payload, data)
+			}
+		}
+		if last {
+			break
+		}
+	}
+	return nil
+}
+
+// oggCapture starts every Ogg page
+var oggCapture = []byte("OggS")
+
+// maxOggHeaderPages bounds the pages read to find the header packets
+const maxOggHeaderPages = 256
+
+// readOggMetadata reads the header packets and the duration of the first
+// stream of an Ogg Vorbis or Opus file
+func readOggMetadata(f *os.File, size int64, data map[string]string) error {
+	var packets [][]byte
+	var packet []byte
+	var serial uint32
+	for off, pages := int64(0), 0; off+27 <= size && len(packets) < 2 && pages < maxOggHeaderPages; pages++ {
+		var h [27]byte
+		if _, err := f.ReadAt(h[:], off); err != nil || !bytes.Equal(h[:4], oggCapture) {
+			return fmt.Errorf("invalid Ogg page")
+		}
+		pageSerial := binary.LittleEndian.Uint32(h[14:])
+		if off == 0 {
+			serial = pageSerial
+		}
+		segments := make([]byte, h[26])
+		if _, err := f.ReadAt(segments, off+27); err != nil {
+			return fmt.Errorf("failed to read Ogg page: %w", err)
+		}
+		var bodySize int64
+		for _, s := range segments {
+			bodySize += int64(s)
+		}
+		body := make([]byte, bodySize)
+		if _, err := f.ReadAt(body, off+27+int64(len(segments))); err != nil {
+			return fmt.Errorf("failed to read Ogg page: %w", err)
+		}
+		off += 27 + int64(len(segments)) + bodySize
+		if pageSerial != serial {
+			continue
+		}
+
+		// Packets end at segments shorter than 255 bytes
+		pos := 0
+		for _, s := range segments {
+			packet = append(packet, body[pos:pos+int(s)]...)
+			pos += int(s)
+			if len(packet) > maxMetadataSegment {
+				return fmt.Errorf("Ogg header packet too large")
+			}
+			if s < 255 {
+				packets = append(packets, packet)
+				packet = nil
+			}
+		}
+	}
+	if len(packets) == 0 {
+		return fmt.Errorf("Ogg file has no packets")
+	}
+
+	var rate, preSkip uint64
+	id := packets[0]
+	switch {
+	case strings.HasPrefix(string(id), "\x01vorbis") && len(id) >= 16:
+		rate = uint64(binary.LittleEndian.Uint32(id[12:]))
+	case strings.HasPrefix(string(id), "OpusHead") && len(id) >= 12:
+		rate, preSkip = 48000, uint64(binary.LittleEndian.Uint16(id[10:]))
+	}
+	if len(packets) > 1 {
+		switch comments := packets[1]; {
+		case strings.HasPrefix(string(comments), "\x03vorbis"):
+			readVorbisComments(comments[7:], data)
+		case strings.HasPrefix(string(comments), "OpusTags"):
+			readVorbisComments(comments[8:], data)
+		}
+	}
+
+	// The granule position of the last page counts the stream's samples
+	if rate > 0 {
+		tailSize := min(size, 64*1024)
+		tail := make([]byte, tailSize)
+		if _, err := f.ReadAt(tail, size-tailSize); err == nil {
+			for i := bytes.LastIndex(tail, oggCapture); i >= 0; i = bytes.LastIndex(tail[:i], oggCapture) {
+				if i+27 > len(tail) || binary.LittleEndian.Uint32(tail[i+14:]) != serial {
+					continue
+				}
+				if granule := binary.LittleEndian.Uint64(tail[i+6:]); granule > preSkip && granule != math.MaxUint64 {
+					setDuration(data, float64(granule-preSkip)/float64(rate))
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+// id3Frame builds an ID3v2.3 frame, or an ID3v2.4 frame with a syncsafe size
+func id3Frame(version int, id string, body []byte) []byte {
+	frame := []byte(id)
+	size := uint32(len(body))
+	if version == 4 {
+		size = size&0x7F | size<<1&0x7F00 | size<<2&0x7F0000 | size<<3&0x7F000000
+	}
+	frame = binary.BigEndian.AppendUint32(frame, size)
+	frame = append(frame, 0, 0)
+	return append(frame, body...)
+}
+
+// id3Tag builds an ID3v2 tag with some padding
+func id3Tag(version int, frames ...[]byte) []byte {
+	body := append(bytes.Join(frames, nil), make([]byte, 32)...)
+	n := len(body)
+	return append([]byte{'I', 'D', '3', byte(version), 0, 0,
+		byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}, body...)
+}
+
+// utf16Text builds a little-endian UTF-16 text frame body with a byte order mark
+func utf16Text(s string) []byte {
+	b := []byte{1, 0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+// id3v1Tag builds an ID3v1.1 tag
+func id3v1Tag(title, artist, album, year string, track byte) []byte {
+	tag := make([]byte, 128)
+	copy(tag, "TAG")
+	copy(tag[3:33], title)
+	copy(tag[33:63], artist)
+	copy(tag[63:93], album)
+	copy(tag[93:97], year)
+	tag[126] = track
+	return tag
+}
+
+// mpegFrame builds a 128 kbit/s, 44.1 kHz MPEG-1 Layer III frame of size
+// bytes, with a Xing header counting frames if frames is positive
+func mpegFrame(size, frames int) []byte {
+	frame := make([]byte, size)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	if frames > 0 {
+		copy(frame[36:], "Xing")
+		binary.BigEndian.PutUint32(frame[40:], 1)
+		binary.BigEndian.PutUint32(frame[44:], uint32(frames))
+	}
+	return frame
+}
+
+// vorbisComments builds a Vorbis comment block
+func vorbisComments(comments ...string) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, 4)
+	b = append(b, "test"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(comments)))
+	for _, c := range comments {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(c)))
+		b = append(b, c...)
+	}
+	return b
+}
+
+// oggPage builds an Ogg page holding one packet
+func oggPage(serial uint32, granule uint64, packet []byte) []byte {
+	page := append([]byte("OggS"), 0, 0)
+	page = binary.LittleEndian.AppendUint64(page, granule)
+	page = binary.LittleEndian.AppendUint32(page, serial)
+	page = append(page, make([]byte, 8)...) // Sequence number and checksum
+	var lacing []byte
+	n := len(packet)
+	for ; n >= 255; n -= 255 {
+		lacing = append(lacing, 255)
+	}
+	lacing = append(lacing, byte(n))
+	page = append(page, byte(len(lacing)))
+	return append(append(page, lacing...), packet...)
+}
+
+// mp4Data builds an item list data box
+func mp4Data(typ uint32, value []byte) []byte {
+	return isoBoxBytes("data", binary.BigEndian.AppendUint32(nil, typ), make([]byte, 4), value)
+}
+
+// mp4MovieHeader builds a version 0 mvhd box
+func mp4MovieHeader(created uint32, timescale, duration uint32) []byte {
+	body := make([]byte, 4)
+	body = binary.BigEndian.AppendUint32(body, created)
+	body = binary.BigEndian.AppendUint32(body, created)
+	body = binary.BigEndian.AppendUint32(body, timescale)
+	body = binary.BigEndian.AppendUint32(body, duration)
+	return isoBoxBytes("mvhd", body, make([]byte, 80))
+}
+
+// mp4Track builds a trak box whose version 0 tkhd box has the given size
+func mp4Track(width, height uint32) []byte {
+	body := make([]byte, 4+20+52)
+	body = binary.BigEndian.AppendUint32(body, width<<16)
+	body = binary.BigEndian.AppendUint32(body, height<<16)
+	return isoBoxBytes("trak", isoBoxBytes("tkhd", body))
+}
+
+func testMP3() []byte {
+	tag := id3Tag(3,
+		id3Frame(3, "TIT2", []byte("\x00Blue Monday\x00")),
+		id3Frame(3, "TPE1", utf16Text("Björk")),
+		id3Frame(3, "TALB", []byte("\x03Début")),
+		id3Frame(3, "TRCK", []byte("\x003/12")),
+		id3Frame(3, "TCON", []byte("\x00(17)Rock")),
+		id3Frame(3, "TYER", []byte("\x001993")),
+		id3Frame(3, "APIC", make([]byte, 100)),
+	)
+	audio := bytes.Repeat(mpegFrame(417, 0), 10)
+	copy(audio, mpegFrame(417, 2000))
+	return bytes.Join([][]byte{tag, audio, id3v1Tag("Ignored", "Ignored", "Ignored", "1990", 9)}, nil)
+}
+
+func testFLAC() []byte {
+	streamInfo := make([]byte, 34)
+	binary.BigEndian.PutUint64(streamInfo[10:], 44100<<44|1<<41|15<<36|44100*200)
+	comments := vorbisComments("TITLE=Weightless", "artist=Marconi Union", "ALBUM=Weightless", "TRACKNUMBER=01", "DATE=2011-11-14", "GENRE=Ambient")
+	return bytes.Join([][]byte{
+		[]byte("fLaC"),
+		{0, 0, 0, 34}, streamInfo,
+		{0x84, 0, byte(len(comments) >> 8), byte(len(comments))}, comments,
+	}, nil)
+}
+
+func testOgg(opus bool) []byte {
+	var id, comments []byte
+	var granule uint64
+	if opus {
+		id = append([]byte("OpusHead\x01\x02"), 0x38, 0x01, 0x80, 0xBB, 0, 0, 0, 0, 0)
+		comments = append([]byte("OpusTags"), vorbisComments("TITLE=Voice Memo", "ARTIST=Me")...)
+		granule = 48000*5 + 312
+	} else {
+		id = append([]byte("\x01vorbis\x00\x00\x00\x00\x02"), binary.LittleEndian.AppendUint32(nil, 44100)...)
+		id = append(id, make([]byte, 14)...)
+		comments = append([]byte("\x03vorbis"), vorbisComments("TITLE=Long Song", "ALBUM=Demos", "DATE=2008")...)
+		comments = append(comments, bytes.Repeat([]byte{0}, 300)...)
+		granule = 44100 * 30
+	}
+	return bytes.Join([][]byte{
+		oggPage(7, 0, id),
+		oggPage(7, 0, comments),
+		oggPage(8, 99999999, []byte("other stream")),
+		oggPage(7, granule, make([]byte, 100)),
+	}, nil)
+}
+
+func testM4A() []byte {
+	ilst := isoBoxBytes("ilst",
+		isoBoxBytes("\xa9nam", mp4Data(1, []byte("So What"))),
+		isoBoxBytes("\xa9ART", mp4Data(1, []byte("Miles Davis"))),
+		isoBoxBytes("\xa9alb", mp4Data(1, []byte("Kind of Blue"))),
+		isoBoxBytes("\xa9day", mp4Data(1, []byte("1959-08-17T07:00:00Z"))),
+		isoBoxBytes("trkn", mp4Data(0, []byte{0, 0, 0, 1, 0, 5, 0, 0})),
+		isoBoxBytes("covr", mp4Data(13, make([]byte, 64))),
+	)
+	meta := isoBoxBytes("meta", make([]byte, 4), isoBoxBytes("hdlr", make([]byte, 25)), ilst)
+	return bytes.Join([][]byte{
+		isoBoxBytes("ftyp", []byte("M4A \x00\x00\x00\x00")),
+		isoBoxBytes("moov", mp4MovieHeader(3660779045, 44100, 44100*562), mp4Track(0, 0), isoBoxBytes("udta", meta)),
+		isoBoxBytes("mdat", make([]byte, 64)),
+	}, nil)
+}
+
+func testMOV() []byte {
+	key := func(name string) []byte {
+		return append(binary.BigEndian.AppendUint32(nil, uint32(8+len(name))), append([]byte("mdta"), name...)...)
+	}
+	keys := isoBoxBytes("keys", []byte{0, 0, 0, 0, 0, 0, 0, 2},
+		key("com.apple.quicktime.make"), key("com.apple.quicktime.creationdate"))
+	ilst := isoBoxBytes("ilst",
+		isoBoxBytes("\x00\x00\x00\x01", mp4Data(1, []byte("Apple"))),
+		isoBoxBytes("\x00\x00\x00\x02", mp4Data(1, []byte("2021-08-09T10:11:12+0200"))),
+	)
+	udta := isoBoxBytes("udta", isoBoxBytes("\xa9nam", []byte{0, 7, 0x15, 0xC7}, []byte("Holiday")))
+	return bytes.Join([][]byte{
+		isoBoxBytes("ftyp", []byte("qt  \x00\x00\x02\x00")),
+		isoBoxBytes("wide"),
+		isoBoxBytes("mdat", make([]byte, 64)),
+		isoBoxBytes("moov",
+			mp4MovieHeader(3711003072, 600, 600*42+300),
+			mp4Track(1920, 1080), mp4Track(0, 0),
+			isoBoxBytes("meta", isoBoxBytes("hdlr", make([]byte, 25)), keys, ilst),
+			udta),
+	}, nil)
+}
+
+func TestReadMediaMetadata(t *testing.T) {
+	dir := t.TempDir()
+	cbr := append(bytes.Repeat(mpegFrame(418, 0), 500), id3v1Tag("Old Song", "Someone", "Tape", "1984", 2)...)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		mimeType string
+		want     map[string]string
+	}{
+		{"song.mp3", testMP3(), MimeMP3, map[string]string{
+			MediaTitle: "Blue Monday", MediaArtist: "Björk", MediaAlbum: "Début", MediaTrack: "3",
+			MediaGenre: "Rock", MediaCreated: "1993", MediaDuration: "52",
+		}},
+		{"cbr.mp3", cbr, MimeMP3, map[string]string{
+			MediaTitle: "Old Song", MediaArtist: "Someone", MediaAlbum: "Tape", MediaTrack: "2",
+			MediaCreated: "1984", MediaDuration: "13",
+		}},
+		{"song.flac", testFLAC(), MimeFLAC, map[string]string{
+			MediaTitle: "Weightless", MediaArtist: "Marconi Union", MediaAlbum: "Weightless", MediaTrack: "1",
+			MediaGenre: "Ambient", MediaCreated: "2011-11-14T00:00:00", MediaDuration: "200",
+		}},
+		{"tagged.flac", append(id3Tag(4, id3Frame(4, "TIT2", []byte("\x03Ignored"))), testFLAC()...), MimeFLAC, map[string]string{
+			MediaTitle: "Weightless", MediaArtist: "Marconi Union", MediaAlbum: "Weightless", MediaTrack: "1",
+			MediaGenre: "Ambient", MediaCreated: "2011-11-14T00:00:00", MediaDuration: "200",
+		}},
+		{"song.ogg", testOgg(false), MimeOgg, map[string]string{
+			MediaTitle: "Long Song", MediaAlbum: "Demos", MediaCreated: "2008", MediaDuration: "30",
+		}},
+		{"memo.opus", testOgg(true), MimeOgg, map[string]string{
+			MediaTitle: "Voice Memo", MediaArtist: "Me", MediaDuration: "5",
+		}},
+		{"song.m4a", testM4A(), MimeM4A, map[string]string{
+			MediaTitle: "So What", MediaArtist: "Miles Davis", MediaAlbum: "Kind of Blue", MediaTrack: "1",
+			MediaCreated: "1959-08-17T07:00:00+00:00", MediaDuration: "562",
+		}},
+		{"clip.mov", testMOV(), MimeQuickTime, map[string]string{
+			MediaTitle: "Holiday", MediaCreated: "2021-08-09T10:11:12+02:00", MediaDuration: "43",
+			MediaWidth: "1920", MediaHeight: "1080",
+		}},
+		{"notes.txt", []byte("hello"), "text/plain", map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readMediaMetadata(path, tt.mimeType)
+			if err != nil {
+				t.Fatalf("readMediaMetadata() error = %v", err)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("readMediaMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadMediaMetadata_MovieHeaderDate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	data := bytes.Join([][]byte{
+		isoBoxBytes("ftyp", []byte("isom\x00\x00\x02\x00")),
+		isoBoxBytes("moov", mp4MovieHeader(3660779045, 1000, 1500), mp4Track(3840, 2160)),
+	}, nil)
+	os.WriteFile(path, data, 0644)
+
+	got, err := readMediaMetadata(path, MimeMP4)
+	if err != nil {
+		t.Fatalf("readMediaMetadata() error = %v", err)
+	}
+	if got[MediaCreated] != "2020-01-02T03:04:05+00:00" || got[MediaDuration] != "2" || got[MediaWidth] != "3840" {
+		t.Errorf("readMediaMetadata() = %v", got)
+	}
+}
+
+func TestReadMediaMetadata_Damaged(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"cut.m4a":   testM4A()[:120],
+		"cut.mov":   testMOV()[:200],
+		"cut.flac":  testFLAC()[:60],
+		"cut.ogg":   testOgg(false)[:50],
+		"cut.mp3":   testMP3()[:40],
+		"empty.mp3": nil,
+	}
+	types := map[string]string{".m4a": MimeM4A, ".mov": MimeQuickTime, ".flac": MimeFLAC, ".ogg": MimeOgg, ".mp3": MimeMP3}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, data, 0644)
+		// Damaged files must not panic and still yield a map
+		if got, _ := readMediaMetadata(path, types[filepath.Ext(name)]); got == nil {
+			t.Errorf("readMediaMetadata(%s) = nil", name)
+		}
+	}
+}
+
+func TestDetectType_Media(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"a.mp3":  testMP3(),
+		"b.mp3":  mpegFrame(418, 0),
+		"a.flac": testFLAC(),
+		"b.flac": append(id3Tag(3), testFLAC()...),
+		"a.ogg":  testOgg(false),
+		"a.m4a":  testM4A(),
+		"a.mov":  testMOV(),
+		"a.mp4":  isoBoxBytes("ftyp", []byte("mp42\x00\x00\x00\x00")),
+	}
+	want := map[string]string{
+		"a.mp3":  MimeMP3,
+		"b.mp3":  MimeMP3,
+		"a.flac": MimeFLAC,
+		"b.flac": MimeFLAC,
+		"a.ogg":  MimeOgg,
+		"a.m4a":  MimeM4A,
+		"a.mov":  MimeQuickTime,
+		"a.mp4":  MimeMP4,
+	}
+
+	fa := NewAnalyzer()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, data, 0644)
+		got, err := fa.DetectType(path)
+		if err != nil || got != want[name] {
+			t.Errorf("DetectType(%s) = %q, %v, want %q", name, got, err, want[name])
+		}
+	}
+}
+
+func TestAnalyze_MediaMetadata(t *testing.T) {
+	dir := t.TempDir()
+	song := filepath.Join(dir, "track03.mp3")
+	os.WriteFile(song, testMP3(), 0644)
+	clip := filepath.Join(dir, "IMG_4821.MOV")
+	os.WriteFile(clip, testMOV(), 0644)
+
+	fa := NewAnalyzer()
+	tests := []struct {
+		path string
+		want map[string]string
+	}{
+		{song, map[string]string{
+			FieldArtist: "Björk", FieldAlbum: "Début", FieldTitle: "Blue Monday", FieldTrack: "3",
+			FieldGenre: "Rock", FieldDuration: "52", FieldCreated: "1993", FieldCreatedYear: "1993",
+			FieldCreatedMonth: "", FieldResolution: "",
+		}},
+		{clip, map[string]string{
+			FieldTitle: "Holiday", FieldResolution: "1920x1080", FieldWidth: "1920", FieldHeight: "1080",
+			FieldCreatedYear: "2021", FieldCreatedMonth: "08", FieldCreatedDay: "09", FieldArtist: "",
+		}},
+	}
+	for _, tt := range tests {
+		metadata, err := fa.Analyze(context.Background(), tt.path)
+		if err != nil {
+			t.Fatalf("Analyze(%s) error = %v", tt.path, err)
+		}
+		fields := metadata.Fields()
+		for name, want := range tt.want {
+			if fields[name] != want {
+				t.Errorf("%s: Fields()[%s] = %q, want %q", filepath.Base(tt.path), name, fields[name], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package analyzer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// mp4Epoch is the start of MP4 and QuickTime timestamps, 1904-01-01 UTC, in
+// Unix seconds
+const mp4Epoch = -2082844800
+
+// mp4Items map iTunes-style item atoms and QuickTime user data atoms to
+// MediaData keys
+var mp4Items = map[string]string{
+	"\xa9nam": MediaTitle,
+	"\xa9ART": MediaArtist,
+	"\xa9alb": MediaAlbum,
+	"\xa9gen": MediaGenre,
+	"\xa9day": MediaCreated,
+	"trkn":    MediaTrack,
+}
+
+// mp4Keys map QuickTime metadata keys to MediaData keys
+var mp4Keys = map[string]string{
+	"com.apple.quicktime.creationdate": MediaCreated,
+	"com.apple.quicktime.title":        MediaTitle,
+	"com.apple.quicktime.artist":       MediaArtist,
+	"com.apple.quicktime.album":        MediaAlbum,
+	"com.apple.quicktime.genre":        MediaGenre,
+}
+
+// readMP4Metadata reads the tags, duration, creation time and picture size
+// of an MP4, M4A or QuickTime file
+func readMP4Metadata(f *os.File, size int64, data map[string]string) error {
+	boxes, err := readISOBoxes(f, 0, size)
+	moov, ok := findISOBox(boxes, "moov")
+	if !ok {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("file has no movie box")
+	}
+	children, err := readISOChildren(f, moov, 0)
+	if err != nil && len(children) == 0 {
+		return err
+	}
+
+	// Tags come first: QuickTime's creation date keeps the local zone
+	// offset that the movie header's UTC time lacks
+	for _, b := range children {
+		switch b.typ {
+		case "meta":
+			readMP4Meta(f, b, data)
+		case "udta":
+			udta, _ := readISOChildren(f, b, 0)
+			for _, u := range udta {
+				if u.typ == "meta" {
+					readMP4Meta(f, u, data)
+				} else if key, ok := mp4Items[u.typ]; ok {
+					readQuickTimeText(f, u, key, data)
+				}
+			}
+		}
+	}
+
+	var width, height uint64
+	for _, b := range children {
+		switch b.typ {
+		case "mvhd":
+			readMP4MovieHeader(f, b, data)
+		case "trak":
+			trak, _ := readISOChildren(f, b, 0)
+			if tkhd, ok := findISOBox(trak, "tkhd"); ok {
+				if w, h := readMP4TrackSize(f, tkhd); w*h > width*height {
+					width, height = w, h
+				}
+			}
+		}
+	}
+	if width > 0 && height > 0 {
+		data[MediaWidth] = strconv.FormatUint(width, 10)
+		data[MediaHeight] = strconv.FormatUint(height, 10)
+	}
+	return nil
+}
+
+// readMP4MovieHeader reads the creation time and duration of an mvhd box
+func readMP4MovieHeader(f *os.File, mvhd isoBox, data map[string]string) {
+	payload, err := readISOPayload(f, mvhd, 1024)
+	if err != nil {
+		return
+	}
+	r := &beReader{b: payload}
+	fieldSize := 4
+	if r.uint(1) == 1 {
+		fieldSize = 8
+	}
+	r.uint(3) // Flags
+	created := r.uint(fieldSize)
+	r.uint(fieldSize) // Modification time
+	timescale := r.uint(4)
+	duration := r.uint(fieldSize)
+	if r.bad {
+		return
+	}
+
+	if created > 0 && created < 1<<40 {
+		setMedia(data, MediaCreated, time.Unix(int64(created)+mp4Epoch, 0).UTC().Format(time.RFC3339))
+	}
+	if timescale > 0 && duration != 1<<(8*fieldSize)-1 {
+		setDuration(data, float64(duration)/float64(timescale))
+	}
+}
+
+// readMP4TrackSize returns the presentation size of a track from its tkhd
+// box; audio tracks have none
+func readMP4TrackSize(f *os.File, tkhd isoBox) (uint64, uint64) {
+	payload, err := readISOPayload(f, tkhd, 1024)
+	if err != nil {
+		return 0, 0
+	}
+	r := &beReader{b: payload}
+	skip := 20 // Times, track ID, reserved and duration of version 0
+	if r.uint(1) == 1 {
+		skip = 32
+	}
+	r.uint(3) // Flags
+	r.bytes(skip + 52)
+	// 16.16 fixed-point numbers
+	width, height := r.uint(4)>>16, r.uint(4)>>16
+	if r.bad {
+		return 0, 0
+	}
+	return width, height
+}
+
+// readMP4Meta reads the item list of a meta box, either iTunes-style with
+// four-character item atoms or QuickTime-style with a keys table
+func readMP4Meta(f *os.File, meta isoBox, data map[string]string) {
+	// The iTunes meta box is a full box; QuickTime's is a plain box
+	var head [4]byte
+	if _, err := f.ReadAt(head[:], meta.offset); err != nil {
+		return
+	}
+	skip := int64(0)
+	if binary.BigEndian.Uint32(head[:]) == 0 {
+		skip = 4
+	}
+	children, _ := readISOChildren(f, meta, skip)
+
+	var keys []string
+	if k, ok := findISOBox(children, "keys"); ok {
+		if payload, err := readISOPayload(f, k, maxMetadataSegment); err == nil {
+			r := &beReader{b: payload}
+			r.uint(4) // Version and flags
+			count := r.uint(4)
+			for i := uint64(0); i < count && !r.bad; i++ {
+				size := int(r.uint(4))
+				r.uint(4) // Namespace
+				keys = append(keys, string(r.bytes(size-8)))
+			}
+		}
+	}
+
+	ilst, ok := findISOBox(children, "ilst")
+	if !ok {
+		return
+	}
+	items, _ := readISOChildren(f, ilst, 0)
+	for _, item := range items {
+		key, ok := mp4Items[item.typ]
+		if !ok && keys != nil {
+			// Items of a keys table are named by their 1-based index
+			index := int(binary.BigEndian.Uint32([]byte(item.typ)))
+			if index >= 1 && index <= len(keys) {
+				key, ok = mp4Keys[keys[index-1]]
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		values, _ := readISOChildren(f, item, 0)
+		dataBox, found := findISOBox(values, "data")
+		if !found {
+			continue
+		}
+		payload, err := readISOPayload(f, dataBox, 64*1024)
+		if err != nil || len(payload) < 8 {
+			continue
+		}
+		value := payload[8:] // After the type indicator and locale
+		if key == MediaTrack {
+			// Track numbers are binary: reserved, track, track count
+			if len(value) >= 4 {
+				setMedia(data, key, strconv.Itoa(int(binary.BigEndian.Uint16(value[2:]))))
+			}
+			continue
+		}
+		setMedia(data, key, string(value))
+	}
+}
+
+// readQuickTimeText reads a QuickTime user data text atom, which holds
+// strings prefixed by their size and language
+func readQuickTimeText(f *os.File, atom isoBox, key string, data map[string]string) {
+	payload, err := readISOPayload(f, atom, 64*1024)
+	if err != nil {
+		return
+	}
+	r := &beReader{b: payload}
+	size := int(r.uint(2))
+	r.uint(2) // Language
+	if text := r.bytes(size); !r.bad {
+		setMedia(data, key, string(text))
+	}
+}
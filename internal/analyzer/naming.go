@@ -0,0 +1,376 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DefaultNameThreshold is the score below which a file needs a smarter name
+const DefaultNameThreshold = 60
+
+// meaninglessBelow is the score below which a name is meaningless rather
+// than generic
+const meaninglessBelow = 30
+
+// Score adjustments of the built-in checks
+const (
+	penaltyPlaceholder = 80 // Only placeholder words such as "untitled"
+	penaltyGeneric     = 50 // Only generic words such as "data"
+	penaltyScheme      = 75 // Camera, phone, screenshot or app naming scheme
+	penaltyRandom      = 75 // Hexadecimal identifier or UUID
+	penaltyShort       = 75 // One or two characters
+	penaltyDigits      = 75 // More than 70% digits
+	penaltySomeDigits  = 30 // More than 50% digits
+	penaltyCopy        = 15 // Copy marker such as " (2)" or "- 副本"
+)
+
+// NameScore is the assessment of a filename: a score from 0 (meaningless)
+// to 100 (clear and specific) and the reasons it lost or gained points
+type NameScore struct {
+	Score   int
+	Quality FileNameQuality
+	Reasons []string
+}
+
+// NameRule adjusts the score of names matching a pattern. Glob patterns
+// match the whole filename, regular expressions any part of it; both
+// ignore case.
+type NameRule struct {
+	Pattern string
+	Regex   bool
+	Score   int    // Added to the score, negative to lower it
+	Reason  string // Shown in the reasons, defaults to the pattern
+}
+
+// NamingOptions configures a NameScorer
+type NamingOptions struct {
+	Threshold    int        // Names scoring below it need a smarter name (default DefaultNameThreshold)
+	Locales      []string   // Locales whose generic words apply, e.g. "en", "zh" (default all)
+	Placeholders []string   // Extra words that leave a name meaningless on their own
+	GenericWords []string   // Extra words that leave a name generic on their own
+	Rules        []NameRule // Extra patterns, applied after the built-in checks
+}
+
+// localeWords are the words that say nothing about a file in a language
+type localeWords struct {
+	placeholders []string
+	generic      []string
+}
+
+// NameLocales lists the locales with built-in generic words
+var NameLocales = []string{"en", "zh", "ja", "de", "fr", "es"}
+
+// builtinWords are the generic words of each locale
+var builtinWords = map[string]localeWords{
+	"en": {
+		placeholders: []string{"untitled", "unnamed", "noname", "new", "file", "document", "image", "photo", "picture", "pic", "img",
+			"download", "screenshot", "screen", "shot", "temp", "tmp", "test", "copy", "scan", "video", "audio", "recording",
+			"export", "output", "sample", "default", "misc", "stuff", "asdf", "foo", "bar"},
+		generic: []string{"doc", "docs", "data", "report", "notes", "note", "info", "backup", "archive", "text", "summary",
+			"final", "draft", "version", "v", "old"},
+	},
+	"zh": {
+		placeholders: []string{"新建", "新建文档", "新建文本文档", "新建文件夹", "无标题", "未命名", "未命名文档", "屏幕截图", "截图", "截屏",
+			"副本", "图片", "照片", "下载", "文件", "文档", "临时", "测试"},
+		generic: []string{"资料", "数据", "报告", "笔记", "记录", "备份", "文本", "总结", "最终版", "草稿"},
+	},
+	"ja": {
+		placeholders: []string{"無題", "新規", "新規ドキュメント", "名称未設定", "画像", "写真", "スクリーンショット", "コピー", "ファイル", "ダウンロード"},
+		generic:      []string{"資料", "データ", "レポート", "メモ"},
+	},
+	"de": {
+		placeholders: []string{"unbenannt", "neu", "neues", "dokument", "bild", "datei", "kopie", "bildschirmfoto"},
+		generic:      []string{"daten", "bericht", "notizen"},
+	},
+	"fr": {
+		placeholders: []string{"sans", "titre", "nouveau", "document", "image", "fichier", "copie", "capture"},
+		generic:      []string{"données", "rapport", "notes"},
+	},
+	"es": {
+		placeholders: []string{"sin", "título", "nuevo", "documento", "imagen", "archivo", "copia", "captura"},
+		generic:      []string{"datos", "informe", "notas"},
+	},
+}
+
+// nameStopwords do not count for or against a name
+var nameStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "of": true, "at": true, "on": true, "in": true, "from": true, "and": true,
+	"de": true, "la": true, "le": true, "der": true, "die": true, "das": true, "von": true, "el": true, "d": true,
+}
+
+// nameSchemes are file names given by cameras, phones and apps, which say
+// nothing about the content
+var nameSchemes = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"camera", regexp.MustCompile(`^(?:img|dsc|dscn|dscf|dcim|sam|pict|imag|mvimg|vid|mov|gopr|gh\d\d|dji|pxl)[_-]?\d`)},
+	{"camera", regexp.MustCompile(`^p\d{7}$`)},
+	{"phone", regexp.MustCompile(`^\d{8}[_-]\d{6}`)},
+	{"screenshot", regexp.MustCompile(`^(?:screenshot|screen ?shot|屏幕截图|截屏|截图|スクリーンショット|capture d'écran|bildschirmfoto)[ _-]*\d`)},
+	{"messaging app", regexp.MustCompile(`^(?:wechat ?image|微信图片|mmexport|wx_camera|qq图片|signal|whatsapp (?:image|video)|telegram|photo|video)[ _-]*\d`)},
+	{"scanner", regexp.MustCompile(`^(?:scan|scanned|scanner)[ _-]?\d`)},
+}
+
+var (
+	randomName  = regexp.MustCompile(`^(?:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[0-9a-f]{12,})$`)
+	copyMarkers = regexp.MustCompile(`(?i)(?:\s*\(\d+\)$|[ _-]copy(?: \d+)?$|^copy of |\s*-?\s*副本(?:\s*\(\d+\))?$|\s*-\s*コピー$|\s*-\s*kopie$|\s*-\s*copie$)`)
+)
+
+// nameRule is a compiled NameRule
+type nameRule struct {
+	NameRule
+	re *regexp.Regexp
+}
+
+// NameScorer scores filenames by how much they say about a file's content
+type NameScorer struct {
+	threshold    int
+	placeholders map[string]bool
+	generic      map[string]bool
+	rules        []nameRule
+}
+
+// sharedNameScorer serves analyzers without a scorer of their own
+var sharedNameScorer = sync.OnceValue(DefaultNameScorer)
+
+// DefaultNameScorer returns a scorer with the built-in words of all locales
+// and the default threshold
+func DefaultNameScorer() *NameScorer {
+	s, _ := NewNameScorer(NamingOptions{})
+	return s
+}
+
+// NewNameScorer creates a scorer from options. It fails for unknown
+// locales and invalid patterns.
+func NewNameScorer(opts NamingOptions) (*NameScorer, error) {
+	s := &NameScorer{
+		threshold:    opts.Threshold,
+		placeholders: make(map[string]bool),
+		generic:      make(map[string]bool),
+	}
+	if s.threshold <= 0 {
+		s.threshold = DefaultNameThreshold
+	}
+
+	locales := opts.Locales
+	if len(locales) == 0 {
+		locales = NameLocales
+	}
+	for _, locale := range locales {
+		words, ok := builtinWords[strings.ToLower(locale)]
+		if !ok {
+			return nil, fmt.Errorf("unknown naming locale %q", locale)
+		}
+		addWords(s.placeholders, words.placeholders)
+		addWords(s.generic, words.generic)
+	}
+	addWords(s.placeholders, opts.Placeholders)
+	addWords(s.generic, opts.GenericWords)
+
+	for _, rule := range opts.Rules {
+		r := nameRule{NameRule: rule}
+		if rule.Regex {
+			re, err := regexp.Compile("(?i)" + rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid name pattern %q: %w", rule.Pattern, err)
+			}
+			r.re = re
+		} else if _, err := filepath.Match(rule.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %w", rule.Pattern, err)
+		}
+		s.rules = append(s.rules, r)
+	}
+	return s, nil
+}
+
+// addWords adds lowercased words to a set
+func addWords(set map[string]bool, words []string) {
+	for _, w := range words {
+		set[strings.ToLower(strings.TrimSpace(w))] = true
+	}
+}
+
+// Threshold returns the score below which a file needs a smarter name
+func (s *NameScorer) Threshold() int {
+	return s.threshold
+}
+
+// Score assesses a filename
+func (s *NameScorer) Score(filename string) NameScore {
+	name := strings.TrimSpace(strings.TrimSuffix(filename, filepath.Ext(filename)))
+	lower := strings.ToLower(name)
+
+	result := NameScore{Score: 100}
+	penalize := func(points int, reason string) {
+		result.Score -= points
+		result.Reasons = append(result.Reasons, fmt.Sprintf("-%d %s", points, reason))
+	}
+
+	if name == "" {
+		penalize(100, "no name")
+	} else {
+		s.checkWords(lower, penalize)
+		for _, scheme := range nameSchemes {
+			if scheme.pattern.MatchString(lower) {
+				penalize(penaltyScheme, scheme.name+" naming scheme")
+				break
+			}
+		}
+		if randomName.MatchString(lower) && strings.ContainsAny(lower, "0123456789") {
+			penalize(penaltyRandom, "random identifier")
+		}
+		if nameWidth(name) <= 2 {
+			penalize(penaltyShort, "too short")
+		}
+		if ratio := digitRatio(lower); ratio > 0.7 {
+			penalize(penaltyDigits, "mostly digits")
+		} else if ratio > 0.5 {
+			penalize(penaltySomeDigits, "many digits")
+		}
+		if copyMarkers.MatchString(name) {
+			penalize(penaltyCopy, "copy marker")
+		}
+	}
+
+	for _, rule := range s.rules {
+		if !rule.matches(filename) {
+			continue
+		}
+		reason := rule.Reason
+		if reason == "" {
+			reason = "matches " + rule.Pattern
+		}
+		result.Score += rule.Score
+		result.Reasons = append(result.Reasons, fmt.Sprintf("%+d %s", rule.Score, reason))
+	}
+
+	result.Score = max(0, min(100, result.Score))
+	result.Quality = s.quality(result.Score)
+	return result
+}
+
+// checkWords penalizes names made only of placeholder and generic words
+func (s *NameScorer) checkWords(lower string, penalize func(int, string)) {
+	var words []string
+	for _, token := range nameTokens(lower) {
+		if !isDigits(token) && !nameStopwords[token] {
+			words = append(words, token)
+		}
+	}
+	if len(words) == 0 {
+		return
+	}
+
+	placeholder := s.placeholders[strings.Join(words, " ")]
+	if !placeholder {
+		for _, w := range words {
+			if !s.placeholders[w] && !s.generic[w] {
+				return // A word that says something
+			}
+			placeholder = placeholder || s.placeholders[w]
+		}
+	}
+	if placeholder {
+		penalize(penaltyPlaceholder, fmt.Sprintf("placeholder name %q", strings.Join(words, " ")))
+	} else {
+		penalize(penaltyGeneric, fmt.Sprintf("generic name %q", strings.Join(words, " ")))
+	}
+}
+
+// quality maps a score to the quality of a name: good from the threshold
+// up, meaningless at the bottom and generic in between
+func (s *NameScorer) quality(score int) FileNameQuality {
+	switch {
+	case score >= s.threshold:
+		return FileNameGood
+	case score < min(s.threshold, meaninglessBelow):
+		return FileNameMeaningless
+	default:
+		return FileNameGeneric
+	}
+}
+
+// matches reports whether a rule applies to a filename
+func (r nameRule) matches(filename string) bool {
+	if r.re != nil {
+		return r.re.MatchString(filename)
+	}
+	ok, _ := filepath.Match(strings.ToLower(r.Pattern), strings.ToLower(filename))
+	return ok
+}
+
+// nameTokens splits a lowercased name into runs of digits, of letters and
+// of CJK characters
+func nameTokens(name string) []string {
+	var tokens []string
+	var current []rune
+	class := 0
+	flush := func() {
+		if len(current) > 0 {
+			tokens = append(tokens, string(current))
+			current = current[:0]
+		}
+	}
+	for _, r := range name {
+		c := 0
+		switch {
+		case r >= '0' && r <= '9':
+			c = 1
+		case isCJK(r):
+			c = 2
+		case unicode.IsLetter(r) || r == '\'':
+			c = 3
+		}
+		if c != class {
+			flush()
+			class = c
+		}
+		if c != 0 {
+			current = append(current, r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// nameWidth returns the length of a name, counting CJK characters, which
+// carry a word or syllable each, twice
+func nameWidth(name string) int {
+	width := 0
+	for _, r := range name {
+		width++
+		if isCJK(r) {
+			width++
+		}
+	}
+	return width
+}
+
+// isCJK reports whether r is a Chinese, Japanese or Korean character
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) || r == 'ー'
+}
+
+// digitRatio returns the share of digits among the characters of a name
+// other than separators
+func digitRatio(name string) float64 {
+	digits, total := 0, 0
+	for _, r := range name {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case r == '-' || r == '_' || r == '.' || unicode.IsSpace(r):
+			continue
+		}
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(digits) / float64(total)
+}
@@ -0,0 +1,133 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNameScorer_Quality(t *testing.T) {
+	s := DefaultNameScorer()
+
+	tests := map[string]FileNameQuality{
+		// Words that merely start like a placeholder
+		"testimony.pdf":        FileNameGood,
+		"imgui-notes.md":       FileNameGood,
+		"copyright.txt":        FileNameGood,
+		"temperature-log.csv":  FileNameGood,
+		"test-results-q3.xlsx": FileNameGood,
+		"budget copy.xlsx":     FileNameGood,
+
+		// Camera, phone and app schemes
+		"IMG_20240101_123456.jpg":               FileNameMeaningless,
+		"DSC01234.JPG":                          FileNameMeaningless,
+		"PXL_20231105_091522123.jpg":            FileNameMeaningless,
+		"P1010001.JPG":                          FileNameMeaningless,
+		"Screenshot 2024-03-01 at 10.00.00.png": FileNameMeaningless,
+		"微信图片_20240101123456.jpg":               FileNameMeaningless,
+		"mmexport1700000000000.jpg":             FileNameMeaningless,
+
+		// Placeholders in several languages
+		"Untitled (3).docx": FileNameMeaningless,
+		"新建文本文档.txt":        FileNameMeaningless,
+		"无标题.png":           FileNameMeaningless,
+		"名称未設定.txt":         FileNameMeaningless,
+		"Sans titre.odt":    FileNameMeaningless,
+		"new file 2.txt":    FileNameMeaningless,
+		"3f2a9c1be4d5.bin":  FileNameMeaningless,
+		"final report.docx": FileNameGeneric,
+		"会议记录.docx":         FileNameGood,
+		"报告.pdf":            FileNameGeneric,
+	}
+	for name, want := range tests {
+		if got := s.Score(name); got.Quality != want {
+			t.Errorf("Score(%q) = %d %s %v, want %s", name, got.Score, got.Quality, got.Reasons, want)
+		}
+	}
+}
+
+func TestNameScorer_Reasons(t *testing.T) {
+	score := DefaultNameScorer().Score("Untitled (2).txt")
+	if score.Score != 5 {
+		t.Errorf("Score = %d, want 5", score.Score)
+	}
+	want := []string{`-80 placeholder name "untitled"`, "-15 copy marker"}
+	if strings.Join(score.Reasons, "; ") != strings.Join(want, "; ") {
+		t.Errorf("Reasons = %q, want %q", score.Reasons, want)
+	}
+
+	if score := DefaultNameScorer().Score("quarterly-budget.xlsx"); score.Score != 100 || len(score.Reasons) != 0 {
+		t.Errorf("Score(good) = %+v", score)
+	}
+}
+
+func TestNameScorer_Options(t *testing.T) {
+	s, err := NewNameScorer(NamingOptions{
+		Threshold:    80,
+		Locales:      []string{"en"},
+		Placeholders: []string{"scratch"},
+		Rules: []NameRule{
+			{Pattern: "README*", Score: 50, Reason: "project file"},
+			{Pattern: `^inv-\d+\.`, Regex: true, Score: -60, Reason: "invoice number"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewNameScorer() error = %v", err)
+	}
+
+	tests := map[string]FileNameQuality{
+		"scratch.txt":     FileNameMeaningless, // Extra placeholder
+		"inv-2024.pdf":    FileNameMeaningless, // Many digits and the rule lower the score to 10
+		"readme.md":       FileNameGood,
+		"无标题.png":         FileNameGood, // Chinese words are not loaded
+		"meeting v2.docx": FileNameGood,
+	}
+	for name, want := range tests {
+		if got := s.Score(name); got.Quality != want {
+			t.Errorf("Score(%q) = %d %s %v, want %s", name, got.Score, got.Quality, got.Reasons, want)
+		}
+	}
+
+	// The threshold decides where good names start
+	if got := s.Score("data notes.txt"); got.Score != 50 || got.Quality != FileNameGeneric {
+		t.Errorf("Score(generic) = %+v", got)
+	}
+	if got := s.Score("Readme.txt"); got.Score != 100 || got.Reasons[0] != "+50 project file" {
+		t.Errorf("Score(readme) = %+v", got)
+	}
+
+	invalid := []NamingOptions{
+		{Locales: []string{"xx"}},
+		{Rules: []NameRule{{Pattern: "[a"}}},
+		{Rules: []NameRule{{Pattern: "(", Regex: true}}},
+	}
+	for _, opts := range invalid {
+		if _, err := NewNameScorer(opts); err == nil {
+			t.Errorf("NewNameScorer(%+v) succeeded", opts)
+		}
+	}
+}
+
+func TestAnalyze_NameScorer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testimony.txt")
+	if err := os.WriteFile(path, []byte("statement"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fa := NewAnalyzer()
+	m, err := fa.Analyze(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.NeedsSmarterName || m.FileNameScore != 100 {
+		t.Errorf("testimony.txt: score %d, needs smarter name %v", m.FileNameScore, m.NeedsSmarterName)
+	}
+
+	s, _ := NewNameScorer(NamingOptions{Rules: []NameRule{{Pattern: "testimony*", Score: -60}}})
+	fa.SetNameScorer(s)
+	if m, _ = fa.Analyze(context.Background(), path); !m.NeedsSmarterName || m.FileNameQuality != FileNameGeneric {
+		t.Errorf("testimony.txt with a rule: score %d, quality %s", m.FileNameScore, m.FileNameQuality)
+	}
+}
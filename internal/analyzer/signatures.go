@@ -0,0 +1,335 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// MIME types recognized by the built-in signatures beyond the documents,
+// images and media types declared elsewhere
+const (
+	MimeZip       = "application/zip"
+	MimeJAR       = "application/java-archive"
+	MimeAPK       = "application/vnd.android.package-archive"
+	MimeGzip      = "application/gzip"
+	MimeBzip2     = "application/x-bzip2"
+	MimeXZ        = "application/x-xz"
+	MimeZstd      = "application/zstd"
+	Mime7z        = "application/x-7z-compressed"
+	MimeRAR       = "application/vnd.rar"
+	MimeTar       = "application/x-tar"
+	MimeELF       = "application/x-elf"
+	MimeSQLite    = "application/vnd.sqlite3"
+	MimeISO       = "application/x-iso9660-image"
+	MimeDMG       = "application/x-apple-diskimage"
+	MimeWAV       = "audio/wav"
+	MimeAVI       = "video/x-msvideo"
+	MimeMatroska  = "video/x-matroska"
+	MimeWebM      = "video/webm"
+	mimeUTF16Text = "text/plain; charset=utf-16"
+)
+
+const (
+	// signatureHeadSize is the length of the file start read for detection
+	signatureHeadSize = 4096
+	// maxSignatureAt is the furthest position from either end of a file
+	// that a signature may check
+	maxSignatureAt = 1 << 20
+)
+
+// Signature identifies a file type by the bytes at a fixed position
+type Signature struct {
+	MimeType   string
+	Offset     int64    // Position of Magic; negative offsets count from the end of the file
+	Magic      []byte   // Bytes expected at Offset
+	Mask       []byte   // Bits of Magic that must match; nil compares all of them
+	Extensions []string // Extensions (without the dot) the signature is limited to; empty for all files
+
+	// refine tells apart the formats sharing a magic number. It returns ""
+	// to let the following signatures try instead.
+	refine func(f *os.File, path string, head []byte) string
+}
+
+// matches reports whether b, the bytes at the signature's position,
+// carries the magic number
+func (s *Signature) matches(b []byte) bool {
+	if len(b) < len(s.Magic) {
+		return false
+	}
+	for i, c := range s.Magic {
+		mask := byte(0xFF)
+		if s.Mask != nil {
+			mask = s.Mask[i]
+		}
+		if b[i]&mask != c&mask {
+			return false
+		}
+	}
+	return true
+}
+
+// appliesTo reports whether the signature is checked for a file name
+func (s *Signature) appliesTo(path string) bool {
+	if len(s.Extensions) == 0 {
+		return true
+	}
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	for _, e := range s.Extensions {
+		if strings.EqualFold(strings.TrimPrefix(e, "."), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// SignatureDB detects file types by their magic numbers. Signatures are
+// checked from the most recently added to the oldest, so custom signatures
+// take precedence over the built-in ones.
+type SignatureDB struct {
+	mu         sync.RWMutex
+	signatures []Signature // Most recent first
+}
+
+// NewSignatureDB creates an empty signature database
+func NewSignatureDB() *SignatureDB {
+	return &SignatureDB{}
+}
+
+// DefaultSignatures creates a database with the built-in signatures for
+// common image, audio, video, document, archive, executable and disk
+// image formats
+func DefaultSignatures() *SignatureDB {
+	db := NewSignatureDB()
+	// Added in reverse order of precedence
+	for i := len(builtinSignatures) - 1; i >= 0; i-- {
+		if err := db.Add(builtinSignatures[i]); err != nil {
+			panic(err)
+		}
+	}
+	return db
+}
+
+// Add adds a signature, checked before all signatures added earlier
+func (db *SignatureDB) Add(sig Signature) error {
+	if sig.MimeType == "" {
+		return fmt.Errorf("signature has no MIME type")
+	}
+	if len(sig.Magic) == 0 {
+		return fmt.Errorf("signature for %s has no magic number", sig.MimeType)
+	}
+	if sig.Mask != nil && len(sig.Mask) != len(sig.Magic) {
+		return fmt.Errorf("signature for %s has a mask of %d bytes for %d magic bytes", sig.MimeType, len(sig.Mask), len(sig.Magic))
+	}
+	if sig.Offset < 0 && int64(len(sig.Magic)) > -sig.Offset {
+		return fmt.Errorf("signature for %s runs past the end of the file", sig.MimeType)
+	}
+	if sig.Offset > maxSignatureAt || sig.Offset < -maxSignatureAt {
+		return fmt.Errorf("signature for %s is more than %d bytes into the file", sig.MimeType, maxSignatureAt)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.signatures = append([]Signature{sig}, db.signatures...)
+	return nil
+}
+
+// Detect returns the MIME type of the open file at path, or "" if no
+// signature matches
+func (db *SignatureDB) Detect(f *os.File, path string) (string, error) {
+	db.mu.RLock()
+	signatures := db.signatures
+	db.mu.RUnlock()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	size := info.Size()
+
+	// Most signatures are at the start; others are read on demand
+	head, err := readAt(f, 0, min(signatureHeadSize, size))
+	if err != nil {
+		return "", fmt.Errorf("failed to read file header: %w", err)
+	}
+
+	for i := range signatures {
+		sig := &signatures[i]
+		if !sig.appliesTo(path) {
+			continue
+		}
+		off, end := sig.Offset, sig.Offset+int64(len(sig.Magic))
+		if off < 0 {
+			off, end = size+off, size+off+int64(len(sig.Magic))
+		}
+		if off < 0 || end > size {
+			continue
+		}
+		window := head[min(off, int64(len(head))):]
+		if end > int64(len(head)) {
+			if window, err = readAt(f, off, end-off); err != nil {
+				return "", fmt.Errorf("failed to read file: %w", err)
+			}
+		}
+		if !sig.matches(window) {
+			continue
+		}
+		if sig.refine == nil {
+			return sig.MimeType, nil
+		}
+		if mimeType := sig.refine(f, path, head); mimeType != "" {
+			return mimeType, nil
+		}
+	}
+	return "", nil
+}
+
+// readAt reads n bytes at off, or fewer if the file ends first
+func readAt(f *os.File, off, n int64) ([]byte, error) {
+	b := make([]byte, n)
+	read, err := f.ReadAt(b, off)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return b[:read], nil
+}
+
+// ParseMagic parses a magic number written in hexadecimal, where "??"
+// matches any byte, into the Magic and Mask of a Signature. Spaces between
+// bytes are allowed.
+func ParseMagic(s string) ([]byte, []byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+	if len(s) == 0 || len(s)%2 != 0 {
+		return nil, nil, fmt.Errorf("invalid magic number %q: want an even number of hex digits", s)
+	}
+	magic := make([]byte, len(s)/2)
+	var mask []byte
+	for i := 0; i < len(s); i += 2 {
+		if s[i:i+2] == "??" {
+			if mask == nil {
+				mask = bytes.Repeat([]byte{0xFF}, len(magic))
+			}
+			mask[i/2] = 0
+			continue
+		}
+		if _, err := hex.Decode(magic[i/2:i/2+1], []byte(s[i:i+2])); err != nil {
+			return nil, nil, fmt.Errorf("invalid magic number %q: %w", s, err)
+		}
+	}
+	return magic, mask, nil
+}
+
+// riffSignature matches RIFF files of a form type such as "WAVE"
+func riffSignature(form, mimeType string) Signature {
+	return Signature{
+		MimeType: mimeType,
+		Magic:    []byte("RIFF\x00\x00\x00\x00" + form),
+		Mask:     []byte("\xFF\xFF\xFF\xFF\x00\x00\x00\x00\xFF\xFF\xFF\xFF"),
+	}
+}
+
+// builtinSignatures are the signatures of DefaultSignatures, in order of
+// precedence
+var builtinSignatures = []Signature{
+	// Images
+	{MimeType: "image/jpeg", Magic: []byte{0xFF, 0xD8, 0xFF}},
+	{MimeType: "image/png", Magic: []byte("\x89PNG\r\n\x1a\n")},
+	{MimeType: "image/gif", Magic: []byte("GIF8")},
+	{MimeType: MimeTIFF, Magic: []byte("II*\x00"), refine: refineTIFF},
+	{MimeType: MimeTIFF, Magic: []byte("MM\x00*"), refine: refineTIFF},
+	riffSignature("WEBP", MimeWebP),
+	// ISO base media files: HEIF images, MP4 and QuickTime movies
+	{MimeType: MimeMP4, Offset: 4, Magic: []byte("ftyp"), refine: refineISOBMFF},
+
+	// Documents
+	{MimeType: MimePDF, Magic: []byte("%PDF")},
+	{MimeType: MimeRTF, Magic: []byte(`{\rtf`)},
+	{MimeType: MimeSQLite, Magic: []byte("SQLite format 3\x00")},
+
+	// Audio and video
+	{MimeType: MimeMP3, Magic: []byte("ID3"), refine: refineID3},
+	{MimeType: MimeFLAC, Magic: []byte("fLaC")},
+	{MimeType: MimeOgg, Magic: []byte("OggS")},
+	riffSignature("WAVE", MimeWAV),
+	riffSignature("AVI ", MimeAVI),
+	{MimeType: MimeMatroska, Magic: []byte{0x1A, 0x45, 0xDF, 0xA3}, refine: refineMatroska},
+
+	// Archives and compressed files
+	{MimeType: MimeZip, Magic: []byte("PK\x03\x04"), refine: refineZip},
+	{MimeType: MimeZip, Magic: []byte("PK\x05\x06")}, // Empty archive
+	{MimeType: MimeGzip, Magic: []byte{0x1F, 0x8B}},
+	{MimeType: MimeBzip2, Magic: []byte("BZh")},
+	{MimeType: MimeXZ, Magic: []byte("\xFD7zXZ\x00")},
+	{MimeType: MimeZstd, Magic: []byte{0x28, 0xB5, 0x2F, 0xFD}},
+	{MimeType: Mime7z, Magic: []byte("7z\xBC\xAF\x27\x1C")},
+	{MimeType: MimeRAR, Magic: []byte("Rar!\x1A\x07")},
+	{MimeType: MimeTar, Offset: 257, Magic: []byte("ustar")},
+
+	// Executables and disk images
+	{MimeType: MimeELF, Magic: []byte("\x7FELF")},
+	{MimeType: MimeISO, Offset: 32769, Magic: []byte("CD001")},
+	{MimeType: MimeDMG, Offset: -512, Magic: []byte("koly")},
+
+	// Text with a UTF-16 byte order mark, before MPEG frames that share
+	// the first bits
+	{MimeType: mimeUTF16Text, Magic: []byte{0xFF, 0xFE}},
+	{MimeType: mimeUTF16Text, Magic: []byte{0xFE, 0xFF}},
+	// MP3 without an ID3 tag starts with an MPEG audio frame
+	{MimeType: MimeMP3, Magic: []byte{0xFF, 0xE0}, Mask: []byte{0xFF, 0xE0}, refine: refineMPEG},
+}
+
+// refineTIFF tells camera raw formats built on TIFF apart by their extension
+func refineTIFF(_ *os.File, path string, _ []byte) string {
+	if raw, ok := rawImageTypes[strings.ToLower(filepath.Ext(path))]; ok {
+		return raw
+	}
+	return MimeTIFF
+}
+
+// refineISOBMFF picks the type of an ISO base media file by its major brand
+func refineISOBMFF(_ *os.File, _ string, head []byte) string {
+	if len(head) < 12 {
+		return ""
+	}
+	if mimeType, ok := heifBrands[string(head[8:12])]; ok {
+		return mimeType
+	}
+	return mp4Brands[string(head[8:12])]
+}
+
+// refineID3 tells MP3 files from FLAC files, which may also start with an
+// ID3 tag
+func refineID3(f *os.File, _ string, _ []byte) string {
+	var magic [4]byte
+	if _, err := f.ReadAt(magic[:], id3v2Size(f)); err == nil && string(magic[:]) == "fLaC" {
+		return MimeFLAC
+	}
+	return MimeMP3
+}
+
+// refineMatroska tells WebM files from other Matroska files by the document
+// type in their EBML header
+func refineMatroska(_ *os.File, _ string, head []byte) string {
+	if bytes.Contains(head[:min(len(head), 64)], []byte("webm")) {
+		return MimeWebM
+	}
+	return MimeMatroska
+}
+
+// refineZip tells ZIP-based documents and packages apart from plain archives
+func refineZip(_ *os.File, path string, _ []byte) string {
+	return detectZipDocument(path)
+}
+
+// refineMPEG checks the rest of an MPEG audio frame header
+func refineMPEG(_ *os.File, _ string, head []byte) string {
+	if isMPEGFrame(head) {
+		return MimeMP3
+	}
+	return ""
+}
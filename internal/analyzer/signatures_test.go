@@ -0,0 +1,185 @@
+package analyzer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectType_Signatures(t *testing.T) {
+	dir := t.TempDir()
+
+	tar := make([]byte, 1024)
+	copy(tar, "notes.txt")
+	copy(tar[257:], "ustar\x0000")
+	iso := make([]byte, 34*1024)
+	copy(iso[32769:], "CD001")
+	dmg := append(bytes.Repeat([]byte{0x78, 0xDA}, 600), make([]byte, 512)...)
+	copy(dmg[len(dmg)-512:], "koly")
+	mkv := []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x88matroska")
+	webm := []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x84webm")
+
+	files := map[string][]byte{
+		"a.wav":     []byte("RIFF\x24\x00\x00\x00WAVEfmt "),
+		"a.avi":     []byte("RIFF\x24\x00\x00\x00AVI LIST"),
+		"a.mkv":     mkv,
+		"a.webm":    webm,
+		"a.tar":     tar,
+		"a.gz":      {0x1F, 0x8B, 0x08, 0x00, 0, 0, 0, 0},
+		"a.bz2":     []byte("BZh91AY&SY"),
+		"a.xz":      []byte("\xFD7zXZ\x00\x00\x04"),
+		"a.zst":     {0x28, 0xB5, 0x2F, 0xFD, 0x04, 0x00},
+		"a.7z":      []byte("7z\xBC\xAF\x27\x1C\x00\x04"),
+		"a.rar":     []byte("Rar!\x1A\x07\x01\x00"),
+		"a.bin":     []byte("\x7FELF\x02\x01\x01\x00"),
+		"a.db":      []byte("SQLite format 3\x00\x10\x00"),
+		"a.iso":     iso,
+		"a.dmg":     dmg,
+		"empty.zip": []byte("PK\x05\x06" + string(make([]byte, 18))),
+		// An unknown ftyp brand is not taken for a movie
+		"a.unknown": isoBoxBytes("ftyp", []byte("zzzz\x00\x00\x00\x00")),
+	}
+	want := map[string]string{
+		"a.wav":     MimeWAV,
+		"a.avi":     MimeAVI,
+		"a.mkv":     MimeMatroska,
+		"a.webm":    MimeWebM,
+		"a.tar":     MimeTar,
+		"a.gz":      MimeGzip,
+		"a.bz2":     MimeBzip2,
+		"a.xz":      MimeXZ,
+		"a.zst":     MimeZstd,
+		"a.7z":      Mime7z,
+		"a.rar":     MimeRAR,
+		"a.bin":     MimeELF,
+		"a.db":      MimeSQLite,
+		"a.iso":     MimeISO,
+		"a.dmg":     MimeDMG,
+		"empty.zip": MimeZip,
+		"a.unknown": "application/octet-stream",
+	}
+
+	fa := NewAnalyzer()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, data, 0644)
+		got, err := fa.DetectType(path)
+		if err != nil || got != want[name] {
+			t.Errorf("DetectType(%s) = %q, %v, want %q", name, got, err, want[name])
+		}
+	}
+}
+
+func TestDetectType_ZipContainers(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		entries [][2]string
+		want    string
+	}{
+		{"lib.jar", [][2]string{{"META-INF/MANIFEST.MF", "Manifest-Version: 1.0\n"}, {"a/B.class", "\xCA\xFE\xBA\xBE"}}, MimeJAR},
+		{"app.apk", [][2]string{{"META-INF/MANIFEST.MF", "Manifest-Version: 1.0\n"}, {"AndroidManifest.xml", "\x03\x00"}}, MimeAPK},
+		{"report.docx", [][2]string{{"[Content_Types].xml", "<Types/>"}, {"word/document.xml", "<w:document/>"}}, MimeDOCX},
+		{"book.epub", [][2]string{{"mimetype", MimeEPUB}, {"META-INF/container.xml", "<container/>"}}, MimeEPUB},
+		{"photos.zip", [][2]string{{"a.jpg", "jpeg"}}, MimeZip},
+	}
+
+	fa := NewAnalyzer()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		writeZip(t, path, tt.entries)
+		got, err := fa.DetectType(path)
+		if err != nil || got != tt.want {
+			t.Errorf("DetectType(%s) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestSignatureDB_Custom(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, data, 0644)
+		return path
+	}
+	blend := write("scene.blend", []byte("BLENDER-v293REND"))
+	save := write("game.sav", []byte("\x00\x00SAVE\x01\x02"))
+	other := write("other.dat", []byte("\x00\x00SAVE\x01\x02"))
+	trailer := write("backup.vbk", append(bytes.Repeat([]byte{0xAA}, 100), "VBK!"...))
+	gz := write("bundle.tgz", []byte{0x1F, 0x8B, 0x08, 0x00})
+
+	fa := NewAnalyzer()
+	db := DefaultSignatures()
+	add := func(mimeType string, offset int64, magic string, exts ...string) {
+		t.Helper()
+		m, mask, err := ParseMagic(magic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Add(Signature{MimeType: mimeType, Offset: offset, Magic: m, Mask: mask, Extensions: exts}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("application/x-blender", 0, "424c454e444552 2d ?? 323933")
+	add("application/x-game-save", 2, "53415645", "sav")
+	add("application/x-veeam-backup", -4, "56424b21")
+	add("application/x-compressed-tar", 0, "1f8b", ".TGZ")
+	fa.SetSignatures(db)
+
+	for path, want := range map[string]string{
+		blend:   "application/x-blender",
+		save:    "application/x-game-save",
+		other:   "application/octet-stream",
+		trailer: "application/x-veeam-backup",
+		gz:      "application/x-compressed-tar",
+	} {
+		got, err := fa.DetectType(path)
+		if err != nil || got != want {
+			t.Errorf("DetectType(%s) = %q, %v, want %q", filepath.Base(path), got, err, want)
+		}
+	}
+
+	// Other analyzers keep the built-in signatures
+	if got, _ := NewAnalyzer().DetectType(gz); got != MimeGzip {
+		t.Errorf("DetectType() with default signatures = %q, want %q", got, MimeGzip)
+	}
+}
+
+func TestSignatureDB_AddInvalid(t *testing.T) {
+	db := NewSignatureDB()
+	for name, sig := range map[string]Signature{
+		"no MIME type": {Magic: []byte("x")},
+		"no magic":     {MimeType: "a/b"},
+		"short mask":   {MimeType: "a/b", Magic: []byte("xy"), Mask: []byte{0xFF}},
+		"past the end": {MimeType: "a/b", Offset: -2, Magic: []byte("xyz")},
+		"too far":      {MimeType: "a/b", Offset: 2 << 20, Magic: []byte("x")},
+		"too far back": {MimeType: "a/b", Offset: -(2 << 20), Magic: []byte("x")},
+	} {
+		if err := db.Add(sig); err == nil {
+			t.Errorf("Add(%s) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestParseMagic(t *testing.T) {
+	magic, mask, err := ParseMagic("52 49 46 46 ?? ?? ?? ?? 57 45 42 50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(magic[:4]) != "RIFF" || string(magic[8:]) != "WEBP" {
+		t.Errorf("magic = %x", magic)
+	}
+	if !bytes.Equal(mask, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF}) {
+		t.Errorf("mask = %x", mask)
+	}
+
+	if _, mask, _ := ParseMagic("7f454c46"); mask != nil {
+		t.Errorf("mask = %x, want nil without wildcards", mask)
+	}
+	for _, invalid := range []string{"", "7f4", "zz", "7f ?"} {
+		if _, _, err := ParseMagic(invalid); err == nil {
+			t.Errorf("ParseMagic(%q) succeeded, want an error", invalid)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/xuanyiying/cleanup-cli/internal/archive"
+)
+
+// Result is one file of a streamed directory analysis. With
+// ScanOptions.Archives the files inside archives follow the archive, with
+// paths like backup.zip!/photos/beach.jpg. Broken symbolic links and link
+// loops are delivered with an error wrapping walk.ErrBrokenLink or
+// walk.ErrLinkLoop.
+type Result struct {
+	Path     string
+	Metadata *FileMetadata // Nil if the file could not be analyzed
+	// Err is why the file could not be analyzed. A result with an error but
+	// no Path reports that the scan itself failed; it is the last result.
+	Err error
+}
+
+// Progress counts the files of a streamed directory analysis
+type Progress struct {
+	Discovered int  // Files found by the walk so far
+	Analyzed   int  // Files analyzed so far, including failures
+	Failed     int  // Files that could not be analyzed
+	Done       bool // The walk finished, so Discovered is the final count
+}
+
+// progressTracker counts files and reports each change to a callback, one
+// call at a time
+type progressTracker struct {
+	mu       sync.Mutex
+	progress Progress
+	report   func(Progress)
+}
+
+func (t *progressTracker) update(change func(p *Progress)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	change(&t.progress)
+	if t.report != nil {
+		t.report(t.progress)
+	}
+}
+
+// AnalyzeStream scans a directory and analyzes its files concurrently,
+// delivering each file on the returned channel as soon as it is analyzed.
+// The walk, the workers and the channel share a bounded buffer, so a slow
+// consumer holds the scan back instead of letting results pile up in
+// memory. The channel is closed when the scan finishes or ctx is
+// cancelled; consumers that stop reading early must cancel ctx.
+func (fa *FileAnalyzer) AnalyzeStream(ctx context.Context, path string, opts *ScanOptions) <-chan Result {
+	if opts == nil {
+		opts = &ScanOptions{
+			Recursive:     true,
+			IncludeHidden: false,
+			CalculateHash: true,
+			Workers:       4,
+		}
+	}
+
+	// Set defaults on a copy, the workers share it
+	scanOpts := *opts
+	if scanOpts.Workers <= 0 {
+		scanOpts.Workers = 4
+	}
+	if scanOpts.Buffer <= 0 {
+		scanOpts.Buffer = 2 * scanOpts.Workers
+	}
+
+	paths := make(chan string, scanOpts.Buffer)
+	results := make(chan Result, scanOpts.Buffer)
+	tracker := &progressTracker{report: scanOpts.OnProgress}
+
+	// Walk the directory, blocking while the workers are busy
+	var walkErr error
+	go func() {
+		defer close(paths)
+		walkErr = fa.walkFiles(ctx, path, &scanOpts, func(filePath string, err error) error {
+			if err != nil {
+				// Broken links and link loops fail without analysis
+				tracker.update(func(p *Progress) { p.Discovered++; p.Analyzed++; p.Failed++ })
+				select {
+				case results <- Result{Path: filePath, Err: err}:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			tracker.update(func(p *Progress) { p.Discovered++ })
+			select {
+			case paths <- filePath:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		tracker.update(func(p *Progress) { p.Done = true })
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < scanOpts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filePath := range paths {
+				if ctx.Err() != nil {
+					continue // Let the walk finish
+				}
+
+				metadata, err := fa.analyzeWithOptions(ctx, filePath, &scanOpts)
+				tracker.update(func(p *Progress) {
+					p.Analyzed++
+					if err != nil {
+						p.Failed++
+					}
+				})
+
+				// Archives are listed before they are delivered so that
+				// their metadata carries the entries
+				var archived []Result
+				if err == nil && scanOpts.Archives && archive.DetectFormat(filePath) != "" {
+					files, listErr := fa.analyzeArchive(ctx, metadata, &scanOpts)
+					if listErr != nil {
+						archived = append(archived, Result{Path: archive.EntryPath(filePath, ""), Err: listErr})
+					}
+					for _, file := range files {
+						archived = append(archived, Result{Path: file.Path, Metadata: file})
+					}
+				}
+
+				for _, result := range append([]Result{{Path: filePath, Metadata: metadata, Err: err}}, archived...) {
+					select {
+					case results <- result:
+					case <-ctx.Done():
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(results)
+		wg.Wait()
+		// The walk has ended: the workers only stop once paths is closed
+		if walkErr != nil && ctx.Err() == nil {
+			select {
+			case results <- Result{Err: fmt.Errorf("failed to scan directory: %w", walkErr)}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return results
+}
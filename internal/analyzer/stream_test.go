@@ -0,0 +1,179 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/walk"
+)
+
+func TestAnalyzeStream(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Mkdir(filepath.Join(tmpDir, "sub"), 0755)
+	for i := 0; i < 20; i++ {
+		os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("file%02d.txt", i)), []byte("hello"), 0644)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "sub", "nested.txt"), []byte("nested"), 0644)
+
+	var mu sync.Mutex
+	var updates []Progress
+	opts := &ScanOptions{
+		Recursive: true,
+		Workers:   3,
+		OnProgress: func(p Progress) {
+			mu.Lock()
+			updates = append(updates, p)
+			mu.Unlock()
+		},
+	}
+
+	seen := make(map[string]bool)
+	for result := range NewAnalyzer().AnalyzeStream(context.Background(), tmpDir, opts) {
+		if result.Err != nil {
+			t.Fatalf("result for %q failed: %v", result.Path, result.Err)
+		}
+		if result.Metadata.Path != result.Path || result.Metadata.MimeType != "text/plain" {
+			t.Errorf("result = %+v", result.Metadata)
+		}
+		seen[result.Path] = true
+	}
+	if len(seen) != 21 {
+		t.Errorf("streamed %d files, want 21", len(seen))
+	}
+
+	last := updates[len(updates)-1]
+	if !last.Done || last.Discovered != 21 || last.Analyzed != 21 || last.Failed != 0 {
+		t.Errorf("last progress = %+v", last)
+	}
+	for i := 1; i < len(updates); i++ {
+		if updates[i].Analyzed > updates[i].Discovered {
+			t.Fatalf("progress %+v analyzed more files than were discovered", updates[i])
+		}
+	}
+}
+
+func TestAnalyzeStream_Backpressure(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 50; i++ {
+		os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("file%02d.txt", i)), []byte("hello"), 0644)
+	}
+
+	var mu sync.Mutex
+	var progress Progress
+	ctx, cancel := context.WithCancel(context.Background())
+	results := NewAnalyzer().AnalyzeStream(ctx, tmpDir, &ScanOptions{
+		Workers: 1,
+		Buffer:  1,
+		OnProgress: func(p Progress) {
+			mu.Lock()
+			progress = p
+			mu.Unlock()
+		},
+	})
+
+	// Nobody reads, so the walk stops once the buffers are full: one result
+	// waiting, one being sent, one path queued and one being handed over
+	time.Sleep(200 * time.Millisecond)
+	mu.Lock()
+	discovered := progress.Discovered
+	mu.Unlock()
+	if discovered > 4 {
+		t.Errorf("discovered %d files without a reader, want the walk held back", discovered)
+	}
+
+	// Cancelling ends the stream
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		for range results {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream was not closed after cancelling")
+	}
+}
+
+func TestAnalyzeStream_ScanError(t *testing.T) {
+	var results []Result
+	for result := range NewAnalyzer().AnalyzeStream(context.Background(), filepath.Join(t.TempDir(), "missing"), nil) {
+		results = append(results, result)
+	}
+	// A missing root is skipped like any entry that can't be accessed
+	if len(results) != 0 {
+		t.Errorf("results = %+v, want none", results)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewAnalyzer().AnalyzeDirectory(ctx, t.TempDir(), nil); err == nil {
+		t.Error("AnalyzeDirectory() with a cancelled context succeeded")
+	}
+}
+
+func TestAnalyzeStream_SkipContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "IMG_0001.jpg")
+	os.WriteFile(path, []byte("not really a photo"), 0644)
+
+	cache := &mapCache{entries: make(map[string]FileMetadata)}
+	fa := NewAnalyzer()
+	fa.SetCache(cache)
+
+	var got *FileMetadata
+	for result := range fa.AnalyzeStream(context.Background(), tmpDir, &ScanOptions{SkipContent: true, CalculateHash: true}) {
+		got = result.Metadata
+	}
+	if got == nil || got.Size != 18 || got.MimeType != "image/jpeg" || got.Hash != "" || got.ContentPreview != "" {
+		t.Fatalf("metadata = %+v, want stat and extension data only", got)
+	}
+	if !got.NeedsSmarterName {
+		t.Error("NeedsSmarterName = false for a camera file name")
+	}
+	if cache.stores != 0 {
+		t.Error("metadata without content was cached")
+	}
+}
+
+func TestAnalyzeStream_Symlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+	tmpDir := t.TempDir()
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("hello"), 0644)
+	os.WriteFile(filepath.Join(outside, "shared.txt"), []byte("shared"), 0644)
+	os.Symlink(outside, filepath.Join(tmpDir, "shared"))
+	os.Symlink(filepath.Join(tmpDir, "gone.txt"), filepath.Join(tmpDir, "broken.txt"))
+
+	for _, follow := range []bool{false, true} {
+		opts := &ScanOptions{Recursive: true, Traversal: walk.Options{FollowSymlinks: follow}}
+		files := make(map[string]error)
+		for result := range NewAnalyzer().AnalyzeStream(context.Background(), tmpDir, opts) {
+			rel, _ := filepath.Rel(tmpDir, result.Path)
+			files[filepath.ToSlash(rel)] = result.Err
+		}
+
+		if err, ok := files["notes.txt"]; !ok || err != nil {
+			t.Errorf("follow=%v: notes.txt = %v, %v", follow, ok, err)
+		}
+		if err := files["broken.txt"]; !errors.Is(err, walk.ErrBrokenLink) {
+			t.Errorf("follow=%v: broken.txt error = %v, want a broken link", follow, err)
+		}
+		if _, ok := files["shared/shared.txt"]; ok != follow {
+			t.Errorf("follow=%v: linked directory walked = %v", follow, ok)
+		}
+		if _, ok := files["shared"]; ok {
+			t.Errorf("follow=%v: the link itself was analyzed", follow)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Format is an archive format
+type Format string
+
+const (
+	FormatZip    Format = "zip"
+	FormatTar    Format = "tar"
+	FormatTarGz  Format = "tar.gz"
+	FormatTarBz2 Format = "tar.bz2"
+	FormatTarXz  Format = "tar.xz"
+	FormatTarZst Format = "tar.zst"
+	Format7z     Format = "7z"
+)
+
+// Separator joins the path of an archive and the path of a file inside it,
+// as in backup.zip!/photos/beach.jpg
+const Separator = "!/"
+
+// DefaultMaxEntries is how many entries are listed per archive by default
+const DefaultMaxEntries = 10000
+
+// ErrTooManyEntries is returned with the entries listed so far when an
+// archive holds more than Options.MaxEntries files
+var ErrTooManyEntries = errors.New("archive has too many entries")
+
+// ErrUnsupported is returned for archives that need a missing external tool
+var ErrUnsupported = errors.New("unsupported archive")
+
+// formatSuffixes map file name suffixes to formats, longest first
+var formatSuffixes = []struct {
+	suffix string
+	format Format
+}{
+	{".tar.gz", FormatTarGz},
+	{".tar.bz2", FormatTarBz2},
+	{".tar.xz", FormatTarXz},
+	{".tar.zst", FormatTarZst},
+	{".tgz", FormatTarGz},
+	{".tbz2", FormatTarBz2},
+	{".txz", FormatTarXz},
+	{".tzst", FormatTarZst},
+	{".zip", FormatZip},
+	{".tar", FormatTar},
+	{".7z", Format7z},
+}
+
+// DetectFormat returns the archive format of a file by its name, or "" if
+// it is not an archive
+func DetectFormat(name string) Format {
+	lower := strings.ToLower(name)
+	for _, fs := range formatSuffixes {
+		if strings.HasSuffix(lower, fs.suffix) {
+			return fs.format
+		}
+	}
+	return ""
+}
+
+// EntryPath returns the virtual path of a file inside an archive
+func EntryPath(archivePath, name string) string {
+	return archivePath + Separator + name
+}
+
+// SplitPath splits a virtual path into the archive and the file inside it
+func SplitPath(p string) (archivePath, name string, ok bool) {
+	return strings.Cut(p, Separator)
+}
+
+// Entry is a file inside an archive
+type Entry struct {
+	Name    string // Slash-separated path inside the archive
+	Size    int64
+	ModTime time.Time
+	SHA256  string // Hash of the content if hashing was requested and possible
+}
+
+// Options controls how archives are listed
+type Options struct {
+	Hash        bool  // Read and hash the content of every entry
+	MaxHashSize int64 // Entries larger than this are not hashed (0 = no limit)
+	MaxEntries  int   // Listing stops after this many entries (default DefaultMaxEntries)
+}
+
+// List returns the files inside an archive. Nothing is extracted to disk;
+// with Hash set every entry is decompressed in memory to hash it. Formats
+// without a Go reader (tar.xz, tar.zst and 7z) are read through the xz,
+// zstd or bsdtar tools when they are installed.
+func List(ctx context.Context, archivePath string, opts *Options) ([]*Entry, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	l := &lister{ctx: ctx, opts: opts, max: opts.MaxEntries}
+	if l.max <= 0 {
+		l.max = DefaultMaxEntries
+	}
+
+	var err error
+	switch format := DetectFormat(archivePath); format {
+	case FormatZip:
+		err = l.listZip(archivePath)
+	case FormatTar, FormatTarGz, FormatTarBz2:
+		err = l.listTarFile(archivePath, format)
+	case FormatTarXz, FormatTarZst, Format7z:
+		err = l.listWithTool(archivePath, format)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupported, path.Base(archivePath))
+	}
+	return l.entries, err
+}
+
+// lister collects the entries of one archive
+type lister struct {
+	ctx     context.Context
+	opts    *Options
+	max     int
+	entries []*Entry
+}
+
+// add records an entry, hashing the content it opens if requested
+func (l *lister) add(name string, size int64, modTime time.Time, open func() (io.ReadCloser, error)) error {
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+	if len(l.entries) >= l.max {
+		return ErrTooManyEntries
+	}
+
+	entry := &Entry{Name: strings.TrimPrefix(path.Clean("/"+name), "/"), Size: size, ModTime: modTime}
+	if l.opts.Hash && (l.opts.MaxHashSize <= 0 || size <= l.opts.MaxHashSize) {
+		// Unreadable entries, such as encrypted ones, are listed unhashed
+		if rc, err := open(); err == nil {
+			h := sha256.New()
+			if _, err := io.Copy(h, io.LimitReader(rc, size+1)); err == nil {
+				entry.SHA256 = fmt.Sprintf("%x", h.Sum(nil))
+			}
+			rc.Close()
+		}
+	}
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+// listZip lists a ZIP archive from its central directory
+func (l *lister) listZip(archivePath string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := l.add(f.Name, int64(f.UncompressedSize64), f.Modified, f.Open); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listTarFile lists a tarball the Go standard library can decompress
+func (l *lister) listTarFile(archivePath string, format Format) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch format {
+	case FormatTarGz:
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case FormatTarBz2:
+		r = bzip2.NewReader(f)
+	}
+	return l.listTar(r)
+}
+
+// listTar lists the regular files of a tar stream
+func (l *lister) listTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		open := func() (io.ReadCloser, error) { return io.NopCloser(tr), nil }
+		if err := l.add(hdr.Name, hdr.Size, hdr.ModTime, open); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testFiles = []struct {
+	name, content string
+}{
+	{"readme.txt", "hello"},
+	{"photos/beach.jpg", "not really a jpeg"},
+}
+
+func sha(content string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
+
+func writeZip(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	zw.Create("photos/")
+	for _, tf := range testFiles {
+		w, err := zw.Create(tf.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(tf.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTarGz(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	tw.WriteHeader(&tar.Header{Name: "photos/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, tf := range testFiles {
+		tw.WriteHeader(&tar.Header{Name: tf.name, Size: int64(len(tf.content)), Mode: 0644, ModTime: time.Now()})
+		tw.Write([]byte(tf.content))
+	}
+	tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "readme.txt"})
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkEntries(t *testing.T, entries []*Entry, hashed bool) {
+	t.Helper()
+	if len(entries) != len(testFiles) {
+		t.Fatalf("List() returned %d entries, want %d", len(entries), len(testFiles))
+	}
+	for i, tf := range testFiles {
+		e := entries[i]
+		if e.Name != tf.name || e.Size != int64(len(tf.content)) {
+			t.Errorf("entry %d = %+v, want %s", i, e, tf.name)
+		}
+		if want := sha(tf.content); hashed && e.SHA256 != want {
+			t.Errorf("%s SHA256 = %q, want %q", e.Name, e.SHA256, want)
+		}
+		if !hashed && e.SHA256 != "" {
+			t.Errorf("%s was hashed without Options.Hash", e.Name)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]Format{
+		"backup.zip":     FormatZip,
+		"Backup.ZIP":     FormatZip,
+		"src.tar":        FormatTar,
+		"src.tar.gz":     FormatTarGz,
+		"src.tgz":        FormatTarGz,
+		"src.tar.bz2":    FormatTarBz2,
+		"src.tar.zst":    FormatTarZst,
+		"src.tar.xz":     FormatTarXz,
+		"photos.7z":      Format7z,
+		"notes.txt":      "",
+		"archive.gz":     "",
+		"zip":            "",
+		"backup.zip.bak": "",
+	}
+	for name, want := range tests {
+		if got := DetectFormat(name); got != want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	p := EntryPath("/home/me/backup.zip", "photos/beach.jpg")
+	if p != "/home/me/backup.zip!/photos/beach.jpg" {
+		t.Errorf("EntryPath() = %q", p)
+	}
+	archivePath, name, ok := SplitPath(p)
+	if !ok || archivePath != "/home/me/backup.zip" || name != "photos/beach.jpg" {
+		t.Errorf("SplitPath() = %q, %q, %v", archivePath, name, ok)
+	}
+	if _, _, ok := SplitPath("/home/me/backup.zip"); ok {
+		t.Error("SplitPath() split a plain path")
+	}
+}
+
+func TestList_Zip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.zip")
+	writeZip(t, path)
+
+	entries, err := List(context.Background(), path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, entries, false)
+
+	entries, err = List(context.Background(), path, &Options{Hash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, entries, true)
+}
+
+func TestList_TarGz(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.tar.gz")
+	writeTarGz(t, path)
+
+	entries, err := List(context.Background(), path, &Options{Hash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, entries, true)
+}
+
+func TestList_TarZst(t *testing.T) {
+	zstd, err := exec.LookPath("zstd")
+	if err != nil {
+		t.Skip("zstd is not installed")
+	}
+	dir := t.TempDir()
+	tarGz := filepath.Join(dir, "backup.tar.gz")
+	writeTarGz(t, tarGz)
+	tarZst := filepath.Join(dir, "backup.tar.zst")
+	// Recompress the tarball with zstd
+	cmd := exec.Command("sh", "-c", "gzip -dc \"$1\" | \"$2\" -q -o \"$3\"", "sh", tarGz, zstd, tarZst)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("failed to create zstd archive: %v: %s", err, out)
+	}
+
+	entries, err := List(context.Background(), tarZst, &Options{Hash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, entries, true)
+}
+
+func TestList_Limits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.zip")
+	writeZip(t, path)
+
+	entries, err := List(context.Background(), path, &Options{MaxEntries: 1})
+	if !errors.Is(err, ErrTooManyEntries) || len(entries) != 1 {
+		t.Errorf("List() with MaxEntries 1 = %d entries, %v", len(entries), err)
+	}
+
+	entries, err = List(context.Background(), path, &Options{Hash: true, MaxHashSize: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].SHA256 == "" || entries[1].SHA256 != "" {
+		t.Errorf("MaxHashSize 5 hashed %q and %q", entries[0].SHA256, entries[1].SHA256)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := List(ctx, path, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("List() with a cancelled context = %v", err)
+	}
+}
+
+func TestList_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := List(context.Background(), filepath.Join(dir, "notes.txt"), nil); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("List() of a text file = %v, want ErrUnsupported", err)
+	}
+
+	damaged := filepath.Join(dir, "damaged.zip")
+	os.WriteFile(damaged, []byte("not a zip"), 0644)
+	if _, err := List(context.Background(), damaged, nil); err == nil {
+		t.Error("List() of a damaged archive succeeded")
+	}
+}
@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// bsdtarPath finds a tar that reads any archive format: bsdtar, or a tar
+// that is bsdtar under another name as on macOS and Windows
+var bsdtarPath = sync.OnceValue(func() string {
+	if path, err := exec.LookPath("bsdtar"); err == nil {
+		return path
+	}
+	if path, err := exec.LookPath("tar"); err == nil {
+		out, err := exec.Command(path, "--version").Output()
+		if err == nil && bytes.Contains(out, []byte("bsdtar")) {
+			return path
+		}
+	}
+	return ""
+})
+
+// toolCommand returns the command that writes the content of an archive to
+// stdout as a tar stream, or an empty name if no tool for the format is
+// installed
+func toolCommand(archivePath string, format Format) (name string, args []string) {
+	decompressor := map[Format]string{FormatTarXz: "xz", FormatTarZst: "zstd"}[format]
+	if decompressor != "" {
+		if path, err := exec.LookPath(decompressor); err == nil {
+			return path, []string{"-dcq", "--", archivePath}
+		}
+	}
+	// bsdtar converts any archive it reads into a tar stream
+	if path := bsdtarPath(); path != "" {
+		return path, []string{"-cf", "-", "--format", "pax", "@" + archivePath}
+	}
+	return "", nil
+}
+
+// listWithTool lists an archive the Go standard library can't read by
+// piping it through an external tool
+func (l *lister) listWithTool(archivePath string, format Format) error {
+	name, args := toolCommand(archivePath, format)
+	if name == "" {
+		tools := "bsdtar"
+		if format == FormatTarXz {
+			tools = "xz or bsdtar"
+		} else if format == FormatTarZst {
+			tools = "zstd or bsdtar"
+		}
+		return fmt.Errorf("%w: reading %s archives needs %s", ErrUnsupported, format, tools)
+	}
+
+	cmd := exec.CommandContext(l.ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	listErr := l.listTar(stdout)
+	if listErr != nil {
+		// Stop the tool if the listing ended early
+		cmd.Process.Kill()
+	} else {
+		// Drain the padding after the end of the tar stream
+		io.Copy(io.Discard, stdout)
+	}
+	waitErr := cmd.Wait()
+	if listErr != nil {
+		return listErr
+	}
+	if waitErr != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("failed to read archive: %s", msg)
+		}
+		return fmt.Errorf("failed to read archive: %w", waitErr)
+	}
+	return nil
+}
@@ -8,7 +8,9 @@ import (
 	"strings"
 
 	"github.com/xuanyiying/cleanup-cli/internal/output"
+	"github.com/xuanyiying/cleanup-cli/internal/prune"
 	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+	"github.com/xuanyiying/cleanup-cli/internal/walk"
 )
 
 // CleanOptions configures cleanup behavior
@@ -18,6 +20,11 @@ type CleanOptions struct {
 	Categories  []JunkCategory // Categories to clean (empty = all)
 	Interactive bool           // Prompt for uncertain files
 	TrashPath   string         // Custom trash directory
+
+	SkipUncertain bool // Leave uncertain files alone, for runs nobody can prompt in
+
+	PruneEmptyDirs bool     // Remove directories emptied by the cleanup
+	ExcludeDirs    []string // Directory names never pruned, such as .git
 }
 
 // CleanResult represents the result of a cleanup operation
@@ -27,6 +34,9 @@ type CleanResult struct {
 	Failed     []*JunkFile
 	SpaceFreed int64
 	Errors     []error
+	PrunedDirs []string
+
+	TransactionID string // Transaction recording the cleanup (empty for dry runs)
 }
 
 // SystemCleaner handles system junk cleanup
@@ -36,6 +46,7 @@ type SystemCleaner struct {
 	prompt     *InteractivePrompt
 	console    *output.Console
 	txnManager *transaction.Manager
+	pruner     *prune.Pruner
 }
 
 // NewSystemCleaner creates a new system cleaner
@@ -48,6 +59,7 @@ func NewSystemCleaner(txnManager *transaction.Manager) *SystemCleaner {
 		prompt:     NewInteractivePrompt(console, os.Stdin),
 		console:    console,
 		txnManager: txnManager,
+		pruner:     prune.NewPruner(txnManager),
 	}
 }
 
@@ -56,6 +68,11 @@ func (c *SystemCleaner) ClearLocations() {
 	c.scanner.ClearLocations()
 }
 
+// SetTraversal sets how junk locations are walked
+func (c *SystemCleaner) SetTraversal(opts walk.Options) {
+	c.scanner.SetTraversal(opts)
+}
+
 // Configure configures the cleaner with custom settings
 func (c *SystemCleaner) Configure(junkLocations []string, importantPatterns []string) {
 	// Add custom junk locations
@@ -150,6 +167,7 @@ func (c *SystemCleaner) Clean(ctx context.Context, opts *CleanOptions) (*CleanRe
 		Failed:     []*JunkFile{},
 		SpaceFreed: 0,
 		Errors:     []error{},
+		PrunedDirs: []string{},
 	}
 
 	// If dry run, just return the scan result
@@ -175,8 +193,13 @@ func (c *SystemCleaner) Clean(ctx context.Context, opts *CleanOptions) (*CleanRe
 		default:
 		}
 
-		// Check if file is uncertain and interactive mode is enabled
-		if opts.Interactive && c.classifier.IsUncertain(file.Path) {
+		// Uncertain files are skipped or confirmed first
+		if (opts.SkipUncertain || opts.Interactive) && c.classifier.IsUncertain(file.Path) {
+			if opts.SkipUncertain {
+				result.Skipped = append(result.Skipped, file)
+				continue
+			}
+
 			filePrompt := &FilePrompt{
 				Path:    file.Path,
 				Size:    file.Size,
@@ -208,6 +231,14 @@ func (c *SystemCleaner) Clean(ctx context.Context, opts *CleanOptions) (*CleanRe
 		}
 	}
 
+	// Remove directories the cleanup left empty, in the same transaction so
+	// undo recreates them before restoring files
+	if opts.PruneEmptyDirs {
+		if err := c.pruneEmptied(ctx, result, opts, tx); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
 	// Commit transaction
 	if err := c.txnManager.Commit(tx); err != nil {
 		c.console.Error("Failed to commit transaction: %v", err)
@@ -217,6 +248,7 @@ func (c *SystemCleaner) Clean(ctx context.Context, opts *CleanOptions) (*CleanRe
 		}
 		return result, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	result.TransactionID = tx.ID
 
 	// Display results
 	c.displayResults(result)
@@ -224,6 +256,11 @@ func (c *SystemCleaner) Clean(ctx context.Context, opts *CleanOptions) (*CleanRe
 	return result, nil
 }
 
+// IsUncertain reports whether a junk file needs a review before cleaning
+func (c *SystemCleaner) IsUncertain(path string) bool {
+	return c.classifier.IsUncertain(path)
+}
+
 // CleanCategory cleans only a specific category
 func (c *SystemCleaner) CleanCategory(ctx context.Context, category JunkCategory, opts *CleanOptions) (*CleanResult, error) {
 	if opts == nil {
@@ -236,6 +273,34 @@ func (c *SystemCleaner) CleanCategory(ctx context.Context, category JunkCategory
 	return c.Clean(ctx, opts)
 }
 
+// pruneEmptied removes directories emptied by cleaning, never touching the
+// junk location roots themselves, excluded directories or protected system
+// paths
+func (c *SystemCleaner) pruneEmptied(ctx context.Context, result *CleanResult, opts *CleanOptions, tx *transaction.Transaction) error {
+	byLocation := make(map[string][]string)
+	for _, file := range result.Cleaned {
+		if file.Location == "" {
+			continue
+		}
+		byLocation[file.Location] = append(byLocation[file.Location], filepath.Dir(file.Path))
+	}
+
+	for location, dirs := range byLocation {
+		pruned, err := c.pruner.PruneEmptied(ctx, tx, location, dirs, &prune.Options{
+			ExcludeDirs:   opts.ExcludeDirs,
+			IncludeHidden: true,
+			IsProtected:   IsProtectedPath,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to prune empty directories in %s: %w", location, err)
+		}
+		result.PrunedDirs = append(result.PrunedDirs, pruned.Removed...)
+		result.Errors = append(result.Errors, pruned.Errors...)
+	}
+
+	return nil
+}
+
 // cleanFile cleans a single file (move to trash or permanently delete)
 func (c *SystemCleaner) cleanFile(file *JunkFile, opts *CleanOptions, tx *transaction.Transaction) error {
 	if opts.Force {
@@ -296,6 +361,11 @@ func (c *SystemCleaner) cleanFile(file *JunkFile, opts *CleanOptions, tx *transa
 
 // getDefaultTrashPath returns the default trash path for the current platform
 func (c *SystemCleaner) getDefaultTrashPath() string {
+	return DefaultTrashPath()
+}
+
+// DefaultTrashPath returns the trash directory used when none is configured
+func DefaultTrashPath() string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return ".cleanup_trash"
@@ -334,6 +404,9 @@ func (c *SystemCleaner) displayResults(result *CleanResult) {
 	c.console.Info("Cleaned: %d files", len(result.Cleaned))
 	c.console.Info("Skipped: %d files", len(result.Skipped))
 	c.console.Info("Failed: %d files", len(result.Failed))
+	if len(result.PrunedDirs) > 0 {
+		c.console.Info("Removed empty directories: %d", len(result.PrunedDirs))
+	}
 	c.console.Success("Space freed: %s", formatFileSize(result.SpaceFreed))
 
 	if len(result.Errors) > 0 {
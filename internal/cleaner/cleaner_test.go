@@ -1,6 +1,7 @@
 package cleaner
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -258,3 +259,52 @@ func TestErrorResilience(t *testing.T) {
 	_, err = os.Stat(goodFile)
 	assert.True(t, os.IsNotExist(err), "Good file should be removed")
 }
+
+func TestCleanPrunesEmptiedDirectories(t *testing.T) {
+	tempDir := t.TempDir()
+	location := filepath.Join(tempDir, "cache")
+	nested := filepath.Join(location, "app", "blobs")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(nested, "blob.bin"), []byte("junk"), 0644))
+
+	txnManager := transaction.NewManager(filepath.Join(tempDir, "txn.log"))
+	cleaner := NewSystemCleaner(txnManager)
+	cleaner.ClearLocations()
+	cleaner.scanner.AddLocation(&JunkLocation{Path: location, Category: CategoryCache, Platform: "all"})
+
+	result, err := cleaner.Clean(context.Background(), &CleanOptions{
+		TrashPath:      filepath.Join(tempDir, "trash"),
+		PruneEmptyDirs: true,
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, result.Cleaned, 1)
+	assert.ElementsMatch(t, []string{nested, filepath.Join(location, "app")}, result.PrunedDirs)
+	// The junk location root itself is kept
+	assert.DirExists(t, location)
+	assert.NoDirExists(t, filepath.Join(location, "app"))
+}
+
+func TestCleanKeepsExcludedEmptiedDirectories(t *testing.T) {
+	tempDir := t.TempDir()
+	location := filepath.Join(tempDir, "cache")
+	nested := filepath.Join(location, "repo", ".git", "objects")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(nested, "pack.tmp"), []byte("junk"), 0644))
+
+	txnManager := transaction.NewManager(filepath.Join(tempDir, "txn.log"))
+	cleaner := NewSystemCleaner(txnManager)
+	cleaner.ClearLocations()
+	cleaner.scanner.AddLocation(&JunkLocation{Path: location, Category: CategoryCache, Platform: "all"})
+
+	result, err := cleaner.Clean(context.Background(), &CleanOptions{
+		TrashPath:      filepath.Join(tempDir, "trash"),
+		PruneEmptyDirs: true,
+		ExcludeDirs:    []string{".git"},
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, result.Cleaned, 1)
+	assert.Empty(t, result.PrunedDirs)
+	assert.DirExists(t, nested)
+}
@@ -7,7 +7,9 @@ import (
 	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
 	"github.com/xuanyiying/cleanup-cli/internal/output"
 )
 
@@ -158,30 +160,39 @@ func (p *InteractivePrompt) ShowPreview(path string, maxChars int) error {
 	}
 	defer file.Close()
 
-	// Read up to maxChars
-	buffer := make([]byte, maxChars)
-	n, err := file.Read(buffer)
-	if err != nil && err != io.EOF {
+	// Read enough bytes for maxChars characters in any encoding
+	buffer := make([]byte, maxChars*utf8.UTFMax)
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 		return fmt.Errorf("cannot read file: %w", err)
 	}
-
-	content := string(buffer[:n])
+	data := buffer[:n]
 
 	// Check if content is likely text
-	if !isTextContent(content) {
-		p.console.Info("File appears to be binary content (showing first %d bytes as hex):", n)
-		p.displayHexPreview(buffer[:n])
+	encoding, isText := analyzer.DetectEncoding(data)
+	truncated := false
+	if !isText {
+		data = data[:min(n, maxChars)]
+		truncated = n > maxChars
+		p.console.Info("File appears to be binary content (showing first %d bytes as hex):", len(data))
+		p.displayHexPreview(data)
 	} else {
-		p.console.Info("File preview (first %d characters):", len(content))
-		p.console.Box("File Content", []string{content})
+		content := []rune(analyzer.DecodeText(data, encoding))
+		if len(content) > maxChars {
+			content = content[:maxChars]
+			truncated = true
+		}
+		if encoding == analyzer.EncodingUTF8 {
+			p.console.Info("File preview (first %d characters):", len(content))
+		} else {
+			p.console.Info("File preview (first %d characters, decoded from %s):", len(content), encoding)
+		}
+		p.console.Box("File Content", []string{string(content)})
 	}
 
 	// Show if file was truncated
-	if n == maxChars {
-		stat, err := file.Stat()
-		if err == nil && stat.Size() > int64(maxChars) {
-			p.console.Info("... (file truncated, showing first %d of %d bytes)", maxChars, stat.Size())
-		}
+	if stat, err := file.Stat(); err == nil && (truncated || stat.Size() > int64(n)) {
+		p.console.Info("... (file truncated, %d bytes in total)", stat.Size())
 	}
 
 	return nil
@@ -250,23 +261,6 @@ func (p *InteractivePrompt) parseInput(input string) PromptAction {
 	}
 }
 
-// isTextContent checks if content appears to be text
-func isTextContent(content string) bool {
-	// Simple heuristic: if more than 95% of characters are printable, consider it text
-	printable := 0
-	for _, r := range content {
-		if r >= 32 && r <= 126 || r == '\n' || r == '\r' || r == '\t' {
-			printable++
-		}
-	}
-
-	if len(content) == 0 {
-		return true
-	}
-
-	return float64(printable)/float64(len(content)) > 0.95
-}
-
 // displayHexPreview displays binary content as hex
 func (p *InteractivePrompt) displayHexPreview(data []byte) {
 	const bytesPerLine = 16
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/xuanyiying/cleanup-cli/internal/output"
+	"golang.org/x/text/encoding/simplifiedchinese"
 	"pgregory.net/rapid"
 )
 
@@ -158,6 +159,37 @@ func TestFilePreviewLengthEdgeCases(t *testing.T) {
 	}
 }
 
+// TestFilePreviewEncodings tests that previews of non-UTF-8 text are decoded
+// and cut at characters, not bytes
+func TestFilePreviewEncodings(t *testing.T) {
+	text := strings.Repeat("会议记录和项目进度安排。", 10)
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(tmpFile, []byte(gbk), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	prompt := NewInteractivePrompt(output.NewConsole(&buf), nil)
+	if err := prompt.ShowPreview(tmpFile, 30); err != nil {
+		t.Fatalf("ShowPreview failed: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "gb18030") || !strings.Contains(out, string([]rune(text)[:30])) {
+		t.Errorf("preview was not decoded:\n%s", out)
+	}
+	if strings.Contains(out, string([]rune(text)[:31])) {
+		t.Errorf("preview is longer than 30 characters:\n%s", out)
+	}
+	if !strings.Contains(out, "file truncated") {
+		t.Error("expected truncation notice")
+	}
+}
+
 // TestAllYesBehavior tests Property 18: All Yes Behavior
 // Feature: enhanced-output-cleanup, Property 18: All Yes Behavior
 func TestAllYesBehavior(t *testing.T) {
@@ -2,12 +2,17 @@ package cleaner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/walk"
 )
 
 // JunkCategory represents a category of junk files
@@ -38,7 +43,8 @@ type JunkFile struct {
 	Size        int64
 	Category    JunkCategory
 	ModTime     time.Time
-	IsImportant bool // If true, requires confirmation
+	IsImportant bool   // If true, requires confirmation
+	Location    string // Root of the junk location the file was found in
 }
 
 // ScanResult represents the result of a junk scan
@@ -55,6 +61,7 @@ type JunkScanner struct {
 	locations  []*JunkLocation
 	classifier *FileClassifier
 	platform   string
+	traversal  walk.Options
 }
 
 // NewJunkScanner creates a new junk scanner
@@ -83,6 +90,12 @@ func (s *JunkScanner) ClearLocations() {
 	s.locations = []*JunkLocation{}
 }
 
+// SetTraversal sets how locations are walked: whether links are followed
+// and mount points crossed
+func (s *JunkScanner) SetTraversal(opts walk.Options) {
+	s.traversal = opts
+}
+
 // Scan scans for junk files
 func (s *JunkScanner) Scan(ctx context.Context) (*ScanResult, error) {
 	result := &ScanResult{
@@ -114,21 +127,7 @@ func (s *JunkScanner) Scan(ctx context.Context) (*ScanResult, error) {
 		
 		// Add files to result
 		for _, file := range locationFiles {
-			// Check if file is important and should be excluded
-			if s.classifier.IsImportant(file.Path) {
-				file.IsImportant = true
-				// Skip important files by default (Requirements 6.5)
-				continue
-			}
-
-			result.Files = append(result.Files, file)
-			result.TotalSize += file.Size
-			
-			// Add to category map
-			if result.ByCategory[file.Category] == nil {
-				result.ByCategory[file.Category] = []*JunkFile{}
-			}
-			result.ByCategory[file.Category] = append(result.ByCategory[file.Category], file)
+			s.collect(result, file)
 		}
 
 		// Add skipped paths and errors
@@ -173,21 +172,7 @@ func (s *JunkScanner) ScanCategory(ctx context.Context, category JunkCategory) (
 		
 		// Add files to result
 		for _, file := range locationFiles {
-			// Check if file is important and should be excluded
-			if s.classifier.IsImportant(file.Path) {
-				file.IsImportant = true
-				// Skip important files by default (Requirements 6.5)
-				continue
-			}
-
-			result.Files = append(result.Files, file)
-			result.TotalSize += file.Size
-			
-			// Add to category map
-			if result.ByCategory[file.Category] == nil {
-				result.ByCategory[file.Category] = []*JunkFile{}
-			}
-			result.ByCategory[file.Category] = append(result.ByCategory[file.Category], file)
+			s.collect(result, file)
 		}
 
 		// Add skipped paths and errors
@@ -198,6 +183,67 @@ func (s *JunkScanner) ScanCategory(ctx context.Context, category JunkCategory) (
 	return result, nil
 }
 
+// ScanStream collects the junk files of a location from a streamed analysis
+// of it, typically AnalyzeStream of the location's path with SkipContent set.
+// Files that can't be accessed are reported as skipped. Broken symbolic
+// links are junk themselves.
+func (s *JunkScanner) ScanStream(ctx context.Context, location *JunkLocation, results <-chan analyzer.Result) (*ScanResult, error) {
+	result := &ScanResult{
+		Files:      []*JunkFile{},
+		TotalSize:  0,
+		ByCategory: make(map[JunkCategory][]*JunkFile),
+		Skipped:    []string{},
+		Errors:     []error{},
+	}
+	root := s.expandPath(location.Path)
+
+	for r := range results {
+		switch {
+		case r.Err != nil && r.Path == "":
+			return result, r.Err
+		case errors.Is(r.Err, fs.ErrPermission):
+			result.Skipped = append(result.Skipped, r.Path)
+		case errors.Is(r.Err, walk.ErrBrokenLink):
+			if file := brokenLink(r.Path, location, root); file != nil {
+				s.collect(result, file)
+			}
+		case r.Err != nil:
+			result.Errors = append(result.Errors, fmt.Errorf("error accessing %s: %w", r.Path, r.Err))
+		case r.Metadata.IsArchived():
+			// Files inside archives can't be deleted on their own
+		default:
+			s.collect(result, &JunkFile{
+				Path:     r.Path,
+				Size:     r.Metadata.Size,
+				Category: location.Category,
+				ModTime:  r.Metadata.ModifiedAt,
+				Location: root,
+			})
+		}
+	}
+
+	return result, ctx.Err()
+}
+
+// collect adds a junk file to a scan result unless it is important
+func (s *JunkScanner) collect(result *ScanResult, file *JunkFile) {
+	// Check if file is important and should be excluded
+	if s.classifier.IsImportant(file.Path) {
+		file.IsImportant = true
+		// Skip important files by default (Requirements 6.5)
+		return
+	}
+
+	result.Files = append(result.Files, file)
+	result.TotalSize += file.Size
+
+	// Add to category map
+	if result.ByCategory[file.Category] == nil {
+		result.ByCategory[file.Category] = []*JunkFile{}
+	}
+	result.ByCategory[file.Category] = append(result.ByCategory[file.Category], file)
+}
+
 // GetDefaultLocations returns default junk locations for current platform
 func (s *JunkScanner) GetDefaultLocations() []*JunkLocation {
 	switch s.platform {
@@ -251,23 +297,30 @@ func (s *JunkScanner) getWindowsJunkLocations() []*JunkLocation {
 func (s *JunkScanner) scanLocation(path string, location *JunkLocation) ([]*JunkFile, []string, []error) {
 	var files []*JunkFile
 	var skipped []string
-	var errors []error
+	var errs []error
 
 	// Check if path exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		// Path doesn't exist, not an error for junk scanning
-		return files, skipped, errors
+		return files, skipped, errs
 	}
 
 	// Walk the directory
-	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+	err := walk.Walk(path, &s.traversal, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
+			// Broken links are junk themselves
+			if errors.Is(err, walk.ErrBrokenLink) {
+				if file := brokenLink(filePath, location, path); file != nil {
+					files = append(files, file)
+				}
+				return nil
+			}
 			// Permission denied or other access error
 			if os.IsPermission(err) {
 				skipped = append(skipped, filePath)
 				return nil // Continue walking
 			}
-			errors = append(errors, fmt.Errorf("error accessing %s: %w", filePath, err))
+			errs = append(errs, fmt.Errorf("error accessing %s: %w", filePath, err))
 			return nil // Continue walking
 		}
 
@@ -283,6 +336,7 @@ func (s *JunkScanner) scanLocation(path string, location *JunkLocation) ([]*Junk
 			Category:    location.Category,
 			ModTime:     info.ModTime(),
 			IsImportant: false,
+			Location:    path,
 		}
 
 		files = append(files, junkFile)
@@ -290,10 +344,26 @@ func (s *JunkScanner) scanLocation(path string, location *JunkLocation) ([]*Junk
 	})
 
 	if err != nil {
-		errors = append(errors, fmt.Errorf("error walking %s: %w", path, err))
+		errs = append(errs, fmt.Errorf("error walking %s: %w", path, err))
 	}
 
-	return files, skipped, errors
+	return files, skipped, errs
+}
+
+// brokenLink returns the junk file of a broken symbolic link, or nil if
+// the link is gone
+func brokenLink(path string, location *JunkLocation, root string) *JunkFile {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil
+	}
+	return &JunkFile{
+		Path:     path,
+		Size:     info.Size(),
+		Category: location.Category,
+		ModTime:  info.ModTime(),
+		Location: root,
+	}
 }
 
 // expandPath expands environment variables and home directory in path
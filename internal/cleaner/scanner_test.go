@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
 	"pgregory.net/rapid"
 )
 
@@ -347,4 +348,79 @@ func TestContextCancellation(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, context.Canceled, err)
 	assert.NotNil(t, result)
-}
\ No newline at end of file
+}
+func TestScanStream(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "cache.bin"), []byte("cached data"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "id_rsa"), []byte("key"), 0600))
+
+	scanner := NewJunkScanner()
+	location := &JunkLocation{Path: tmpDir, Category: CategoryCache, Platform: "all"}
+	ctx := context.Background()
+	results := analyzer.NewAnalyzer().AnalyzeStream(ctx, tmpDir, &analyzer.ScanOptions{
+		Recursive:     true,
+		IncludeHidden: true,
+		SkipContent:   true,
+	})
+
+	result, err := scanner.ScanStream(ctx, location, results)
+	require.NoError(t, err)
+
+	// Important files are never junk
+	require.Len(t, result.Files, 1)
+	assert.Equal(t, filepath.Join(tmpDir, "cache.bin"), result.Files[0].Path)
+	assert.Equal(t, CategoryCache, result.Files[0].Category)
+	assert.Equal(t, tmpDir, result.Files[0].Location)
+	assert.Equal(t, int64(11), result.TotalSize)
+	assert.Len(t, result.ByCategory[CategoryCache], 1)
+}
+
+func TestScanStream_SkipsArchivedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	backup := filepath.Join(tmpDir, "old.zip")
+	inner := backup + "!/cache.bin"
+
+	results := make(chan analyzer.Result, 2)
+	results <- analyzer.Result{Path: backup, Metadata: &analyzer.FileMetadata{Path: backup, Size: 10}}
+	results <- analyzer.Result{Path: inner, Metadata: &analyzer.FileMetadata{Path: inner, Size: 5, ArchivePath: backup}}
+	close(results)
+
+	location := &JunkLocation{Path: tmpDir, Category: CategoryCache, Platform: "all"}
+	result, err := NewJunkScanner().ScanStream(context.Background(), location, results)
+	require.NoError(t, err)
+	require.Len(t, result.Files, 1)
+	assert.Equal(t, backup, result.Files[0].Path)
+}
+
+func TestScan_BrokenLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "cache.bin"), []byte("cached data"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(tmpDir, "gone.bin"), filepath.Join(tmpDir, "stale.lnk")))
+
+	location := &JunkLocation{Path: tmpDir, Category: CategoryCache, Platform: "all"}
+	scanner := NewJunkScanner()
+	scanner.ClearLocations()
+	scanner.AddLocation(location)
+	ctx := context.Background()
+
+	// Broken links are junk, whether the location is walked or streamed
+	walked, err := scanner.Scan(ctx)
+	require.NoError(t, err)
+	streamed, err := scanner.ScanStream(ctx, location, analyzer.NewAnalyzer().AnalyzeStream(ctx, tmpDir, &analyzer.ScanOptions{
+		Recursive:   true,
+		SkipContent: true,
+	}))
+	require.NoError(t, err)
+
+	for _, result := range []*ScanResult{walked, streamed} {
+		var paths []string
+		for _, file := range result.Files {
+			paths = append(paths, filepath.Base(file.Path))
+		}
+		assert.ElementsMatch(t, []string{"cache.bin", "stale.lnk"}, paths)
+		assert.Empty(t, result.Errors)
+	}
+}
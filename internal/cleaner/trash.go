@@ -0,0 +1,173 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+	"github.com/xuanyiying/cleanup-cli/internal/walk"
+)
+
+// EmptyTrashOptions configures emptying the trash
+type EmptyTrashOptions struct {
+	TrashPath string        // Trash directory (default: ~/.cleanup/trash)
+	OlderThan time.Duration // Only remove entries trashed at least this long ago (0 = all)
+	DryRun    bool          // Report what would be removed without removing it
+}
+
+// EmptyTrashResult represents the result of emptying the trash
+type EmptyTrashResult struct {
+	Removed       []string
+	Kept          int // Entries younger than the age threshold
+	SpaceFreed    int64
+	Errors        []error
+	TransactionID string
+}
+
+// EmptyTrash permanently deletes entries from the trash. An entry's age is
+// taken from the transaction that moved it there, falling back to its
+// modification time for entries the transaction log doesn't know about.
+// Deletions are recorded in a transaction but cannot be undone.
+func (c *SystemCleaner) EmptyTrash(ctx context.Context, opts *EmptyTrashOptions) (*EmptyTrashResult, error) {
+	if opts == nil {
+		opts = &EmptyTrashOptions{}
+	}
+
+	trashPath := opts.TrashPath
+	if trashPath == "" {
+		trashPath = c.getDefaultTrashPath()
+	}
+	trashPath = filepath.Clean(trashPath)
+
+	result := &EmptyTrashResult{
+		Removed: []string{},
+		Errors:  []error{},
+	}
+
+	entries, err := os.ReadDir(trashPath)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash directory: %w", err)
+	}
+
+	trashedAt := c.trashedTimes(trashPath)
+	now := time.Now()
+
+	var tx *transaction.Transaction
+	if !opts.DryRun {
+		tx = c.txnManager.Begin()
+	}
+
+	for _, entry := range entries {
+		select {
+		case <-ctx.Done():
+			return result, c.finishEmptyTrash(tx, result, ctx.Err())
+		default:
+		}
+
+		path := filepath.Join(trashPath, entry.Name())
+
+		when, ok := trashedAt[path]
+		if !ok {
+			info, err := entry.Info()
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("failed to stat %s: %w", path, err))
+				continue
+			}
+			when = info.ModTime()
+		}
+		if opts.OlderThan > 0 && now.Sub(when) < opts.OlderThan {
+			result.Kept++
+			continue
+		}
+
+		size := entrySize(path, entry)
+
+		if !opts.DryRun {
+			if err := os.RemoveAll(path); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("failed to delete %s: %w", path, err))
+				continue
+			}
+			c.txnManager.AddOperation(tx, &transaction.ExecutedOperation{
+				Type:   transaction.OpDelete,
+				Source: path,
+				Target: "",
+				Backup: "", // Emptying the trash is permanent
+			})
+		}
+
+		result.Removed = append(result.Removed, path)
+		result.SpaceFreed += size
+	}
+
+	return result, c.finishEmptyTrash(tx, result, nil)
+}
+
+// finishEmptyTrash commits the deletions made so far, returning cause if set
+func (c *SystemCleaner) finishEmptyTrash(tx *transaction.Transaction, result *EmptyTrashResult, cause error) error {
+	if tx == nil {
+		return cause
+	}
+
+	if err := c.txnManager.Commit(tx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	result.TransactionID = tx.ID
+	return cause
+}
+
+// trashedTimes maps trash entries to when a transaction moved them there
+func (c *SystemCleaner) trashedTimes(trashPath string) map[string]time.Time {
+	times := make(map[string]time.Time)
+
+	history, err := c.txnManager.GetHistory(0)
+	if err != nil {
+		return times
+	}
+
+	for _, tx := range history {
+		if tx.Status != transaction.StatusCommitted {
+			continue
+		}
+		for _, op := range tx.Operations {
+			if op.Type != transaction.OpMove || filepath.Dir(op.Target) != trashPath {
+				continue
+			}
+			// The latest move into the trash wins
+			if tx.Timestamp.After(times[op.Target]) {
+				times[op.Target] = tx.Timestamp
+			}
+		}
+	}
+
+	return times
+}
+
+// entrySize returns the size of a trash entry, including directory contents.
+// Files with several hard links are counted once.
+func entrySize(path string, entry fs.DirEntry) int64 {
+	if !entry.IsDir() {
+		info, err := entry.Info()
+		if err != nil {
+			return 0
+		}
+		return info.Size()
+	}
+
+	var size int64
+	links := walk.HardLinks{}
+	_ = walk.Walk(path, nil, func(_ string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() || links.Seen(info) {
+			return nil
+		}
+		size += info.Size()
+		return nil
+	})
+	return size
+}
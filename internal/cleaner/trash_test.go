@@ -0,0 +1,60 @@
+package cleaner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+)
+
+func TestEmptyTrashHonorsAge(t *testing.T) {
+	tempDir := t.TempDir()
+	trashDir := filepath.Join(tempDir, "trash")
+	txnManager := transaction.NewManager(filepath.Join(tempDir, "txn.log"))
+	c := NewSystemCleaner(txnManager)
+
+	// Trashed just now by a cleanup, although the file itself is old
+	source := filepath.Join(tempDir, "recent.log")
+	require.NoError(t, os.WriteFile(source, []byte("recent"), 0644))
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	require.NoError(t, os.Chtimes(source, old, old))
+	tx := txnManager.Begin()
+	require.NoError(t, c.cleanFile(&JunkFile{Path: source, Size: 6}, &CleanOptions{TrashPath: trashDir}, tx))
+	require.NoError(t, txnManager.Commit(tx))
+
+	// Unknown to the transaction log, so its modification time counts
+	stale := filepath.Join(trashDir, "stale.tmp")
+	require.NoError(t, os.WriteFile(stale, []byte("stale data"), 0644))
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	opts := &EmptyTrashOptions{TrashPath: trashDir, OlderThan: 30 * 24 * time.Hour, DryRun: true}
+	preview, err := c.EmptyTrash(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{stale}, preview.Removed)
+	assert.Equal(t, 1, preview.Kept)
+	assert.Empty(t, preview.TransactionID)
+	assert.FileExists(t, stale, "dry run must not delete")
+
+	opts.DryRun = false
+	result, err := c.EmptyTrash(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{stale}, result.Removed)
+	assert.Equal(t, int64(len("stale data")), result.SpaceFreed)
+	assert.NotEmpty(t, result.TransactionID)
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, filepath.Join(trashDir, "recent.log"))
+}
+
+func TestEmptyTrashMissingDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	c := NewSystemCleaner(transaction.NewManager(filepath.Join(tempDir, "txn.log")))
+
+	result, err := c.EmptyTrash(context.Background(), &EmptyTrashOptions{TrashPath: filepath.Join(tempDir, "none")})
+	require.NoError(t, err)
+	assert.Empty(t, result.Removed)
+}
@@ -11,20 +11,49 @@ import (
 
 // CleanupConfig represents the complete configuration for the cleanup CLI
 type CleanupConfig struct {
-	Ollama             OllamaConfig      `yaml:"ollama" mapstructure:"ollama"`
-	AI                 AIConfig          `yaml:"ai" mapstructure:"ai"`
-	Rules              []*Rule           `yaml:"rules" mapstructure:"rules"`
-	DefaultStrategy    *OrganizeStrategy `yaml:"defaultStrategy" mapstructure:"defaultStrategy"`
-	TransactionLogPath string            `yaml:"transactionLogPath" mapstructure:"transactionLogPath"`
-	TrashPath          string            `yaml:"trashPath" mapstructure:"trashPath"`
-	Exclude            *ExcludeConfig    `yaml:"exclude" mapstructure:"exclude"`
-	Cleaner            *CleanerConfig    `yaml:"cleaner" mapstructure:"cleaner"`
+	Ollama             OllamaConfig       `yaml:"ollama" mapstructure:"ollama"`
+	AI                 AIConfig           `yaml:"ai" mapstructure:"ai"`
+	Rules              []*Rule            `yaml:"rules" mapstructure:"rules"`
+	DefaultStrategy    *OrganizeStrategy  `yaml:"defaultStrategy" mapstructure:"defaultStrategy"`
+	TransactionLogPath string             `yaml:"transactionLogPath" mapstructure:"transactionLogPath"`
+	TrashPath          string             `yaml:"trashPath" mapstructure:"trashPath"`
+	Exclude            *ExcludeConfig     `yaml:"exclude" mapstructure:"exclude"`
+	Traversal          *TraversalConfig   `yaml:"traversal,omitempty" mapstructure:"traversal"`
+	Naming             *NamingConfig      `yaml:"naming,omitempty" mapstructure:"naming"`
+	Cleaner            *CleanerConfig     `yaml:"cleaner" mapstructure:"cleaner"`
+	Signatures         []*SignatureConfig `yaml:"signatures,omitempty" mapstructure:"signatures"`
 }
 
 // AIConfig represents the AI configuration
 type AIConfig struct {
-	Provider string       `yaml:"provider" mapstructure:"provider"`
-	OpenAI   OpenAIConfig `yaml:"openai" mapstructure:"openai"`
+	Provider  string          `yaml:"provider" mapstructure:"provider"`
+	Providers []string        `yaml:"providers,omitempty" mapstructure:"providers"` // 按顺序尝试的 AI 服务，如 [ollama, openai]；为空时只用 provider
+	OpenAI    OpenAIConfig    `yaml:"openai" mapstructure:"openai"`
+	Privacy   *PrivacyConfig  `yaml:"privacy,omitempty" mapstructure:"privacy"`
+	Fallback  *FallbackConfig `yaml:"fallback,omitempty" mapstructure:"fallback"`
+	Cache     *AICacheConfig  `yaml:"cache,omitempty" mapstructure:"cache"`
+}
+
+// AICacheConfig controls the on-disk cache of AI answers
+type AICacheConfig struct {
+	Disabled  bool   `yaml:"disabled,omitempty" mapstructure:"disabled"`   // 不缓存 AI 的回答
+	Path      string `yaml:"path,omitempty" mapstructure:"path"`           // 缓存文件，默认 ~/.cleanup/ai-cache.json.gz
+	MaxSizeMB int    `yaml:"maxSizeMB,omitempty" mapstructure:"maxSizeMB"` // 缓存上限（MB），超出时淘汰最久未用的回答，默认 32
+	MaxAge    string `yaml:"maxAge,omitempty" mapstructure:"maxAge"`       // cleanup cache prune 删除多久未用的回答，如 90d
+}
+
+// FallbackConfig controls when a failing AI provider is skipped
+type FallbackConfig struct {
+	FailureThreshold int           `yaml:"failureThreshold,omitempty" mapstructure:"failureThreshold"` // 连续失败多少次后暂停使用该服务，默认 3
+	Cooldown         time.Duration `yaml:"cooldown,omitempty" mapstructure:"cooldown"`                 // 暂停多久后重新检查健康状态，默认 1m
+	ProbeTimeout     time.Duration `yaml:"probeTimeout,omitempty" mapstructure:"probeTimeout"`         // 健康检查超时，默认 5s
+}
+
+// PrivacyConfig controls what file content may be sent to the AI provider
+type PrivacyConfig struct {
+	Strict    bool              `yaml:"strict,omitempty" mapstructure:"strict"`       // 本地模型也使用远程服务的严格默认策略
+	Actions   map[string]string `yaml:"actions,omitempty" mapstructure:"actions"`     // 按敏感内容类型覆盖处理方式：allow、redact、block
+	NeverSend []string          `yaml:"neverSend,omitempty" mapstructure:"neverSend"` // 永不发送给 AI 的文件或目录模式
 }
 
 // OpenAIConfig represents OpenAI compatible service configuration
@@ -41,6 +70,14 @@ type CleanerConfig struct {
 	ImportantPatterns []string `yaml:"importantPatterns" mapstructure:"importantPatterns"` // Custom important file patterns
 }
 
+// SignatureConfig represents a custom file type signature
+type SignatureConfig struct {
+	MimeType   string   `yaml:"mimeType" mapstructure:"mimeType"`               // 识别出的 MIME 类型
+	Offset     int64    `yaml:"offset" mapstructure:"offset"`                   // 魔数偏移，负数表示从文件末尾起算
+	Magic      string   `yaml:"magic" mapstructure:"magic"`                     // 十六进制魔数，?? 匹配任意字节
+	Extensions []string `yaml:"extensions,omitempty" mapstructure:"extensions"` // 仅对这些扩展名生效（可选）
+}
+
 // ExcludeConfig represents files and directories to exclude from scanning
 type ExcludeConfig struct {
 	Extensions []string `yaml:"extensions" mapstructure:"extensions"` // 要排除的文件扩展名
@@ -48,6 +85,31 @@ type ExcludeConfig struct {
 	Dirs       []string `yaml:"dirs" mapstructure:"dirs"`             // 要排除的目录名
 }
 
+// TraversalConfig represents how directories are walked
+type TraversalConfig struct {
+	FollowSymlinks bool `yaml:"followSymlinks" mapstructure:"followSymlinks"` // 跟随符号链接（检测循环）
+	OneFileSystem  bool `yaml:"oneFileSystem" mapstructure:"oneFileSystem"`   // 不进入其他文件系统的挂载点
+	IncludePseudo  bool `yaml:"includePseudo" mapstructure:"includePseudo"`   // 进入 /proc、/sys 等伪文件系统
+}
+
+// NamingConfig controls how filenames are scored and which files get a
+// smarter name
+type NamingConfig struct {
+	Threshold    int                  `yaml:"threshold,omitempty" mapstructure:"threshold"`       // 低于此分数（0-100）的文件名需要智能重命名，默认 60
+	Locales      []string             `yaml:"locales,omitempty" mapstructure:"locales"`           // 启用哪些语言的通用词：en、zh、ja、de、fr、es，默认全部
+	Placeholders []string             `yaml:"placeholders,omitempty" mapstructure:"placeholders"` // 单独出现时文件名无意义的词
+	GenericWords []string             `yaml:"genericWords,omitempty" mapstructure:"genericWords"` // 单独出现时文件名过于笼统的词
+	Patterns     []*NamePatternConfig `yaml:"patterns,omitempty" mapstructure:"patterns"`         // 自定义评分规则
+}
+
+// NamePatternConfig adjusts the score of filenames matching a pattern
+type NamePatternConfig struct {
+	Pattern string `yaml:"pattern" mapstructure:"pattern"`         // 通配符（匹配完整文件名）或正则表达式，不区分大小写
+	Regex   bool   `yaml:"regex,omitempty" mapstructure:"regex"`   // pattern 是正则表达式
+	Score   int    `yaml:"score" mapstructure:"score"`             // 加减的分数，负数表示降低
+	Reason  string `yaml:"reason,omitempty" mapstructure:"reason"` // 评分说明
+}
+
 // OllamaConfig represents Ollama service configuration
 type OllamaConfig struct {
 	BaseURL   string                 `yaml:"baseUrl" mapstructure:"baseUrl"`
@@ -67,7 +129,8 @@ type Rule struct {
 
 // RuleCondition represents a condition for rule matching
 type RuleCondition struct {
-	Type     string      `yaml:"type" mapstructure:"type"`
+	Type     string      `yaml:"type" mapstructure:"type"`             // extension, pattern, size, date, composite, metadata or archive
+	Field    string      `yaml:"field,omitempty" mapstructure:"field"` // Metadata field, or the file time of "date" conditions
 	Value    interface{} `yaml:"value" mapstructure:"value"`
 	Operator string      `yaml:"operator" mapstructure:"operator"`
 }
@@ -141,6 +204,9 @@ func (m *Manager) Save(config *CleanupConfig) error {
 	m.v.Set("trashPath", config.TrashPath)
 	m.v.Set("cleaner", config.Cleaner)
 	m.v.Set("exclude", config.Exclude)
+	m.v.Set("traversal", config.Traversal)
+	m.v.Set("naming", config.Naming)
+	m.v.Set("signatures", config.Signatures)
 
 	// Write to file
 	if err := m.v.WriteConfigAs(m.path); err != nil {
@@ -181,3 +181,17 @@ func generateRandomConfig(t *rapid.T) *CleanupConfig {
 		Rules:              rules,
 	}
 }
+
+// Test that the example configuration shipped with the repository loads
+func TestExampleConfiguration(t *testing.T) {
+	config, err := NewManager(filepath.Join("..", "..", ".cleanuprc.yaml")).Load()
+	require.NoError(t, err)
+
+	require.NotNil(t, config.AI.Privacy)
+	assert.Equal(t, "allow", config.AI.Privacy.Actions["email"])
+	assert.Equal(t, []string{"~/Documents/Finance"}, config.AI.Privacy.NeverSend)
+
+	require.NotNil(t, config.AI.Cache)
+	assert.Equal(t, 32, config.AI.Cache.MaxSizeMB)
+	assert.Equal(t, "90d", config.AI.Cache.MaxAge)
+}
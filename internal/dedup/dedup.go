@@ -10,6 +10,10 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+	"github.com/xuanyiying/cleanup-cli/internal/walk"
 )
 
 // FileInfo represents information about a file for deduplication
@@ -18,7 +22,12 @@ type FileInfo struct {
 	Size     int64
 	Hash     string
 	ModTime  time.Time
-	IsBackup bool // Whether this file is in a backup/trash location
+	IsBackup bool     // Whether this file is in a backup/trash location
+	Archive  string   // Archive holding the file; empty for files on disk
+	Links    []string // Other hard links to the file; removing it frees no space while they remain
+
+	stat os.FileInfo
+	id   walk.FileID
 }
 
 // DuplicateGroup represents a group of duplicate files
@@ -28,27 +37,54 @@ type DuplicateGroup struct {
 	Files []*FileInfo
 }
 
+// OnDisk returns the files of the group that are not inside archives
+func (g *DuplicateGroup) OnDisk() []*FileInfo {
+	files := make([]*FileInfo, 0, len(g.Files))
+	for _, file := range g.Files {
+		if file.Archive == "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// hashAlgorithm names the hash of file contents kept in a HashCache
+const hashAlgorithm = "sha256"
+
+// HashCache remembers file hashes between runs so that unchanged files are
+// not read again
+type HashCache interface {
+	// Hash returns the hash of a file computed with algorithm if the file
+	// has not changed since
+	Hash(path string, info os.FileInfo, algorithm string) (string, bool)
+	// SetHash records the hash of a file computed with algorithm
+	SetHash(path string, info os.FileInfo, algorithm, hash string)
+}
+
 // Deduplicator finds and manages duplicate files
 type Deduplicator struct {
 	// Configuration
-	MinSize int64 // Minimum file size to consider (skip tiny files)
-	MaxSize int64 // Maximum file size to hash (0 = no limit)
+	MinSize   int64        // Minimum file size to consider (skip tiny files)
+	MaxSize   int64        // Maximum file size to hash (0 = no limit)
+	Cache     HashCache    // Hashes of unchanged files (optional)
+	Traversal walk.Options // How FindDuplicates treats links and mount points
 }
 
 // NewDeduplicator creates a new deduplicator
 func NewDeduplicator() *Deduplicator {
 	return &Deduplicator{
-		MinSize: 1024,        // 1KB minimum
+		MinSize: 1024,              // 1KB minimum
 		MaxSize: 100 * 1024 * 1024, // 100MB maximum by default
 	}
 }
 
-// FindDuplicates scans a directory and finds duplicate files
+// FindDuplicates scans a directory and finds duplicate files. Hard links
+// to one file are not duplicates: they are reported as the Links of the
+// first path found.
 func (d *Deduplicator) FindDuplicates(ctx context.Context, rootPath string) ([]*DuplicateGroup, error) {
-	// First pass: group by size
-	sizeGroups := make(map[int64][]*FileInfo)
+	c := d.newCollector()
 
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	err := walk.Walk(rootPath, &d.Traversal, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Skip files we can't access
 		}
@@ -60,25 +96,20 @@ func (d *Deduplicator) FindDuplicates(ctx context.Context, rootPath string) ([]*
 		default:
 		}
 
-		// Skip directories and files outside size range
-		if info.IsDir() {
-			return nil
-		}
-		if info.Size() < d.MinSize {
-			return nil
-		}
-		if d.MaxSize > 0 && info.Size() > d.MaxSize {
+		// Skip directories, and links that are not followed
+		if !info.Mode().IsRegular() {
 			return nil
 		}
 
-		fileInfo := &FileInfo{
+		id, _ := walk.ID(info)
+		c.add(&FileInfo{
 			Path:     path,
 			Size:     info.Size(),
 			ModTime:  info.ModTime(),
 			IsBackup: isBackupLocation(path),
-		}
-
-		sizeGroups[info.Size()] = append(sizeGroups[info.Size()], fileInfo)
+			stat:     info,
+			id:       id,
+		})
 		return nil
 	})
 
@@ -86,48 +117,135 @@ func (d *Deduplicator) FindDuplicates(ctx context.Context, rootPath string) ([]*
 		return nil, fmt.Errorf("failed to scan directory: %w", err)
 	}
 
-	// Second pass: hash files with same size
-	hashGroups := make(map[string]*DuplicateGroup)
+	return c.duplicates(), nil
+}
 
-	for size, files := range sizeGroups {
-		// Only hash if there are multiple files of the same size
-		if len(files) < 2 {
-			continue
+// FindDuplicatesStream finds duplicate files among those of a streamed
+// analysis. Files are hashed as soon as a second file of the same size
+// arrives, so hashing overlaps the scan. Scans with SkipContent set are
+// enough: only paths, sizes and times are used. Files inside archives,
+// streamed with ScanOptions.Archives and CalculateHash, are compared by the
+// hash the scan computed, so groups show copies that are already archived.
+func (d *Deduplicator) FindDuplicatesStream(ctx context.Context, results <-chan analyzer.Result) ([]*DuplicateGroup, error) {
+	c := d.newCollector()
+
+	for result := range results {
+		if result.Err != nil {
+			if result.Path == "" {
+				return nil, result.Err
+			}
+			continue // Skip files that couldn't be analyzed
 		}
 
-		for _, file := range files {
-			// Check context cancellation
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			default:
-			}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
-			hash, err := d.hashFile(file.Path)
-			if err != nil {
-				continue // Skip files we can't hash
-			}
+		m := result.Metadata
+		if m.IsArchived() && m.ArchiveHash == "" {
+			continue // Archived files are only compared by their hash
+		}
+		c.add(&FileInfo{
+			Path:     result.Path,
+			Size:     m.Size,
+			Hash:     m.ArchiveHash,
+			ModTime:  m.ModifiedAt,
+			IsBackup: isBackupLocation(result.Path),
+			Archive:  m.ArchivePath,
+			id:       m.FileID,
+		})
+	}
 
-			file.Hash = hash
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.duplicates(), nil
+}
 
-			if group, exists := hashGroups[hash]; exists {
-				group.Files = append(group.Files, file)
-			} else {
-				hashGroups[hash] = &DuplicateGroup{
-					Hash:  hash,
-					Size:  size,
-					Files: []*FileInfo{file},
-				}
-			}
+// collector groups files by size and hashes the files of sizes seen more
+// than once
+type collector struct {
+	d           *Deduplicator
+	firstBySize map[int64]*FileInfo // Files not hashed yet, as no other file has their size
+	hashGroups  map[string]*DuplicateGroup
+	byID        map[walk.FileID]*FileInfo // Files by identity, to recognize hard links
+}
+
+func (d *Deduplicator) newCollector() *collector {
+	return &collector{
+		d:           d,
+		firstBySize: make(map[int64]*FileInfo),
+		hashGroups:  make(map[string]*DuplicateGroup),
+		byID:        make(map[walk.FileID]*FileInfo),
+	}
+}
+
+// add records a file, hashing it if another file of its size was seen
+func (c *collector) add(file *FileInfo) {
+	// Skip files outside size range
+	if file.Size < c.d.MinSize {
+		return
+	}
+	if c.d.MaxSize > 0 && file.Size > c.d.MaxSize {
+		return
+	}
+
+	// Another link to a file seen before shares its data
+	if !file.id.IsZero() {
+		if first, seen := c.byID[file.id]; seen {
+			first.Links = append(first.Links, file.Path)
+			return
 		}
+		c.byID[file.id] = file
 	}
 
+	first, seen := c.firstBySize[file.Size]
+	if !seen {
+		c.firstBySize[file.Size] = file
+		return
+	}
+	if first != nil {
+		c.hash(first)
+		c.firstBySize[file.Size] = nil
+	}
+	c.hash(file)
+}
+
+// hash adds a file to the group of its hash, hashing it unless the hash
+// is known
+func (c *collector) hash(file *FileInfo) {
+	hash := file.Hash
+	if hash == "" {
+		var err error
+		if hash, err = c.d.cachedHash(file); err != nil {
+			return // Skip files we can't hash
+		}
+	}
+
+	file.Hash = hash
+	if group, exists := c.hashGroups[hash]; exists {
+		group.Files = append(group.Files, file)
+	} else {
+		c.hashGroups[hash] = &DuplicateGroup{
+			Hash:  hash,
+			Size:  file.Size,
+			Files: []*FileInfo{file},
+		}
+	}
+}
+
+// duplicates returns the groups with more than one file, at least one of
+// them on disk
+func (c *collector) duplicates() []*DuplicateGroup {
 	// Filter to only groups with duplicates
 	var duplicates []*DuplicateGroup
-	for _, group := range hashGroups {
-		if len(group.Files) > 1 {
-			// Sort files: prefer non-backup, then newer files
+	for _, group := range c.hashGroups {
+		if len(group.Files) > 1 && len(group.OnDisk()) > 0 {
+			// Sort files: files on disk first, then non-backup, then newer files
 			sort.Slice(group.Files, func(i, j int) bool {
+				if (group.Files[i].Archive == "") != (group.Files[j].Archive == "") {
+					return group.Files[i].Archive == ""
+				}
 				if group.Files[i].IsBackup != group.Files[j].IsBackup {
 					return !group.Files[i].IsBackup // Non-backup first
 				}
@@ -142,7 +260,30 @@ func (d *Deduplicator) FindDuplicates(ctx context.Context, rootPath string) ([]*
 		return duplicates[i].Size > duplicates[j].Size
 	})
 
-	return duplicates, nil
+	return duplicates
+}
+
+// cachedHash returns the hash of a file from the cache, hashing and caching
+// it if the file changed since
+func (d *Deduplicator) cachedHash(file *FileInfo) (string, error) {
+	if d.Cache == nil {
+		return d.hashFile(file.Path)
+	}
+	if file.stat == nil {
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return "", err
+		}
+		file.stat = info
+	}
+	if hash, ok := d.Cache.Hash(file.Path, file.stat, hashAlgorithm); ok {
+		return hash, nil
+	}
+	hash, err := d.hashFile(file.Path)
+	if err == nil {
+		d.Cache.SetHash(file.Path, file.stat, hashAlgorithm, hash)
+	}
+	return hash, err
 }
 
 // hashFile computes SHA-256 hash of a file
@@ -187,14 +328,16 @@ func isBackupLocation(path string) bool {
 
 // RemovalPlan represents a plan for removing duplicate files
 type RemovalPlan struct {
-	Groups      []*DuplicateGroup
-	ToRemove    []*FileInfo
-	ToKeep      []*FileInfo
-	SpaceSaved  int64
+	Groups     []*DuplicateGroup
+	ToRemove   []*FileInfo
+	ToKeep     []*FileInfo
+	SpaceSaved int64
 }
 
 // CreateRemovalPlan creates a plan for removing duplicates
 // keepStrategy: "newest", "oldest", "first", "manual"
+// Files inside archives are never removed, and one copy on disk is always kept.
+// A file with other hard links is kept in place of the strategy's choice.
 func (d *Deduplicator) CreateRemovalPlan(groups []*DuplicateGroup, keepStrategy string) *RemovalPlan {
 	plan := &RemovalPlan{
 		Groups:   groups,
@@ -203,7 +346,9 @@ func (d *Deduplicator) CreateRemovalPlan(groups []*DuplicateGroup, keepStrategy
 	}
 
 	for _, group := range groups {
-		if len(group.Files) < 2 {
+		files := group.OnDisk()
+		if len(files) < 2 {
+			plan.ToKeep = append(plan.ToKeep, files...)
 			continue
 		}
 
@@ -212,19 +357,32 @@ func (d *Deduplicator) CreateRemovalPlan(groups []*DuplicateGroup, keepStrategy
 		case "newest":
 			keepIndex = 0 // Already sorted with newest first
 		case "oldest":
-			keepIndex = len(group.Files) - 1
+			keepIndex = len(files) - 1
 		case "first":
 			keepIndex = 0
 		default:
 			keepIndex = 0 // Default to newest
 		}
 
-		for i, file := range group.Files {
+		// Removing a file with other hard links frees no space, so such a
+		// file is kept instead
+		if len(files[keepIndex].Links) == 0 {
+			for i, file := range files {
+				if len(file.Links) > 0 {
+					keepIndex = i
+					break
+				}
+			}
+		}
+
+		for i, file := range files {
 			if i == keepIndex {
 				plan.ToKeep = append(plan.ToKeep, file)
 			} else {
 				plan.ToRemove = append(plan.ToRemove, file)
-				plan.SpaceSaved += file.Size
+				if len(file.Links) == 0 {
+					plan.SpaceSaved += file.Size
+				}
 			}
 		}
 	}
@@ -255,6 +413,68 @@ func (d *Deduplicator) ExecuteRemovalPlan(ctx context.Context, plan *RemovalPlan
 	return nil
 }
 
+// TrashResult represents the outcome of moving duplicates to the trash
+type TrashResult struct {
+	Moved         []*FileInfo
+	SpaceSaved    int64
+	Errors        []error
+	TransactionID string
+}
+
+// TrashRemovalPlan moves the duplicates of a plan into trashDir instead of
+// deleting them, recording the moves in a transaction so they can be undone
+func (d *Deduplicator) TrashRemovalPlan(ctx context.Context, plan *RemovalPlan, trashDir string, txnManager *transaction.Manager) (*TrashResult, error) {
+	result := &TrashResult{
+		Moved:  make([]*FileInfo, 0),
+		Errors: make([]error, 0),
+	}
+
+	if err := os.MkdirAll(trashDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create trash directory: %w", err)
+	}
+
+	tx := txnManager.Begin()
+
+	for _, file := range plan.ToRemove {
+		if err := ctx.Err(); err != nil {
+			if rbErr := txnManager.Rollback(tx); rbErr != nil {
+				return result, fmt.Errorf("failed to rollback transaction: %w", rbErr)
+			}
+			return result, err
+		}
+
+		// Generate unique trash filename
+		target := filepath.Join(trashDir, filepath.Base(file.Path))
+		for counter := 1; ; counter++ {
+			if _, err := os.Stat(target); os.IsNotExist(err) {
+				break
+			}
+			target = filepath.Join(trashDir, fmt.Sprintf("%s.%d", filepath.Base(file.Path), counter))
+		}
+
+		if err := os.Rename(file.Path, target); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("failed to move %s to trash: %w", file.Path, err))
+			continue
+		}
+
+		txnManager.AddOperation(tx, &transaction.ExecutedOperation{
+			Type:   transaction.OpMove,
+			Source: file.Path,
+			Target: target,
+			Backup: target, // Backup is the trash location
+		})
+		result.Moved = append(result.Moved, file)
+		result.SpaceSaved += file.Size
+	}
+
+	if err := txnManager.Commit(tx); err != nil {
+		return result, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	result.TransactionID = tx.ID
+
+	return result, nil
+}
+
 // Stats returns statistics about duplicates
 type Stats struct {
 	TotalGroups      int
@@ -262,6 +482,8 @@ type Stats struct {
 	TotalFiles       int
 	WastedSpace      int64
 	LargestDuplicate int64
+	ArchivedCopies   int // Copies inside archives, not counted as files
+	HardLinks        int // Further hard links to files of the groups, not counted as files
 }
 
 // GetStats calculates statistics from duplicate groups
@@ -269,10 +491,23 @@ func GetStats(groups []*DuplicateGroup) *Stats {
 	stats := &Stats{}
 
 	for _, group := range groups {
+		onDisk := group.OnDisk()
+		files := len(onDisk)
 		stats.TotalGroups++
-		stats.TotalFiles += len(group.Files)
-		stats.TotalDuplicates += len(group.Files) - 1
-		stats.WastedSpace += group.Size * int64(len(group.Files)-1)
+		stats.TotalFiles += files
+		stats.TotalDuplicates += files - 1
+		stats.ArchivedCopies += len(group.Files) - files
+
+		// One copy is kept, preferably one with other hard links, and
+		// removing the other linked files would free no space either
+		linked := 0
+		for _, file := range onDisk {
+			stats.HardLinks += len(file.Links)
+			if len(file.Links) > 0 {
+				linked++
+			}
+		}
+		stats.WastedSpace += group.Size * int64(files-max(linked, 1))
 
 		if group.Size > stats.LargestDuplicate {
 			stats.LargestDuplicate = group.Size
@@ -1,11 +1,16 @@
 package dedup
 
 import (
+	"archive/zip"
 	"context"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+	"github.com/xuanyiying/cleanup-cli/internal/walk"
 )
 
 func TestDeduplicator_FindDuplicates(t *testing.T) {
@@ -247,3 +252,255 @@ func TestExecuteRemovalPlan_Actual(t *testing.T) {
 		t.Error("File was not removed")
 	}
 }
+
+func TestTrashRemovalPlan_Undoable(t *testing.T) {
+	tmpDir := t.TempDir()
+	trashDir := filepath.Join(tmpDir, "trash")
+	testFile := filepath.Join(tmpDir, "copy.txt")
+	if err := os.WriteFile(testFile, []byte("duplicate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &RemovalPlan{
+		ToRemove: []*FileInfo{
+			{Path: testFile, Size: 9},
+		},
+	}
+
+	txnManager := transaction.NewManager(filepath.Join(tmpDir, "txn.log"))
+	result, err := NewDeduplicator().TrashRemovalPlan(context.Background(), plan, trashDir, txnManager)
+	if err != nil {
+		t.Fatalf("TrashRemovalPlan failed: %v", err)
+	}
+
+	if len(result.Moved) != 1 || result.SpaceSaved != 9 || result.TransactionID == "" {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(trashDir, "copy.txt")); err != nil {
+		t.Errorf("Duplicate not in trash: %v", err)
+	}
+
+	// Undo restores the duplicate
+	if err := txnManager.Undo(result.TransactionID); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if _, err := os.Stat(testFile); err != nil {
+		t.Errorf("Duplicate not restored: %v", err)
+	}
+}
+
+// countingCache is a HashCache that counts the hashes it hands out
+type countingCache struct {
+	hashes map[string]string
+	hits   int
+}
+
+func (c *countingCache) Hash(path string, info os.FileInfo, algorithm string) (string, bool) {
+	hash, ok := c.hashes[algorithm+":"+path]
+	if ok {
+		c.hits++
+	}
+	return hash, ok
+}
+
+func (c *countingCache) SetHash(path string, info os.FileInfo, algorithm, hash string) {
+	c.hashes[algorithm+":"+path] = hash
+}
+
+func TestDeduplicator_Cache(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("same"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache := &countingCache{hashes: make(map[string]string)}
+	d := NewDeduplicator()
+	d.MinSize = 1
+	d.Cache = cache
+
+	first, err := d.FindDuplicates(context.Background(), tmpDir)
+	if err != nil || len(first) != 1 {
+		t.Fatalf("FindDuplicates() = %v, %v", first, err)
+	}
+	if len(cache.hashes) != 3 || cache.hits != 0 {
+		t.Fatalf("first run cached %d hashes with %d hits", len(cache.hashes), cache.hits)
+	}
+
+	// The second run takes every hash from the cache
+	second, err := d.FindDuplicates(context.Background(), tmpDir)
+	if err != nil || len(second) != 1 || len(second[0].Files) != 3 {
+		t.Fatalf("FindDuplicates() = %v, %v", second, err)
+	}
+	if cache.hits != 3 {
+		t.Errorf("second run had %d cache hits, want 3", cache.hits)
+	}
+	if second[0].Hash != first[0].Hash {
+		t.Errorf("cached hash %s differs from %s", second[0].Hash, first[0].Hash)
+	}
+}
+
+func TestDeduplicator_FindDuplicatesStream(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Mkdir(filepath.Join(tmpDir, "backup"), 0755)
+	files := map[string]string{
+		"a.txt":         "same content",
+		"backup/a.txt":  "same content",
+		"b.txt":         "same size!!!",
+		"unique.txt":    "something else entirely",
+		".hidden/x.txt": "same content",
+	}
+	os.Mkdir(filepath.Join(tmpDir, ".hidden"), 0755)
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := NewDeduplicator()
+	d.MinSize = 1
+	ctx := context.Background()
+	// The scan options decide which files are compared: hidden ones are not
+	results := analyzer.NewAnalyzer().AnalyzeStream(ctx, tmpDir, &analyzer.ScanOptions{
+		Recursive:   true,
+		ExcludeDirs: []string{".hidden"},
+		SkipContent: true,
+	})
+
+	groups, err := d.FindDuplicatesStream(ctx, results)
+	if err != nil {
+		t.Fatalf("FindDuplicatesStream failed: %v", err)
+	}
+	if len(groups) != 1 || len(groups[0].Files) != 2 {
+		t.Fatalf("Expected 1 group of 2 files, got %+v", groups)
+	}
+	// Files outside backup locations come first
+	if groups[0].Files[0].Path != filepath.Join(tmpDir, "a.txt") || !groups[0].Files[1].IsBackup {
+		t.Errorf("Unexpected order: %s, %s", groups[0].Files[0].Path, groups[0].Files[1].Path)
+	}
+}
+
+func TestDeduplicator_ArchivedCopies(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "photo.jpg"), []byte("holiday photo"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "copy.jpg"), []byte("holiday photo"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "report.pdf"), []byte("quarterly report"), 0644)
+
+	// The zip holds copies of a photo and the report, and a file of its own
+	backup := filepath.Join(tmpDir, "backup.zip")
+	f, err := os.Create(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"2023/photo.jpg": "holiday photo",
+		"report.pdf":     "quarterly report",
+		"only.txt":       "only in the archive",
+	} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	f.Close()
+
+	d := NewDeduplicator()
+	d.MinSize = 1
+	ctx := context.Background()
+	results := analyzer.NewAnalyzer().AnalyzeStream(ctx, tmpDir, &analyzer.ScanOptions{
+		Recursive:     true,
+		SkipContent:   true,
+		CalculateHash: true,
+		Archives:      true,
+	})
+	groups, err := d.FindDuplicatesStream(ctx, results)
+	if err != nil {
+		t.Fatalf("FindDuplicatesStream failed: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(groups))
+	}
+
+	for _, group := range groups {
+		last := group.Files[len(group.Files)-1]
+		if last.Archive != backup {
+			t.Errorf("Expected the archived copy last, got %s", last.Path)
+		}
+	}
+
+	// Archived copies are reported but never removed, and a file whose only
+	// copy is archived is kept
+	plan := d.CreateRemovalPlan(groups, "newest")
+	if len(plan.ToRemove) != 1 || plan.ToRemove[0].Archive != "" || len(plan.ToKeep) != 2 {
+		t.Errorf("Unexpected plan: remove %d, keep %d", len(plan.ToRemove), len(plan.ToKeep))
+	}
+
+	stats := GetStats(groups)
+	if stats.TotalFiles != 3 || stats.TotalDuplicates != 1 || stats.ArchivedCopies != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestDeduplicator_HardLinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := []byte("the same report, stored once")
+	original := filepath.Join(tmpDir, "report.pdf")
+	os.WriteFile(original, content, 0644)
+	if err := os.Link(original, filepath.Join(tmpDir, "linked.pdf")); err != nil {
+		t.Skip("hard links not supported:", err)
+	}
+	if _, ok := walk.ID(mustStat(t, original)); !ok {
+		t.Skip("file IDs not reported on this platform")
+	}
+
+	d := NewDeduplicator()
+	d.MinSize = 1
+	ctx := context.Background()
+
+	// Two links to one file are not duplicates
+	groups, err := d.FindDuplicates(ctx, tmpDir)
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+	if len(groups) != 0 {
+		t.Fatalf("Expected no duplicates among hard links, got %d groups", len(groups))
+	}
+
+	// A real copy is, and the links are reported with the file
+	os.WriteFile(filepath.Join(tmpDir, "copy.pdf"), content, 0644)
+	for name, find := range map[string]func() ([]*DuplicateGroup, error){
+		"walk": func() ([]*DuplicateGroup, error) { return d.FindDuplicates(ctx, tmpDir) },
+		"stream": func() ([]*DuplicateGroup, error) {
+			results := analyzer.NewAnalyzer().AnalyzeStream(ctx, tmpDir, &analyzer.ScanOptions{Recursive: true, SkipContent: true})
+			return d.FindDuplicatesStream(ctx, results)
+		},
+	} {
+		groups, err := find()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(groups) != 1 || len(groups[0].Files) != 2 {
+			t.Fatalf("%s: expected one group of two files, got %+v", name, groups)
+		}
+		stats := GetStats(groups)
+		if stats.HardLinks != 1 || stats.TotalFiles != 2 || stats.WastedSpace != int64(len(content)) {
+			t.Errorf("%s: unexpected stats: %+v", name, stats)
+		}
+
+		// The linked file is kept, so removing the copy frees its space
+		plan := d.CreateRemovalPlan(groups, "oldest")
+		if len(plan.ToRemove) != 1 || len(plan.ToRemove[0].Links) != 0 || plan.SpaceSaved != int64(len(content)) {
+			t.Errorf("%s: unexpected plan: remove %+v, saving %d", name, plan.ToRemove, plan.SpaceSaved)
+		}
+	}
+}
+
+func mustStat(t *testing.T, path string) os.FileInfo {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
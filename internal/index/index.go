@@ -0,0 +1,405 @@
+package index
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+// formatVersion is bumped whenever the meaning of stored entries changes;
+// index files of another version are discarded on load
+const formatVersion = 2
+
+// Entry is what the index remembers about one file. It stays valid while
+// the file keeps its inode, size and modification time.
+type Entry struct {
+	Inode       uint64                 `json:"inode,omitempty"`
+	Size        int64                  `json:"size"`
+	ModTime     time.Time              `json:"mtime"`
+	Metadata    *analyzer.FileMetadata `json:"metadata,omitempty"`
+	Hashes      map[string]string      `json:"hashes,omitempty"`      // Content hashes by algorithm
+	Suggestions map[string]string      `json:"suggestions,omitempty"` // AI suggestions by kind
+	IndexedAt   time.Time              `json:"indexed_at"`
+}
+
+// indexFile is the on-disk layout of an index
+type indexFile struct {
+	Version int               `json:"version"`
+	SavedAt time.Time         `json:"saved_at"`
+	Entries map[string]*Entry `json:"entries"`
+}
+
+// Index is a persistent cache of file analysis results keyed by path, so
+// that repeated scans only read the files that changed since
+type Index struct {
+	path    string
+	mu      sync.RWMutex
+	entries map[string]*Entry
+	savedAt time.Time
+	dirty   bool
+	hits    int
+	misses  int
+}
+
+// NewIndex creates an empty index stored at path
+func NewIndex(path string) *Index {
+	return &Index{
+		path:    path,
+		entries: make(map[string]*Entry),
+	}
+}
+
+// Open loads the index stored at path. A missing file gives an empty index.
+func Open(path string) (*Index, error) {
+	idx := NewIndex(path)
+	if err := idx.load(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Path returns the file the index is stored in
+func (idx *Index) Path() string {
+	return idx.path
+}
+
+// load reads the entries from disk, replacing those in memory
+func (idx *Index) load() error {
+	f, err := os.Open(idx.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	var file indexFile
+	if err := json.NewDecoder(zr).Decode(&file); err != nil {
+		return fmt.Errorf("failed to unmarshal index: %w", err)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.entries = make(map[string]*Entry)
+	if file.Version == formatVersion && file.Entries != nil {
+		idx.entries = file.Entries
+		idx.savedAt = file.SavedAt
+	}
+	return nil
+}
+
+// Save writes the index to disk if it changed since it was loaded. The file
+// is replaced atomically so that an interrupted save keeps the old index.
+func (idx *Index) Save() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.dirty {
+		return nil
+	}
+
+	dir := filepath.Dir(idx.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(idx.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create index file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	now := time.Now()
+	zw := gzip.NewWriter(tmp)
+	err = json.NewEncoder(zw).Encode(&indexFile{Version: formatVersion, SavedAt: now, Entries: idx.entries})
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), idx.path); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	idx.savedAt = now
+	idx.dirty = false
+	return nil
+}
+
+// Len returns the number of files in the index
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.entries)
+}
+
+// Hits returns how many lookups since the index was opened were answered
+// from it and how many were not
+func (idx *Index) Hits() (hits, misses int) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.hits, idx.misses
+}
+
+// matches reports whether an entry still describes the file
+func (e *Entry) matches(info os.FileInfo) bool {
+	if e.Size != info.Size() || !e.ModTime.Equal(info.ModTime()) {
+		return false
+	}
+	// Inodes are compared where the platform reports them
+	inode := fileInode(info)
+	return e.Inode == 0 || inode == 0 || e.Inode == inode
+}
+
+// valid returns the entry of path if the file has not changed since it was
+// indexed. The caller holds the lock.
+func (idx *Index) valid(path string, info os.FileInfo) (*Entry, bool) {
+	e, ok := idx.entries[path]
+	if !ok || !e.matches(info) {
+		return nil, false
+	}
+	return e, true
+}
+
+// upsert returns the entry of path for updating, starting a new one if the
+// file changed since it was indexed. The caller holds the write lock.
+func (idx *Index) upsert(path string, info os.FileInfo) *Entry {
+	idx.dirty = true
+	if e, ok := idx.valid(path, info); ok {
+		e.IndexedAt = time.Now()
+		return e
+	}
+	e := &Entry{
+		Inode:     fileInode(info),
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		IndexedAt: time.Now(),
+	}
+	idx.entries[path] = e
+	return e
+}
+
+// Lookup returns a copy of the metadata stored for path if the file has not
+// changed since it was indexed
+func (idx *Index) Lookup(path string, info os.FileInfo) (*analyzer.FileMetadata, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	e, ok := idx.valid(path, info)
+	if !ok || e.Metadata == nil {
+		idx.misses++
+		return nil, false
+	}
+	idx.hits++
+	return copyMetadata(e.Metadata), true
+}
+
+// Store records the metadata of a file. AI suggestions on the metadata are
+// not stored; they are kept apart with SetSuggestion.
+func (idx *Index) Store(path string, info os.FileInfo, metadata *analyzer.FileMetadata) {
+	m := copyMetadata(metadata)
+	m.SuggestedName = ""
+	m.SuggestedBy = ""
+	m.ScenarioCategory = ""
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.upsert(path, info).Metadata = m
+}
+
+// copyMetadata copies metadata so that callers can't change the index
+func copyMetadata(metadata *analyzer.FileMetadata) *analyzer.FileMetadata {
+	m := *metadata
+	m.ExifData = maps.Clone(metadata.ExifData)
+	m.MediaData = maps.Clone(metadata.MediaData)
+	return &m
+}
+
+// Hash returns the content hash of a file computed with algorithm if the
+// file has not changed since it was hashed
+func (idx *Index) Hash(path string, info os.FileInfo, algorithm string) (string, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if e, ok := idx.valid(path, info); ok {
+		if hash, ok := e.Hashes[algorithm]; ok {
+			idx.hits++
+			return hash, true
+		}
+	}
+	idx.misses++
+	return "", false
+}
+
+// SetHash records the content hash of a file computed with algorithm
+func (idx *Index) SetHash(path string, info os.FileInfo, algorithm, hash string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	e := idx.upsert(path, info)
+	if e.Hashes == nil {
+		e.Hashes = make(map[string]string)
+	}
+	e.Hashes[algorithm] = hash
+}
+
+// Suggestion returns the AI suggestion of a kind stored for an analyzed
+// file, as long as the file has not changed since
+func (idx *Index) Suggestion(file *analyzer.FileMetadata, kind string) (string, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	e, ok := idx.entries[file.Path]
+	if !ok || e.Size != file.Size || !e.ModTime.Equal(file.ModifiedAt) {
+		return "", false
+	}
+	suggestion, ok := e.Suggestions[kind]
+	return suggestion, ok
+}
+
+// SetSuggestion records an AI suggestion for an analyzed file. Files that
+// are not in the index, or changed since, are left out.
+func (idx *Index) SetSuggestion(file *analyzer.FileMetadata, kind, suggestion string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	e, ok := idx.entries[file.Path]
+	if !ok || e.Size != file.Size || !e.ModTime.Equal(file.ModifiedAt) {
+		return
+	}
+	if e.Suggestions == nil {
+		e.Suggestions = make(map[string]string)
+	}
+	e.Suggestions[kind] = suggestion
+	idx.dirty = true
+}
+
+// Clear removes the entries of root and the files below it, or all entries
+// if root is empty. It returns the number of entries removed.
+func (idx *Index) Clear(root string) int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	removed := 0
+	for path := range idx.entries {
+		if root == "" || within(root, path) {
+			delete(idx.entries, path)
+			removed++
+		}
+	}
+	if removed > 0 {
+		idx.dirty = true
+	}
+	return removed
+}
+
+// within reports whether path is root or below it
+func within(root, path string) bool {
+	root = filepath.Clean(root)
+	return path == root || strings.HasPrefix(path, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// Status summarizes an index
+type Status struct {
+	Path      string
+	Entries   int
+	Current   int       // Files unchanged since they were indexed
+	Changed   int       // Files changed since they were indexed
+	Missing   int       // Files that no longer exist
+	Hashed    int       // Entries with a content hash
+	Suggested int       // Entries with AI suggestions
+	FileSize  int64     // Size of the index file
+	SavedAt   time.Time // Zero if the index was never saved
+}
+
+// Check stats every indexed file to tell current entries from changed and
+// missing ones
+func (idx *Index) Check(ctx context.Context) (*Status, error) {
+	paths := idx.paths()
+	status := &Status{Path: idx.path, Entries: len(paths)}
+	if info, err := os.Stat(idx.path); err == nil {
+		status.FileSize = info.Size()
+	}
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+
+		idx.mu.RLock()
+		e := idx.entries[path]
+		switch {
+		case e == nil:
+		case err != nil:
+			status.Missing++
+		case e.matches(info):
+			status.Current++
+		default:
+			status.Changed++
+		}
+		if e != nil && (len(e.Hashes) > 0 || e.Metadata != nil && e.Metadata.Hash != "") {
+			status.Hashed++
+		}
+		if e != nil && len(e.Suggestions) > 0 {
+			status.Suggested++
+		}
+		idx.mu.RUnlock()
+	}
+
+	idx.mu.RLock()
+	status.SavedAt = idx.savedAt
+	idx.mu.RUnlock()
+	return status, nil
+}
+
+// Prune removes the entries of files that no longer exist or changed since
+// they were indexed, and returns how many were removed
+func (idx *Index) Prune(ctx context.Context) (int, error) {
+	removed := 0
+	for _, path := range idx.paths() {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		info, err := os.Stat(path)
+
+		idx.mu.Lock()
+		if e, ok := idx.entries[path]; ok && (err != nil || info.IsDir() || !e.matches(info)) {
+			delete(idx.entries, path)
+			idx.dirty = true
+			removed++
+		}
+		idx.mu.Unlock()
+	}
+	return removed, nil
+}
+
+// paths returns the indexed paths in order
+func (idx *Index) paths() []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	paths := make([]string, 0, len(idx.entries))
+	for path := range idx.entries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,217 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+)
+
+func writeFile(t *testing.T, path, content string) os.FileInfo {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestIndex_LookupAndStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	info := writeFile(t, path, "hello")
+
+	idx := NewIndex(filepath.Join(dir, "index.json.gz"))
+	if _, ok := idx.Lookup(path, info); ok {
+		t.Fatal("Lookup() found a file that was never stored")
+	}
+
+	idx.Store(path, info, &analyzer.FileMetadata{
+		Path:          path,
+		MimeType:      "text/plain",
+		ExifData:      map[string]string{"Make": "Canon"},
+		SuggestedName: "greeting",
+	})
+	m, ok := idx.Lookup(path, info)
+	if !ok || m.MimeType != "text/plain" || m.ExifData["Make"] != "Canon" {
+		t.Fatalf("Lookup() = %+v, %v", m, ok)
+	}
+	if m.SuggestedName != "" {
+		t.Errorf("SuggestedName = %q, want AI suggestions kept out of the metadata", m.SuggestedName)
+	}
+
+	// Callers get a copy
+	m.ExifData["Make"] = "Nikon"
+	if m, _ := idx.Lookup(path, info); m.ExifData["Make"] != "Canon" {
+		t.Error("changing looked-up metadata changed the index")
+	}
+
+	// A changed file misses
+	later := info.ModTime().Add(time.Second)
+	os.Chtimes(path, later, later)
+	changed, _ := os.Stat(path)
+	if _, ok := idx.Lookup(path, changed); ok {
+		t.Error("Lookup() found a file whose modification time changed")
+	}
+	grown := writeFile(t, path, "hello, world")
+	if _, ok := idx.Lookup(path, grown); ok {
+		t.Error("Lookup() found a file whose size changed")
+	}
+
+	if hits, misses := idx.Hits(); hits != 2 || misses != 3 {
+		t.Errorf("Hits() = %d, %d, want 2, 3", hits, misses)
+	}
+}
+
+func TestIndex_ReplacedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	info := writeFile(t, path, "hello")
+
+	idx := NewIndex(filepath.Join(dir, "index.json.gz"))
+	idx.Store(path, info, &analyzer.FileMetadata{Path: path})
+
+	// Same size and time, but another file
+	other := filepath.Join(dir, "b.txt")
+	writeFile(t, other, "HELLO")
+	os.Chtimes(other, info.ModTime(), info.ModTime())
+	if err := os.Rename(other, path); err != nil {
+		t.Fatal(err)
+	}
+	replaced, _ := os.Stat(path)
+	if fileInode(replaced) == 0 {
+		t.Skip("platform reports no inodes")
+	}
+	if _, ok := idx.Lookup(path, replaced); ok {
+		t.Error("Lookup() found a file replaced by another with the same size and time")
+	}
+}
+
+func TestIndex_HashesAndSuggestions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	info := writeFile(t, path, "hello")
+
+	idx := NewIndex(filepath.Join(dir, "index.json.gz"))
+	idx.SetHash(path, info, "sha256", "abc")
+	if hash, ok := idx.Hash(path, info, "sha256"); !ok || hash != "abc" {
+		t.Errorf("Hash() = %q, %v", hash, ok)
+	}
+	if _, ok := idx.Hash(path, info, "md5"); ok {
+		t.Error("Hash() found a hash of another algorithm")
+	}
+
+	// Storing metadata keeps the hashes of an unchanged file
+	file := &analyzer.FileMetadata{Path: path, Size: info.Size(), ModifiedAt: info.ModTime()}
+	idx.Store(path, info, file)
+	if _, ok := idx.Hash(path, info, "sha256"); !ok {
+		t.Error("Store() dropped the hash of an unchanged file")
+	}
+
+	idx.SetSuggestion(file, "name", "greeting")
+	if s, ok := idx.Suggestion(file, "name"); !ok || s != "greeting" {
+		t.Errorf("Suggestion() = %q, %v", s, ok)
+	}
+	stale := *file
+	stale.Size++
+	if _, ok := idx.Suggestion(&stale, "name"); ok {
+		t.Error("Suggestion() found a suggestion for a changed file")
+	}
+
+	// Files that were never indexed get no suggestions
+	unknown := &analyzer.FileMetadata{Path: filepath.Join(dir, "b.txt")}
+	idx.SetSuggestion(unknown, "name", "x")
+	if _, ok := idx.Suggestion(unknown, "name"); ok {
+		t.Error("SetSuggestion() added a file that is not indexed")
+	}
+}
+
+func TestIndex_SaveAndOpen(t *testing.T) {
+	dir := t.TempDir()
+	indexPath := filepath.Join(dir, "cache", "index.json.gz")
+	path := filepath.Join(dir, "a.txt")
+	info := writeFile(t, path, "hello")
+
+	idx, err := Open(indexPath)
+	if err != nil || idx.Len() != 0 {
+		t.Fatalf("Open() of a missing index = %v, %v", idx, err)
+	}
+	idx.Store(path, info, &analyzer.FileMetadata{
+		Path:       path,
+		MimeType:   "text/plain",
+		ModifiedAt: info.ModTime(),
+		MediaData:  map[string]string{analyzer.MediaArtist: "AC/DC"},
+	})
+	idx.SetHash(path, info, "sha256", "abc")
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, ok := reopened.Lookup(path, info)
+	if !ok || m.MimeType != "text/plain" || m.MediaData[analyzer.MediaArtist] != "AC/DC" {
+		t.Fatalf("Lookup() after reopening = %+v, %v", m, ok)
+	}
+	if hash, _ := reopened.Hash(path, info, "sha256"); hash != "abc" {
+		t.Errorf("Hash() after reopening = %q", hash)
+	}
+
+	// Damaged index files are reported
+	os.WriteFile(indexPath, []byte("not gzip"), 0644)
+	if _, err := Open(indexPath); err == nil {
+		t.Error("Open() of a damaged index succeeded")
+	}
+}
+
+func TestIndex_CheckPruneAndClear(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	os.Mkdir(sub, 0755)
+
+	idx := NewIndex(filepath.Join(dir, "index.json.gz"))
+	paths := map[string]string{
+		"current": filepath.Join(dir, "current.txt"),
+		"changed": filepath.Join(dir, "changed.txt"),
+		"missing": filepath.Join(dir, "missing.txt"),
+		"nested":  filepath.Join(sub, "nested.txt"),
+	}
+	for _, path := range paths {
+		info := writeFile(t, path, "hello")
+		idx.Store(path, info, &analyzer.FileMetadata{Path: path})
+	}
+	writeFile(t, paths["changed"], "hello, world")
+	os.Remove(paths["missing"])
+
+	status, err := idx.Check(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Entries != 4 || status.Current != 2 || status.Changed != 1 || status.Missing != 1 {
+		t.Errorf("Check() = %+v", status)
+	}
+
+	removed, err := idx.Prune(context.Background())
+	if err != nil || removed != 2 || idx.Len() != 2 {
+		t.Errorf("Prune() = %d, %v; %d entries left", removed, err, idx.Len())
+	}
+
+	// Clear only drops the directory given, not siblings sharing its prefix
+	if removed := idx.Clear(filepath.Join(dir, "su")); removed != 0 {
+		t.Errorf("Clear(su) removed %d entries", removed)
+	}
+	if removed := idx.Clear(sub); removed != 1 || idx.Len() != 1 {
+		t.Errorf("Clear(sub) removed %d entries, %d left", removed, idx.Len())
+	}
+	if removed := idx.Clear(""); removed != 1 || idx.Len() != 0 {
+		t.Errorf("Clear() removed %d entries, %d left", removed, idx.Len())
+	}
+}
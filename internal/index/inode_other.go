@@ -0,0 +1,11 @@
+//go:build !unix
+
+package index
+
+import "os"
+
+// fileInode returns 0: the platform's FileInfo carries no file ID, so
+// entries are matched by size and modification time alone
+func fileInode(info os.FileInfo) uint64 {
+	return 0
+}
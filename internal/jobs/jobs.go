@@ -0,0 +1,390 @@
+// Package jobs runs the scheduler's built-in jobs in-process through the
+// organizer, cleaner and dedup packages.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/cleaner"
+	"github.com/xuanyiying/cleanup-cli/internal/dedup"
+	"github.com/xuanyiying/cleanup-cli/internal/organizer"
+	"github.com/xuanyiying/cleanup-cli/internal/prune"
+	"github.com/xuanyiying/cleanup-cli/internal/scheduler"
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+	"github.com/xuanyiying/cleanup-cli/pkg/filelock"
+)
+
+// JunkCleanLockKey is the process lock key of junk cleanups, which work on
+// the system's junk locations rather than a single directory
+const JunkCleanLockKey = "junk-clean"
+
+// junkCategories lists the categories a junk-clean job accepts
+var junkCategories = map[string]bool{
+	string(cleaner.CategoryCache):     true,
+	string(cleaner.CategoryLogs):      true,
+	string(cleaner.CategoryTemp):      true,
+	string(cleaner.CategoryTrash):     true,
+	string(cleaner.CategoryBrowser):   true,
+	string(cleaner.CategoryDeveloper): true,
+	string(cleaner.CategorySystem):    true,
+}
+
+// Runner executes built-in jobs. It implements scheduler.JobRunner.
+type Runner struct {
+	analyzer   analyzer.Analyzer
+	organizer  *organizer.Organizer
+	cleaner    *cleaner.SystemCleaner
+	txnManager *transaction.Manager
+
+	ScanOptions *analyzer.ScanOptions // Exclusions applied by organize and junk-clean jobs; its Traversal applies to all jobs
+	TrashPath   string                // Trash used by dedup and trash-empty (default: ~/.cleanup/trash)
+	LockDir     string                // Process locks keep jobs and manual commands apart (empty = no locking)
+}
+
+// NewRunner creates a job runner using the given services
+func NewRunner(fileAnalyzer analyzer.Analyzer, fileOrganizer *organizer.Organizer, systemCleaner *cleaner.SystemCleaner, txnManager *transaction.Manager) *Runner {
+	return &Runner{
+		analyzer:    fileAnalyzer,
+		organizer:   fileOrganizer,
+		cleaner:     systemCleaner,
+		txnManager:  txnManager,
+		ScanOptions: &analyzer.ScanOptions{},
+	}
+}
+
+// Validate checks a job's configuration, including the parameters only the
+// runner knows how to interpret
+func Validate(job *scheduler.JobConfig) error {
+	if err := job.Validate(); err != nil {
+		return err
+	}
+
+	for _, category := range job.Categories {
+		if !junkCategories[category] {
+			return fmt.Errorf("unknown junk category %q", category)
+		}
+	}
+	return nil
+}
+
+// Run executes job, writing a summary of what it did to out. In report mode
+// nothing is changed and the result describes what the job would do.
+func (r *Runner) Run(ctx context.Context, job *scheduler.JobConfig, out io.Writer) (*scheduler.JobResult, error) {
+	if err := Validate(job); err != nil {
+		return nil, err
+	}
+
+	result := &scheduler.JobResult{
+		Kind:           job.Kind,
+		DryRun:         job.ReportOnly(),
+		TransactionIDs: []string{},
+	}
+	if result.DryRun {
+		fmt.Fprintf(out, "[REPORT ONLY] %s job makes no changes\n", job.Kind)
+	} else {
+		lock, err := r.lock(job)
+		if err != nil {
+			return result, err
+		}
+		defer lock.Unlock()
+	}
+
+	var err error
+	switch job.Kind {
+	case scheduler.JobOrganize:
+		err = r.organize(ctx, job, out, result)
+	case scheduler.JobJunkClean:
+		err = r.junkClean(ctx, job, out, result)
+	case scheduler.JobDedup:
+		err = r.dedup(ctx, job, out, result)
+	case scheduler.JobTrashEmpty:
+		err = r.trashEmpty(ctx, job, out, result)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	verb := "Touched"
+	if result.DryRun {
+		verb = "Would touch"
+	}
+	fmt.Fprintf(out, "%s %d files, %s freed\n", verb, result.FilesTouched, formatBytes(result.BytesFreed))
+
+	if len(result.Errors) > 0 {
+		return result, fmt.Errorf("%s job finished with %d errors", job.Kind, len(result.Errors))
+	}
+	return result, nil
+}
+
+// organize applies the organization rules to the files of a directory
+func (r *Runner) organize(ctx context.Context, job *scheduler.JobConfig, out io.Writer, result *scheduler.JobResult) error {
+	root, err := resolveDir(job.Path)
+	if err != nil {
+		return err
+	}
+
+	scanOpts := *r.ScanOptions
+	scanOpts.Recursive = job.Recursive
+
+	files, err := r.analyzer.AnalyzeDirectory(ctx, root, &scanOpts)
+	if err != nil {
+		return fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	strategy := &organizer.OrganizeStrategy{
+		UseAI:            job.UseAI,
+		CreateFolders:    true,
+		ConflictStrategy: organizer.ConflictSuffix,
+		DryRun:           job.ReportOnly(),
+		MaxConcurrency:   organizer.DefaultConcurrency,
+		PruneEmptyDirs:   job.PruneEmpty,
+		PruneRoot:        root,
+		PruneOptions: &prune.Options{
+			ExcludeDirs: scanOpts.ExcludeDirs,
+			IsProtected: cleaner.IsProtectedPath,
+		},
+		BaseDir: root,
+	}
+
+	plan, err := r.organizer.Organize(ctx, files, strategy)
+	if err != nil {
+		return fmt.Errorf("failed to generate organization plan: %w", err)
+	}
+
+	if job.ReportOnly() {
+		for _, op := range plan.Operations {
+			fmt.Fprintf(out, "  Would %s: %s → %s\n", op.Type, op.Source, op.Target)
+		}
+		result.FilesTouched = len(plan.Operations)
+		return nil
+	}
+
+	batch, err := r.organizer.ExecutePlan(ctx, plan, strategy)
+	if batch != nil {
+		result.FilesTouched = batch.Successful
+		result.TransactionIDs = append(result.TransactionIDs, batch.TransactionIDs...)
+		result.Errors = appendErrors(result.Errors, batch.Errors)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to execute plan: %w", err)
+	}
+	return nil
+}
+
+// junkClean moves junk files of the configured categories to the trash.
+// Nobody can confirm uncertain files in a scheduled run, so they are skipped.
+func (r *Runner) junkClean(ctx context.Context, job *scheduler.JobConfig, out io.Writer, result *scheduler.JobResult) error {
+	opts := &cleaner.CleanOptions{
+		DryRun:         job.ReportOnly(),
+		TrashPath:      r.TrashPath,
+		SkipUncertain:  true,
+		PruneEmptyDirs: job.PruneEmpty,
+		ExcludeDirs:    r.ScanOptions.ExcludeDirs,
+	}
+	for _, category := range job.Categories {
+		opts.Categories = append(opts.Categories, cleaner.JunkCategory(category))
+	}
+	r.cleaner.SetTraversal(r.ScanOptions.Traversal)
+
+	if job.ReportOnly() {
+		scan, err := r.cleaner.Preview(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, file := range scan.Files {
+			if r.cleaner.IsUncertain(file.Path) {
+				fmt.Fprintf(out, "  Would skip (uncertain): %s\n", file.Path)
+				result.FilesSkipped++
+				continue
+			}
+			fmt.Fprintf(out, "  Would clean: %s (%s)\n", file.Path, formatBytes(file.Size))
+			result.FilesTouched++
+			result.BytesFreed += file.Size
+		}
+		return nil
+	}
+
+	clean, err := r.cleaner.Clean(ctx, opts)
+	if clean != nil {
+		for _, file := range clean.Cleaned {
+			fmt.Fprintf(out, "  Cleaned: %s (%s)\n", file.Path, formatBytes(file.Size))
+		}
+		for _, file := range clean.Skipped {
+			fmt.Fprintf(out, "  Skipped (uncertain, review with 'cleanup junk clean'): %s\n", file.Path)
+		}
+		for _, cleanErr := range clean.Errors {
+			fmt.Fprintf(out, "  ✗ %v\n", cleanErr)
+		}
+		result.FilesTouched = len(clean.Cleaned)
+		result.FilesSkipped = len(clean.Skipped)
+		result.BytesFreed = clean.SpaceFreed
+		if clean.TransactionID != "" {
+			result.TransactionIDs = append(result.TransactionIDs, clean.TransactionID)
+		}
+		result.Errors = appendErrors(result.Errors, clean.Errors)
+	}
+	return err
+}
+
+// dedup moves duplicate files of a directory to the trash, keeping one copy
+func (r *Runner) dedup(ctx context.Context, job *scheduler.JobConfig, out io.Writer, result *scheduler.JobResult) error {
+	root, err := resolveDir(job.Path)
+	if err != nil {
+		return err
+	}
+
+	deduplicator := dedup.NewDeduplicator()
+	deduplicator.Traversal = r.ScanOptions.Traversal
+	if job.MinSize > 0 {
+		deduplicator.MinSize = job.MinSize
+	}
+
+	groups, err := deduplicator.FindDuplicates(ctx, root)
+	if err != nil {
+		return fmt.Errorf("failed to find duplicates: %w", err)
+	}
+
+	keep := job.Keep
+	if keep == "" {
+		keep = "newest"
+	}
+	plan := deduplicator.CreateRemovalPlan(groups, keep)
+
+	if job.ReportOnly() {
+		for _, file := range plan.ToRemove {
+			fmt.Fprintf(out, "  Would remove: %s (%s)\n", file.Path, formatBytes(file.Size))
+		}
+		result.FilesTouched = len(plan.ToRemove)
+		result.BytesFreed = plan.SpaceSaved
+		return nil
+	}
+
+	if len(plan.ToRemove) == 0 {
+		return nil
+	}
+
+	trashed, err := deduplicator.TrashRemovalPlan(ctx, plan, r.trashPath(), r.txnManager)
+	if trashed != nil {
+		result.FilesTouched = len(trashed.Moved)
+		result.BytesFreed = trashed.SpaceSaved
+		if trashed.TransactionID != "" {
+			result.TransactionIDs = append(result.TransactionIDs, trashed.TransactionID)
+		}
+		result.Errors = appendErrors(result.Errors, trashed.Errors)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove duplicates: %w", err)
+	}
+	return nil
+}
+
+// trashEmpty permanently deletes trash entries older than the job's threshold
+func (r *Runner) trashEmpty(ctx context.Context, job *scheduler.JobConfig, out io.Writer, result *scheduler.JobResult) error {
+	olderThan, err := job.OlderThanDuration()
+	if err != nil {
+		return err
+	}
+
+	emptied, err := r.cleaner.EmptyTrash(ctx, &cleaner.EmptyTrashOptions{
+		TrashPath: r.trashPath(),
+		OlderThan: olderThan,
+		DryRun:    job.ReportOnly(),
+	})
+	if emptied != nil {
+		verb := "Deleted"
+		if job.ReportOnly() {
+			verb = "Would delete"
+		}
+		for _, path := range emptied.Removed {
+			fmt.Fprintf(out, "  %s: %s\n", verb, path)
+		}
+		result.FilesTouched = len(emptied.Removed)
+		result.BytesFreed = emptied.SpaceFreed
+		if emptied.TransactionID != "" {
+			result.TransactionIDs = append(result.TransactionIDs, emptied.TransactionID)
+		}
+		result.Errors = appendErrors(result.Errors, emptied.Errors)
+	}
+	return err
+}
+
+// lock takes the process lock of what job changes, so that it never runs at
+// the same time as another job or a manual command on the same target
+func (r *Runner) lock(job *scheduler.JobConfig) (*filelock.ProcessLock, error) {
+	if r.LockDir == "" {
+		return nil, nil
+	}
+
+	var key string
+	switch job.Kind {
+	case scheduler.JobOrganize, scheduler.JobDedup:
+		dir, err := resolveDir(job.Path)
+		if err != nil {
+			return nil, err
+		}
+		key = dir
+	case scheduler.JobJunkClean:
+		key = JunkCleanLockKey
+	case scheduler.JobTrashEmpty:
+		key = r.trashPath()
+	}
+
+	lock, err := filelock.TryLockProcess(r.LockDir, key)
+	if errors.Is(err, filelock.ErrLocked) {
+		return nil, fmt.Errorf("%w: %v", scheduler.ErrSkipRun, err)
+	}
+	return lock, err
+}
+
+// trashPath returns the trash directory jobs move files into
+func (r *Runner) trashPath() string {
+	if r.TrashPath != "" {
+		return r.TrashPath
+	}
+	return cleaner.DefaultTrashPath()
+}
+
+// resolveDir expands and checks the directory a job works on
+func resolveDir(path string) (string, error) {
+	absPath, err := filepath.Abs(cleaner.ExpandPath(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return "", fmt.Errorf("directory not found: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("not a directory: %s", absPath)
+	}
+	return absPath, nil
+}
+
+// appendErrors adds the messages of errs to messages
+func appendErrors(messages []string, errs []error) []string {
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+// formatBytes formats a byte count for display
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,191 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/cleaner"
+	"github.com/xuanyiying/cleanup-cli/internal/config"
+	"github.com/xuanyiying/cleanup-cli/internal/organizer"
+	"github.com/xuanyiying/cleanup-cli/internal/rules"
+	"github.com/xuanyiying/cleanup-cli/internal/scheduler"
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+	"github.com/xuanyiying/cleanup-cli/pkg/filelock"
+)
+
+// newTestRunner creates a runner that moves PDFs to Documents and trashes into tmpDir
+func newTestRunner(t *testing.T, tmpDir string) (*Runner, *transaction.Manager) {
+	t.Helper()
+
+	txnManager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+	fileAnalyzer := analyzer.NewAnalyzer()
+
+	engine := rules.NewEngine()
+	require.NoError(t, engine.LoadRules([]*config.Rule{
+		{
+			Name:      "pdf",
+			Condition: &config.RuleCondition{Type: "extension", Value: "pdf", Operator: "match"},
+			Action:    &config.RuleAction{Type: "move", Target: "Documents"},
+		},
+	}))
+
+	runner := NewRunner(fileAnalyzer, organizer.NewOrganizerWithDeps(txnManager, engine, fileAnalyzer),
+		cleaner.NewSystemCleaner(txnManager), txnManager)
+	runner.TrashPath = filepath.Join(tmpDir, "trash")
+	return runner, txnManager
+}
+
+func TestRunOrganize(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "inbox")
+	require.NoError(t, os.MkdirAll(root, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "report.pdf"), []byte("pdf"), 0644))
+	runner, txnManager := newTestRunner(t, tmpDir)
+
+	job := &scheduler.JobConfig{Kind: scheduler.JobOrganize, Path: root, Policy: scheduler.PolicyReport}
+	var out bytes.Buffer
+	result, err := runner.Run(context.Background(), job, &out)
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 1, result.FilesTouched)
+	assert.Empty(t, result.TransactionIDs)
+	assert.Contains(t, out.String(), "Would move")
+	assert.FileExists(t, filepath.Join(root, "report.pdf"), "report mode must not move files")
+
+	job.Policy = scheduler.PolicyApply
+	result, err = runner.Run(context.Background(), job, &out)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.FilesTouched)
+	require.Len(t, result.TransactionIDs, 1)
+	assert.FileExists(t, filepath.Join(root, "Documents", "report.pdf"))
+
+	// Scheduled runs are undoable like manual ones
+	require.NoError(t, txnManager.Undo(result.TransactionIDs[0]))
+	assert.FileExists(t, filepath.Join(root, "report.pdf"))
+}
+
+func TestRunDedup(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "photos")
+	require.NoError(t, os.MkdirAll(root, 0755))
+	content := bytes.Repeat([]byte("x"), 2048)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.jpg"), content, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b.jpg"), content, 0644))
+	runner, _ := newTestRunner(t, tmpDir)
+
+	job := &scheduler.JobConfig{Kind: scheduler.JobDedup, Path: root, Policy: scheduler.PolicyReport}
+	result, err := runner.Run(context.Background(), job, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.FilesTouched)
+	assert.Equal(t, int64(2048), result.BytesFreed)
+	entries, _ := os.ReadDir(root)
+	assert.Len(t, entries, 2, "report mode must not remove duplicates")
+
+	job.Policy = scheduler.PolicyApply
+	result, err = runner.Run(context.Background(), job, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.FilesTouched)
+	assert.Len(t, result.TransactionIDs, 1)
+	entries, _ = os.ReadDir(root)
+	assert.Len(t, entries, 1)
+	trashed, _ := os.ReadDir(runner.TrashPath)
+	assert.Len(t, trashed, 1, "duplicates go to the trash")
+}
+
+func TestRunTrashEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+	runner, _ := newTestRunner(t, tmpDir)
+	require.NoError(t, os.MkdirAll(runner.TrashPath, 0755))
+
+	old := time.Now().Add(-48 * time.Hour)
+	stale := filepath.Join(runner.TrashPath, "old.txt")
+	require.NoError(t, os.WriteFile(stale, []byte("old"), 0644))
+	require.NoError(t, os.Chtimes(stale, old, old))
+	require.NoError(t, os.WriteFile(filepath.Join(runner.TrashPath, "new.txt"), []byte("new"), 0644))
+
+	job := &scheduler.JobConfig{Kind: scheduler.JobTrashEmpty, OlderThan: "1d"}
+	result, err := runner.Run(context.Background(), job, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.FilesTouched)
+	assert.Equal(t, int64(3), result.BytesFreed)
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, filepath.Join(runner.TrashPath, "new.txt"))
+}
+
+func TestRunJunkCleanSkipsUncertain(t *testing.T) {
+	tmpDir := t.TempDir()
+	runner, _ := newTestRunner(t, tmpDir)
+	junkDir := filepath.Join(tmpDir, "tmp")
+	require.NoError(t, os.MkdirAll(junkDir, 0755))
+	runner.cleaner.ClearLocations()
+	runner.cleaner.Configure([]string{junkDir}, nil)
+
+	// Recently modified files are uncertain and need a review
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	stale := filepath.Join(junkDir, "stale.tmp")
+	recent := filepath.Join(junkDir, "recent.tmp")
+	require.NoError(t, os.WriteFile(stale, []byte("old"), 0644))
+	require.NoError(t, os.Chtimes(stale, old, old))
+	require.NoError(t, os.WriteFile(recent, []byte("new"), 0644))
+
+	job := &scheduler.JobConfig{Kind: scheduler.JobJunkClean, Policy: scheduler.PolicyReport}
+	var out bytes.Buffer
+	result, err := runner.Run(context.Background(), job, &out)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.FilesTouched)
+	assert.Equal(t, 1, result.FilesSkipped)
+	assert.Contains(t, out.String(), "Would skip (uncertain): "+recent)
+
+	job.Policy = scheduler.PolicyApply
+	out.Reset()
+	result, err = runner.Run(context.Background(), job, &out)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.FilesTouched)
+	assert.Equal(t, 1, result.FilesSkipped)
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, recent)
+	assert.Contains(t, out.String(), "Cleaned: "+stale)
+	assert.Contains(t, out.String(), recent)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(&scheduler.JobConfig{Kind: scheduler.JobJunkClean, Categories: []string{"cache", "logs"}}))
+	assert.Error(t, Validate(&scheduler.JobConfig{Kind: scheduler.JobJunkClean, Categories: []string{"photos"}}))
+	assert.Error(t, Validate(&scheduler.JobConfig{Kind: scheduler.JobOrganize}))
+}
+
+func TestRunSkipsLockedTarget(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "inbox")
+	require.NoError(t, os.MkdirAll(root, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "report.pdf"), []byte("pdf"), 0644))
+	runner, _ := newTestRunner(t, tmpDir)
+	runner.LockDir = filepath.Join(tmpDir, "locks")
+
+	// A manual organize of the same directory is in progress
+	lock, err := filelock.TryLockProcess(runner.LockDir, root)
+	require.NoError(t, err)
+
+	job := &scheduler.JobConfig{Kind: scheduler.JobOrganize, Path: root}
+	_, err = runner.Run(context.Background(), job, &bytes.Buffer{})
+	assert.ErrorIs(t, err, scheduler.ErrSkipRun)
+	assert.FileExists(t, filepath.Join(root, "report.pdf"))
+
+	// Reports don't change anything, so they don't wait for the lock
+	job.Policy = scheduler.PolicyReport
+	_, err = runner.Run(context.Background(), job, &bytes.Buffer{})
+	assert.NoError(t, err)
+
+	require.NoError(t, lock.Unlock())
+	job.Policy = scheduler.PolicyApply
+	_, err = runner.Run(context.Background(), job, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(root, "Documents", "report.pdf"))
+}
@@ -74,6 +74,7 @@ type generateRequest struct {
 	Model  string `json:"model"`
 	Prompt string `json:"prompt"`
 	Stream bool   `json:"stream"`
+	Format any    `json:"format,omitempty"` // JSON schema the response must follow
 }
 
 // generateResponse represents a response from the Ollama generate endpoint
@@ -102,12 +103,24 @@ func (c *OllamaClient) Analyze(ctx context.Context, prompt string, contextStr st
 		fullPrompt = fmt.Sprintf("%s\n\nContext: %s", prompt, contextStr)
 	}
 
-	reqBody := generateRequest{
+	genResp, err := c.generate(ctx, generateRequest{
 		Model:  c.config.Model,
 		Prompt: fullPrompt,
 		Stream: false,
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	return &ai.AnalysisResult{
+		Success: genResp.Done,
+		Content: genResp.Response,
+		Tokens:  genResp.EvalCount,
+	}, nil
+}
+
+// generate sends a request to the Ollama generate endpoint
+func (c *OllamaClient) generate(ctx context.Context, reqBody generateRequest) (*generateResponse, error) {
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
@@ -135,12 +148,7 @@ func (c *OllamaClient) Analyze(ctx context.Context, prompt string, contextStr st
 	if err := json.NewDecoder(resp.Body).Decode(&genResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
-
-	return &ai.AnalysisResult{
-		Success: genResp.Done,
-		Content: genResp.Response,
-		Tokens:  genResp.EvalCount,
-	}, nil
+	return &genResp, nil
 }
 
 // SuggestName generates name suggestions for a file based on its metadata
@@ -188,3 +196,24 @@ func (c *OllamaClient) SuggestCategory(ctx context.Context, file *analyzer.FileM
 
 	return []string{ai.CleanSuggestedCategory(result.Content)}, nil
 }
+
+// AnalyzeFile asks for the name, category, confidence, language and reason
+// of a file in one request, constraining the response to
+// ai.FileAnalysisSchema and asking again if it is malformed
+func (c *OllamaClient) AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*ai.FileAnalysis, error) {
+	return ai.RequestFileAnalysis(ctx, file, func(ctx context.Context, prompt string) (string, error) {
+		genResp, err := c.generate(ctx, generateRequest{
+			Model:  c.config.Model,
+			Prompt: prompt,
+			Stream: false,
+			Format: ai.FileAnalysisSchema(),
+		})
+		if err != nil {
+			return "", err
+		}
+		if !genResp.Done {
+			return "", fmt.Errorf("analysis did not complete successfully")
+		}
+		return genResp.Response, nil
+	})
+}
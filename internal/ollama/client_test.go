@@ -446,3 +446,36 @@ func TestAnalyzeWithContext(t *testing.T) {
 	assert.Equal(t, "Result with context", result.Content)
 	assert.Equal(t, 15, result.Tokens)
 }
+
+func TestAnalyzeFile(t *testing.T) {
+	answers := []string{
+		"It is a report.",
+		`{"name": "q3-sales-report", "category": "report", "confidence": 0.9, "language": "en", "reason": "Quarterly sales figures"}`,
+	}
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		json.NewEncoder(w).Encode(generateResponse{Response: answers[len(requests)-1], Done: true})
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.OllamaConfig{BaseURL: server.URL, Model: "test-model", Timeout: 5 * time.Second})
+	analysis, err := client.AnalyzeFile(context.Background(), &analyzer.FileMetadata{
+		Name:           "doc1.txt",
+		MimeType:       "text/plain",
+		ContentPreview: "Sales by region for the third quarter",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "q3-sales-report", analysis.Name)
+	assert.Equal(t, "report", analysis.Category)
+	assert.Equal(t, "Quarterly sales figures", analysis.Reason)
+
+	// The response is constrained to the schema, and a malformed one is asked again
+	require.Len(t, requests, 2)
+	format, ok := requests[0]["format"].(map[string]any)
+	require.True(t, ok, "request has no JSON schema format")
+	assert.Equal(t, "object", format["type"])
+	assert.Contains(t, requests[1]["prompt"], "Your previous answer was rejected")
+}
@@ -8,6 +8,9 @@ const (
 	// DefaultConcurrency is the default number of concurrent operations
 	DefaultConcurrency = 4
 
+	// StreamBatchSize is how many streamed files are planned together
+	StreamBatchSize = 256
+
 	// MaxPreviewLength is the maximum length of content preview
 	MaxPreviewLength = 500
 
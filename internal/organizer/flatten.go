@@ -0,0 +1,285 @@
+package organizer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/prune"
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+)
+
+// FlattenOptions controls how files from nested subdirectories are collected
+type FlattenOptions struct {
+	Depth            int              // Maximum subdirectory depth to collect from (0 = unlimited)
+	Into             string           // Destination directory (defaults to the root being flattened)
+	ConflictStrategy ConflictStrategy // ConflictOverwrite is refused: undo could not bring the replaced file back
+	DryRun           bool
+	KeepEmptyDirs    bool           // Leave directories emptied by the flatten in place
+	PruneOptions     *prune.Options // Exclusions and protection for removing emptied directories
+}
+
+// FlattenResult represents the result of a flatten operation
+type FlattenResult struct {
+	Moved         []*PlannedOperation
+	Skipped       []string
+	RemovedDirs   []string
+	FailedFiles   map[string]error
+	TransactionID string
+}
+
+// Flatten collects files from nested subdirectories of root into a single
+// directory. All moves and directory removals are recorded in one transaction
+// so the whole flatten can be undone.
+func (o *Organizer) Flatten(ctx context.Context, root string, files []*analyzer.FileMetadata, opts *FlattenOptions) (*FlattenResult, error) {
+	if opts == nil {
+		opts = &FlattenOptions{
+			ConflictStrategy: ConflictSuffix,
+		}
+	}
+	if opts.ConflictStrategy == ConflictOverwrite {
+		return nil, fmt.Errorf("flatten does not overwrite files, as undo could not restore them")
+	}
+
+	root = filepath.Clean(root)
+	into := root
+	if opts.Into != "" {
+		into = filepath.Clean(opts.Into)
+	}
+
+	result := &FlattenResult{
+		Moved:       make([]*PlannedOperation, 0),
+		Skipped:     make([]string, 0),
+		RemovedDirs: make([]string, 0),
+		FailedFiles: make(map[string]error),
+	}
+
+	// Plan moves; claimed tracks targets taken earlier in this run so that
+	// same-named files from different subdirectories don't collide in dry-run
+	claimed := make(map[string]bool)
+	sourceDirs := make(map[string]bool)
+
+	for _, file := range files {
+		dir := filepath.Dir(file.Path)
+		// Files already inside a separate destination stay where they are
+		if into != root && (dir == into || isWithin(into, dir)) {
+			continue
+		}
+
+		depth, ok := relativeDepth(root, dir)
+		if !ok {
+			continue
+		}
+		if depth == 0 && into == root {
+			continue
+		}
+		if opts.Depth > 0 && depth > opts.Depth {
+			continue
+		}
+
+		target, err := o.flattenTarget(filepath.Join(into, file.Name), opts.ConflictStrategy, claimed)
+		if err != nil {
+			result.FailedFiles[file.Path] = err
+			continue
+		}
+		if target == "" {
+			result.Skipped = append(result.Skipped, file.Path)
+			continue
+		}
+
+		claimed[target] = true
+		sourceDirs[dir] = true
+		result.Moved = append(result.Moved, &PlannedOperation{
+			Type:   OpMove,
+			Source: file.Path,
+			Target: target,
+			Reason: "flatten",
+		})
+	}
+
+	if opts.DryRun {
+		if !opts.KeepEmptyDirs {
+			pending := make([]string, 0, len(result.Moved))
+			for _, op := range result.Moved {
+				pending = append(pending, op.Source)
+			}
+			pruneOpts := opts.pruneOptions()
+			pruneOpts.DryRun = true
+			pruneOpts.Pending = pending
+			pruned, err := o.pruner.PruneEmptied(ctx, nil, root, sourceDirList(sourceDirs), pruneOpts)
+			if err != nil {
+				return result, err
+			}
+			result.RemovedDirs = pruned.Removed
+		}
+		return result, nil
+	}
+
+	if len(result.Moved) == 0 {
+		return result, nil
+	}
+
+	tx := o.txnManager.Begin()
+	if err := o.mkdirAll(tx, into); err != nil {
+		if rbErr := o.txnManager.Rollback(tx); rbErr != nil {
+			return result, fmt.Errorf("failed to create target directory: %w (rollback failed: %v)", err, rbErr)
+		}
+		return result, fmt.Errorf("failed to create target directory: %w", err)
+	}
+	moved := make([]*PlannedOperation, 0, len(result.Moved))
+
+	for _, op := range result.Moved {
+		select {
+		case <-ctx.Done():
+			if err := o.txnManager.Rollback(tx); err != nil {
+				return result, fmt.Errorf("flatten cancelled and rollback failed: %w", err)
+			}
+			return result, ctx.Err()
+		default:
+		}
+
+		// Re-check on disk in case something appeared since planning
+		if _, err := os.Stat(op.Target); err == nil {
+			target, err := o.resolveConflict(op.Target, opts.ConflictStrategy)
+			if err != nil {
+				result.FailedFiles[op.Source] = err
+				continue
+			}
+			if target == "" {
+				result.Skipped = append(result.Skipped, op.Source)
+				continue
+			}
+			op.Target = target
+		}
+
+		if err := os.Rename(op.Source, op.Target); err != nil {
+			result.FailedFiles[op.Source] = fmt.Errorf("failed to move file: %w", err)
+			continue
+		}
+
+		o.txnManager.AddOperation(tx, &transaction.ExecutedOperation{
+			Type:   transaction.OpMove,
+			Source: op.Source,
+			Target: op.Target,
+			Backup: op.Target, // The moved file itself is what undo restores
+		})
+		moved = append(moved, op)
+	}
+	result.Moved = moved
+
+	// Without any moves the created directories are not worth keeping
+	if len(moved) == 0 {
+		if err := o.txnManager.Rollback(tx); err != nil {
+			return result, fmt.Errorf("failed to remove created directories: %w", err)
+		}
+		return result, nil
+	}
+
+	if !opts.KeepEmptyDirs {
+		pruned, err := o.pruner.PruneEmptied(ctx, tx, root, sourceDirList(sourceDirs), opts.pruneOptions())
+		if err != nil {
+			if rbErr := o.txnManager.Rollback(tx); rbErr != nil {
+				return result, fmt.Errorf("failed to prune empty directories: %w (rollback failed: %v)", err, rbErr)
+			}
+			return result, fmt.Errorf("failed to prune empty directories: %w", err)
+		}
+		result.RemovedDirs = pruned.Removed
+	}
+
+	if err := o.txnManager.Commit(tx); err != nil {
+		if rbErr := o.txnManager.Rollback(tx); rbErr != nil {
+			return result, fmt.Errorf("failed to commit transaction: %w (rollback failed: %v)", err, rbErr)
+		}
+		return result, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	result.TransactionID = tx.ID
+
+	return result, nil
+}
+
+// mkdirAll creates dir and any missing parents, recording each directory it
+// creates so that undoing the transaction removes them again
+func (o *Organizer) mkdirAll(tx *transaction.Transaction, dir string) error {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil || filepath.Dir(d) == d {
+			break
+		}
+		missing = append(missing, d)
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], DefaultDirPermissions); err != nil {
+			if os.IsExist(err) {
+				continue
+			}
+			return err
+		}
+		o.txnManager.AddOperation(tx, &transaction.ExecutedOperation{
+			Type:   transaction.OpMkdir,
+			Target: missing[i],
+		})
+	}
+	return nil
+}
+
+// pruneOptions returns the options for removing emptied directories: the
+// caller's exclusions and protected paths, hidden directories included
+func (opts *FlattenOptions) pruneOptions() *prune.Options {
+	pruneOpts := &prune.Options{}
+	if opts.PruneOptions != nil {
+		*pruneOpts = *opts.PruneOptions
+	}
+	pruneOpts.IncludeHidden = true
+	return pruneOpts
+}
+
+// flattenTarget resolves a conflict for a flatten target, also treating
+// targets already claimed by earlier files in the same run as conflicts
+func (o *Organizer) flattenTarget(target string, strategy ConflictStrategy, claimed map[string]bool) (string, error) {
+	if !claimed[target] {
+		return o.resolveConflict(target, strategy)
+	}
+
+	switch strategy {
+	case ConflictSkip:
+		return "", nil
+	case ConflictSuffix, ConflictPrompt:
+		return o.generateUniquePath(target), nil
+	default:
+		return "", fmt.Errorf("unknown conflict strategy: %s", strategy)
+	}
+}
+
+// relativeDepth returns how many directory levels dir is below root
+func relativeDepth(root, dir string) (int, bool) {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return 0, false
+	}
+	if rel == "." {
+		return 0, true
+	}
+	return len(strings.Split(rel, string(filepath.Separator))), true
+}
+
+// isWithin reports whether path is strictly inside dir
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." {
+		return false
+	}
+	return !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// sourceDirList converts a set of directories into a slice
+func sourceDirList(dirs map[string]bool) []string {
+	list := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		list = append(list, dir)
+	}
+	return list
+}
@@ -0,0 +1,159 @@
+package organizer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/prune"
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+)
+
+// createNestedFiles creates files relative to root and returns their metadata
+func createNestedFiles(t *testing.T, root string, relPaths ...string) []*analyzer.FileMetadata {
+	t.Helper()
+
+	files := make([]*analyzer.FileMetadata, 0, len(relPaths))
+	for _, rel := range relPaths {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(rel), 0644))
+		files = append(files, &analyzer.FileMetadata{
+			Path: path,
+			Name: filepath.Base(path),
+		})
+	}
+	return files
+}
+
+func TestFlattenCollectsAndRemovesEmptyDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	txnManager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+	organizer := NewOrganizer(txnManager)
+
+	files := createNestedFiles(t, root, "top.txt", "a/one.txt", "a/b/two.txt", "c/three.txt")
+
+	result, err := organizer.Flatten(context.Background(), root, files, &FlattenOptions{
+		ConflictStrategy: ConflictSuffix,
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, result.Moved, 3)
+	assert.NotEmpty(t, result.TransactionID)
+	for _, name := range []string{"top.txt", "one.txt", "two.txt", "three.txt"} {
+		assert.FileExists(t, filepath.Join(root, name))
+	}
+	assert.NoDirExists(t, filepath.Join(root, "a"))
+	assert.NoDirExists(t, filepath.Join(root, "c"))
+	assert.ElementsMatch(t, []string{
+		filepath.Join(root, "a", "b"),
+		filepath.Join(root, "a"),
+		filepath.Join(root, "c"),
+	}, result.RemovedDirs)
+
+	// Undo restores files and recreates the removed directories
+	require.NoError(t, txnManager.Undo(result.TransactionID))
+	assert.FileExists(t, filepath.Join(root, "a", "b", "two.txt"))
+	assert.FileExists(t, filepath.Join(root, "a", "one.txt"))
+	assert.FileExists(t, filepath.Join(root, "c", "three.txt"))
+}
+
+func TestFlattenDepthAndConflicts(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	organizer := NewOrganizer(transaction.NewManager(filepath.Join(tmpDir, "transactions.json")))
+
+	files := createNestedFiles(t, root, "a/same.txt", "b/same.txt", "a/deep/nested/far.txt")
+
+	result, err := organizer.Flatten(context.Background(), root, files, &FlattenOptions{
+		Depth:            1,
+		ConflictStrategy: ConflictSuffix,
+	})
+	require.NoError(t, err)
+
+	// Both same.txt files are collected, the deep file is out of range
+	assert.Len(t, result.Moved, 2)
+	assert.NotEqual(t, result.Moved[0].Target, result.Moved[1].Target)
+	assert.FileExists(t, filepath.Join(root, "a", "deep", "nested", "far.txt"))
+	assert.DirExists(t, filepath.Join(root, "a"))
+	assert.NoDirExists(t, filepath.Join(root, "b"))
+
+	// Overwriting would lose files undo cannot bring back
+	files = createNestedFiles(t, root, "c/same.txt")
+	_, err = organizer.Flatten(context.Background(), root, files, &FlattenOptions{ConflictStrategy: ConflictOverwrite})
+	assert.Error(t, err)
+	assert.FileExists(t, filepath.Join(root, "c", "same.txt"))
+}
+
+func TestFlattenDryRunAndInto(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	into := filepath.Join(root, "all")
+	organizer := NewOrganizer(transaction.NewManager(filepath.Join(tmpDir, "transactions.json")))
+
+	files := createNestedFiles(t, root, "top.txt", "x/one.txt", "all/kept.txt")
+
+	result, err := organizer.Flatten(context.Background(), root, files, &FlattenOptions{
+		Into:             into,
+		ConflictStrategy: ConflictSuffix,
+		DryRun:           true,
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, result.Moved, 2)
+	assert.Equal(t, []string{filepath.Join(root, "x")}, result.RemovedDirs)
+	assert.Empty(t, result.TransactionID)
+
+	// Nothing changed on disk
+	assert.FileExists(t, filepath.Join(root, "top.txt"))
+	assert.FileExists(t, filepath.Join(root, "x", "one.txt"))
+}
+
+func TestFlattenKeepsExcludedDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	organizer := NewOrganizer(transaction.NewManager(filepath.Join(tmpDir, "transactions.json")))
+
+	files := createNestedFiles(t, root, "a/one.txt", "b/two.txt")
+	require.NoError(t, os.Mkdir(filepath.Join(root, "a", "node_modules"), 0755))
+
+	result, err := organizer.Flatten(context.Background(), root, files, &FlattenOptions{
+		ConflictStrategy: ConflictSuffix,
+		PruneOptions: &prune.Options{
+			ExcludeDirs: []string{"node_modules"},
+			IsProtected: func(path string) bool { return filepath.Base(path) == "b" },
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, result.Moved, 2)
+	assert.Empty(t, result.RemovedDirs)
+	assert.DirExists(t, filepath.Join(root, "a", "node_modules"))
+	assert.DirExists(t, filepath.Join(root, "b"))
+}
+
+func TestFlattenUndoRemovesCreatedDestination(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	into := filepath.Join(tmpDir, "collected", "all")
+	txnManager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+	organizer := NewOrganizer(txnManager)
+
+	files := createNestedFiles(t, root, "a/one.txt")
+
+	result, err := organizer.Flatten(context.Background(), root, files, &FlattenOptions{
+		Into:             into,
+		ConflictStrategy: ConflictSuffix,
+	})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(into, "one.txt"))
+
+	require.NoError(t, txnManager.Undo(result.TransactionID))
+	assert.FileExists(t, filepath.Join(root, "a", "one.txt"))
+	assert.NoDirExists(t, filepath.Join(tmpDir, "collected"))
+}
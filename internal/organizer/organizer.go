@@ -12,6 +12,7 @@ import (
 	"github.com/xuanyiying/cleanup-cli/internal/ai"
 	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
 	"github.com/xuanyiying/cleanup-cli/internal/config"
+	"github.com/xuanyiying/cleanup-cli/internal/prune"
 	"github.com/xuanyiying/cleanup-cli/internal/rules"
 	"github.com/xuanyiying/cleanup-cli/internal/transaction"
 	"github.com/xuanyiying/cleanup-cli/pkg/template"
@@ -93,6 +94,7 @@ type BatchResult struct {
 	Errors         []error
 	TransactionIDs []string
 	FailedFiles    map[string]error
+	PrunedDirs     []string
 }
 
 // OrganizeStrategy represents the strategy for organizing files
@@ -102,6 +104,19 @@ type OrganizeStrategy struct {
 	ConflictStrategy ConflictStrategy
 	DryRun           bool
 	MaxConcurrency   int
+	PruneEmptyDirs   bool           // Remove directories emptied by the run
+	PruneRoot        string         // Directories at or above this path are never pruned
+	PruneOptions     *prune.Options // Exclusions and protection for pruning
+	BaseDir          string         // Relative rule targets resolve against this directory (default: working directory)
+}
+
+// SuggestionCache remembers the AI suggestions for files between runs
+type SuggestionCache interface {
+	// Suggestion returns the suggestion of a kind ("name" or "category")
+	// stored for a file that has not changed since
+	Suggestion(file *analyzer.FileMetadata, kind string) (string, bool)
+	// SetSuggestion records a suggestion for a file
+	SetSuggestion(file *analyzer.FileMetadata, kind, suggestion string)
 }
 
 // Organizer handles file organization operations
@@ -110,10 +125,10 @@ type Organizer struct {
 	ruleEngine   rules.Engine
 	analyzer     analyzer.Analyzer
 	templateExp  *template.Expander
-	aiCache      *ai.Cache
+	suggestions  SuggestionCache
+	pruner       *prune.Pruner
 	ollamaClient interface {
-		SuggestName(ctx context.Context, file *analyzer.FileMetadata) ([]string, error)
-		SuggestCategory(ctx context.Context, file *analyzer.FileMetadata) ([]string, error)
+		AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*ai.FileAnalysis, error)
 	}
 }
 
@@ -124,7 +139,7 @@ func NewOrganizer(txnManager *transaction.Manager) *Organizer {
 		ruleEngine:  rules.NewEngine(),
 		analyzer:    analyzer.NewAnalyzer(),
 		templateExp: template.NewExpander(make(map[string]string)),
-		aiCache:     ai.NewCache(24 * time.Hour), // Cache for 24 hours
+		pruner:      prune.NewPruner(txnManager),
 	}
 }
 
@@ -135,7 +150,7 @@ func NewOrganizerWithDeps(txnManager *transaction.Manager, ruleEngine rules.Engi
 		ruleEngine:   ruleEngine,
 		analyzer:     analyzer,
 		templateExp:  template.NewExpander(make(map[string]string)),
-		aiCache:      ai.NewCache(24 * time.Hour),
+		pruner:       prune.NewPruner(txnManager),
 		ollamaClient: nil,
 	}
 }
@@ -145,6 +160,11 @@ func (o *Organizer) SetOllamaClient(client ai.Client) {
 	o.ollamaClient = client
 }
 
+// SetSuggestionCache sets where AI suggestions are kept between runs
+func (o *Organizer) SetSuggestionCache(c SuggestionCache) {
+	o.suggestions = c
+}
+
 // Rename renames a file with conflict resolution
 func (o *Organizer) Rename(ctx context.Context, source, newName string, opts *RenameOptions) (*OperationResult, error) {
 	if opts == nil {
@@ -255,7 +275,7 @@ func (o *Organizer) Rename(ctx context.Context, source, newName string, opts *Re
 		Type:   transaction.OpRename,
 		Source: source,
 		Target: finalTarget,
-		Backup: backupPath,
+		Backup: finalTarget, // The file itself is what undo restores
 	}
 	o.txnManager.AddOperation(tx, op)
 
@@ -419,7 +439,7 @@ func (o *Organizer) Move(ctx context.Context, source, targetDir string, opts *Mo
 		Type:   transaction.OpMove,
 		Source: source,
 		Target: finalTarget,
-		Backup: backupPath,
+		Backup: finalTarget, // The file itself is what undo restores
 	}
 	o.txnManager.AddOperation(tx, op)
 
@@ -580,10 +600,61 @@ func (o *Organizer) Organize(ctx context.Context, files []*analyzer.FileMetadata
 		}
 	}
 
-	plan := &OrganizePlan{
+	plan := newOrganizePlan()
+	if err := o.planFiles(ctx, plan, files, strategy); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// OrganizeStream generates an execution plan for files as a streamed
+// analysis delivers them. Files are planned in batches of StreamBatchSize,
+// so memory holds one batch rather than the whole tree and the analyzer
+// keeps scanning while a batch waits for AI suggestions. Files that could
+// not be analyzed are skipped. On error the caller should cancel the
+// stream's context.
+func (o *Organizer) OrganizeStream(ctx context.Context, results <-chan analyzer.Result, strategy *OrganizeStrategy) (*OrganizePlan, error) {
+	if strategy == nil {
+		strategy = &OrganizeStrategy{
+			UseAI:            true,
+			CreateFolders:    true,
+			ConflictStrategy: ConflictSuffix,
+			DryRun:           false,
+			MaxConcurrency:   DefaultConcurrency,
+		}
+	}
+
+	plan := newOrganizePlan()
+	batch := make([]*analyzer.FileMetadata, 0, StreamBatchSize)
+	for result := range results {
+		if result.Err != nil {
+			if result.Path == "" {
+				return nil, result.Err
+			}
+			continue
+		}
+
+		batch = append(batch, result.Metadata)
+		if len(batch) == StreamBatchSize {
+			if err := o.planFiles(ctx, plan, batch, strategy); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := o.planFiles(ctx, plan, batch, strategy); err != nil {
+		return nil, err
+	}
+	return plan, ctx.Err()
+}
+
+// newOrganizePlan returns an empty plan
+func newOrganizePlan() *OrganizePlan {
+	return &OrganizePlan{
 		Operations: make([]*PlannedOperation, 0),
 		Summary: &PlanSummary{
-			TotalFiles:      len(files),
+			TotalFiles:      0,
 			TotalOperations: 0,
 			MoveCount:       0,
 			RenameCount:     0,
@@ -591,6 +662,19 @@ func (o *Organizer) Organize(ctx context.Context, files []*analyzer.FileMetadata
 			EstimatedSize:   0,
 		},
 	}
+}
+
+// planFiles adds the operations for files to a plan
+func (o *Organizer) planFiles(ctx context.Context, plan *OrganizePlan, files []*analyzer.FileMetadata, strategy *OrganizeStrategy) error {
+	// Files inside archives are only listed, never moved or renamed
+	onDisk := make([]*analyzer.FileMetadata, 0, len(files))
+	for _, file := range files {
+		if !file.IsArchived() {
+			onDisk = append(onDisk, file)
+		}
+	}
+	files = onDisk
+	plan.Summary.TotalFiles += len(files)
 
 	// Phase 1: Batch process AI requests concurrently
 	if strategy.UseAI && o.ollamaClient != nil {
@@ -601,7 +685,7 @@ func (o *Organizer) Organize(ctx context.Context, files []*analyzer.FileMetadata
 	for _, file := range files {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return ctx.Err()
 		default:
 		}
 
@@ -617,7 +701,7 @@ func (o *Organizer) Organize(ctx context.Context, files []*analyzer.FileMetadata
 				Type:   OpRename,
 				Source: file.Path,
 				Target: renamedPath,
-				Reason: "AI-suggested meaningful name",
+				Reason: aiRenameReason(file),
 			}
 			plan.Operations = append(plan.Operations, op)
 			plan.Summary.RenameCount++
@@ -661,8 +745,17 @@ func (o *Organizer) Organize(ctx context.Context, files []*analyzer.FileMetadata
 
 			// Construct full target path with filename
 			targetDir := targetPath
+			if strategy.BaseDir != "" && !filepath.IsAbs(targetDir) {
+				targetDir = filepath.Join(strategy.BaseDir, targetDir)
+			}
 			targetFullPath := filepath.Join(targetDir, file.Name)
 
+			// File is already where the rule wants it
+			if filepath.Clean(targetFullPath) == filepath.Clean(sourcePath) {
+				plan.Summary.SkipCount++
+				continue
+			}
+
 			op = &PlannedOperation{
 				Type:   OpMove,
 				Source: sourcePath,
@@ -688,10 +781,23 @@ func (o *Organizer) Organize(ctx context.Context, files []*analyzer.FileMetadata
 		plan.Summary.EstimatedSize += file.Size
 	}
 
-	return plan, nil
+	return nil
+}
+
+// suggestedByCache attributes AI suggestions taken from the cache or the
+// file index rather than asked for
+const suggestedByCache = "cache"
+
+// suggestedBy names the provider of an answer, or the cache if a provider
+// answered from its cache of an earlier run
+func suggestedBy(by *ai.Attribution) string {
+	if by.Cached {
+		return suggestedByCache
+	}
+	return by.Provider
 }
 
-// batchProcessAI processes AI requests concurrently with caching
+// batchProcessAI processes AI requests concurrently
 func (o *Organizer) batchProcessAI(ctx context.Context, files []*analyzer.FileMetadata, maxConcurrency int) {
 	// Collect files that need AI processing
 	var aiFiles []*analyzer.FileMetadata
@@ -721,37 +827,7 @@ func (o *Organizer) batchProcessAI(ctx context.Context, files []*analyzer.FileMe
 				default:
 				}
 
-				// Process scenario analysis
-				if file.NeedsScenarioAnalysis {
-					cacheKey := ai.GenerateKey("category", file.ContentPreview)
-					if cached, found := o.aiCache.Get(cacheKey); found {
-						if len(cached) > 0 {
-							file.ScenarioCategory = cached[0]
-						}
-					} else {
-						categories, err := o.ollamaClient.SuggestCategory(ctx, file)
-						if err == nil && len(categories) > 0 && categories[0] != "" {
-							file.ScenarioCategory = categories[0]
-							o.aiCache.Set(cacheKey, categories)
-						}
-					}
-				}
-
-				// Process name suggestion
-				if file.NeedsSmarterName {
-					cacheKey := ai.GenerateKey("name", file.ContentPreview)
-					if cached, found := o.aiCache.Get(cacheKey); found {
-						if len(cached) > 0 {
-							file.SuggestedName = cached[0]
-						}
-					} else {
-						suggestions, err := o.ollamaClient.SuggestName(ctx, file)
-						if err == nil && len(suggestions) > 0 && suggestions[0] != "" {
-							file.SuggestedName = suggestions[0]
-							o.aiCache.Set(cacheKey, suggestions)
-						}
-					}
-				}
+				o.suggest(ctx, file)
 			}
 		}()
 	}
@@ -766,6 +842,95 @@ func (o *Organizer) batchProcessAI(ctx context.Context, files []*analyzer.FileMe
 	wg.Wait()
 }
 
+// suggest fills in the name and category a file needs. Suggestions stored
+// by an earlier run are reused; the rest come from one analysis request.
+func (o *Organizer) suggest(ctx context.Context, file *analyzer.FileMetadata) {
+	needCategory, needName := file.NeedsScenarioAnalysis, file.NeedsSmarterName
+	if needCategory {
+		if stored, found := o.storedSuggestion(file, "category"); found {
+			file.ScenarioCategory = stored
+			file.SuggestedBy = suggestedByCache
+			needCategory = false
+		}
+	}
+	if needName {
+		if stored, found := o.storedSuggestion(file, "name"); found {
+			file.SuggestedName = stored
+			file.SuggestedBy = suggestedByCache
+			file.SuggestionReason, _ = o.storedSuggestion(file, "reason")
+			needName = false
+		}
+	}
+	if !needCategory && !needName {
+		return
+	}
+
+	callCtx, by := ai.WithAttribution(ctx)
+	analysis, err := o.ollamaClient.AnalyzeFile(callCtx, file)
+	if err != nil || analysis == nil {
+		return
+	}
+	if file.Language == "" {
+		file.Language = analysis.Language
+	}
+
+	suggested := false
+	if needCategory && analysis.Category != "" {
+		file.ScenarioCategory = analysis.Category
+		o.storeSuggestion(file, "category", analysis.Category)
+		suggested = true
+	}
+	if needName && analysis.Name != "" {
+		file.SuggestedName = analysis.Name
+		o.storeSuggestion(file, "name", analysis.Name)
+		if analysis.Reason != "" {
+			o.storeSuggestion(file, "reason", analysis.Reason)
+		}
+		suggested = true
+	}
+	if suggested {
+		file.SuggestedBy = suggestedBy(by)
+		file.SuggestionReason = analysis.Reason
+		file.SuggestionConfidence = analysis.Confidence
+	}
+}
+
+// aiRenameReason explains a rename to the AI-suggested name, naming the
+// provider that suggested it, how sure it was and why
+func aiRenameReason(file *analyzer.FileMetadata) string {
+	reason := "AI-suggested meaningful name"
+	var about []string
+	if file.SuggestedBy != "" {
+		about = append(about, file.SuggestedBy)
+	}
+	if file.SuggestionConfidence > 0 {
+		about = append(about, fmt.Sprintf("%.0f%% confident", file.SuggestionConfidence*100))
+	}
+	if len(about) > 0 {
+		reason += " (" + strings.Join(about, ", ") + ")"
+	}
+	if file.SuggestionReason != "" {
+		reason += ": " + file.SuggestionReason
+	}
+	return reason
+}
+
+// storedSuggestion returns the AI suggestion kept for a file from an
+// earlier run
+func (o *Organizer) storedSuggestion(file *analyzer.FileMetadata, kind string) (string, bool) {
+	if o.suggestions == nil {
+		return "", false
+	}
+	return o.suggestions.Suggestion(file, kind)
+}
+
+// storeSuggestion keeps an AI suggestion for later runs
+func (o *Organizer) storeSuggestion(file *analyzer.FileMetadata, kind, suggestion string) {
+	if o.suggestions != nil {
+		o.suggestions.SetSuggestion(file, kind, suggestion)
+	}
+}
+
 // ExecutePlan executes an organization plan with error resilience
 func (o *Organizer) ExecutePlan(ctx context.Context, plan *OrganizePlan, strategy *OrganizeStrategy) (*BatchResult, error) {
 	if plan == nil {
@@ -789,6 +954,7 @@ func (o *Organizer) ExecutePlan(ctx context.Context, plan *OrganizePlan, strateg
 		Errors:         make([]error, 0),
 		TransactionIDs: make([]string, 0),
 		FailedFiles:    make(map[string]error),
+		PrunedDirs:     make([]string, 0),
 	}
 
 	// If dry-run mode, just return the plan without executing
@@ -811,6 +977,9 @@ func (o *Organizer) ExecutePlan(ctx context.Context, plan *OrganizePlan, strateg
 	totalOps := len(plan.Operations)
 	completed := 0
 
+	// Directories files were moved out of, for pruning afterwards
+	vacatedDirs := make(map[string]bool)
+
 	for idx, op := range plan.Operations {
 		select {
 		case <-ctx.Done():
@@ -870,6 +1039,9 @@ func (o *Organizer) ExecutePlan(ctx context.Context, plan *OrganizePlan, strateg
 					if opResult.TransactionID != "" {
 						result.TransactionIDs = append(result.TransactionIDs, opResult.TransactionID)
 					}
+					if operation.Type == OpMove {
+						vacatedDirs[filepath.Dir(operation.Source)] = true
+					}
 				} else {
 					result.Failed++
 					if opResult.Error != nil {
@@ -882,9 +1054,78 @@ func (o *Organizer) ExecutePlan(ctx context.Context, plan *OrganizePlan, strateg
 	}
 
 	wg.Wait()
+
+	if strategy.PruneEmptyDirs && len(vacatedDirs) > 0 {
+		if err := o.pruneVacatedDirs(ctx, vacatedDirs, strategy, result); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
 	return result, nil
 }
 
+// pruneVacatedDirs removes directories emptied by a plan execution in a
+// transaction of its own. Undoing a move recreates its source directory.
+func (o *Organizer) pruneVacatedDirs(ctx context.Context, vacatedDirs map[string]bool, strategy *OrganizeStrategy, result *BatchResult) error {
+	dirs := make([]string, 0, len(vacatedDirs))
+	for dir := range vacatedDirs {
+		dirs = append(dirs, dir)
+	}
+
+	tx := o.txnManager.Begin()
+
+	// Without a root only the vacated directories themselves are candidates
+	roots := map[string][]string{strategy.PruneRoot: dirs}
+	if strategy.PruneRoot == "" {
+		roots = make(map[string][]string, len(dirs))
+		for _, dir := range dirs {
+			roots[filepath.Dir(dir)] = append(roots[filepath.Dir(dir)], dir)
+		}
+	}
+
+	for root, rootDirs := range roots {
+		pruned, err := o.pruner.PruneEmptied(ctx, tx, root, rootDirs, strategy.PruneOptions)
+		if err != nil {
+			if rbErr := o.txnManager.Rollback(tx); rbErr != nil {
+				return fmt.Errorf("failed to prune empty directories: %w (rollback failed: %v)", err, rbErr)
+			}
+			return fmt.Errorf("failed to prune empty directories: %w", err)
+		}
+		result.PrunedDirs = append(result.PrunedDirs, pruned.Removed...)
+		result.Errors = append(result.Errors, pruned.Errors...)
+	}
+
+	if len(tx.Operations) == 0 {
+		return nil
+	}
+
+	if err := o.txnManager.Commit(tx); err != nil {
+		return fmt.Errorf("failed to commit prune transaction: %w", err)
+	}
+
+	result.TransactionIDs = append(result.TransactionIDs, tx.ID)
+	return nil
+}
+
+// sanitizePathValue makes a metadata value usable as a path element
+func sanitizePathValue(value string) string {
+	value = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '-'
+		}
+		if r < 0x20 {
+			return -1
+		}
+		return r
+	}, value)
+	value = strings.Trim(strings.TrimSpace(value), ".")
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
 // expandActionTemplate expands a rule action template using file metadata
 func (o *Organizer) expandActionTemplate(action *config.RuleAction, file *analyzer.FileMetadata) (string, error) {
 	if action == nil || action.Target == "" {
@@ -905,6 +1146,15 @@ func (o *Organizer) expandActionTemplate(action *config.RuleAction, file *analyz
 		placeholders["category"] = "uncategorized"
 	}
 
+	// Embedded metadata such as {camera.model} or {taken.year}; values a
+	// file lacks become "unknown"
+	for name, value := range file.Fields() {
+		if value == "" {
+			value = "unknown"
+		}
+		placeholders[name] = sanitizePathValue(value)
+	}
+
 	expander := template.NewExpander(placeholders)
 	return expander.ExpandPath(action.Target)
 }
@@ -2,13 +2,20 @@ package organizer
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xuanyiying/cleanup-cli/internal/ai"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/config"
+	"github.com/xuanyiying/cleanup-cli/internal/rules"
 	"github.com/xuanyiying/cleanup-cli/internal/transaction"
 	"pgregory.net/rapid"
 )
@@ -539,3 +546,292 @@ func TestBatchErrorResilience(t *testing.T) {
 		os.RemoveAll(filepath.Join(tmpDir, "target"))
 	})
 }
+
+func TestOrganizeResolvesTargetsAgainstBaseDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	txnManager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+
+	engine := rules.NewEngine()
+	require.NoError(t, engine.LoadRules([]*config.Rule{
+		{
+			Name:      "pdf",
+			Condition: &config.RuleCondition{Type: "extension", Value: "pdf", Operator: "match"},
+			Action:    &config.RuleAction{Type: "move", Target: "Documents"},
+		},
+	}))
+	organizer := NewOrganizerWithDeps(txnManager, engine, nil)
+
+	files := []*analyzer.FileMetadata{
+		{Path: filepath.Join(root, "new.pdf"), Name: "new.pdf", Extension: "pdf"},
+		{Path: filepath.Join(root, "Documents", "done.pdf"), Name: "done.pdf", Extension: "pdf"},
+	}
+
+	plan, err := organizer.Organize(context.Background(), files, &OrganizeStrategy{BaseDir: root})
+	require.NoError(t, err)
+
+	// Files already in their target directory are skipped
+	require.Len(t, plan.Operations, 1)
+	assert.Equal(t, filepath.Join(root, "Documents", "new.pdf"), plan.Operations[0].Target)
+	assert.Equal(t, 1, plan.Summary.SkipCount)
+}
+
+func TestMoveIsUndoable(t *testing.T) {
+	tmpDir := t.TempDir()
+	txnManager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+	organizer := NewOrganizer(txnManager)
+
+	source := filepath.Join(tmpDir, "file.txt")
+	require.NoError(t, os.WriteFile(source, []byte("content"), 0644))
+
+	result, err := organizer.Move(context.Background(), source, filepath.Join(tmpDir, "target"), nil)
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.NoFileExists(t, source)
+
+	require.NoError(t, txnManager.Undo(result.TransactionID))
+	assert.FileExists(t, source)
+	assert.NoFileExists(t, result.Target)
+}
+
+func TestUndoMoveAfterPrune(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	source := filepath.Join(root, "old", "report.pdf")
+	require.NoError(t, os.MkdirAll(filepath.Dir(source), 0755))
+	require.NoError(t, os.WriteFile(source, []byte("content"), 0644))
+
+	txnManager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+	organizer := NewOrganizer(txnManager)
+	plan := &OrganizePlan{Operations: []*PlannedOperation{
+		{Type: OpMove, Source: source, Target: filepath.Join(root, "Documents", "report.pdf")},
+	}}
+
+	result, err := organizer.ExecutePlan(context.Background(), plan, &OrganizeStrategy{
+		CreateFolders:  true,
+		PruneEmptyDirs: true,
+		PruneRoot:      root,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Dir(source)}, result.PrunedDirs)
+	require.Len(t, result.TransactionIDs, 2)
+	assert.NoDirExists(t, filepath.Dir(source))
+
+	// The move is undone on its own, before the prune that followed it
+	require.NoError(t, txnManager.Undo(result.TransactionIDs[0]))
+	assert.FileExists(t, source)
+}
+
+func TestExpandActionTemplate_Metadata(t *testing.T) {
+	organizer := NewOrganizer(transaction.NewManager(filepath.Join(t.TempDir(), "transactions.json")))
+	photo := &analyzer.FileMetadata{
+		Path:      "/photos/IMG_0001.jpg",
+		Extension: "jpg",
+		ExifData: map[string]string{
+			analyzer.ExifMake:  "Canon",
+			analyzer.ExifModel: "EOS R5/R6",
+		},
+		TakenAt: time.Date(2024, 7, 14, 9, 30, 0, 0, time.Local),
+	}
+
+	target, err := organizer.expandActionTemplate(&config.RuleAction{Type: "move", Target: "Photos/{taken.year}/{taken.month}/{camera.model}"}, photo)
+	require.NoError(t, err)
+	assert.Equal(t, "Photos/2024/07/EOS R5-R6", target)
+
+	// Files without the metadata go to an "unknown" folder
+	target, err = organizer.expandActionTemplate(&config.RuleAction{Type: "move", Target: "Photos/{taken.year}/{lens}"}, &analyzer.FileMetadata{Extension: "png"})
+	require.NoError(t, err)
+	assert.Equal(t, "Photos/unknown/unknown", target)
+
+	song := &analyzer.FileMetadata{
+		Path:      "/downloads/track03.mp3",
+		Extension: "mp3",
+		MediaData: map[string]string{analyzer.MediaArtist: "AC/DC", analyzer.MediaAlbum: "Back in Black"},
+	}
+	target, err = organizer.expandActionTemplate(&config.RuleAction{Type: "move", Target: "Music/{artist}/{album}"}, song)
+	require.NoError(t, err)
+	assert.Equal(t, "Music/AC-DC/Back in Black", target)
+
+	clip := &analyzer.FileMetadata{
+		Path:      "/downloads/clip.mov",
+		Extension: "mov",
+		MediaData: map[string]string{analyzer.MediaCreated: "2021-08-09T10:11:12+02:00"},
+	}
+	target, err = organizer.expandActionTemplate(&config.RuleAction{Type: "move", Target: "Videos/{created.year}"}, clip)
+	require.NoError(t, err)
+	assert.Equal(t, "Videos/2021", target)
+}
+
+// countingNamer suggests names and counts the requests
+type countingNamer struct {
+	mu     sync.Mutex
+	calls  int
+	reason string
+}
+
+func (n *countingNamer) CheckHealth(ctx context.Context) error { return nil }
+
+func (n *countingNamer) Analyze(ctx context.Context, prompt string, contextContent string) (*ai.AnalysisResult, error) {
+	return &ai.AnalysisResult{Success: true}, nil
+}
+
+func (n *countingNamer) SuggestName(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	n.calls++
+	return []string{"suggested-" + file.ContentPreview}, nil
+}
+
+func (n *countingNamer) SuggestCategory(ctx context.Context, file *analyzer.FileMetadata) ([]string, error) {
+	n.calls++
+	return []string{"report"}, nil
+}
+
+func (n *countingNamer) AnalyzeFile(ctx context.Context, file *analyzer.FileMetadata) (*ai.FileAnalysis, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.calls++
+	analysis := &ai.FileAnalysis{Name: "suggested-" + file.ContentPreview, Category: "report", Language: "en", Reason: n.reason}
+	if n.reason != "" {
+		analysis.Confidence = 0.85
+	}
+	return analysis, nil
+}
+
+// mapSuggestions is a SuggestionCache keyed by path and kind
+type mapSuggestions struct {
+	mu          sync.Mutex
+	suggestions map[string]string
+}
+
+func (m *mapSuggestions) Suggestion(file *analyzer.FileMetadata, kind string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.suggestions[kind+":"+file.Path]
+	return s, ok
+}
+
+func (m *mapSuggestions) SetSuggestion(file *analyzer.FileMetadata, kind, suggestion string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.suggestions[kind+":"+file.Path] = suggestion
+}
+
+func TestOrganizeReusesStoredSuggestions(t *testing.T) {
+	tmpDir := t.TempDir()
+	newPath := filepath.Join(tmpDir, "new.txt")
+	stored := &mapSuggestions{suggestions: map[string]string{
+		"name:" + filepath.Join(tmpDir, "old.txt"): "from-index",
+	}}
+	namer := &countingNamer{}
+
+	organizer := NewOrganizer(transaction.NewManager(filepath.Join(tmpDir, "transactions.json")))
+	organizer.ollamaClient = ai.NewChain([]ai.Provider{{Name: "ollama", Client: namer}}, ai.ChainOptions{})
+	organizer.SetSuggestionCache(stored)
+
+	files := []*analyzer.FileMetadata{
+		{Path: filepath.Join(tmpDir, "old.txt"), Name: "old.txt", Extension: "txt", ContentPreview: "old", NeedsSmarterName: true},
+		{Path: newPath, Name: "new.txt", Extension: "txt", ContentPreview: "new", NeedsSmarterName: true},
+	}
+	plan, err := organizer.Organize(context.Background(), files, &OrganizeStrategy{UseAI: true, MaxConcurrency: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, namer.calls, "only the file without a stored suggestion is sent to the AI")
+	assert.Equal(t, "from-index", files[0].SuggestedName)
+	assert.Equal(t, "suggested-new", files[1].SuggestedName)
+	assert.Equal(t, "suggested-new", stored.suggestions["name:"+newPath])
+
+	// Each suggestion says where it came from
+	assert.Equal(t, "cache", files[0].SuggestedBy)
+	assert.Equal(t, "ollama", files[1].SuggestedBy)
+	reasons := make(map[string]string)
+	for _, op := range plan.Operations {
+		reasons[op.Source] = op.Reason
+	}
+	assert.Equal(t, "AI-suggested meaningful name (ollama)", reasons[newPath])
+}
+
+func TestOrganizeAnalyzesOncePerFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "doc1.txt")
+	stored := &mapSuggestions{suggestions: map[string]string{}}
+	namer := &countingNamer{reason: "Quarterly sales figures by region"}
+
+	organizer := NewOrganizer(transaction.NewManager(filepath.Join(tmpDir, "transactions.json")))
+	organizer.ollamaClient = ai.NewChain([]ai.Provider{{Name: "ollama", Client: namer}}, ai.ChainOptions{})
+	organizer.SetSuggestionCache(stored)
+
+	file := &analyzer.FileMetadata{Path: path, Name: "doc1.txt", Extension: "txt", ContentPreview: "sales",
+		NeedsSmarterName: true, NeedsScenarioAnalysis: true}
+	plan, err := organizer.Organize(context.Background(), []*analyzer.FileMetadata{file}, &OrganizeStrategy{UseAI: true, MaxConcurrency: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, namer.calls, "name and category come from one request")
+	assert.Equal(t, "suggested-sales", file.SuggestedName)
+	assert.Equal(t, "report", file.ScenarioCategory)
+	assert.Equal(t, "en", file.Language)
+	require.NotEmpty(t, plan.Operations)
+	assert.Equal(t, "AI-suggested meaningful name (ollama, 85% confident): Quarterly sales figures by region", plan.Operations[0].Reason)
+
+	// The reason is kept with the name for later runs
+	assert.Equal(t, "Quarterly sales figures by region", stored.suggestions["reason:"+path])
+	assert.Equal(t, "report", stored.suggestions["category:"+path])
+}
+
+func TestSuggestedBy(t *testing.T) {
+	assert.Equal(t, "ollama", suggestedBy(&ai.Attribution{Provider: "ollama"}))
+	assert.Equal(t, "cache", suggestedBy(&ai.Attribution{Provider: "ollama", Cached: true}))
+}
+
+func TestOrganizeStream(t *testing.T) {
+	tmpDir := t.TempDir()
+	engine := rules.NewEngine()
+	require.NoError(t, engine.LoadRules([]*config.Rule{
+		{
+			Name:      "pdf",
+			Condition: &config.RuleCondition{Type: "extension", Value: "pdf", Operator: "match"},
+			Action:    &config.RuleAction{Type: "move", Target: "Documents"},
+		},
+	}))
+	organizer := NewOrganizerWithDeps(transaction.NewManager(filepath.Join(tmpDir, "transactions.json")), engine, nil)
+
+	// More files than fit in one batch
+	results := make(chan analyzer.Result)
+	go func() {
+		defer close(results)
+		for i := 0; i < StreamBatchSize+10; i++ {
+			name := fmt.Sprintf("file%d.pdf", i)
+			results <- analyzer.Result{
+				Path:     filepath.Join(tmpDir, name),
+				Metadata: &analyzer.FileMetadata{Path: filepath.Join(tmpDir, name), Name: name, Extension: "pdf", Size: 1},
+			}
+		}
+		results <- analyzer.Result{Path: filepath.Join(tmpDir, "broken.pdf"), Err: fmt.Errorf("unreadable")}
+		// Files inside archives are never planned
+		inner := filepath.Join(tmpDir, "backup.zip") + "!/report.pdf"
+		results <- analyzer.Result{
+			Path:     inner,
+			Metadata: &analyzer.FileMetadata{Path: inner, Name: "report.pdf", Extension: "pdf", ArchivePath: filepath.Join(tmpDir, "backup.zip")},
+		}
+		results <- analyzer.Result{
+			Path:     filepath.Join(tmpDir, "notes.txt"),
+			Metadata: &analyzer.FileMetadata{Path: filepath.Join(tmpDir, "notes.txt"), Name: "notes.txt", Extension: "txt"},
+		}
+	}()
+
+	plan, err := organizer.OrganizeStream(context.Background(), results, &OrganizeStrategy{BaseDir: tmpDir})
+	require.NoError(t, err)
+	assert.Equal(t, StreamBatchSize+11, plan.Summary.TotalFiles)
+	assert.Equal(t, StreamBatchSize+10, plan.Summary.MoveCount)
+	assert.Equal(t, 1, plan.Summary.SkipCount)
+	assert.Equal(t, filepath.Join(tmpDir, "Documents", "file0.pdf"), plan.Operations[0].Target)
+}
+
+func TestOrganizeStreamScanError(t *testing.T) {
+	organizer := NewOrganizer(transaction.NewManager(filepath.Join(t.TempDir(), "transactions.json")))
+
+	results := make(chan analyzer.Result, 1)
+	results <- analyzer.Result{Err: fmt.Errorf("failed to scan directory")}
+	close(results)
+
+	_, err := organizer.OrganizeStream(context.Background(), results, nil)
+	assert.Error(t, err)
+}
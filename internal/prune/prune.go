@@ -0,0 +1,240 @@
+// Package prune removes directories left empty by file operations.
+//
+// Every removal is recorded as an rmdir operation in the caller's transaction,
+// so undoing the transaction recreates the directories before restoring files.
+package prune
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+	"github.com/xuanyiying/cleanup-cli/internal/walk"
+)
+
+// Options controls which directories may be pruned
+type Options struct {
+	ExcludeDirs   []string               // Directory names never removed or descended into
+	IncludeHidden bool                   // Also prune hidden directories
+	IsProtected   func(path string) bool // Extra protected path check (e.g. cleaner.IsProtectedPath)
+	DryRun        bool                   // Report what would be removed without removing it
+	Pending       []string               // Paths treated as already gone (used to preview a run)
+	Traversal     walk.Options           // How PruneTree walks root; symbolic links are never followed
+}
+
+// Result represents the result of a prune operation
+type Result struct {
+	Removed []string
+	Errors  []error
+}
+
+// Pruner removes empty directories and records the removals
+type Pruner struct {
+	txnManager *transaction.Manager
+}
+
+// NewPruner creates a new empty-directory pruner
+func NewPruner(txnManager *transaction.Manager) *Pruner {
+	return &Pruner{
+		txnManager: txnManager,
+	}
+}
+
+// PruneEmptied removes the given directories, and their parents below root,
+// if they are empty. It is meant to run after an operation moved or deleted
+// files out of dirs. root itself is never removed, and neither is anything
+// inside a directory PruneTree would not descend into.
+func (p *Pruner) PruneEmptied(ctx context.Context, tx *transaction.Transaction, root string, dirs []string, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	root = filepath.Clean(root)
+
+	candidates := make(map[string]bool)
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		if insideExcluded(root, dir, opts) {
+			continue
+		}
+		for d := dir; isWithin(root, d); d = filepath.Dir(d) {
+			if candidates[d] {
+				break
+			}
+			candidates[d] = true
+		}
+	}
+
+	list := make([]string, 0, len(candidates))
+	for d := range candidates {
+		list = append(list, d)
+	}
+
+	return p.prune(ctx, tx, root, list, opts)
+}
+
+// PruneTree removes every empty directory below root, including directories
+// that only contain other empty directories. root itself is never removed.
+func (p *Pruner) PruneTree(ctx context.Context, tx *transaction.Transaction, root string, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	root = filepath.Clean(root)
+
+	// Removing a linked directory would remove the link
+	traversal := opts.Traversal
+	traversal.FollowSymlinks = false
+
+	var dirs []string
+	err := walk.Walk(root, &traversal, func(path string, info os.FileInfo, err error) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if err != nil {
+			return nil // Skip paths we can't access
+		}
+		if !info.IsDir() || path == root {
+			return nil
+		}
+		if !allowed(path, opts) {
+			return filepath.SkipDir
+		}
+
+		dirs = append(dirs, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	return p.prune(ctx, tx, root, dirs, opts)
+}
+
+// prune removes the empty candidates deepest first, so that a parent left
+// empty by removing its children is removed as well
+func (p *Pruner) prune(ctx context.Context, tx *transaction.Transaction, root string, dirs []string, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	result := &Result{
+		Removed: make([]string, 0),
+		Errors:  make([]error, 0),
+	}
+
+	gone := make(map[string]bool, len(opts.Pending))
+	for _, path := range opts.Pending {
+		gone[filepath.Clean(path)] = true
+	}
+
+	for _, dir := range sortDeepestFirst(dirs) {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		default:
+		}
+
+		if dir == root || !isWithin(root, dir) || !allowed(dir, opts) {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				result.Errors = append(result.Errors, fmt.Errorf("failed to read %s: %w", dir, err))
+			}
+			continue
+		}
+
+		empty := true
+		for _, entry := range entries {
+			if !gone[filepath.Join(dir, entry.Name())] {
+				empty = false
+				break
+			}
+		}
+		if !empty {
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := os.Remove(dir); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("failed to remove %s: %w", dir, err))
+				continue
+			}
+			p.txnManager.AddOperation(tx, &transaction.ExecutedOperation{
+				Type:   transaction.OpRmdir,
+				Source: dir,
+			})
+		}
+
+		gone[dir] = true
+		result.Removed = append(result.Removed, dir)
+	}
+
+	return result, nil
+}
+
+// insideExcluded reports whether dir lies below a directory between root and
+// dir that may not be pruned, such as the objects directory of .git
+func insideExcluded(root, dir string, opts *Options) bool {
+	for d := filepath.Dir(dir); isWithin(root, d); d = filepath.Dir(d) {
+		if !allowed(d, opts) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether dir may be pruned under the given options
+func allowed(dir string, opts *Options) bool {
+	name := filepath.Base(dir)
+
+	if !opts.IncludeHidden && strings.HasPrefix(name, ".") {
+		return false
+	}
+
+	for _, exclude := range opts.ExcludeDirs {
+		if strings.EqualFold(name, exclude) {
+			return false
+		}
+	}
+
+	if homeDir, err := os.UserHomeDir(); err == nil && filepath.Clean(homeDir) == dir {
+		return false
+	}
+
+	if opts.IsProtected != nil && opts.IsProtected(dir) {
+		return false
+	}
+
+	return true
+}
+
+// isWithin reports whether path is strictly inside dir
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." {
+		return false
+	}
+	return !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// sortDeepestFirst sorts directories so children come before their parents
+func sortDeepestFirst(dirs []string) []string {
+	sort.Slice(dirs, func(i, j int) bool {
+		di := strings.Count(dirs[i], string(filepath.Separator))
+		dj := strings.Count(dirs[j], string(filepath.Separator))
+		if di != dj {
+			return di > dj
+		}
+		return dirs[i] < dirs[j]
+	})
+	return dirs
+}
@@ -0,0 +1,101 @@
+package prune
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+)
+
+func TestPruneTree(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	manager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+
+	for _, dir := range []string{"a/b/c", "keep", "node_modules/empty", ".hidden"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "keep", "file.txt"), []byte("x"), 0644))
+
+	tx := manager.Begin()
+	result, err := NewPruner(manager).PruneTree(context.Background(), tx, root, &Options{
+		ExcludeDirs: []string{"node_modules"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, manager.Commit(tx))
+
+	assert.Equal(t, []string{
+		filepath.Join(root, "a", "b", "c"),
+		filepath.Join(root, "a", "b"),
+		filepath.Join(root, "a"),
+	}, result.Removed)
+	assert.NoDirExists(t, filepath.Join(root, "a"))
+	assert.DirExists(t, filepath.Join(root, "keep"))
+	assert.DirExists(t, filepath.Join(root, "node_modules", "empty"))
+	assert.DirExists(t, filepath.Join(root, ".hidden"))
+	assert.DirExists(t, root)
+
+	// Undo recreates the removed directories
+	require.NoError(t, manager.Undo(tx.ID))
+	assert.DirExists(t, filepath.Join(root, "a", "b", "c"))
+}
+
+func TestPruneEmptiedStopsAtRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	manager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "x", "y"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "untouched"), 0755))
+
+	tx := manager.Begin()
+	result, err := NewPruner(manager).PruneEmptied(context.Background(), tx, root, []string{filepath.Join(root, "x", "y")}, nil)
+	require.NoError(t, err)
+
+	assert.Len(t, result.Removed, 2)
+	assert.Len(t, tx.Operations, 2)
+	assert.DirExists(t, root)
+	// Only directories the run emptied are candidates
+	assert.DirExists(t, filepath.Join(root, "untouched"))
+}
+
+func TestPruneDryRunWithPending(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	manager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+
+	file := filepath.Join(root, "dir", "file.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	require.NoError(t, os.WriteFile(file, []byte("x"), 0644))
+
+	result, err := NewPruner(manager).PruneEmptied(context.Background(), nil, root, []string{filepath.Dir(file)}, &Options{
+		DryRun:  true,
+		Pending: []string{file},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{filepath.Dir(file)}, result.Removed)
+	assert.FileExists(t, file)
+}
+
+func TestPruneRespectsProtectedPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	manager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+
+	protected := filepath.Join(root, "system")
+	require.NoError(t, os.MkdirAll(protected, 0755))
+
+	tx := manager.Begin()
+	result, err := NewPruner(manager).PruneTree(context.Background(), tx, root, &Options{
+		IsProtected: func(path string) bool { return path == protected },
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Removed)
+	assert.DirExists(t, protected)
+}
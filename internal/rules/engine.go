@@ -2,13 +2,16 @@ package rules
 
 import (
 	"fmt"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
 	"github.com/xuanyiying/cleanup-cli/internal/config"
+	"github.com/xuanyiying/cleanup-cli/internal/scheduler"
 )
 
 // Engine defines the interface for rule matching and application
@@ -92,6 +95,10 @@ func (re *RuleEngine) matchesCondition(file *analyzer.FileMetadata, condition *c
 		return re.matchDate(file, condition)
 	case "composite":
 		return re.matchComposite(file, condition)
+	case "metadata":
+		return re.matchMetadata(file, condition)
+	case "archive":
+		return re.matchArchive(file, condition)
 	default:
 		return false
 	}
@@ -218,6 +225,21 @@ func (re *RuleEngine) matchDate(file *analyzer.FileMetadata, condition *config.R
 		return false
 	}
 
+	var fileTime time.Time
+	switch condition.Field {
+	case "", "modified":
+		fileTime = file.ModifiedAt
+	case "created":
+		fileTime = file.CreatedAt
+	case "accessed":
+		fileTime = file.AccessedAt
+	default:
+		return false
+	}
+	if fileTime.IsZero() {
+		return false
+	}
+
 	var targetTime time.Time
 
 	switch v := condition.Value.(type) {
@@ -229,7 +251,12 @@ func (re *RuleEngine) matchDate(file *analyzer.FileMetadata, condition *config.R
 			// Try other formats
 			targetTime, err = time.Parse("2006-01-02", v)
 			if err != nil {
-				return false
+				// An age such as "180d" means that long ago
+				age, err := scheduler.ParseAge(v)
+				if err != nil {
+					return false
+				}
+				targetTime = time.Now().Add(-age)
 			}
 		}
 	case time.Time:
@@ -240,17 +267,146 @@ func (re *RuleEngine) matchDate(file *analyzer.FileMetadata, condition *config.R
 
 	switch condition.Operator {
 	case "before":
-		return file.ModifiedAt.Before(targetTime)
+		return fileTime.Before(targetTime)
 	case "after":
-		return file.ModifiedAt.After(targetTime)
+		return fileTime.After(targetTime)
 	case "eq":
 		// Compare dates only (ignore time)
-		return file.ModifiedAt.Format("2006-01-02") == targetTime.Format("2006-01-02")
+		return fileTime.Format("2006-01-02") == targetTime.Format("2006-01-02")
 	default:
 		return false
 	}
 }
 
+// matchMetadata checks an embedded metadata field of the file, such as
+// camera.model or taken. Files without the field only match "missing".
+func (re *RuleEngine) matchMetadata(file *analyzer.FileMetadata, condition *config.RuleCondition) bool {
+	value, known := file.Fields()[condition.Field]
+	if !known {
+		return false
+	}
+
+	switch condition.Operator {
+	case "exists":
+		return value != ""
+	case "missing":
+		return value == ""
+	}
+	if value == "" || condition.Value == nil {
+		return false
+	}
+	target := fmt.Sprint(condition.Value)
+
+	switch condition.Operator {
+	case "eq":
+		return strings.EqualFold(value, target)
+	case "ne":
+		return !strings.EqualFold(value, target)
+	case "contains":
+		return strings.Contains(strings.ToLower(value), strings.ToLower(target))
+	case "match", "glob":
+		match, err := filepath.Match(strings.ToLower(target), strings.ToLower(value))
+		return err == nil && match
+	case "regex":
+		re, err := regexp.Compile(target)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(value)
+	case "gt", "lt", "gte", "lte":
+		v, err1 := strconv.ParseFloat(value, 64)
+		t, err2 := strconv.ParseFloat(target, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		switch condition.Operator {
+		case "gt":
+			return v > t
+		case "lt":
+			return v < t
+		case "gte":
+			return v >= t
+		default:
+			return v <= t
+		}
+	case "before", "after":
+		v, ok1 := parseMetadataTime(value)
+		t, ok2 := parseMetadataTime(target)
+		if age, err := scheduler.ParseAge(target); !ok2 && err == nil {
+			t, ok2 = time.Now().Add(-age), true
+		}
+		if !ok1 || !ok2 {
+			return false
+		}
+		if condition.Operator == "before" {
+			return v.Before(t)
+		}
+		return v.After(t)
+	default:
+		return false
+	}
+}
+
+// matchArchive checks the files inside an archive. "contains" and
+// "not_contains" match a glob against each file's path and name in the
+// archive, "regex" a regular expression against its path. Only archives
+// listed during the scan match.
+func (re *RuleEngine) matchArchive(file *analyzer.FileMetadata, condition *config.RuleCondition) bool {
+	if file.ArchiveEntries == nil || condition.Value == nil {
+		return false
+	}
+	target := fmt.Sprint(condition.Value)
+
+	var matches func(entry string) bool
+	switch condition.Operator {
+	case "contains", "not_contains", "":
+		pattern := strings.ToLower(target)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return false
+		}
+		matches = func(entry string) bool {
+			entry = strings.ToLower(entry)
+			inPath, _ := path.Match(pattern, entry)
+			inName, _ := path.Match(pattern, path.Base(entry))
+			return inPath || inName
+		}
+	case "regex":
+		pattern, err := regexp.Compile(target)
+		if err != nil {
+			return false
+		}
+		matches = pattern.MatchString
+	default:
+		return false
+	}
+
+	found := false
+	for _, entry := range file.ArchiveEntries {
+		if matches(entry) {
+			found = true
+			break
+		}
+	}
+	if condition.Operator == "not_contains" {
+		return !found
+	}
+	return found
+}
+
+// parseMetadataTime parses a date of a metadata field or condition value;
+// dates without a zone offset are in local time
+func parseMetadataTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
 // matchComposite checks composite conditions (and, or)
 func (re *RuleEngine) matchComposite(file *analyzer.FileMetadata, condition *config.RuleCondition) bool {
 	if condition.Value == nil {
@@ -316,6 +472,9 @@ func (re *RuleEngine) mapToCondition(m map[string]interface{}) *config.RuleCondi
 	if op, ok := m["operator"].(string); ok {
 		condition.Operator = op
 	}
+	if f, ok := m["field"].(string); ok {
+		condition.Field = f
+	}
 
 	return condition
 }
@@ -357,6 +357,42 @@ func TestDateMatching(t *testing.T) {
 	}
 }
 
+func TestDateMatching_FileTimes(t *testing.T) {
+	now := time.Now()
+	file := &analyzer.FileMetadata{
+		Path:       "/test/report.pdf",
+		CreatedAt:  time.Date(2023, 3, 14, 9, 0, 0, 0, time.Local),
+		ModifiedAt: now.AddDate(0, 0, -10),
+		AccessedAt: now.AddDate(0, 0, -200),
+	}
+	never := &analyzer.FileMetadata{Path: "/test/new.pdf", ModifiedAt: now}
+
+	tests := []struct {
+		name      string
+		condition *config.RuleCondition
+		file      bool
+		never     bool
+	}{
+		{"not opened in 180 days", &config.RuleCondition{Type: "date", Field: "accessed", Operator: "before", Value: "180d"}, true, false},
+		{"opened this week", &config.RuleCondition{Type: "date", Field: "accessed", Operator: "after", Value: "168h"}, false, false},
+		{"created in 2023", &config.RuleCondition{Type: "date", Field: "created", Operator: "after", Value: "2023-01-01"}, true, false},
+		{"modified recently", &config.RuleCondition{Type: "date", Field: "modified", Operator: "after", Value: "30d"}, true, true},
+		{"modified by default", &config.RuleCondition{Type: "date", Operator: "before", Value: "7d"}, true, false},
+		{"unknown field", &config.RuleCondition{Type: "date", Field: "changed", Operator: "before", Value: "7d"}, false, false},
+		{"invalid age", &config.RuleCondition{Type: "date", Field: "accessed", Operator: "before", Value: "soon"}, false, false},
+		{"created year field", &config.RuleCondition{Type: "metadata", Field: "file.created.year", Operator: "eq", Value: 2023}, true, false},
+		{"accessed field age", &config.RuleCondition{Type: "metadata", Field: "file.accessed", Operator: "before", Value: "180d"}, true, false},
+	}
+
+	engine := NewEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.file, engine.matchesCondition(file, tt.condition), "file")
+			assert.Equal(t, tt.never, engine.matchesCondition(never, tt.condition), "never accessed")
+		})
+	}
+}
+
 // TestCompositeConditions tests AND/OR composite conditions
 func TestCompositeConditions(t *testing.T) {
 	file := &analyzer.FileMetadata{
@@ -516,3 +552,109 @@ func TestEmptyRules(t *testing.T) {
 	actions := engine.Apply(file, matchedRules)
 	assert.Nil(t, actions)
 }
+
+// TestMetadataCondition tests conditions on embedded metadata fields
+func TestMetadataCondition(t *testing.T) {
+	photo := &analyzer.FileMetadata{
+		Path:      "/photos/IMG_0001.jpg",
+		Extension: "jpg",
+		ExifData: map[string]string{
+			analyzer.ExifModel:            "Canon EOS R5",
+			analyzer.ExifImageWidth:       "8192",
+			analyzer.ExifDateTimeOriginal: "2023-07-14T18:22:05+02:00",
+		},
+		TakenAt: time.Date(2023, 7, 14, 18, 22, 5, 0, time.FixedZone("", 2*3600)),
+	}
+	screenshot := &analyzer.FileMetadata{Path: "/photos/screen.png"}
+
+	tests := []struct {
+		name      string
+		condition *config.RuleCondition
+		photo     bool
+		screen    bool
+	}{
+		{"exists", &config.RuleCondition{Type: "metadata", Field: "camera.model", Operator: "exists"}, true, false},
+		{"missing", &config.RuleCondition{Type: "metadata", Field: "taken", Operator: "missing"}, false, true},
+		{"eq ignores case", &config.RuleCondition{Type: "metadata", Field: "camera.model", Operator: "eq", Value: "canon eos r5"}, true, false},
+		{"contains", &config.RuleCondition{Type: "metadata", Field: "camera.model", Operator: "contains", Value: "EOS"}, true, false},
+		{"glob", &config.RuleCondition{Type: "metadata", Field: "camera.model", Operator: "match", Value: "canon*"}, true, false},
+		{"regex", &config.RuleCondition{Type: "metadata", Field: "camera.model", Operator: "regex", Value: `R\d$`}, true, false},
+		{"numeric", &config.RuleCondition{Type: "metadata", Field: "width", Operator: "gte", Value: 4000}, true, false},
+		{"year", &config.RuleCondition{Type: "metadata", Field: "taken.year", Operator: "eq", Value: 2023}, true, false},
+		{"after", &config.RuleCondition{Type: "metadata", Field: "taken", Operator: "after", Value: "2023-01-01"}, true, false},
+		{"before", &config.RuleCondition{Type: "metadata", Field: "taken", Operator: "before", Value: "2023-07-14T12:00:00Z"}, false, false},
+		{"unknown field", &config.RuleCondition{Type: "metadata", Field: "shutter", Operator: "exists"}, false, false},
+	}
+
+	engine := NewEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.photo, engine.matchesCondition(photo, tt.condition), "photo")
+			assert.Equal(t, tt.screen, engine.matchesCondition(screenshot, tt.condition), "screenshot")
+		})
+	}
+
+	// Metadata conditions inside composite conditions, as loaded from YAML
+	composite := &config.RuleCondition{
+		Type:     "composite",
+		Operator: "and",
+		Value: []interface{}{
+			map[string]interface{}{"type": "extension", "value": "jpg", "operator": "match"},
+			map[string]interface{}{"type": "metadata", "field": "camera.model", "operator": "exists"},
+		},
+	}
+	assert.True(t, engine.matchesCondition(photo, composite))
+
+	// Audio and video tags
+	song := &analyzer.FileMetadata{
+		Path: "/music/track03.mp3",
+		MediaData: map[string]string{
+			analyzer.MediaArtist:   "Björk",
+			analyzer.MediaDuration: "252",
+			analyzer.MediaCreated:  "1993",
+		},
+	}
+	assert.True(t, engine.matchesCondition(song, &config.RuleCondition{Type: "metadata", Field: "artist", Operator: "eq", Value: "björk"}))
+	assert.True(t, engine.matchesCondition(song, &config.RuleCondition{Type: "metadata", Field: "duration", Operator: "gt", Value: 240}))
+	assert.True(t, engine.matchesCondition(song, &config.RuleCondition{Type: "metadata", Field: "created", Operator: "before", Value: "2000-01-01"}))
+	assert.False(t, engine.matchesCondition(song, &config.RuleCondition{Type: "metadata", Field: "album", Operator: "exists"}))
+}
+
+// TestArchiveCondition tests conditions on the files inside archives
+func TestArchiveCondition(t *testing.T) {
+	backup := &analyzer.FileMetadata{
+		Path:           "/backups/photos.zip",
+		Name:           "photos.zip",
+		Extension:      "zip",
+		ArchiveEntries: []string{"2023/IMG_0001.JPG", "2023/notes.txt"},
+	}
+	unlisted := &analyzer.FileMetadata{Path: "/backups/other.zip", Name: "other.zip", Extension: "zip"}
+
+	tests := []struct {
+		name      string
+		condition *config.RuleCondition
+		backup    bool
+	}{
+		{"name glob", &config.RuleCondition{Type: "archive", Operator: "contains", Value: "*.jpg"}, true},
+		{"path glob", &config.RuleCondition{Type: "archive", Operator: "contains", Value: "2023/*.txt"}, true},
+		{"no match", &config.RuleCondition{Type: "archive", Operator: "contains", Value: "*.mp4"}, false},
+		{"not contains", &config.RuleCondition{Type: "archive", Operator: "not_contains", Value: "*.exe"}, true},
+		{"not contains match", &config.RuleCondition{Type: "archive", Operator: "not_contains", Value: "*.jpg"}, false},
+		{"regex", &config.RuleCondition{Type: "archive", Operator: "regex", Value: `^\d{4}/IMG_`}, true},
+		{"bad pattern", &config.RuleCondition{Type: "archive", Operator: "contains", Value: "[a-"}, false},
+		{"unknown operator", &config.RuleCondition{Type: "archive", Operator: "gt", Value: "1"}, false},
+	}
+
+	engine := NewEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.backup, engine.matchesCondition(backup, tt.condition))
+			// Archives that were not listed never match
+			assert.False(t, engine.matchesCondition(unlisted, tt.condition))
+		})
+	}
+
+	// The number of entries is a metadata field
+	assert.True(t, engine.matchesCondition(backup, &config.RuleCondition{Type: "metadata", Field: "archive.entries", Operator: "gte", Value: 2}))
+	assert.True(t, engine.matchesCondition(backup, &config.RuleCondition{Type: "metadata", Field: "archive.format", Operator: "eq", Value: "zip"}))
+}
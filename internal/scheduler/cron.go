@@ -0,0 +1,306 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes when a task runs
+type Schedule interface {
+	// Next returns the first run time strictly after t
+	Next(t time.Time) time.Time
+}
+
+// intervalSchedule runs a fixed duration after the previous run
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+func (s *intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// cronSchedule runs at the calendar times matched by a cron expression
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64 // Bit sets of allowed values
+	domAny, dowAny                bool   // Field was '*' (affects day matching)
+	loc                           *time.Location
+}
+
+// descriptors maps the @-shorthands to their cron expressions
+var descriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+// cronField describes the valid range and names of a cron field
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// ParseSchedule parses a schedule specification. It accepts durations of at
+// least a minute ("30m", "2h"), the descriptors @hourly, @daily, @weekly,
+// @monthly and @yearly, and standard 5-field cron expressions
+// ("0 3 * * SUN"). Calendar schedules are evaluated in the named time zone,
+// or the local zone if timezone is empty.
+func ParseSchedule(spec, timezone string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	loc := time.Local
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", timezone, err)
+		}
+	}
+
+	if expr, ok := descriptors[strings.ToLower(spec)]; ok {
+		return parseCron(expr, loc)
+	}
+
+	if len(strings.Fields(spec)) == 5 {
+		return parseCron(spec, loc)
+	}
+
+	interval, err := parseSchedule(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &intervalSchedule{interval: interval}, nil
+}
+
+// NextRunAfter returns when a task on schedule runs next, given when it last
+// ran (zero if it never ran)
+func NextRunAfter(schedule Schedule, lastRun, now time.Time) time.Time {
+	if !lastRun.IsZero() {
+		if next := schedule.Next(lastRun); next.After(now) {
+			return next
+		}
+	}
+	return schedule.Next(now)
+}
+
+// UpcomingRuns returns the next n run times after from
+func UpcomingRuns(schedule Schedule, from time.Time, n int) []time.Time {
+	runs := make([]time.Time, 0, n)
+	t := from
+	for i := 0; i < n; i++ {
+		t = schedule.Next(t)
+		if t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+	return runs
+}
+
+// parseCron parses a 5-field cron expression
+func parseCron(expr string, loc *time.Location) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression must have 5 fields, got %d", len(fields))
+	}
+
+	sets := make([]uint64, 5)
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s field %q: %w", cronFields[i].name, field, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday may be written as 0 or 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &cronSchedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: strings.HasPrefix(fields[2], "*"),
+		dowAny: strings.HasPrefix(fields[4], "*"),
+		loc:    loc,
+	}, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges and steps
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := spec.min, spec.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = parseCronValue(bounds[0], spec); err != nil {
+				return 0, err
+			}
+			if hi, err = parseCronValue(bounds[1], spec); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("range %s is backwards", rangePart)
+			}
+		default:
+			v, err := parseCronValue(rangePart, spec)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "5/15" means every 15 starting at 5
+			if !strings.Contains(part, "/") {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// parseCronValue parses a single number or name within a field's range
+func parseCronValue(s string, spec cronField) (int, error) {
+	if v, ok := spec.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < spec.min || v > spec.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, spec.min, spec.max)
+	}
+	return v, nil
+}
+
+// Next returns the first matching minute strictly after t, or the zero time
+// if the expression never matches (e.g. "0 0 30 2 *"). A wall-clock minute
+// that repeats when clocks fall back matches only its first occurrence; one
+// skipped when clocks spring forward runs at the first minute after the gap.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	from := t
+	t = t.In(s.loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, s.loc)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if s.skippedMatch(t) && t.After(from) {
+			return t
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = s.nextHour(t)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = s.nextMinute(t)
+			continue
+		}
+		// During the repeated hour, time.Date resolves to the first
+		// occurrence, which may already lie before from
+		if !t.After(from) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, s.loc)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// nextHour returns the start of the hour after t. Inside a spring-forward
+// gap time.Date may resolve to a time before t, so it steps by elapsed time
+func (s *cronSchedule) nextHour(t time.Time) time.Time {
+	next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.loc)
+	if next.After(t) {
+		return next
+	}
+	u := t.Add(time.Hour)
+	return time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), 0, 0, 0, s.loc)
+}
+
+// nextMinute returns the minute after t, stepping over a spring-forward gap
+// the same way as nextHour
+func (s *cronSchedule) nextMinute(t time.Time) time.Time {
+	next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, s.loc)
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Minute)
+}
+
+// skippedMatch reports whether t directly follows a gap in the wall clock
+// (clocks springing forward) that hides a matching minute
+func (s *cronSchedule) skippedMatch(t time.Time) bool {
+	prev := t.Add(-time.Minute)
+	wall := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	}
+
+	for w := wall(prev).Add(time.Minute); w.Before(wall(t)); w = w.Add(time.Minute) {
+		if s.hour&(1<<uint(w.Hour())) != 0 && s.minute&(1<<uint(w.Minute())) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dayMatches applies cron's day rule: when both day of month and day of week
+// are restricted, a day matching either one qualifies
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
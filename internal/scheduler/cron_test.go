@@ -0,0 +1,174 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronSchedule_TimeZone(t *testing.T) {
+	schedule, err := ParseSchedule("0 3 * * *", "America/New_York")
+	if err != nil {
+		t.Fatalf("ParseSchedule failed: %v", err)
+	}
+
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	got := schedule.Next(from)
+
+	// 03:00 in New York during daylight saving time is 07:00 UTC
+	want := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got.UTC(), want)
+	}
+
+	if _, err := ParseSchedule("@daily", "Not/AZone"); err == nil {
+		t.Error("Expected error for unknown time zone")
+	}
+}
+
+func TestCronSchedule_DaylightSavingFallBack(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	schedule, err := ParseSchedule("30 1 * * *", "America/New_York")
+	if err != nil {
+		t.Fatalf("ParseSchedule failed: %v", err)
+	}
+
+	// 01:30 happens twice on 2024-11-03; the task must run only once
+	first := schedule.Next(time.Date(2024, 11, 3, 0, 0, 0, 0, loc))
+	second := schedule.Next(first)
+	if second.Sub(first) < 24*time.Hour {
+		t.Errorf("Expected a single run per day, got %v then %v", first, second)
+	}
+}
+
+func TestCronSchedule_NextDuringRepeatedHour(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	// 01:10 EST on 2026-11-01 comes after 01:30 EDT the same night
+	now := time.Date(2026, 11, 1, 1, 10, 0, 0, loc).Add(time.Hour)
+	if _, offset := now.Zone(); offset != -5*3600 {
+		t.Fatalf("Expected %v to be in EST", now)
+	}
+
+	for _, expr := range []string{"30 1 * * *", "*/5 * * * *", "0 2 * * *"} {
+		schedule, err := ParseSchedule(expr, "America/New_York")
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) failed: %v", expr, err)
+		}
+		if next := schedule.Next(now); !next.After(now) {
+			t.Errorf("%s: Next(%v) = %v, not after it", expr, now, next)
+		}
+	}
+}
+
+func TestCronSchedule_DaylightSavingSpringForward(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	// 02:00-02:59 does not exist on 2026-03-08; the run happens at 03:00
+	tests := map[string]time.Time{
+		"30 2 * * *": time.Date(2026, 3, 8, 3, 0, 0, 0, loc),
+		"0 3 * * *":  time.Date(2026, 3, 8, 3, 0, 0, 0, loc),
+		"30 3 * * *": time.Date(2026, 3, 8, 3, 30, 0, 0, loc),
+	}
+	for expr, want := range tests {
+		schedule, err := ParseSchedule(expr, "America/New_York")
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) failed: %v", expr, err)
+		}
+		first := schedule.Next(time.Date(2026, 3, 8, 0, 0, 0, 0, loc))
+		if !first.Equal(want) {
+			t.Errorf("%s: Next() = %v, want %v", expr, first, want)
+		}
+		// The next day runs at the usual time again
+		if second := schedule.Next(first); second.Sub(first) < 23*time.Hour {
+			t.Errorf("%s: Expected a single run per day, got %v then %v", expr, first, second)
+		}
+	}
+}
+
+func TestCronSchedule_DayOfMonthOrDayOfWeek(t *testing.T) {
+	// Both restricted: the 1st of the month or any Monday
+	schedule, err := ParseSchedule("0 0 1 * 1", "UTC")
+	if err != nil {
+		t.Fatalf("ParseSchedule failed: %v", err)
+	}
+
+	runs := UpcomingRuns(schedule, time.Date(2024, 3, 27, 0, 0, 0, 0, time.UTC), 3)
+	want := []time.Time{
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), // Monday and the 1st
+		time.Date(2024, 4, 8, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC),
+	}
+	if len(runs) != len(want) {
+		t.Fatalf("Expected %d runs, got %d", len(want), len(runs))
+	}
+	for i := range want {
+		if !runs[i].Equal(want[i]) {
+			t.Errorf("run %d = %v, want %v", i, runs[i], want[i])
+		}
+	}
+}
+
+func TestCronSchedule_NeverMatches(t *testing.T) {
+	schedule, err := ParseSchedule("0 0 30 2 *", "UTC")
+	if err != nil {
+		t.Fatalf("ParseSchedule failed: %v", err)
+	}
+
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Errorf("Expected no next run, got %v", next)
+	}
+	if runs := UpcomingRuns(schedule, time.Now(), 5); len(runs) != 0 {
+		t.Errorf("Expected no upcoming runs, got %d", len(runs))
+	}
+}
+
+func TestNextRunAfter(t *testing.T) {
+	schedule, _ := ParseSchedule("1h", "")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Continues from the last run while that is still ahead
+	if got := NextRunAfter(schedule, now.Add(-20*time.Minute), now); !got.Equal(now.Add(40 * time.Minute)) {
+		t.Errorf("NextRunAfter() = %v", got)
+	}
+	// Otherwise counts from now
+	if got := NextRunAfter(schedule, now.Add(-3*time.Hour), now); !got.Equal(now.Add(time.Hour)) {
+		t.Errorf("NextRunAfter() = %v", got)
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	base := time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 100; i++ {
+		got := withJitter(base, 5*time.Minute)
+		if got.Before(base) || !got.Before(base.Add(5*time.Minute)) {
+			t.Fatalf("withJitter() = %v, outside window", got)
+		}
+	}
+
+	if got := withJitter(base, 0); !got.Equal(base) {
+		t.Errorf("Expected no jitter, got %v", got)
+	}
+}
+
+func TestTaskConfig_Validate(t *testing.T) {
+	valid := &TaskConfig{ID: "a", Schedule: "0 3 * * 0", Timezone: "UTC", Jitter: "10m", Command: "true"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+
+	invalid := &TaskConfig{ID: "b", Schedule: "@daily", Jitter: "soon"}
+	if err := invalid.Validate(); err == nil {
+		t.Error("Expected error for invalid jitter")
+	}
+}
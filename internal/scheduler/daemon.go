@@ -0,0 +1,239 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultReloadInterval is how often the daemon checks the store for changes
+	DefaultReloadInterval = 30 * time.Second
+	// DefaultGracePeriod is how long running tasks may finish during shutdown
+	DefaultGracePeriod = 30 * time.Second
+)
+
+// Daemon runs the tasks of a store until it is stopped, picking up changes
+// made to the store while it runs
+type Daemon struct {
+	store          *Store
+	history        *History
+	out            io.Writer
+	ReloadInterval time.Duration
+	GracePeriod    time.Duration
+	Jobs           JobRunner // Runs tasks with a built-in job
+
+	mu       sync.Mutex
+	sched    *Scheduler
+	loaded   map[string]TaskConfig
+	storeMod time.Time
+}
+
+// NewDaemon creates a daemon for the tasks in store. Task output is written
+// to out and every run is recorded in history.
+func NewDaemon(store *Store, history *History, out io.Writer) *Daemon {
+	return &Daemon{
+		store:          store,
+		history:        history,
+		out:            out,
+		ReloadInterval: DefaultReloadInterval,
+		GracePeriod:    DefaultGracePeriod,
+		loaded:         make(map[string]TaskConfig),
+	}
+}
+
+// Run starts the daemon and serves until ctx is cancelled
+func (d *Daemon) Run(ctx context.Context) error {
+	if err := d.Start(); err != nil {
+		return err
+	}
+	return d.Serve(ctx)
+}
+
+// Start loads the stored tasks and starts scheduling them
+func (d *Daemon) Start() error {
+	d.mu.Lock()
+	d.sched = NewScheduler()
+	d.sched.SetHistory(d.history)
+	d.mu.Unlock()
+
+	if err := d.Reload(); err != nil {
+		d.shutdown()
+		return err
+	}
+	return nil
+}
+
+// Serve reloads the tasks whenever the store changes until ctx is cancelled.
+// Running tasks then get the grace period to finish before they are cancelled.
+func (d *Daemon) Serve(ctx context.Context) error {
+	ticker := time.NewTicker(d.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return d.shutdown()
+		case <-ticker.C:
+			if !d.storeChanged() {
+				continue
+			}
+			if err := d.Reload(); err != nil {
+				fmt.Fprintf(d.out, "Warning: failed to reload scheduled tasks: %v\n", err)
+			}
+		}
+	}
+}
+
+// Reload synchronizes the running tasks with the store. Unchanged tasks keep
+// their timers; changed tasks are restarted and removed tasks are stopped.
+func (d *Daemon) Reload() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sched == nil {
+		return fmt.Errorf("daemon is not running")
+	}
+
+	modTime := d.store.ModTime()
+	cfg, err := d.store.Load()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(cfg.Tasks))
+	for _, tc := range cfg.Tasks {
+		seen[tc.ID] = true
+
+		if loaded, ok := d.loaded[tc.ID]; ok {
+			if reflect.DeepEqual(loaded, *tc) {
+				continue
+			}
+			_ = d.sched.RemoveTask(tc.ID)
+			delete(d.loaded, tc.ID)
+		}
+
+		task := newTaskFromConfig(tc)
+		if d.history != nil {
+			if last, err := d.history.LastRun(tc.ID); err == nil && last != nil {
+				task.LastRun = last.StartedAt
+			}
+		}
+
+		fn, err := TaskFuncFor(tc, d.Jobs, d.out)
+		if err != nil {
+			return fmt.Errorf("failed to schedule task %s: %w", tc.ID, err)
+		}
+		if err := d.sched.AddTask(task, fn); err != nil {
+			return fmt.Errorf("failed to schedule task %s: %w", tc.ID, err)
+		}
+		d.loaded[tc.ID] = *tc
+	}
+
+	for id := range d.loaded {
+		if !seen[id] {
+			_ = d.sched.RemoveTask(id)
+			delete(d.loaded, id)
+		}
+	}
+
+	d.storeMod = modTime
+	return nil
+}
+
+// TaskFuncFor returns what a stored task runs: its built-in job, executed by
+// jobs, or its command. Output is mirrored to out.
+func TaskFuncFor(tc *TaskConfig, jobs JobRunner, out io.Writer) (TaskFunc, error) {
+	if tc.Job == nil {
+		return CommandFunc(tc.Command, out), nil
+	}
+	if jobs == nil {
+		return nil, fmt.Errorf("no runner for %s jobs", tc.Job.Kind)
+	}
+	return JobFunc(jobs, tc.Job, out, tc.Then...), nil
+}
+
+// RunNow runs fn as a run of the task outside any schedule and records the
+// outcome in history (if not nil)
+func RunNow(ctx context.Context, taskID string, fn TaskFunc, history *History) *RunRecord {
+	record := &RunRecord{
+		TaskID:    taskID,
+		StartedAt: time.Now(),
+	}
+
+	err := fn(withRunRecord(ctx, record))
+	record.finish(err, ctx.Err() != nil)
+
+	if history != nil {
+		// The run happened even if it can't be recorded
+		_ = history.Record(record)
+	}
+	return record
+}
+
+// storeChanged reports whether the store file changed since the last reload
+func (d *Daemon) storeChanged() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return !d.store.ModTime().Equal(d.storeMod)
+}
+
+// Tasks returns the tasks currently scheduled by the daemon
+func (d *Daemon) Tasks() []*Task {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sched == nil {
+		return nil
+	}
+	return d.sched.ListTasks()
+}
+
+// shutdown waits for running tasks, then stops the scheduler
+func (d *Daemon) shutdown() error {
+	d.mu.Lock()
+	sched := d.sched
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.GracePeriod)
+	defer cancel()
+
+	err := sched.Shutdown(ctx)
+	sched.Stop()
+
+	d.mu.Lock()
+	d.sched = nil
+	d.loaded = make(map[string]TaskConfig)
+	d.mu.Unlock()
+	return err
+}
+
+// newTaskFromConfig creates a schedulable task from its stored configuration
+func newTaskFromConfig(tc *TaskConfig) *Task {
+	// Validated when the store was loaded
+	jitter, _ := tc.JitterDuration()
+	overlap, _ := ParseOverlapPolicy(tc.Overlap)
+	catchUp, _ := ParseCatchUpPolicy(tc.CatchUp)
+
+	task := &Task{
+		ID:       tc.ID,
+		Name:     tc.Name,
+		Schedule: tc.Schedule,
+		Timezone: tc.Timezone,
+		Jitter:   jitter,
+		Command:  tc.Command,
+		Args:     tc.Args,
+		Job:      tc.Job,
+		Then:     tc.Then,
+		Overlap:  overlap,
+		CatchUp:  catchUp,
+		Enabled:  tc.Enabled,
+	}
+	if tc.Trigger != nil {
+		task.Trigger, _ = NewTrigger(tc.Trigger)
+	}
+	return task
+}
@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDaemon_ReloadsStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "schedule.yaml"))
+	if err := store.Add(&TaskConfig{ID: "one", Schedule: "1h", Command: "true", Enabled: true}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	daemon := NewDaemon(store, NewHistory(filepath.Join(dir, "runs.json")), io.Discard)
+	daemon.ReloadInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- daemon.Run(ctx) }()
+
+	waitFor(t, func() bool { return len(daemon.Tasks()) == 1 })
+
+	// Changes made by other processes are picked up
+	if err := store.Add(&TaskConfig{ID: "two", Schedule: "2h", Command: "true"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := store.Remove("one"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	waitFor(t, func() bool {
+		tasks := daemon.Tasks()
+		return len(tasks) == 1 && tasks[0].ID == "two"
+	})
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+}
+
+func TestScheduler_ShutdownWaitsForRunningTask(t *testing.T) {
+	s := NewScheduler()
+	history := NewHistory(filepath.Join(t.TempDir(), "runs.json"))
+	s.SetHistory(history)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fn := func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}
+
+	// Last run a minute ago, so the next run is due right away
+	task := &Task{ID: "slow", Schedule: "1m", Enabled: true, LastRun: time.Now().Add(-time.Minute + 10*time.Millisecond)}
+	if err := s.AddTask(task, fn); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	<-started
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownDone <- s.Shutdown(ctx)
+	}()
+
+	select {
+	case <-shutdownDone:
+		t.Fatal("Shutdown returned while the task was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-shutdownDone; err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	s.Stop()
+
+	last, err := history.LastRun("slow")
+	if err != nil || last == nil {
+		t.Fatalf("Expected run to be recorded: %v", err)
+	}
+	if last.Status != RunSucceeded {
+		t.Errorf("Expected succeeded run, got %s", last.Status)
+	}
+}
+
+func TestScheduler_ShutdownCancelsAfterGracePeriod(t *testing.T) {
+	s := NewScheduler()
+
+	started := make(chan struct{})
+	fn := func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	task := &Task{ID: "stuck", Schedule: "1m", Enabled: true, LastRun: time.Now().Add(-time.Minute + 10*time.Millisecond)}
+	if err := s.AddTask(task, fn); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err == nil {
+		t.Error("Expected error when running tasks had to be cancelled")
+	}
+	s.Stop()
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRunNow_RecordsRun(t *testing.T) {
+	history := NewHistory(filepath.Join(t.TempDir(), "runs.json"))
+
+	record := RunNow(context.Background(), "manual", func(ctx context.Context) error { return nil }, history)
+	if record.Status != RunSucceeded {
+		t.Errorf("Expected succeeded run, got %+v", record)
+	}
+
+	record = RunNow(context.Background(), "manual", func(ctx context.Context) error { return ErrSkipRun }, history)
+	if record.Status != RunSkipped {
+		t.Errorf("Expected skipped run, got %+v", record)
+	}
+
+	runs, err := history.Runs("manual", 0)
+	if err != nil {
+		t.Fatalf("Runs failed: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Errorf("Expected 2 recorded runs, got %d", len(runs))
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd)
+
+package scheduler
+
+import "errors"
+
+// diskUsage is not supported on this platform
+func diskUsage(path string) (free, total uint64, err error) {
+	return 0, 0, errors.New("free space is not supported on this platform")
+}
@@ -0,0 +1,265 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// weekdayNames are the systemd names of the cron day-of-week values
+var weekdayNames = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// errTriggerExport is returned for tasks that run on a file system trigger,
+// which only the daemon can evaluate
+var errTriggerExport = errors.New("triggered tasks can only run in 'cleanup daemon'")
+
+// SystemdUnits holds the generated unit files of a task
+type SystemdUnits struct {
+	Name    string // Unit name without suffix, e.g. "cleanup-daily"
+	Service string
+	Timer   string
+}
+
+// UnitName returns the systemd unit name (without suffix) for a task
+func UnitName(taskID string) string {
+	var b strings.Builder
+	b.WriteString("cleanup-")
+	for _, r := range taskID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// ExportSystemd generates a oneshot service running the task through
+// "<executable> schedule run <id>" and a timer with the same schedule.
+// Catch-up run-once maps to Persistent=, jitter to RandomizedDelaySec=.
+// systemd never starts a service that is still running, which matches the
+// skip overlap policy; queue and replace can't be expressed.
+func ExportSystemd(tc *TaskConfig, executable string) (*SystemdUnits, error) {
+	if tc.Trigger != nil {
+		return nil, errTriggerExport
+	}
+	schedule, err := tc.ParsedSchedule()
+	if err != nil {
+		return nil, err
+	}
+	jitter, err := tc.JitterDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	var timer strings.Builder
+	fmt.Fprintf(&timer, "# Generated by cleanup from scheduled task %q\n", tc.ID)
+	fmt.Fprintf(&timer, "[Unit]\nDescription=Cleanup timer: %s\n\n[Timer]\n", tc.Name)
+
+	switch s := schedule.(type) {
+	case *cronSchedule:
+		for _, calendar := range s.onCalendar(tc.Timezone) {
+			fmt.Fprintf(&timer, "OnCalendar=%s\n", calendar)
+		}
+		if tc.CatchUp == string(CatchUpRunOnce) {
+			timer.WriteString("Persistent=true\n")
+		}
+	case *intervalSchedule:
+		// First run one interval after the timer starts, then one interval
+		// after each run. systemd keeps no state for these across reboots.
+		seconds := int64(s.interval / time.Second)
+		fmt.Fprintf(&timer, "OnActiveSec=%d\nOnUnitActiveSec=%d\n", seconds, seconds)
+		if tc.CatchUp == string(CatchUpRunOnce) {
+			timer.WriteString("# Catch-up is not supported for interval timers; missed runs are skipped\n")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported schedule %q", tc.Schedule)
+	}
+	if jitter > 0 {
+		fmt.Fprintf(&timer, "RandomizedDelaySec=%d\n", int64(jitter/time.Second))
+	}
+	if tc.Overlap != "" && tc.Overlap != string(OverlapSkip) {
+		fmt.Fprintf(&timer, "# Overlap policy %q is not supported by systemd; overlapping runs are skipped\n", tc.Overlap)
+	}
+	timer.WriteString("\n[Install]\nWantedBy=timers.target\n")
+
+	var service strings.Builder
+	fmt.Fprintf(&service, "# Generated by cleanup from scheduled task %q\n", tc.ID)
+	fmt.Fprintf(&service, "[Unit]\nDescription=Cleanup task: %s\n\n[Service]\nType=oneshot\n", tc.Name)
+	fmt.Fprintf(&service, "ExecStart=%s schedule run %s\n", systemdQuote(executable), systemdQuote(tc.ID))
+
+	return &SystemdUnits{
+		Name:    UnitName(tc.ID),
+		Service: service.String(),
+		Timer:   timer.String(),
+	}, nil
+}
+
+// ExportCrontab generates the crontab entry of a task, preceded by comments
+// for whatever cron can't express. Interval schedules are only supported
+// when they divide an hour or a day evenly and are then aligned to the clock.
+// A time zone is set with CRON_TZ=, which applies to all following entries.
+// Overlapping runs are skipped by "schedule run" itself.
+func ExportCrontab(tc *TaskConfig, executable string) (string, error) {
+	if tc.Trigger != nil {
+		return "", errTriggerExport
+	}
+	schedule, err := tc.ParsedSchedule()
+	if err != nil {
+		return "", err
+	}
+
+	var spec string
+	switch s := schedule.(type) {
+	case *cronSchedule:
+		spec = tc.Schedule
+		if expr, ok := descriptors[strings.ToLower(strings.TrimSpace(spec))]; ok {
+			spec = expr
+		}
+	case *intervalSchedule:
+		if spec, err = intervalCron(s.interval); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unsupported schedule %q", tc.Schedule)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s (%s)\n", tc.Name, tc.ID)
+	if tc.CatchUp == string(CatchUpRunOnce) {
+		b.WriteString("# Catch-up is not supported by cron; missed runs are skipped\n")
+	}
+	if tc.Overlap != "" && tc.Overlap != string(OverlapSkip) {
+		fmt.Fprintf(&b, "# Overlap policy %q is not supported by cron; overlapping runs are skipped\n", tc.Overlap)
+	}
+	if tc.Timezone != "" {
+		fmt.Fprintf(&b, "CRON_TZ=%s\n", tc.Timezone)
+	}
+
+	command := fmt.Sprintf("%s schedule run %s", shellQuote(executable), shellQuote(tc.ID))
+	if tc.Jitter != "" {
+		command = fmt.Sprintf("%s schedule run --jitter %s", shellQuote(executable), shellQuote(tc.ID))
+	}
+	// cron turns unescaped % into newlines
+	fmt.Fprintf(&b, "%s %s\n", spec, strings.ReplaceAll(command, "%", `\%`))
+
+	return b.String(), nil
+}
+
+// intervalCron expresses an interval as a clock-aligned cron schedule
+func intervalCron(interval time.Duration) (string, error) {
+	switch {
+	case interval%time.Minute == 0 && interval < time.Hour && time.Hour%interval == 0:
+		return fmt.Sprintf("*/%d * * * *", interval/time.Minute), nil
+	case interval == time.Hour:
+		return "0 * * * *", nil
+	case interval%time.Hour == 0 && interval < 24*time.Hour && (24*time.Hour)%interval == 0:
+		return fmt.Sprintf("0 */%d * * *", interval/time.Hour), nil
+	case interval == 24*time.Hour:
+		return "0 0 * * *", nil
+	}
+	return "", fmt.Errorf("interval %s cannot be expressed in cron; use a cron expression instead", interval)
+}
+
+// onCalendar returns the systemd OnCalendar= expressions matching the same
+// times as the cron schedule. When both day of month and day of week are
+// restricted, cron runs on days matching either, which takes two expressions.
+func (s *cronSchedule) onCalendar(timezone string) []string {
+	clock := fmt.Sprintf("%s:%s:00",
+		calendarField(s.hour, 0, 23, "%02d"),
+		calendarField(s.minute, 0, 59, "%02d"))
+	months := calendarField(s.month, 1, 12, "%02d")
+	days := calendarField(s.dom, 1, 31, "%02d")
+	weekdays := weekdayField(s.dow)
+
+	var exprs []string
+	switch {
+	case s.domAny || s.dowAny:
+		expr := fmt.Sprintf("*-%s-%s %s", months, days, clock)
+		if weekdays != "" {
+			expr = weekdays + " " + expr
+		}
+		exprs = []string{expr}
+	default:
+		exprs = []string{
+			fmt.Sprintf("*-%s-%s %s", months, days, clock),
+			fmt.Sprintf("%s *-%s-* %s", weekdays, months, clock),
+		}
+	}
+
+	if timezone != "" {
+		for i := range exprs {
+			exprs[i] += " " + timezone
+		}
+	}
+	return exprs
+}
+
+// calendarField formats a cron bit set as a systemd calendar component:
+// "*" when every value is allowed, otherwise a list of values and ranges
+func calendarField(set uint64, min, max int, format string) string {
+	all := (uint64(1)<<uint(max+1) - 1) &^ (uint64(1)<<uint(min) - 1)
+	if set&all == all {
+		return "*"
+	}
+
+	var parts []string
+	for v := min; v <= max; v++ {
+		if set&(1<<uint(v)) == 0 {
+			continue
+		}
+		end := v
+		for end+1 <= max && set&(1<<uint(end+1)) != 0 {
+			end++
+		}
+		switch {
+		case end-v >= 2:
+			parts = append(parts, fmt.Sprintf(format+".."+format, v, end))
+		case end > v:
+			parts = append(parts, fmt.Sprintf(format+","+format, v, end))
+		default:
+			parts = append(parts, fmt.Sprintf(format, v))
+		}
+		v = end
+	}
+	return strings.Join(parts, ",")
+}
+
+// weekdayField formats the day-of-week set as systemd weekday names (empty
+// when every day is allowed)
+func weekdayField(set uint64) string {
+	set &= 0x7f // Sunday as 7 was folded into 0 when parsing
+	if bits.OnesCount64(set) == 7 {
+		return ""
+	}
+
+	names := make([]string, 0, 7)
+	for _, day := range []int{1, 2, 3, 4, 5, 6, 0} { // systemd weeks start on Monday
+		if set&(1<<uint(day)) != 0 {
+			names = append(names, weekdayNames[day])
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// systemdQuote quotes a word for an Exec= line if it needs it
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;$%") {
+		return s
+	}
+	s = strings.ReplaceAll(s, "%", "%%")
+	s = strings.ReplaceAll(s, "$", "$$")
+	return strconv.Quote(s)
+}
+
+// shellQuote quotes a word for sh if it needs it
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'\\;$&|<>()*?[]#~`!{}%") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
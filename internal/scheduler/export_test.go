@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOnCalendar(t *testing.T) {
+	tests := []struct {
+		expr     string
+		timezone string
+		want     []string
+	}{
+		{"@daily", "", []string{"*-*-* 00:00:00"}},
+		{"@hourly", "", []string{"*-*-* *:00:00"}},
+		{"@weekly", "", []string{"Sun *-*-* 00:00:00"}},
+		{"@monthly", "", []string{"*-*-01 00:00:00"}},
+		{"@yearly", "", []string{"*-01-01 00:00:00"}},
+		{"*/15 9-17 * * MON-FRI", "", []string{"Mon,Tue,Wed,Thu,Fri *-*-* 09..17:00,15,30,45:00"}},
+		{"30 2 * 1,6 *", "Europe/Berlin", []string{"*-01,06-* 02:30:00 Europe/Berlin"}},
+		{"0 0 * * 0,7", "", []string{"Sun *-*-* 00:00:00"}},
+		// cron runs when either day field matches
+		{"0 0 1,15 * SUN", "", []string{"*-*-01,15 00:00:00", "Sun *-*-* 00:00:00"}},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.expr, "")
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) failed: %v", tt.expr, err)
+		}
+		got := schedule.(*cronSchedule).onCalendar(tt.timezone)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("onCalendar(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestIntervalCron(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		want     string
+	}{
+		{5 * time.Minute, "*/5 * * * *"},
+		{time.Hour, "0 * * * *"},
+		{6 * time.Hour, "0 */6 * * *"},
+		{24 * time.Hour, "0 0 * * *"},
+	}
+	for _, tt := range tests {
+		got, err := intervalCron(tt.interval)
+		if err != nil || got != tt.want {
+			t.Errorf("intervalCron(%s) = %q, %v; want %q", tt.interval, got, err, tt.want)
+		}
+	}
+
+	for _, interval := range []time.Duration{7 * time.Minute, 90 * time.Minute, 48 * time.Hour, 30 * time.Second} {
+		if _, err := intervalCron(interval); err == nil {
+			t.Errorf("Expected error for interval %s", interval)
+		}
+	}
+}
+
+func TestExportSystemd(t *testing.T) {
+	tc := &TaskConfig{
+		ID:       "weekly junk",
+		Name:     "Weekly Junk",
+		Schedule: "0 3 * * SUN",
+		Timezone: "Europe/Berlin",
+		Jitter:   "15m",
+		CatchUp:  string(CatchUpRunOnce),
+		Overlap:  string(OverlapQueue),
+		Command:  "true",
+	}
+
+	units, err := ExportSystemd(tc, "/opt/my tools/cleanup")
+	if err != nil {
+		t.Fatalf("ExportSystemd failed: %v", err)
+	}
+	if units.Name != "cleanup-weekly_junk" {
+		t.Errorf("Expected sanitized unit name, got %q", units.Name)
+	}
+	for _, want := range []string{
+		"OnCalendar=Sun *-*-* 03:00:00 Europe/Berlin\n",
+		"Persistent=true\n",
+		"RandomizedDelaySec=900\n",
+		`# Overlap policy "queue"`,
+		"WantedBy=timers.target\n",
+	} {
+		if !strings.Contains(units.Timer, want) {
+			t.Errorf("Timer is missing %q:\n%s", want, units.Timer)
+		}
+	}
+	if want := `ExecStart="/opt/my tools/cleanup" schedule run "weekly junk"`; !strings.Contains(units.Service, want) {
+		t.Errorf("Service is missing %q:\n%s", want, units.Service)
+	}
+
+	tc = &TaskConfig{ID: "often", Name: "Often", Schedule: "30m", Command: "true"}
+	units, err = ExportSystemd(tc, "/usr/bin/cleanup")
+	if err != nil {
+		t.Fatalf("ExportSystemd failed: %v", err)
+	}
+	if !strings.Contains(units.Timer, "OnActiveSec=1800\nOnUnitActiveSec=1800\n") {
+		t.Errorf("Expected monotonic timer for interval schedule:\n%s", units.Timer)
+	}
+}
+
+func TestExportCrontab(t *testing.T) {
+	tc := &TaskConfig{ID: "50%", Name: "Half", Schedule: "@weekly", Timezone: "Asia/Tokyo", Jitter: "5m", Command: "true"}
+	entry, err := ExportCrontab(tc, "/usr/bin/cleanup")
+	if err != nil {
+		t.Fatalf("ExportCrontab failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(entry), "\n")
+	if got := lines[len(lines)-2]; got != "CRON_TZ=Asia/Tokyo" {
+		t.Errorf("Expected CRON_TZ line, got %q", got)
+	}
+	if got, want := lines[len(lines)-1], `0 0 * * 0 /usr/bin/cleanup schedule run --jitter '50\%'`; got != want {
+		t.Errorf("Entry = %q, want %q", got, want)
+	}
+
+	tc = &TaskConfig{ID: "odd", Name: "Odd", Schedule: "7m", Command: "true"}
+	if _, err := ExportCrontab(tc, "/usr/bin/cleanup"); err == nil {
+		t.Error("Expected error for interval cron can't express")
+	}
+}
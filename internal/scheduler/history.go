@@ -0,0 +1,242 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRunsPerTask is how many runs are kept per task in the history
+	DefaultRunsPerTask = 50
+	// DefaultOutputTail is how many bytes of task output are kept per run
+	DefaultOutputTail = 4096
+)
+
+// RunStatus represents the outcome of a task run
+type RunStatus string
+
+const (
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+	RunCancelled RunStatus = "cancelled"
+	RunSkipped   RunStatus = "skipped"
+)
+
+// RunRecord describes a single run of a scheduled task
+type RunRecord struct {
+	TaskID    string        `json:"task_id"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Status    RunStatus     `json:"status"`
+	ExitCode  int           `json:"exit_code"`
+	Error     string        `json:"error,omitempty"`
+	Output    string        `json:"output,omitempty"`  // Tail of the combined output
+	Result    *JobResult    `json:"result,omitempty"`  // Structured result of built-in jobs
+	Then      []*JobResult  `json:"then,omitempty"`    // Results of the follow-up jobs
+	Trigger   string        `json:"trigger,omitempty"` // Why a triggered run started
+}
+
+// finish fills in the outcome of a run
+func (r *RunRecord) finish(err error, cancelled bool) {
+	r.Duration = time.Since(r.StartedAt)
+
+	switch {
+	case err == nil:
+		r.Status = RunSucceeded
+		r.ExitCode = 0
+	case errors.Is(err, ErrSkipRun):
+		r.Status = RunSkipped
+		r.ExitCode = 0
+		r.Error = err.Error()
+	case cancelled:
+		r.Status = RunCancelled
+		r.ExitCode = -1
+		r.Error = err.Error()
+	default:
+		r.Status = RunFailed
+		r.ExitCode = 1
+		r.Error = err.Error()
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			r.ExitCode = exitErr.ExitCode()
+		}
+	}
+}
+
+type runRecordKey struct{}
+
+// withRunRecord attaches the record of the current run to ctx
+func withRunRecord(ctx context.Context, record *RunRecord) context.Context {
+	return context.WithValue(ctx, runRecordKey{}, record)
+}
+
+// runRecordFrom returns the record of the current run, if any
+func runRecordFrom(ctx context.Context) *RunRecord {
+	record, _ := ctx.Value(runRecordKey{}).(*RunRecord)
+	return record
+}
+
+// History stores the run records of scheduled tasks
+type History struct {
+	path        string
+	runsPerTask int
+	mu          sync.Mutex
+}
+
+// NewHistory creates a run history stored at path
+func NewHistory(path string) *History {
+	return &History{
+		path:        path,
+		runsPerTask: DefaultRunsPerTask,
+	}
+}
+
+// Record appends a run record, dropping the oldest runs of the task beyond
+// the per-task limit
+func (h *History) Record(record *RunRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records, err := h.load()
+	if err != nil {
+		return err
+	}
+	records = append(records, record)
+
+	// Keep only the newest runs of each task
+	counts := make(map[string]int)
+	kept := make([]*RunRecord, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		counts[records[i].TaskID]++
+		if counts[records[i].TaskID] <= h.runsPerTask {
+			kept = append(kept, records[i])
+		}
+	}
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+
+	return h.save(kept)
+}
+
+// Runs returns the most recent runs of a task, oldest first
+func (h *History) Runs(taskID string, limit int) ([]*RunRecord, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*RunRecord, 0)
+	for _, record := range records {
+		if record.TaskID == taskID {
+			runs = append(runs, record)
+		}
+	}
+
+	if limit > 0 && len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+	return runs, nil
+}
+
+// LastRun returns the most recent run of a task, or nil if it never ran
+func (h *History) LastRun(taskID string) (*RunRecord, error) {
+	runs, err := h.Runs(taskID, 1)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return runs[0], nil
+}
+
+// load reads all run records from disk
+func (h *History) load() ([]*RunRecord, error) {
+	data, err := os.ReadFile(h.path)
+	if os.IsNotExist(err) {
+		return []*RunRecord{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+
+	var records []*RunRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal run history: %w", err)
+	}
+	return records, nil
+}
+
+// save writes all run records to disk
+func (h *History) save(records []*RunRecord) error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run history: %w", err)
+	}
+
+	if err := os.WriteFile(h.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	return nil
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	max  int
+	data []byte
+	mu   sync.Mutex
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data = append(b.data, p...)
+	if len(b.data) > b.max {
+		b.data = b.data[len(b.data)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
+
+// CommandFunc returns a TaskFunc that runs command through the shell. Output
+// is mirrored to out (if not nil) and its tail is kept in the run history.
+func CommandFunc(command string, out io.Writer) TaskFunc {
+	return func(ctx context.Context) error {
+		tail := &tailBuffer{max: DefaultOutputTail}
+
+		var w io.Writer = tail
+		if out != nil {
+			w = io.MultiWriter(out, tail)
+		}
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Stdout = w
+		cmd.Stderr = w
+		err := cmd.Run()
+
+		if record := runRecordFrom(ctx); record != nil {
+			record.Output = tail.String()
+		}
+		return err
+	}
+}
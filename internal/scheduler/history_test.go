@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory_RecordAndRuns(t *testing.T) {
+	history := NewHistory(filepath.Join(t.TempDir(), "runs.json"))
+	history.runsPerTask = 3
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := history.Record(&RunRecord{TaskID: "a", StartedAt: start.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	if err := history.Record(&RunRecord{TaskID: "b", StartedAt: start}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	runs, err := history.Runs("a", 0)
+	if err != nil {
+		t.Fatalf("Runs failed: %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("Expected 3 runs kept, got %d", len(runs))
+	}
+	if !runs[0].StartedAt.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("Expected oldest kept run to be the third, got %v", runs[0].StartedAt)
+	}
+
+	last, err := history.LastRun("a")
+	if err != nil || last == nil {
+		t.Fatalf("LastRun failed: %v", err)
+	}
+	if !last.StartedAt.Equal(start.Add(4 * time.Minute)) {
+		t.Errorf("Unexpected last run: %v", last.StartedAt)
+	}
+
+	if last, _ := history.LastRun("never"); last != nil {
+		t.Error("Expected no run for unknown task")
+	}
+}
+
+func TestCommandFunc_RecordsOutputAndExitCode(t *testing.T) {
+	var out strings.Builder
+	record := &RunRecord{TaskID: "cmd", StartedAt: time.Now()}
+
+	err := CommandFunc("echo hello; exit 3", &out)(withRunRecord(context.Background(), record))
+	record.finish(err, false)
+
+	if record.Status != RunFailed {
+		t.Errorf("Expected failed status, got %s", record.Status)
+	}
+	if record.ExitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d", record.ExitCode)
+	}
+	if strings.TrimSpace(record.Output) != "hello" {
+		t.Errorf("Unexpected output tail: %q", record.Output)
+	}
+	if strings.TrimSpace(out.String()) != "hello" {
+		t.Errorf("Output was not mirrored: %q", out.String())
+	}
+}
+
+func TestTailBuffer_KeepsLastBytes(t *testing.T) {
+	tail := &tailBuffer{max: 5}
+	fmt.Fprint(tail, "abc")
+	fmt.Fprint(tail, "defgh")
+
+	if tail.String() != "defgh" {
+		t.Errorf("Expected tail 'defgh', got %q", tail.String())
+	}
+}
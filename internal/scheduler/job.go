@@ -0,0 +1,171 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JobKind identifies a built-in job that runs in-process instead of a shell command
+type JobKind string
+
+const (
+	JobOrganize   JobKind = "organize"
+	JobJunkClean  JobKind = "junk-clean"
+	JobDedup      JobKind = "dedup"
+	JobTrashEmpty JobKind = "trash-empty"
+)
+
+// JobKinds lists the supported built-in jobs
+var JobKinds = []JobKind{JobOrganize, JobJunkClean, JobDedup, JobTrashEmpty}
+
+// JobPolicy controls whether a job changes anything
+type JobPolicy string
+
+const (
+	PolicyApply  JobPolicy = "apply"  // Perform the job's changes
+	PolicyReport JobPolicy = "report" // Dry run: only report what would change
+)
+
+// JobConfig describes a built-in job and its parameters
+type JobConfig struct {
+	Kind       JobKind   `yaml:"kind"`
+	Policy     JobPolicy `yaml:"policy,omitempty"`      // apply (default) or report
+	Path       string    `yaml:"path,omitempty"`        // organize, dedup: directory to process
+	Recursive  bool      `yaml:"recursive,omitempty"`   // organize: include subdirectories
+	UseAI      bool      `yaml:"use_ai,omitempty"`      // organize: ask the AI model for suggestions
+	PruneEmpty bool      `yaml:"prune_empty,omitempty"` // organize, junk-clean: remove emptied directories
+	Categories []string  `yaml:"categories,omitempty"`  // junk-clean: categories to clean (empty = all)
+	Keep       string    `yaml:"keep,omitempty"`        // dedup: newest, oldest or first
+	MinSize    int64     `yaml:"min_size,omitempty"`    // dedup: smallest file to consider in bytes
+	OlderThan  string    `yaml:"older_than,omitempty"`  // trash-empty: only files trashed longer ago, e.g. "7d"
+}
+
+// ReportOnly reports whether the job only reports what it would change
+func (j *JobConfig) ReportOnly() bool {
+	return j.Policy == PolicyReport
+}
+
+// OlderThanDuration returns the trash-empty age threshold (zero for all files)
+func (j *JobConfig) OlderThanDuration() (time.Duration, error) {
+	if j.OlderThan == "" {
+		return 0, nil
+	}
+
+	age, err := ParseAge(j.OlderThan)
+	if err != nil {
+		return 0, fmt.Errorf("invalid older_than: %w", err)
+	}
+	return age, nil
+}
+
+// Validate checks the job kind, policy and the parameters it requires
+func (j *JobConfig) Validate() error {
+	switch j.Policy {
+	case "", PolicyApply, PolicyReport:
+	default:
+		return fmt.Errorf("unknown job policy %q (use apply or report)", j.Policy)
+	}
+
+	switch j.Kind {
+	case JobOrganize:
+		if j.Path == "" {
+			return fmt.Errorf("%s job requires a path", j.Kind)
+		}
+	case JobDedup:
+		if j.Path == "" {
+			return fmt.Errorf("%s job requires a path", j.Kind)
+		}
+		switch j.Keep {
+		case "", "newest", "oldest", "first":
+		default:
+			return fmt.Errorf("unknown keep strategy %q (use newest, oldest or first)", j.Keep)
+		}
+		if j.MinSize < 0 {
+			return fmt.Errorf("min_size must not be negative")
+		}
+	case JobJunkClean:
+	case JobTrashEmpty:
+		if _, err := j.OlderThanDuration(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown job kind %q", j.Kind)
+	}
+
+	return nil
+}
+
+// ParseAge parses a duration that may also be given in days, such as "7d"
+func ParseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age: %s", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age: %s", s)
+	}
+	return age, nil
+}
+
+// JobResult summarizes what a built-in job changed (or would change in report mode)
+type JobResult struct {
+	Kind           JobKind  `json:"kind"`
+	DryRun         bool     `json:"dry_run,omitempty"`
+	FilesTouched   int      `json:"files_touched"`
+	FilesSkipped   int      `json:"files_skipped,omitempty"` // Files left alone for review, e.g. uncertain junk
+	BytesFreed     int64    `json:"bytes_freed"`
+	TransactionIDs []string `json:"transaction_ids,omitempty"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// JobRunner executes built-in jobs
+type JobRunner interface {
+	// Run executes job, writing progress to out
+	Run(ctx context.Context, job *JobConfig, out io.Writer) (*JobResult, error)
+}
+
+// JobFunc returns a TaskFunc that executes job through runner, followed by
+// the jobs in then as long as the previous one succeeds. Output is mirrored
+// to out (if not nil); its tail and the jobs' results are kept in the run
+// history.
+func JobFunc(runner JobRunner, job *JobConfig, out io.Writer, then ...*JobConfig) TaskFunc {
+	return func(ctx context.Context) error {
+		tail := &tailBuffer{max: DefaultOutputTail}
+
+		var w io.Writer = tail
+		if out != nil {
+			w = io.MultiWriter(out, tail)
+		}
+
+		record := runRecordFrom(ctx)
+		result, err := runner.Run(ctx, job, w)
+		if record != nil {
+			record.Result = result
+		}
+
+		for _, next := range then {
+			if err != nil {
+				break
+			}
+			fmt.Fprintf(w, "\n▶ %s\n", next.Kind)
+			result, err = runner.Run(ctx, next, w)
+			if record != nil && result != nil {
+				record.Then = append(record.Then, result)
+			}
+		}
+
+		if record != nil {
+			record.Output = tail.String()
+		}
+		return err
+	}
+}
@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeJobRunner records the jobs it runs and returns a fixed result
+type fakeJobRunner struct {
+	ran chan *JobConfig
+	err error
+}
+
+func (r *fakeJobRunner) Run(ctx context.Context, job *JobConfig, out io.Writer) (*JobResult, error) {
+	fmt.Fprintf(out, "running %s\n", job.Kind)
+	r.ran <- job
+	return &JobResult{
+		Kind:           job.Kind,
+		DryRun:         job.ReportOnly(),
+		FilesTouched:   3,
+		BytesFreed:     2048,
+		TransactionIDs: []string{"tx-1"},
+	}, r.err
+}
+
+func TestJobConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     JobConfig
+		wantErr bool
+	}{
+		{"organize", JobConfig{Kind: JobOrganize, Path: "/tmp"}, false},
+		{"organize without path", JobConfig{Kind: JobOrganize}, true},
+		{"junk-clean", JobConfig{Kind: JobJunkClean, Categories: []string{"cache"}}, false},
+		{"dedup report", JobConfig{Kind: JobDedup, Path: "/tmp", Policy: PolicyReport, Keep: "oldest"}, false},
+		{"dedup bad keep", JobConfig{Kind: JobDedup, Path: "/tmp", Keep: "largest"}, true},
+		{"trash-empty days", JobConfig{Kind: JobTrashEmpty, OlderThan: "30d"}, false},
+		{"trash-empty bad age", JobConfig{Kind: JobTrashEmpty, OlderThan: "a month"}, true},
+		{"unknown kind", JobConfig{Kind: "defrag"}, true},
+		{"unknown policy", JobConfig{Kind: JobJunkClean, Policy: "maybe"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.job.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTaskConfig_ValidateCommandOrJob(t *testing.T) {
+	job := &JobConfig{Kind: JobJunkClean}
+
+	if err := (&TaskConfig{ID: "a", Schedule: "@daily", Job: job}).Validate(); err != nil {
+		t.Errorf("Validate failed for job task: %v", err)
+	}
+	if err := (&TaskConfig{ID: "b", Schedule: "@daily"}).Validate(); err == nil {
+		t.Error("Expected error for task without command or job")
+	}
+	if err := (&TaskConfig{ID: "c", Schedule: "@daily", Command: "true", Job: job}).Validate(); err == nil {
+		t.Error("Expected error for task with both command and job")
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"7d", 7 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"12h", 12 * time.Hour, false},
+		{"-1d", 0, true},
+		{"week", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAge(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAge(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAge(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestJobFunc_RecordsResult(t *testing.T) {
+	runner := &fakeJobRunner{ran: make(chan *JobConfig, 1)}
+	fn := JobFunc(runner, &JobConfig{Kind: JobDedup, Path: "/tmp", Policy: PolicyReport}, nil)
+
+	record := &RunRecord{TaskID: "dedup"}
+	if err := fn(withRunRecord(context.Background(), record)); err != nil {
+		t.Fatalf("JobFunc failed: %v", err)
+	}
+
+	if record.Result == nil {
+		t.Fatal("Expected job result in run record")
+	}
+	if !record.Result.DryRun || record.Result.FilesTouched != 3 || record.Result.BytesFreed != 2048 {
+		t.Errorf("Unexpected result: %+v", record.Result)
+	}
+	if !strings.Contains(record.Output, "running dedup") {
+		t.Errorf("Expected job output in record, got %q", record.Output)
+	}
+}
+
+func TestJobFunc_RunsFollowUpJobs(t *testing.T) {
+	runner := &fakeJobRunner{ran: make(chan *JobConfig, 3)}
+	then := []*JobConfig{{Kind: JobTrashEmpty, OlderThan: "7d"}}
+	fn := JobFunc(runner, &JobConfig{Kind: JobJunkClean, Categories: []string{"cache", "temp"}}, nil, then...)
+
+	record := &RunRecord{TaskID: "low-disk"}
+	if err := fn(withRunRecord(context.Background(), record)); err != nil {
+		t.Fatalf("JobFunc failed: %v", err)
+	}
+	if record.Result == nil || record.Result.Kind != JobJunkClean {
+		t.Errorf("Expected junk-clean result, got %+v", record.Result)
+	}
+	if len(record.Then) != 1 || record.Then[0].Kind != JobTrashEmpty {
+		t.Errorf("Expected trash-empty follow-up result, got %+v", record.Then)
+	}
+
+	// A failed job stops the chain
+	runner = &fakeJobRunner{ran: make(chan *JobConfig, 3), err: fmt.Errorf("disk error")}
+	fn = JobFunc(runner, &JobConfig{Kind: JobJunkClean}, nil, then...)
+	record = &RunRecord{TaskID: "low-disk"}
+	if err := fn(withRunRecord(context.Background(), record)); err == nil {
+		t.Fatal("Expected error from failed job")
+	}
+	if len(runner.ran) != 1 || len(record.Then) != 0 {
+		t.Errorf("Expected follow-up jobs to be skipped after a failure, ran %d", len(runner.ran))
+	}
+}
+
+func TestDaemon_RunsJobs(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "schedule.yaml"))
+	history := NewHistory(filepath.Join(dir, "runs.json"))
+
+	// Last ran a minute ago, so the job is due right away
+	if err := history.Record(&RunRecord{TaskID: "purge", StartedAt: time.Now().Add(-time.Minute + 10*time.Millisecond)}); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	job := &JobConfig{Kind: JobTrashEmpty, OlderThan: "30d"}
+	if err := store.Add(&TaskConfig{ID: "purge", Schedule: "1m", Job: job, Enabled: true}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	runner := &fakeJobRunner{ran: make(chan *JobConfig, 1)}
+	daemon := NewDaemon(store, history, io.Discard)
+	daemon.Jobs = runner
+	if err := daemon.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	select {
+	case ran := <-runner.ran:
+		if ran.Kind != JobTrashEmpty || ran.OlderThan != "30d" {
+			t.Errorf("Unexpected job: %+v", ran)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for job to run")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := daemon.Serve(ctx); err != nil {
+		t.Fatalf("Serve returned error: %v", err)
+	}
+
+	last, err := history.LastRun("purge")
+	if err != nil {
+		t.Fatalf("LastRun failed: %v", err)
+	}
+	if last.Result == nil || last.Result.TransactionIDs[0] != "tx-1" {
+		t.Errorf("Expected job result in history, got %+v", last)
+	}
+}
+
+func TestDaemon_JobWithoutRunner(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "schedule.yaml"))
+	if err := store.Add(&TaskConfig{ID: "clean", Schedule: "1h", Job: &JobConfig{Kind: JobJunkClean}}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	daemon := NewDaemon(store, NewHistory(filepath.Join(dir, "runs.json")), io.Discard)
+	if err := daemon.Start(); err == nil {
+		t.Error("Expected error scheduling a job without a runner")
+	}
+}
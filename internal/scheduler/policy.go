@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OverlapPolicy decides what happens when a run is due while the previous
+// run of the same task is still in progress
+type OverlapPolicy string
+
+const (
+	OverlapSkip    OverlapPolicy = "skip"    // Drop the new run (default)
+	OverlapQueue   OverlapPolicy = "queue"   // Start the new run once the current one finishes
+	OverlapReplace OverlapPolicy = "replace" // Cancel the current run and start the new one
+)
+
+// CatchUpPolicy decides what happens to runs missed while the daemon was not
+// running or the machine was asleep
+type CatchUpPolicy string
+
+const (
+	CatchUpSkip    CatchUpPolicy = "skip"     // Wait for the next scheduled time (default)
+	CatchUpRunOnce CatchUpPolicy = "run-once" // Run once right away, however many runs were missed
+)
+
+// ErrSkipRun marks a run that was skipped rather than failed, e.g. because
+// the resource it works on is busy
+var ErrSkipRun = errors.New("run skipped")
+
+var (
+	// MissedRunTolerance is how late a run may start before it counts as missed
+	MissedRunTolerance = 5 * time.Minute
+
+	// wallClockCheckInterval bounds how long the scheduler sleeps before
+	// re-checking the wall clock. Timers stop while the machine is suspended,
+	// so a single long timer would fire late after a resume.
+	wallClockCheckInterval = time.Minute
+)
+
+// ParseOverlapPolicy validates an overlap policy (empty means skip)
+func ParseOverlapPolicy(s string) (OverlapPolicy, error) {
+	switch policy := OverlapPolicy(s); policy {
+	case "":
+		return OverlapSkip, nil
+	case OverlapSkip, OverlapQueue, OverlapReplace:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown overlap policy %q (use skip, queue or replace)", s)
+	}
+}
+
+// ParseCatchUpPolicy validates a catch-up policy (empty means skip)
+func ParseCatchUpPolicy(s string) (CatchUpPolicy, error) {
+	switch policy := CatchUpPolicy(s); policy {
+	case "":
+		return CatchUpSkip, nil
+	case CatchUpSkip, CatchUpRunOnce:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown catch-up policy %q (use skip or run-once)", s)
+	}
+}
@@ -0,0 +1,197 @@
+package scheduler
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newPolicyScheduler creates a scheduler with a task that is not due for an
+// hour, so tests can dispatch runs by hand
+func newPolicyScheduler(t *testing.T, task *Task, fn TaskFunc) (*Scheduler, *scheduledTask, *History) {
+	t.Helper()
+
+	s := NewScheduler()
+	history := NewHistory(filepath.Join(t.TempDir(), "runs.json"))
+	s.SetHistory(history)
+	t.Cleanup(s.Stop)
+
+	if err := s.AddTask(task, fn); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	return s, s.tasks[task.ID], history
+}
+
+// statuses returns the recorded statuses of a task's runs, oldest first
+func statuses(t *testing.T, history *History, taskID string) []RunStatus {
+	t.Helper()
+
+	runs, err := history.Runs(taskID, 0)
+	if err != nil {
+		t.Fatalf("Runs failed: %v", err)
+	}
+	result := make([]RunStatus, len(runs))
+	for i, run := range runs {
+		result[i] = run.Status
+	}
+	return result
+}
+
+func TestOverlapSkip(t *testing.T) {
+	release := make(chan struct{})
+	var runs int32
+	fn := func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return nil
+	}
+
+	s, st, history := newPolicyScheduler(t, &Task{ID: "skip", Schedule: "1h", Enabled: true}, fn)
+
+	s.dispatch(st)
+	waitFor(t, func() bool { return atomic.LoadInt32(&runs) == 1 })
+	s.dispatch(st)
+	close(release)
+
+	waitFor(t, func() bool { return len(statuses(t, history, "skip")) == 2 })
+	got := statuses(t, history, "skip")
+	if got[0] != RunSkipped || got[1] != RunSucceeded {
+		t.Errorf("Expected a skipped run while the first was running, got %v", got)
+	}
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("Expected 1 run, got %d", n)
+	}
+}
+
+func TestOverlapQueue(t *testing.T) {
+	release := make(chan struct{}, 2)
+	var runs int32
+	fn := func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return nil
+	}
+
+	s, st, history := newPolicyScheduler(t, &Task{ID: "queue", Schedule: "1h", Overlap: OverlapQueue, Enabled: true}, fn)
+
+	s.dispatch(st)
+	waitFor(t, func() bool { return atomic.LoadInt32(&runs) == 1 })
+
+	// Runs due while one is in progress collapse into a single queued run
+	s.dispatch(st)
+	s.dispatch(st)
+	release <- struct{}{}
+	release <- struct{}{}
+
+	waitFor(t, func() bool { return len(statuses(t, history, "queue")) == 2 })
+	got := statuses(t, history, "queue")
+	if got[0] != RunSucceeded || got[1] != RunSucceeded {
+		t.Errorf("Expected two successful runs, got %v", got)
+	}
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Errorf("Expected 2 runs, got %d", n)
+	}
+}
+
+func TestOverlapReplace(t *testing.T) {
+	var runs int32
+	fn := func(ctx context.Context) error {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}
+
+	s, st, history := newPolicyScheduler(t, &Task{ID: "replace", Schedule: "1h", Overlap: OverlapReplace, Enabled: true}, fn)
+
+	s.dispatch(st)
+	waitFor(t, func() bool { return atomic.LoadInt32(&runs) == 1 })
+	s.dispatch(st)
+
+	waitFor(t, func() bool { return len(statuses(t, history, "replace")) == 2 })
+	got := statuses(t, history, "replace")
+	if got[0] != RunCancelled || got[1] != RunSucceeded {
+		t.Errorf("Expected the running run to be replaced, got %v", got)
+	}
+}
+
+func TestCatchUpRunOnce(t *testing.T) {
+	ran := make(chan struct{}, 5)
+	fn := func(ctx context.Context) error {
+		ran <- struct{}{}
+		return nil
+	}
+
+	// Three runs were missed; only one is made up
+	task := &Task{ID: "catchup", Schedule: "1h", CatchUp: CatchUpRunOnce, Enabled: true, LastRun: time.Now().Add(-3*time.Hour - time.Minute)}
+	_, _, history := newPolicyScheduler(t, task, fn)
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("missed run was not caught up")
+	}
+
+	waitFor(t, func() bool { return len(statuses(t, history, "catchup")) == 1 })
+	select {
+	case <-ran:
+		t.Error("Expected a single catch-up run")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestCatchUpSkip(t *testing.T) {
+	fn := func(ctx context.Context) error {
+		t.Error("missed run should not be made up")
+		return nil
+	}
+
+	// The scheduler was asleep past the run's time
+	task := &Task{ID: "missed", Schedule: "1h", Enabled: false}
+	s, st, history := newPolicyScheduler(t, task, fn)
+	s.mu.Lock()
+	st.task.NextRun = time.Now().Add(-MissedRunTolerance - time.Minute)
+	s.mu.Unlock()
+	if err := s.EnableTask("missed"); err != nil {
+		t.Fatalf("EnableTask failed: %v", err)
+	}
+
+	waitFor(t, func() bool { return len(statuses(t, history, "missed")) == 1 })
+	if got := statuses(t, history, "missed"); got[0] != RunSkipped {
+		t.Errorf("Expected the missed run to be recorded as skipped, got %v", got)
+	}
+
+	task, _ = s.GetTask("missed")
+	s.mu.RLock()
+	nextRun := task.NextRun
+	s.mu.RUnlock()
+	if !nextRun.After(time.Now()) {
+		t.Errorf("Expected next run in the future, got %v", nextRun)
+	}
+}
+
+func TestParsePolicies(t *testing.T) {
+	if p, err := ParseOverlapPolicy(""); err != nil || p != OverlapSkip {
+		t.Errorf("ParseOverlapPolicy(\"\") = %v, %v", p, err)
+	}
+	if _, err := ParseOverlapPolicy("parallel"); err == nil {
+		t.Error("Expected error for unknown overlap policy")
+	}
+	if p, err := ParseCatchUpPolicy("run-once"); err != nil || p != CatchUpRunOnce {
+		t.Errorf("ParseCatchUpPolicy(\"run-once\") = %v, %v", p, err)
+	}
+	if _, err := ParseCatchUpPolicy("all"); err == nil {
+		t.Error("Expected error for unknown catch-up policy")
+	}
+}
+
+func TestRunRecordSkippedError(t *testing.T) {
+	record := &RunRecord{StartedAt: time.Now()}
+	record.finish(ErrSkipRun, false)
+	if record.Status != RunSkipped || record.ExitCode != 0 {
+		t.Errorf("Expected skipped run, got %+v", record)
+	}
+}
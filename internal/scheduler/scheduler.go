@@ -3,23 +3,31 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 )
 
 // Task represents a scheduled task
 type Task struct {
-	ID          string
-	Name        string
-	Schedule    string // Cron expression
-	Command     string
-	Args        []string
-	Enabled     bool
-	LastRun     time.Time
-	NextRun     time.Time
-	RunCount    int
-	FailCount   int
-	LastError   string
+	ID        string
+	Name      string
+	Schedule  string        // Interval, @descriptor or cron expression
+	Trigger   *Trigger      // File system condition run instead of Schedule
+	Timezone  string        // Time zone for calendar schedules (default: local)
+	Jitter    time.Duration // Random delay of up to this much added to each run
+	Command   string
+	Args      []string
+	Job       *JobConfig    // Built-in job run instead of Command
+	Then      []*JobConfig  // Built-in jobs run after Job, in order
+	Overlap   OverlapPolicy // What to do when a run is due while one is in progress
+	CatchUp   CatchUpPolicy // What to do about runs missed while asleep or stopped
+	Enabled   bool
+	LastRun   time.Time
+	NextRun   time.Time
+	RunCount  int
+	FailCount int
+	LastError string
 }
 
 // TaskFunc is a function that can be scheduled
@@ -32,25 +40,41 @@ type Scheduler struct {
 	ctx      context.Context
 	cancel   context.CancelFunc
 	wg       sync.WaitGroup
+	draining chan struct{}
+	drainOne sync.Once
+	history  *History
 }
 
 type scheduledTask struct {
 	task     *Task
 	fn       TaskFunc
-	ticker   *time.Ticker
+	schedule Schedule
 	stopChan chan struct{}
+
+	running   bool               // A run is in progress
+	queued    bool               // Another run starts when the current one finishes
+	cancelRun context.CancelFunc // Cancels the run in progress
+	reason    string             // Why a triggered run starts
 }
 
 // NewScheduler creates a new scheduler
 func NewScheduler() *Scheduler {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
-		tasks:  make(map[string]*scheduledTask),
-		ctx:    ctx,
-		cancel: cancel,
+		tasks:    make(map[string]*scheduledTask),
+		ctx:      ctx,
+		cancel:   cancel,
+		draining: make(chan struct{}),
 	}
 }
 
+// SetHistory records every task run in history
+func (s *Scheduler) SetHistory(history *History) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = history
+}
+
 // AddTask adds a task to the scheduler
 func (s *Scheduler) AddTask(task *Task, fn TaskFunc) error {
 	s.mu.Lock()
@@ -60,28 +84,42 @@ func (s *Scheduler) AddTask(task *Task, fn TaskFunc) error {
 		return fmt.Errorf("task %s already exists", task.ID)
 	}
 
-	// Parse schedule
-	interval, err := parseSchedule(task.Schedule)
-	if err != nil {
-		return fmt.Errorf("invalid schedule: %w", err)
-	}
-
 	st := &scheduledTask{
 		task:     task,
 		fn:       fn,
-		ticker:   time.NewTicker(interval),
 		stopChan: make(chan struct{}),
 	}
 
+	// Triggered tasks run when their condition is met, not on a schedule
+	if task.Trigger != nil {
+		s.tasks[task.ID] = st
+		if task.Enabled {
+			s.start(st)
+		}
+		return nil
+	}
+
+	schedule, err := ParseSchedule(task.Schedule, task.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	st.schedule = schedule
+
 	s.tasks[task.ID] = st
 
-	// Calculate next run
-	task.NextRun = time.Now().Add(interval)
+	// Continue the schedule from the last recorded run, if there is one. A
+	// run missed in the meantime is due right away if it should be caught up.
+	now := time.Now()
+	task.NextRun = withJitter(NextRunAfter(schedule, task.LastRun, now), task.Jitter)
+	if task.CatchUp == CatchUpRunOnce && !task.LastRun.IsZero() {
+		if missed := schedule.Next(task.LastRun); !missed.IsZero() && !missed.After(now) {
+			task.NextRun = missed
+		}
+	}
 
 	// Start task if enabled
 	if task.Enabled {
-		s.wg.Add(1)
-		go s.runTask(st)
+		s.start(st)
 	}
 
 	return nil
@@ -98,7 +136,6 @@ func (s *Scheduler) RemoveTask(taskID string) error {
 	}
 
 	// Stop the task
-	st.ticker.Stop()
 	close(st.stopChan)
 	delete(s.tasks, taskID)
 
@@ -117,8 +154,7 @@ func (s *Scheduler) EnableTask(taskID string) error {
 
 	if !st.task.Enabled {
 		st.task.Enabled = true
-		s.wg.Add(1)
-		go s.runTask(st)
+		s.start(st)
 	}
 
 	return nil
@@ -169,37 +205,261 @@ func (s *Scheduler) ListTasks() []*Task {
 	return tasks
 }
 
-// runTask runs a scheduled task
+// start runs the loop that dispatches the runs of a task. Callers hold s.mu.
+func (s *Scheduler) start(st *scheduledTask) {
+	s.wg.Add(1)
+	if st.task.Trigger != nil {
+		go s.watchTrigger(st)
+	} else {
+		go s.runTask(st)
+	}
+}
+
+// runTask waits for each scheduled run of a task and dispatches it
 func (s *Scheduler) runTask(st *scheduledTask) {
 	defer s.wg.Done()
 
+	s.mu.RLock()
+	stopChan := st.stopChan
+	s.mu.RUnlock()
+
+	for {
+		s.mu.RLock()
+		nextRun := st.task.NextRun
+		s.mu.RUnlock()
+
+		// A calendar schedule that never matches again just idles
+		if nextRun.IsZero() {
+			select {
+			case <-s.ctx.Done():
+			case <-s.draining:
+			case <-stopChan:
+			}
+			return
+		}
+
+		if !s.waitUntil(nextRun, stopChan) {
+			return
+		}
+
+		now := time.Now()
+		next := st.schedule.Next(now)
+		// A next run that is already due would make this loop spin
+		if !next.IsZero() && !next.After(now) {
+			next = now.Add(time.Minute)
+		}
+		s.mu.Lock()
+		st.task.NextRun = withJitter(next, st.task.Jitter)
+		catchUp := st.task.CatchUp
+		s.mu.Unlock()
+
+		if now.Sub(nextRun) > MissedRunTolerance && catchUp != CatchUpRunOnce {
+			s.recordSkipped(st, fmt.Sprintf("missed run at %s", nextRun.Format("2006-01-02 15:04")))
+			continue
+		}
+
+		s.dispatch(st)
+	}
+}
+
+// watchTrigger checks the condition of a triggered task at the trigger's
+// interval and dispatches a run whenever it fires. Measurement errors are
+// recorded as skipped runs when they first occur.
+func (s *Scheduler) watchTrigger(st *scheduledTask) {
+	defer s.wg.Done()
+
+	s.mu.RLock()
+	stopChan := st.stopChan
+	trigger := st.task.Trigger
+	s.mu.RUnlock()
+
 	for {
+		reason, fired, err := trigger.Check()
+		if trigger.changedError(err) {
+			s.mu.Lock()
+			st.task.LastError = err.Error()
+			s.mu.Unlock()
+			s.recordSkipped(st, fmt.Sprintf("trigger check failed: %v", err))
+		}
+		if fired {
+			s.mu.Lock()
+			st.reason = reason
+			s.mu.Unlock()
+			s.dispatch(st)
+		}
+
+		if !s.waitUntil(time.Now().Add(trigger.Interval), stopChan) {
+			return
+		}
+	}
+}
+
+// waitUntil blocks until the wall clock reaches t. It returns false if the
+// task was stopped or the scheduler is shutting down first.
+func (s *Scheduler) waitUntil(t time.Time, stopChan chan struct{}) bool {
+	t = t.Round(0) // Compare against the wall clock, not the monotonic one
+
+	for {
+		wait := time.Until(t)
+		if wait <= 0 {
+			return true
+		}
+		if wait > wallClockCheckInterval {
+			wait = wallClockCheckInterval
+		}
+
+		timer := time.NewTimer(wait)
 		select {
 		case <-s.ctx.Done():
+			timer.Stop()
+			return false
+		case <-s.draining:
+			timer.Stop()
+			return false
+		case <-stopChan:
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatch starts a due run, applying the task's overlap policy if the
+// previous run is still in progress
+func (s *Scheduler) dispatch(st *scheduledTask) {
+	s.mu.Lock()
+	if st.running {
+		switch st.task.Overlap {
+		case OverlapQueue:
+			st.queued = true
+		case OverlapReplace:
+			st.queued = true
+			st.cancelRun()
+		default:
+			s.mu.Unlock()
+			s.recordSkipped(st, "previous run still in progress")
 			return
-		case <-st.stopChan:
-			return
-		case <-st.ticker.C:
-			if !st.task.Enabled {
-				continue
-			}
+		}
+		s.mu.Unlock()
+		return
+	}
+	st.running = true
+	ctx, cancel := context.WithCancel(s.ctx)
+	st.cancelRun = cancel
+	s.mu.Unlock()
 
-			// Update last run time
-			st.task.LastRun = time.Now()
-			st.task.RunCount++
+	s.wg.Add(1)
+	go s.execute(ctx, st)
+}
 
-			// Execute task
-			if err := st.fn(s.ctx); err != nil {
-				st.task.FailCount++
-				st.task.LastError = err.Error()
-			} else {
-				st.task.LastError = ""
-			}
+// execute runs a task, then any run queued while it was in progress
+func (s *Scheduler) execute(ctx context.Context, st *scheduledTask) {
+	defer s.wg.Done()
 
-			// Calculate next run
-			interval, _ := parseSchedule(st.task.Schedule)
-			st.task.NextRun = time.Now().Add(interval)
+	for {
+		s.runOnce(ctx, st)
+
+		s.mu.Lock()
+		st.cancelRun()
+		if !st.queued || s.stopping(st) {
+			st.running = false
+			st.queued = false
+			s.mu.Unlock()
+			return
 		}
+		st.queued = false
+		ctx, st.cancelRun = context.WithCancel(s.ctx)
+		s.mu.Unlock()
+	}
+}
+
+// stopping reports whether queued runs of st must not start. Callers hold s.mu.
+func (s *Scheduler) stopping(st *scheduledTask) bool {
+	select {
+	case <-s.ctx.Done():
+		return true
+	case <-s.draining:
+		return true
+	case <-st.stopChan:
+		return true
+	default:
+		return s.tasks[st.task.ID] != st
+	}
+}
+
+// runOnce executes a task a single time and records the outcome
+func (s *Scheduler) runOnce(ctx context.Context, st *scheduledTask) {
+	s.mu.Lock()
+	if !st.task.Enabled {
+		s.mu.Unlock()
+		return
+	}
+	st.task.LastRun = time.Now()
+	st.task.RunCount++
+	history := s.history
+	reason := st.reason
+	st.reason = ""
+	s.mu.Unlock()
+
+	record := &RunRecord{
+		TaskID:    st.task.ID,
+		StartedAt: st.task.LastRun,
+		Trigger:   reason,
+	}
+
+	// Execute task
+	err := st.fn(withRunRecord(ctx, record))
+	record.finish(err, ctx.Err() != nil)
+
+	s.mu.Lock()
+	if err != nil && record.Status != RunSkipped {
+		st.task.FailCount++
+		st.task.LastError = err.Error()
+	} else {
+		st.task.LastError = ""
+	}
+	s.mu.Unlock()
+
+	if history != nil {
+		// A failure to record history must not stop the schedule
+		_ = history.Record(record)
+	}
+}
+
+// recordSkipped records a run that did not start
+func (s *Scheduler) recordSkipped(st *scheduledTask, reason string) {
+	s.mu.RLock()
+	history := s.history
+	s.mu.RUnlock()
+
+	if history != nil {
+		_ = history.Record(&RunRecord{
+			TaskID:    st.task.ID,
+			StartedAt: time.Now(),
+			Status:    RunSkipped,
+			Error:     reason,
+		})
+	}
+}
+
+// Shutdown stops starting new runs and waits for running tasks to finish.
+// If ctx expires first, running tasks are cancelled.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.drainOne.Do(func() { close(s.draining) })
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return fmt.Errorf("running tasks cancelled: %w", ctx.Err())
 	}
 }
 
@@ -212,26 +472,13 @@ func (s *Scheduler) Stop() {
 	defer s.mu.Unlock()
 
 	for _, st := range s.tasks {
-		st.ticker.Stop()
 		close(st.stopChan)
 	}
+	s.tasks = make(map[string]*scheduledTask)
 }
 
-// parseSchedule parses a schedule string into a duration
-// Supports simple formats: "1h", "30m", "1d", "@hourly", "@daily", "@weekly"
+// parseSchedule parses an interval schedule such as "30m" or "2h"
 func parseSchedule(schedule string) (time.Duration, error) {
-	switch schedule {
-	case "@hourly":
-		return time.Hour, nil
-	case "@daily":
-		return 24 * time.Hour, nil
-	case "@weekly":
-		return 7 * 24 * time.Hour, nil
-	case "@monthly":
-		return 30 * 24 * time.Hour, nil
-	}
-
-	// Try parsing as duration
 	duration, err := time.ParseDuration(schedule)
 	if err != nil {
 		return 0, fmt.Errorf("invalid schedule format: %s", schedule)
@@ -244,6 +491,14 @@ func parseSchedule(schedule string) (time.Duration, error) {
 	return duration, nil
 }
 
+// withJitter delays t by a random amount below jitter
+func withJitter(t time.Time, jitter time.Duration) time.Time {
+	if jitter <= 0 || t.IsZero() {
+		return t
+	}
+	return t.Add(time.Duration(rand.Int63n(int64(jitter))))
+}
+
 // Config represents scheduler configuration
 type Config struct {
 	Tasks []*TaskConfig `yaml:"tasks"`
@@ -251,12 +506,80 @@ type Config struct {
 
 // TaskConfig represents a task configuration
 type TaskConfig struct {
-	ID       string   `yaml:"id"`
-	Name     string   `yaml:"name"`
-	Schedule string   `yaml:"schedule"`
-	Command  string   `yaml:"command"`
-	Args     []string `yaml:"args"`
-	Enabled  bool     `yaml:"enabled"`
+	ID       string         `yaml:"id"`
+	Name     string         `yaml:"name"`
+	Schedule string         `yaml:"schedule,omitempty"`
+	Trigger  *TriggerConfig `yaml:"trigger,omitempty"` // File system condition run instead of Schedule
+	Timezone string         `yaml:"timezone,omitempty"`
+	Jitter   string         `yaml:"jitter,omitempty"`
+	Command  string         `yaml:"command,omitempty"`
+	Args     []string       `yaml:"args,omitempty"`
+	Job      *JobConfig     `yaml:"job,omitempty"`      // Built-in job run instead of Command
+	Then     []*JobConfig   `yaml:"then,omitempty"`     // Built-in jobs run after Job, in order
+	Overlap  string         `yaml:"overlap,omitempty"`  // skip (default), queue or replace
+	CatchUp  string         `yaml:"catch_up,omitempty"` // skip (default) or run-once
+	Enabled  bool           `yaml:"enabled"`
+}
+
+// ParsedSchedule returns the task's schedule in its time zone
+func (tc *TaskConfig) ParsedSchedule() (Schedule, error) {
+	return ParseSchedule(tc.Schedule, tc.Timezone)
+}
+
+// JitterDuration returns the task's jitter window (zero if none)
+func (tc *TaskConfig) JitterDuration() (time.Duration, error) {
+	if tc.Jitter == "" {
+		return 0, nil
+	}
+
+	jitter, err := time.ParseDuration(tc.Jitter)
+	if err != nil || jitter < 0 {
+		return 0, fmt.Errorf("invalid jitter: %s", tc.Jitter)
+	}
+	return jitter, nil
+}
+
+// Validate checks the task's schedule or trigger, time zone, jitter, policies
+// and what it runs
+func (tc *TaskConfig) Validate() error {
+	if tc.Trigger != nil {
+		switch {
+		case tc.Schedule != "":
+			return fmt.Errorf("task must have either a schedule or a trigger, not both")
+		case tc.Timezone != "" || tc.Jitter != "" || tc.CatchUp != "":
+			return fmt.Errorf("timezone, jitter and catch-up only apply to scheduled tasks")
+		}
+		if err := tc.Trigger.Validate(); err != nil {
+			return err
+		}
+	} else if _, err := tc.ParsedSchedule(); err != nil {
+		return err
+	}
+	if _, err := tc.JitterDuration(); err != nil {
+		return err
+	}
+	if _, err := ParseOverlapPolicy(tc.Overlap); err != nil {
+		return err
+	}
+	if _, err := ParseCatchUpPolicy(tc.CatchUp); err != nil {
+		return err
+	}
+
+	switch {
+	case tc.Job != nil && tc.Command != "":
+		return fmt.Errorf("task must have either a command or a job, not both")
+	case tc.Job != nil:
+		for _, job := range append([]*JobConfig{tc.Job}, tc.Then...) {
+			if err := job.Validate(); err != nil {
+				return err
+			}
+		}
+	case tc.Command == "":
+		return fmt.Errorf("task must have a command or a job")
+	case len(tc.Then) > 0:
+		return fmt.Errorf("follow-up jobs require a job")
+	}
+	return nil
 }
 
 // LoadConfig loads scheduler configuration
@@ -266,9 +589,8 @@ func LoadConfig(tasks []*TaskConfig) (*Config, error) {
 	}
 
 	for _, task := range tasks {
-		// Validate schedule
-		if _, err := parseSchedule(task.Schedule); err != nil {
-			return nil, fmt.Errorf("invalid schedule for task %s: %w", task.ID, err)
+		if err := task.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid task %s: %w", task.ID, err)
 		}
 
 		config.Tasks = append(config.Tasks, task)
@@ -182,29 +182,42 @@ func TestScheduler_TaskError(t *testing.T) {
 }
 
 func TestParseSchedule(t *testing.T) {
+	from := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC) // A Friday
 	tests := []struct {
 		schedule string
-		want     time.Duration
+		want     time.Time
 		wantErr  bool
 	}{
-		{"@hourly", time.Hour, false},
-		{"@daily", 24 * time.Hour, false},
-		{"@weekly", 7 * 24 * time.Hour, false},
-		{"1h", time.Hour, false},
-		{"30m", 30 * time.Minute, false},
-		{"1h30m", 90 * time.Minute, false},
-		{"invalid", 0, true},
-		{"30s", 0, true}, // Too short
+		// Intervals run a fixed duration after the previous run
+		{"1h", from.Add(time.Hour), false},
+		{"30m", from.Add(30 * time.Minute), false},
+		{"1h30m", from.Add(90 * time.Minute), false},
+		// Descriptors follow the calendar
+		{"@hourly", time.Date(2024, 3, 15, 11, 0, 0, 0, time.UTC), false},
+		{"@daily", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), false},
+		{"@weekly", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC), false},
+		{"@monthly", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), false},
+		// Cron expressions
+		{"0 3 * * SUN", time.Date(2024, 3, 17, 3, 0, 0, 0, time.UTC), false},
+		{"*/15 9-17 * * mon-fri", time.Date(2024, 3, 15, 10, 45, 0, 0, time.UTC), false},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC), false},
+		{"invalid", time.Time{}, true},
+		{"30s", time.Time{}, true}, // Too short
+		{"61 * * * *", time.Time{}, true},
+		{"0 0 * * 1-", time.Time{}, true},
 	}
 
 	for _, tt := range tests {
-		got, err := parseSchedule(tt.schedule)
+		schedule, err := ParseSchedule(tt.schedule, "UTC")
 		if (err != nil) != tt.wantErr {
-			t.Errorf("parseSchedule(%s) error = %v, wantErr %v", tt.schedule, err, tt.wantErr)
+			t.Errorf("ParseSchedule(%s) error = %v, wantErr %v", tt.schedule, err, tt.wantErr)
 			continue
 		}
-		if got != tt.want {
-			t.Errorf("parseSchedule(%s) = %v, want %v", tt.schedule, got, tt.want)
+		if err != nil {
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("ParseSchedule(%s).Next() = %v, want %v", tt.schedule, got, tt.want)
 		}
 	}
 }
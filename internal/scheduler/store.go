@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Store persists scheduled task configurations in a YAML file
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a task store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{
+		path: path,
+	}
+}
+
+// Path returns the location of the store file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load reads all stored tasks. A missing file is an empty store.
+func (s *Store) Load() (*Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Get returns the stored task with the given ID
+func (s *Store) Get(id string) (*TaskConfig, error) {
+	cfg, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range cfg.Tasks {
+		if task.ID == id {
+			return task, nil
+		}
+	}
+	return nil, fmt.Errorf("task %s not found", id)
+}
+
+// Add validates and stores a new task
+func (s *Store) Add(task *TaskConfig) error {
+	if task.ID == "" {
+		return fmt.Errorf("task ID is required")
+	}
+	if err := task.Validate(); err != nil {
+		return fmt.Errorf("invalid task: %w", err)
+	}
+
+	return s.update(func(cfg *Config) error {
+		for _, existing := range cfg.Tasks {
+			if existing.ID == task.ID {
+				return fmt.Errorf("task %s already exists", task.ID)
+			}
+		}
+		cfg.Tasks = append(cfg.Tasks, task)
+		return nil
+	})
+}
+
+// Remove deletes a stored task
+func (s *Store) Remove(id string) error {
+	return s.update(func(cfg *Config) error {
+		for i, task := range cfg.Tasks {
+			if task.ID == id {
+				cfg.Tasks = append(cfg.Tasks[:i], cfg.Tasks[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("task %s not found", id)
+	})
+}
+
+// SetEnabled enables or disables a stored task
+func (s *Store) SetEnabled(id string, enabled bool) error {
+	return s.update(func(cfg *Config) error {
+		for _, task := range cfg.Tasks {
+			if task.ID == id {
+				task.Enabled = enabled
+				return nil
+			}
+		}
+		return fmt.Errorf("task %s not found", id)
+	})
+}
+
+// ModTime returns when the store file last changed (zero if it doesn't exist)
+func (s *Store) ModTime() time.Time {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// update loads the store, applies fn and saves the result
+func (s *Store) update(fn func(cfg *Config) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(cfg); err != nil {
+		return err
+	}
+	return s.save(cfg)
+}
+
+// load reads and validates the store file
+func (s *Store) load() (*Config, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return &Config{Tasks: make([]*TaskConfig, 0)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedule file: %w", err)
+	}
+
+	var raw Config
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse schedule file: %w", err)
+	}
+
+	return LoadConfig(raw.Tasks)
+}
+
+// save writes the store file atomically
+func (s *Store) save(cfg *Config) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create schedule directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schedule file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write schedule file: %w", err)
+	}
+	return nil
+}
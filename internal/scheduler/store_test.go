@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_AddAndLoad(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "schedule.yaml"))
+
+	cfg, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load of missing file failed: %v", err)
+	}
+	if len(cfg.Tasks) != 0 {
+		t.Fatalf("Expected empty store, got %d tasks", len(cfg.Tasks))
+	}
+
+	task := &TaskConfig{ID: "daily", Name: "Daily", Schedule: "@daily", Command: "echo hi", Enabled: true}
+	if err := store.Add(task); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := store.Add(task); err == nil {
+		t.Error("Expected error when adding duplicate task")
+	}
+	if err := store.Add(&TaskConfig{ID: "bad", Schedule: "sometimes"}); err == nil {
+		t.Error("Expected error for invalid schedule")
+	}
+
+	// A second store on the same file sees the task
+	got, err := NewStore(store.Path()).Get("daily")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Command != "echo hi" || !got.Enabled {
+		t.Errorf("Unexpected task: %+v", got)
+	}
+}
+
+func TestStore_EnableAndRemove(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "schedule.yaml"))
+	if err := store.Add(&TaskConfig{ID: "task", Schedule: "1h", Command: "true", Enabled: true}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if err := store.SetEnabled("task", false); err != nil {
+		t.Fatalf("SetEnabled failed: %v", err)
+	}
+	got, _ := store.Get("task")
+	if got.Enabled {
+		t.Error("Task should be disabled")
+	}
+
+	if err := store.SetEnabled("missing", true); err == nil {
+		t.Error("Expected error for missing task")
+	}
+
+	if err := store.Remove("task"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := store.Get("task"); err == nil {
+		t.Error("Expected removed task to be gone")
+	}
+	if err := store.Remove("task"); err == nil {
+		t.Error("Expected error removing missing task")
+	}
+}
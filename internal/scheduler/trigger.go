@@ -0,0 +1,299 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xuanyiying/cleanup-cli/internal/walk"
+)
+
+// TriggerKind identifies the file system condition that starts a task
+type TriggerKind string
+
+const (
+	TriggerFreeSpace TriggerKind = "free-space" // Free space on a file system drops below a threshold
+	TriggerDirSize   TriggerKind = "dir-size"   // A directory grows beyond a size limit
+)
+
+// DefaultTriggerInterval is how often trigger conditions are checked
+const DefaultTriggerInterval = 5 * time.Minute
+
+// TriggerConfig describes when a triggered task runs
+type TriggerConfig struct {
+	Kind     TriggerKind `yaml:"kind"`
+	Path     string      `yaml:"path"`               // free-space: any path on the file system; dir-size: the directory
+	Below    string      `yaml:"below,omitempty"`    // free-space: threshold, e.g. "10%" or "5GB"
+	Above    string      `yaml:"above,omitempty"`    // dir-size: limit, e.g. "20GB"
+	Reset    string      `yaml:"reset,omitempty"`    // Level at which the trigger re-arms (default: 5 points or 10% past the threshold)
+	Interval string      `yaml:"interval,omitempty"` // How often the condition is checked (default 5m)
+}
+
+// Validate checks the trigger kind, path, thresholds and interval
+func (c *TriggerConfig) Validate() error {
+	_, err := NewTrigger(c)
+	return err
+}
+
+// String describes the condition, e.g. "free space on / < 10%"
+func (c *TriggerConfig) String() string {
+	switch c.Kind {
+	case TriggerFreeSpace:
+		return fmt.Sprintf("free space on %s < %s", c.Path, c.Below)
+	case TriggerDirSize:
+		return fmt.Sprintf("size of %s > %s", c.Path, c.Above)
+	}
+	return string(c.Kind)
+}
+
+// level is a trigger threshold: a number of bytes or a percentage
+type level struct {
+	value   float64
+	percent bool
+}
+
+func (l level) String() string {
+	if l.percent {
+		return strconv.FormatFloat(math.Round(l.value*10)/10, 'f', -1, 64) + "%"
+	}
+	return formatSize(int64(l.value))
+}
+
+// Trigger evaluates a file system condition with hysteresis: once it fires,
+// it fires again only after the level has recovered past the reset level
+type Trigger struct {
+	Kind     TriggerKind
+	Path     string
+	Interval time.Duration
+
+	threshold level
+	reset     level
+	measure   func() (level, error)
+
+	mu      sync.Mutex
+	armed   bool
+	lastErr string // Last measurement error
+}
+
+// NewTrigger creates an armed trigger from its configuration
+func NewTrigger(c *TriggerConfig) (*Trigger, error) {
+	if c.Path == "" {
+		return nil, fmt.Errorf("%s trigger requires a path", c.Kind)
+	}
+
+	t := &Trigger{
+		Kind:     c.Kind,
+		Path:     c.Path,
+		Interval: DefaultTriggerInterval,
+		armed:    true,
+	}
+	if c.Interval != "" {
+		interval, err := time.ParseDuration(c.Interval)
+		if err != nil || interval < time.Second {
+			return nil, fmt.Errorf("invalid trigger interval: %s", c.Interval)
+		}
+		t.Interval = interval
+	}
+
+	var err error
+	switch c.Kind {
+	case TriggerFreeSpace:
+		if c.Below == "" {
+			return nil, fmt.Errorf("free-space trigger requires a threshold (below)")
+		}
+		if t.threshold, err = parseLevel(c.Below, true); err != nil {
+			return nil, err
+		}
+		if t.threshold.percent {
+			t.reset = level{value: min(t.threshold.value+5, 100), percent: true}
+		} else {
+			t.reset = level{value: t.threshold.value * 1.1}
+		}
+		t.measure = t.freeSpace
+	case TriggerDirSize:
+		if c.Above == "" {
+			return nil, fmt.Errorf("dir-size trigger requires a limit (above)")
+		}
+		if t.threshold, err = parseLevel(c.Above, false); err != nil {
+			return nil, err
+		}
+		t.reset = level{value: t.threshold.value * 0.9}
+		t.measure = t.dirSize
+	default:
+		return nil, fmt.Errorf("unknown trigger kind %q (use free-space or dir-size)", c.Kind)
+	}
+
+	if c.Reset != "" {
+		if t.reset, err = parseLevel(c.Reset, c.Kind == TriggerFreeSpace); err != nil {
+			return nil, err
+		}
+		if t.reset.percent != t.threshold.percent {
+			return nil, fmt.Errorf("reset level %s must use the same unit as the threshold", c.Reset)
+		}
+		if c.Kind == TriggerFreeSpace && t.reset.value <= t.threshold.value {
+			return nil, fmt.Errorf("reset level %s must be above the threshold %s", c.Reset, c.Below)
+		}
+		if c.Kind == TriggerDirSize && t.reset.value >= t.threshold.value {
+			return nil, fmt.Errorf("reset level %s must be below the limit %s", c.Reset, c.Above)
+		}
+	}
+
+	return t, nil
+}
+
+// Check measures the condition and reports whether the trigger fires, with
+// the reason. A fired trigger disarms until the level reaches the reset level.
+func (t *Trigger) Check() (string, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, err := t.measure()
+	if err != nil {
+		return "", false, err
+	}
+
+	switch t.Kind {
+	case TriggerFreeSpace:
+		if t.armed && current.value < t.threshold.value {
+			t.armed = false
+			return fmt.Sprintf("free space on %s is %s, below %s", t.Path, current, t.threshold), true, nil
+		}
+		if !t.armed && current.value >= t.reset.value {
+			t.armed = true
+		}
+	case TriggerDirSize:
+		if t.armed && current.value > t.threshold.value {
+			t.armed = false
+			return fmt.Sprintf("%s is %s, above %s", t.Path, current, t.threshold), true, nil
+		}
+		if !t.armed && current.value <= t.reset.value {
+			t.armed = true
+		}
+	}
+	return "", false, nil
+}
+
+// changedError remembers the outcome of the last check and reports whether
+// err is a new error, so that a lasting problem is reported only once
+func (t *Trigger) changedError(err error) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	changed := msg != t.lastErr
+	t.lastErr = msg
+	return err != nil && changed
+}
+
+// freeSpace measures the space available to unprivileged users on the file
+// system holding the trigger's path, in the unit of the threshold
+func (t *Trigger) freeSpace() (level, error) {
+	free, total, err := diskUsage(t.Path)
+	if err != nil {
+		return level{}, fmt.Errorf("failed to get free space of %s: %w", t.Path, err)
+	}
+	if !t.threshold.percent {
+		return level{value: float64(free)}, nil
+	}
+	if total == 0 {
+		return level{}, fmt.Errorf("file system of %s reports no capacity", t.Path)
+	}
+	return level{value: float64(free) / float64(total) * 100, percent: true}, nil
+}
+
+// dirSize measures the total size of the regular files below the trigger's
+// path, counting files with several hard links once. Unreadable
+// subdirectories are skipped.
+func (t *Trigger) dirSize() (level, error) {
+	var size int64
+	links := walk.HardLinks{}
+	err := walk.Walk(t.Path, nil, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == t.Path {
+				return err
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() && !links.Seen(info) {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return level{}, fmt.Errorf("failed to get size of %s: %w", t.Path, err)
+	}
+	return level{value: float64(size)}, nil
+}
+
+// parseLevel parses a size such as "5GB" or "512MB" or, if allowed, a
+// percentage such as "10%"
+func parseLevel(s string, allowPercent bool) (level, error) {
+	s = strings.TrimSpace(s)
+	if number, ok := strings.CutSuffix(s, "%"); ok {
+		if !allowPercent {
+			return level{}, fmt.Errorf("invalid threshold: %s (only free-space thresholds can be percentages)", s)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil || value <= 0 || value >= 100 {
+			return level{}, fmt.Errorf("invalid threshold: %s", s)
+		}
+		return level{value: value, percent: true}, nil
+	}
+
+	size, err := parseSize(s)
+	if err != nil || size <= 0 {
+		return level{}, fmt.Errorf("invalid threshold: %s", s)
+	}
+	return level{value: float64(size)}, nil
+}
+
+// parseSize parses sizes like "100KB", "1.5GB" or a plain number of bytes
+func parseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+
+	// Longest suffixes first so "GB" is not taken for "B"
+	suffixes := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	}
+	for _, sf := range suffixes {
+		if number, ok := strings.CutSuffix(upper, sf.suffix); ok {
+			value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid size: %s", s)
+			}
+			return int64(value * sf.multiplier), nil
+		}
+	}
+
+	size, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	return size, nil
+}
+
+// formatSize formats a byte count for trigger reasons
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeLevel makes a trigger measure the levels written to current
+func fakeLevel(trigger *Trigger, current *level) {
+	trigger.measure = func() (level, error) { return *current, nil }
+}
+
+func TestTrigger_FreeSpaceHysteresis(t *testing.T) {
+	trigger, err := NewTrigger(&TriggerConfig{Kind: TriggerFreeSpace, Path: "/", Below: "10%"})
+	if err != nil {
+		t.Fatalf("NewTrigger failed: %v", err)
+	}
+	current := level{value: 50, percent: true}
+	fakeLevel(trigger, &current)
+
+	steps := []struct {
+		free float64
+		want bool
+	}{
+		{50, false},
+		{9, true},   // Dropped below the threshold
+		{8, false},  // Still low: don't fire again
+		{12, false}, // Recovered, but not past the reset level (15%)
+		{9, false},
+		{15, false}, // Re-armed
+		{9.5, true},
+	}
+	for i, step := range steps {
+		current.value = step.free
+		reason, fired, err := trigger.Check()
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		if fired != step.want {
+			t.Errorf("step %d (%.1f%% free): fired = %v, want %v", i, step.free, fired, step.want)
+		}
+		if fired && !strings.Contains(reason, "below 10%") {
+			t.Errorf("Unexpected reason %q", reason)
+		}
+	}
+}
+
+func TestTrigger_DirSize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.bin"), make([]byte, 600), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	trigger, err := NewTrigger(&TriggerConfig{Kind: TriggerDirSize, Path: dir, Above: "1KB", Reset: "512"})
+	if err != nil {
+		t.Fatalf("NewTrigger failed: %v", err)
+	}
+	if _, fired, _ := trigger.Check(); fired {
+		t.Error("Expected no trigger below the limit")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "sub", "b.bin"), make([]byte, 600), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, fired, err := trigger.Check(); err != nil || !fired {
+		t.Errorf("Expected trigger above the limit, got %v, %v", fired, err)
+	}
+
+	// Removing one file is not enough to re-arm
+	os.Remove(filepath.Join(dir, "sub", "b.bin"))
+	trigger.Check()
+	if err := os.WriteFile(filepath.Join(dir, "sub", "b.bin"), make([]byte, 600), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, fired, _ := trigger.Check(); fired {
+		t.Error("Expected no trigger before the size dropped to the reset level")
+	}
+
+	if _, err := (&Trigger{Kind: TriggerDirSize, Path: filepath.Join(dir, "missing")}).dirSize(); err == nil {
+		t.Error("Expected error for missing directory")
+	}
+}
+
+func TestTrigger_FreeSpaceMeasures(t *testing.T) {
+	trigger, err := NewTrigger(&TriggerConfig{Kind: TriggerFreeSpace, Path: t.TempDir(), Below: "1B"})
+	if err != nil {
+		t.Fatalf("NewTrigger failed: %v", err)
+	}
+	if _, err := trigger.freeSpace(); err != nil {
+		t.Skipf("free space not available: %v", err)
+	}
+	if _, fired, err := trigger.Check(); err != nil || fired {
+		t.Errorf("Expected more than 1 byte free, got %v, %v", fired, err)
+	}
+}
+
+func TestNewTrigger_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TriggerConfig
+		wantErr bool
+	}{
+		{"percent", TriggerConfig{Kind: TriggerFreeSpace, Path: "/", Below: "10%"}, false},
+		{"bytes", TriggerConfig{Kind: TriggerFreeSpace, Path: "/", Below: "5GB", Reset: "8G"}, false},
+		{"dir size", TriggerConfig{Kind: TriggerDirSize, Path: "/tmp", Above: "20GB", Interval: "15m"}, false},
+		{"missing path", TriggerConfig{Kind: TriggerFreeSpace, Below: "10%"}, true},
+		{"missing threshold", TriggerConfig{Kind: TriggerFreeSpace, Path: "/"}, true},
+		{"percent dir size", TriggerConfig{Kind: TriggerDirSize, Path: "/tmp", Above: "50%"}, true},
+		{"reset below threshold", TriggerConfig{Kind: TriggerFreeSpace, Path: "/", Below: "10%", Reset: "5%"}, true},
+		{"reset unit mismatch", TriggerConfig{Kind: TriggerFreeSpace, Path: "/", Below: "10%", Reset: "5GB"}, true},
+		{"bad interval", TriggerConfig{Kind: TriggerDirSize, Path: "/tmp", Above: "1GB", Interval: "soon"}, true},
+		{"unknown kind", TriggerConfig{Kind: "inode", Path: "/"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTrigger(&tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTrigger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTaskConfig_ValidateTrigger(t *testing.T) {
+	trigger := &TriggerConfig{Kind: TriggerFreeSpace, Path: "/", Below: "10%"}
+	valid := &TaskConfig{ID: "low-disk", Trigger: trigger, Job: &JobConfig{Kind: JobJunkClean}, Then: []*JobConfig{{Kind: JobTrashEmpty, OlderThan: "7d"}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid triggered task, got %v", err)
+	}
+
+	both := &TaskConfig{ID: "both", Schedule: "@daily", Trigger: trigger, Command: "true"}
+	if err := both.Validate(); err == nil {
+		t.Error("Expected error for task with schedule and trigger")
+	}
+	jitter := &TaskConfig{ID: "jitter", Trigger: trigger, Jitter: "5m", Command: "true"}
+	if err := jitter.Validate(); err == nil {
+		t.Error("Expected error for jitter on a triggered task")
+	}
+	thenWithoutJob := &TaskConfig{ID: "then", Schedule: "@daily", Command: "true", Then: []*JobConfig{{Kind: JobTrashEmpty}}}
+	if err := thenWithoutJob.Validate(); err == nil {
+		t.Error("Expected error for follow-up jobs without a job")
+	}
+}
+
+func TestScheduler_RunsTriggeredTask(t *testing.T) {
+	trigger, err := NewTrigger(&TriggerConfig{Kind: TriggerFreeSpace, Path: "/", Below: "10%", Interval: "1s"})
+	if err != nil {
+		t.Fatalf("NewTrigger failed: %v", err)
+	}
+	current := level{value: 5, percent: true}
+	fakeLevel(trigger, &current)
+
+	var runs int32
+	fn := func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}
+	_, _, history := newPolicyScheduler(t, &Task{ID: "low-disk", Trigger: trigger, Enabled: true}, fn)
+
+	waitFor(t, func() bool { return len(statuses(t, history, "low-disk")) == 1 })
+	runs1, _ := history.Runs("low-disk", 0)
+	if !strings.Contains(runs1[0].Trigger, "free space on / is 5%") {
+		t.Errorf("Expected trigger reason in run record, got %q", runs1[0].Trigger)
+	}
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("Expected 1 run, got %d", n)
+	}
+}
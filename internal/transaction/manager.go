@@ -26,9 +26,16 @@ const (
 	OpRename OperationType = "rename"
 	OpDelete OperationType = "delete"
 	OpMkdir  OperationType = "mkdir"
+	OpRmdir  OperationType = "rmdir"
 )
 
-// ExecutedOperation represents a single file operation that was executed
+// ExecutedOperation represents a single file operation that was executed.
+//
+// Undoing a move or rename moves Target back to Source. For these Backup
+// names where the file is restored from, which is Target itself (for the
+// trash, the file in the trash). Records written by older versions leave it
+// empty, or name a backup of a file the move replaced that no longer
+// exists; they are undone the same way.
 type ExecutedOperation struct {
 	Type   OperationType `json:"type"`
 	Source string        `json:"source"`
@@ -95,6 +102,18 @@ func (m *Manager) Commit(tx *Transaction) error {
 	return nil
 }
 
+// Discard drops a pending transaction that recorded nothing, without
+// writing it to the log
+func (m *Manager) Discard(tx *Transaction) {
+	if tx == nil || len(tx.Operations) > 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.txns, tx.ID)
+}
+
 // Rollback rolls back a transaction by reversing its operations
 // Bug Fix #2: Improved error tolerance - collect all errors instead of failing fast
 func (m *Manager) Rollback(tx *Transaction) error {
@@ -113,11 +132,8 @@ func (m *Manager) Rollback(tx *Transaction) error {
 
 		switch op.Type {
 		case OpMove, OpRename:
-			// Restore from backup
-			if op.Backup != "" {
-				if err := os.Rename(op.Target, op.Source); err != nil {
-					errors = append(errors, fmt.Errorf("failed to rollback operation %d: %w", i, err))
-				}
+			if err := moveBack(op); err != nil {
+				errors = append(errors, fmt.Errorf("failed to rollback operation %d: %w", i, err))
 			}
 		case OpDelete:
 			// Restore from backup
@@ -131,6 +147,11 @@ func (m *Manager) Rollback(tx *Transaction) error {
 			if err := os.Remove(op.Target); err != nil {
 				// Ignore error if directory is not empty
 			}
+		case OpRmdir:
+			// Recreate the removed directory
+			if err := os.MkdirAll(op.Source, 0755); err != nil {
+				errors = append(errors, fmt.Errorf("failed to rollback rmdir operation %d: %w", i, err))
+			}
 		}
 	}
 
@@ -196,11 +217,8 @@ func (m *Manager) Undo(transactionID string) error {
 
 		switch op.Type {
 		case OpMove, OpRename:
-			// Restore from backup
-			if op.Backup != "" {
-				if err := os.Rename(op.Target, op.Source); err != nil {
-					errors = append(errors, fmt.Errorf("failed to undo operation %d: %w", i, err))
-				}
+			if err := moveBack(op); err != nil {
+				errors = append(errors, fmt.Errorf("failed to undo operation %d: %w", i, err))
 			}
 		case OpDelete:
 			// Restore from backup
@@ -214,6 +232,11 @@ func (m *Manager) Undo(transactionID string) error {
 			if err := os.Remove(op.Target); err != nil {
 				// Ignore error if directory is not empty
 			}
+		case OpRmdir:
+			// Recreate the removed directory
+			if err := os.MkdirAll(op.Source, 0755); err != nil {
+				errors = append(errors, fmt.Errorf("failed to undo rmdir operation %d: %w", i, err))
+			}
 		}
 	}
 
@@ -232,6 +255,15 @@ func (m *Manager) Undo(transactionID string) error {
 	return nil
 }
 
+// moveBack reverses a move or rename, recreating the source directory if it
+// has been removed since (e.g. pruned once the move emptied it)
+func moveBack(op *ExecutedOperation) error {
+	if err := os.MkdirAll(filepath.Dir(op.Source), 0755); err != nil {
+		return err
+	}
+	return os.Rename(op.Target, op.Source)
+}
+
 // AddOperation adds an operation to a transaction
 func (m *Manager) AddOperation(tx *Transaction, op *ExecutedOperation) {
 	if tx != nil && op != nil {
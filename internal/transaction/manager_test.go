@@ -68,6 +68,18 @@ func TestTransactionPersistence(t *testing.T) {
 	assert.Len(t, history[0].Operations, 1)
 }
 
+func TestDiscardEmptyTransaction(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "transactions.json")
+	manager := NewManager(logPath)
+
+	manager.Discard(manager.Begin())
+
+	history, err := manager.GetHistory(10)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	assert.NoFileExists(t, logPath)
+}
+
 func TestGetHistory(t *testing.T) {
 	tmpDir := t.TempDir()
 	logPath := filepath.Join(tmpDir, "transactions.json")
@@ -145,6 +157,31 @@ func TestUndoOperation(t *testing.T) {
 	assert.FileExists(t, sourceFile)
 }
 
+func TestUndoOlderMoveRecords(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "transactions.json")
+
+	// Older versions recorded no backup for a plain move, and the removed
+	// backup of a replaced file for a rename
+	moved := filepath.Join(tmpDir, "Documents", "report.pdf")
+	renamed := filepath.Join(tmpDir, "q3-report.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(moved), 0755))
+	require.NoError(t, os.WriteFile(moved, []byte("report"), 0644))
+	require.NoError(t, os.WriteFile(renamed, []byte("notes"), 0644))
+
+	log := `[{"id": "txn_1", "timestamp": "2024-01-01T00:00:00Z", "status": "committed", "operations": [
+		{"type": "move", "source": "` + filepath.ToSlash(filepath.Join(tmpDir, "report.pdf")) + `", "target": "` + filepath.ToSlash(moved) + `", "backup": ""},
+		{"type": "rename", "source": "` + filepath.ToSlash(filepath.Join(tmpDir, "notes.txt")) + `", "target": "` + filepath.ToSlash(renamed) + `", "backup": "` + filepath.ToSlash(renamed) + `.backup"}
+	]}]`
+	require.NoError(t, os.WriteFile(logPath, []byte(log), 0644))
+
+	require.NoError(t, NewManager(logPath).Undo("txn_1"))
+	assert.FileExists(t, filepath.Join(tmpDir, "report.pdf"))
+	assert.FileExists(t, filepath.Join(tmpDir, "notes.txt"))
+	assert.NoFileExists(t, moved)
+	assert.NoFileExists(t, renamed)
+}
+
 func TestUndoNonExistentTransaction(t *testing.T) {
 	tmpDir := t.TempDir()
 	logPath := filepath.Join(tmpDir, "transactions.json")
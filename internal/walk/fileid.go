@@ -0,0 +1,36 @@
+package walk
+
+import "os"
+
+// FileID identifies a file independently of its path. Hard links to a file
+// share its ID.
+type FileID struct {
+	Dev uint64
+	Ino uint64
+}
+
+// IsZero reports whether the ID is unknown
+func (id FileID) IsZero() bool {
+	return id == FileID{}
+}
+
+// HardLinks remembers the files with several hard links seen during a walk,
+// so that each is counted once
+type HardLinks map[FileID]bool
+
+// Seen reports whether a file is another link to a file seen before, and
+// records it otherwise. Files with a single link are never recorded.
+func (h HardLinks) Seen(info os.FileInfo) bool {
+	if Links(info) < 2 {
+		return false
+	}
+	id, ok := ID(info)
+	if !ok {
+		return false
+	}
+	if h[id] {
+		return true
+	}
+	h[id] = true
+	return false
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package walk
+
+import "os"
+
+// ID returns false: the platform's FileInfo carries no file ID, so hard
+// links are not recognized
+func ID(info os.FileInfo) (FileID, bool) {
+	return FileID{}, false
+}
+
+// Links returns 1: the platform's FileInfo carries no link count
+func Links(info os.FileInfo) uint64 {
+	return 1
+}
+
+// device returns false: mount points are not detected on this platform
+func device(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
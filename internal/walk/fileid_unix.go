@@ -0,0 +1,32 @@
+//go:build unix
+
+package walk
+
+import (
+	"os"
+	"syscall"
+)
+
+// ID returns the identity of a file: its device and inode numbers
+func ID(info os.FileInfo) (FileID, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return FileID{Dev: uint64(st.Dev), Ino: uint64(st.Ino)}, true
+	}
+	return FileID{}, false
+}
+
+// Links returns the number of hard links to a file
+func Links(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Nlink)
+	}
+	return 1
+}
+
+// device returns the device number of the file system holding a file
+func device(info os.FileInfo) (uint64, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Dev), true
+	}
+	return 0, false
+}
@@ -0,0 +1,25 @@
+//go:build darwin || freebsd
+
+package walk
+
+import "golang.org/x/sys/unix"
+
+// pseudoTypes are the file systems that describe the kernel, processes or
+// devices rather than holding files
+var pseudoTypes = map[string]bool{
+	"devfs":     true,
+	"fdescfs":   true,
+	"procfs":    true,
+	"linprocfs": true,
+	"linsysfs":  true,
+	"autofs":    true,
+}
+
+// isPseudo reports whether a directory is on a pseudo-filesystem
+func isPseudo(path string) bool {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return false
+	}
+	return pseudoTypes[unix.ByteSliceToString(st.Fstypename[:])]
+}
@@ -0,0 +1,41 @@
+//go:build linux
+
+package walk
+
+import "golang.org/x/sys/unix"
+
+// pseudoMagics are the statfs types of file systems that describe the
+// kernel, processes or devices rather than holding files
+var pseudoMagics = map[int64]bool{
+	unix.PROC_SUPER_MAGIC:    true,
+	unix.SYSFS_MAGIC:         true,
+	unix.DEVPTS_SUPER_MAGIC:  true,
+	unix.CGROUP_SUPER_MAGIC:  true,
+	unix.CGROUP2_SUPER_MAGIC: true,
+	unix.DEBUGFS_MAGIC:       true,
+	unix.TRACEFS_MAGIC:       true,
+	unix.SECURITYFS_MAGIC:    true,
+	unix.PSTOREFS_MAGIC:      true,
+	unix.BPF_FS_MAGIC:        true,
+	0x62656570:               true, // configfs
+	unix.EFIVARFS_MAGIC:      true,
+	unix.SELINUX_MAGIC:       true,
+	unix.SMACK_MAGIC:         true,
+	unix.HUGETLBFS_MAGIC:     true,
+	0x19800202:               true, // mqueue
+	unix.BINFMTFS_MAGIC:      true,
+	unix.AUTOFS_SUPER_MAGIC:  true,
+	unix.NSFS_MAGIC:          true,
+	0x65735543:               true, // fusectl
+	0x1373:                   true, // devfs
+}
+
+// isPseudo reports whether a directory is on a pseudo-filesystem. /dev is
+// recognized by its path: devtmpfs reports the type of tmpfs.
+func isPseudo(path string) bool {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return false
+	}
+	return pseudoMagics[int64(st.Type)] || path == "/dev"
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd
+
+package walk
+
+// isPseudo returns false: pseudo-filesystems are not detected on this
+// platform
+func isPseudo(path string) bool {
+	return false
+}
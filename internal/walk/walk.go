@@ -0,0 +1,180 @@
+// Package walk traverses directory trees under an explicit policy for
+// symbolic links, hard links and mount points. It is shared by every part
+// of the CLI that scans directories, so that they all agree on which files
+// a tree holds.
+package walk
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrBrokenLink is reported for symbolic links whose target is missing
+	ErrBrokenLink = errors.New("broken symbolic link")
+	// ErrLinkLoop is reported for symbolic links to a directory that
+	// contains the link, which would otherwise be walked forever
+	ErrLinkLoop = errors.New("symbolic link loop")
+)
+
+// Options controls how Walk treats links and mount points. The zero value
+// does not follow symbolic links, crosses into other file systems and skips
+// pseudo-filesystems.
+type Options struct {
+	FollowSymlinks bool // Walk into linked directories and report linked files with their target's info
+	OneFileSystem  bool // Do not descend into directories on another file system than the root
+	IncludePseudo  bool // Descend into pseudo-filesystems such as /proc, /sys and /dev
+}
+
+// Walk walks the file tree rooted at root like filepath.Walk, calling fn for
+// each file and directory in lexical order. The root is resolved if it is a
+// symbolic link. Other links are reported with their own info unless
+// FollowSymlinks is set, in which case fn receives the target's info and
+// linked directories are walked under the link's path. Broken links and
+// link loops are reported to fn with the link's info and an error wrapping
+// ErrBrokenLink or ErrLinkLoop.
+//
+// Mount points of pseudo-filesystems and, with OneFileSystem, of any other
+// file system are skipped without calling fn.
+func Walk(root string, opts *Options, fn filepath.WalkFunc) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		w := &walker{opts: opts, fn: fn, ancestors: make(map[dirKey]bool)}
+		w.rootDev, w.hasDev = device(info)
+		err = w.walk(root, info)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// dirKey identifies a directory for loop detection: by file ID where the
+// platform reports one, by its resolved path elsewhere
+type dirKey struct {
+	id   FileID
+	path string
+}
+
+// walker holds the state of one Walk
+type walker struct {
+	opts      *Options
+	fn        filepath.WalkFunc
+	rootDev   uint64
+	hasDev    bool
+	ancestors map[dirKey]bool // Directories being walked, from the root down
+}
+
+// walk reports a file or walks a directory
+func (w *walker) walk(path string, info os.FileInfo) error {
+	if !info.IsDir() {
+		return w.fn(path, info, nil)
+	}
+
+	if err := w.fn(path, info, nil); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+
+	key := keyOf(path, info)
+	w.ancestors[key] = true
+	defer delete(w.ancestors, key)
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if err := w.fn(path, info, err); err != nil && err != filepath.SkipDir {
+			return err
+		}
+		return nil
+	}
+
+	dirDev, _ := device(info)
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		childInfo, err := entry.Info()
+		if err != nil {
+			if err := w.fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+
+		if childInfo.Mode()&fs.ModeSymlink != 0 {
+			var linkErr error
+			childInfo, linkErr = w.resolve(child, childInfo)
+			if linkErr != nil {
+				if err := w.fn(child, childInfo, linkErr); err != nil && err != filepath.SkipDir {
+					return err
+				}
+				continue
+			}
+		}
+
+		if childInfo.IsDir() && w.skipMount(child, childInfo, dirDev) {
+			continue
+		}
+
+		if err := w.walk(child, childInfo); err != nil {
+			if err == filepath.SkipDir {
+				break // A file skipped the rest of its directory
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve returns the info a symbolic link is walked with: its own unless
+// links are followed, otherwise its target's. It fails for broken links and
+// for links to a directory being walked.
+func (w *walker) resolve(path string, link os.FileInfo) (os.FileInfo, error) {
+	target, err := os.Stat(path)
+	if err != nil {
+		return link, fmt.Errorf("%s: %w", path, ErrBrokenLink)
+	}
+	if !w.opts.FollowSymlinks {
+		return link, nil
+	}
+	if target.IsDir() && w.ancestors[keyOf(path, target)] {
+		return link, fmt.Errorf("%s: %w", path, ErrLinkLoop)
+	}
+	return target, nil
+}
+
+// skipMount reports whether a directory is a mount point the options keep
+// the walk out of. parentDev is the device of the directory holding it.
+func (w *walker) skipMount(path string, info os.FileInfo, parentDev uint64) bool {
+	dev, ok := device(info)
+	if !ok || !w.hasDev || dev == parentDev {
+		return false
+	}
+	if w.opts.OneFileSystem && dev != w.rootDev {
+		return true
+	}
+	return !w.opts.IncludePseudo && isPseudo(path)
+}
+
+// keyOf returns the loop detection key of a directory
+func keyOf(path string, info os.FileInfo) dirKey {
+	if id, ok := ID(info); ok {
+		return dirKey{id: id}
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return dirKey{path: path}
+}
@@ -0,0 +1,184 @@
+package walk
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// makeTree creates a tree with a linked file, a linked directory, a broken
+// link and a link back to the root
+func makeTree(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "docs"), 0755)
+	os.WriteFile(filepath.Join(root, "docs", "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(outside, "b.txt"), []byte("b"), 0644)
+
+	links := map[string]string{
+		"file.txt": filepath.Join(root, "docs", "a.txt"),
+		"shared":   outside,
+		"broken":   filepath.Join(root, "missing"),
+		"docs/up":  root,
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// collect walks root and returns the paths reported without error, relative
+// to root, and the errors by path
+func collect(t *testing.T, root string, opts *Options) ([]string, map[string]error) {
+	t.Helper()
+	var paths []string
+	errs := make(map[string]error)
+	err := Walk(root, opts, func(path string, info os.FileInfo, err error) error {
+		rel, _ := filepath.Rel(root, path)
+		if err != nil {
+			errs[filepath.ToSlash(rel)] = err
+			return nil
+		}
+		if path != root {
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	sort.Strings(paths)
+	return paths, errs
+}
+
+func TestWalk_Symlinks(t *testing.T) {
+	root := makeTree(t)
+
+	paths, errs := collect(t, root, nil)
+	want := []string{"docs", "docs/a.txt", "docs/up", "file.txt", "shared"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	if !errors.Is(errs["broken"], ErrBrokenLink) || len(errs) != 1 {
+		t.Errorf("errors = %v, want a broken link", errs)
+	}
+}
+
+func TestWalk_FollowSymlinks(t *testing.T) {
+	root := makeTree(t)
+
+	paths, errs := collect(t, root, &Options{FollowSymlinks: true})
+	want := []string{"docs", "docs/a.txt", "file.txt", "shared", "shared/b.txt"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	if !errors.Is(errs["broken"], ErrBrokenLink) || !errors.Is(errs["docs/up"], ErrLinkLoop) || len(errs) != 2 {
+		t.Errorf("errors = %v, want a broken link and a loop", errs)
+	}
+
+	// Followed links are reported with the target's info
+	Walk(root, &Options{FollowSymlinks: true}, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			t.Errorf("%s reported as a link", path)
+		}
+		return nil
+	})
+}
+
+func TestWalk_LinkedRoot(t *testing.T) {
+	root := makeTree(t)
+	link := filepath.Join(t.TempDir(), "root")
+	if err := os.Symlink(filepath.Join(root, "docs"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, _ := collect(t, link, nil)
+	if !slices.Equal(paths, []string{"a.txt", "up"}) {
+		t.Errorf("paths = %v, want the linked directory's files", paths)
+	}
+}
+
+func TestWalk_Skip(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		os.MkdirAll(filepath.Join(root, dir), 0755)
+		for _, name := range []string{"1", "2"} {
+			os.WriteFile(filepath.Join(root, dir, name), nil, 0644)
+		}
+	}
+
+	var seen []string
+	Walk(root, nil, func(path string, info os.FileInfo, err error) error {
+		rel, _ := filepath.Rel(root, path)
+		seen = append(seen, filepath.ToSlash(rel))
+		switch filepath.ToSlash(rel) {
+		case "a":
+			return filepath.SkipDir // Skips the directory
+		case "b/1":
+			return filepath.SkipDir // Skips the rest of b
+		}
+		return nil
+	})
+	if !slices.Equal(seen, []string{".", "a", "b", "b/1"}) {
+		t.Errorf("visited %v", seen)
+	}
+}
+
+func TestHardLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file IDs are not reported on Windows")
+	}
+	dir := t.TempDir()
+	original := filepath.Join(dir, "original")
+	os.WriteFile(original, []byte("data"), 0644)
+	if err := os.Link(original, filepath.Join(dir, "link")); err != nil {
+		t.Skip("hard links not supported:", err)
+	}
+	os.WriteFile(filepath.Join(dir, "other"), []byte("data"), 0644)
+
+	seen := HardLinks{}
+	var counted []string
+	Walk(dir, nil, func(path string, info os.FileInfo, err error) error {
+		if !info.IsDir() && !seen.Seen(info) {
+			counted = append(counted, filepath.Base(path))
+		}
+		return nil
+	})
+	if !slices.Equal(counted, []string{"link", "other"}) {
+		t.Errorf("counted %v, want the first link and the other file", counted)
+	}
+
+	a, _ := os.Stat(original)
+	b, _ := os.Stat(filepath.Join(dir, "link"))
+	idA, okA := ID(a)
+	idB, okB := ID(b)
+	if !okA || !okB || idA != idB || idA.IsZero() || Links(a) != 2 {
+		t.Errorf("ID() = %v, %v; Links() = %d", idA, idB, Links(a))
+	}
+}
+
+func TestIsPseudo(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pseudo-filesystems are detected by type on Linux")
+	}
+	if _, err := os.Stat("/proc/self"); err != nil {
+		t.Skip("/proc is not mounted")
+	}
+	if !isPseudo("/proc") {
+		t.Error("/proc is not recognized as a pseudo-filesystem")
+	}
+	if isPseudo(t.TempDir()) {
+		t.Error("a temporary directory is recognized as a pseudo-filesystem")
+	}
+}
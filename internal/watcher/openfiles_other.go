@@ -0,0 +1,9 @@
+//go:build !linux
+
+package watcher
+
+// isOpenForWriting is not supported on this platform; settling relies on
+// size, modification time and download companions alone
+func isOpenForWriting(path string) bool {
+	return false
+}
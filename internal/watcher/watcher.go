@@ -0,0 +1,441 @@
+// Package watcher organizes files as they arrive in a directory.
+//
+// File system events are collected until a file has settled (its size and
+// modification time stop changing, no download companion such as .part or
+// .crdownload exists next to it, and no process has it open for writing).
+// Settled files are batched and passed through the rule engine and organizer,
+// so every batch is recorded as normal transactions that 'cleanup undo' can
+// revert.
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/organizer"
+	"github.com/xuanyiying/cleanup-cli/internal/walk"
+)
+
+const (
+	// DefaultSettleTime is how long a file must stay unchanged before it is organized
+	DefaultSettleTime = 2 * time.Second
+	// DefaultBatchWindow is how long settled files are collected before organizing them
+	DefaultBatchWindow = 5 * time.Second
+)
+
+// temporarySuffixes mark in-progress downloads and editor scratch files
+var temporarySuffixes = []string{".part", ".crdownload", ".tmp"}
+
+// Options controls how a directory is watched
+type Options struct {
+	ScanOptions *analyzer.ScanOptions       // Recursion and exclusions
+	Strategy    *organizer.OrganizeStrategy // Strategy used for every batch
+	SettleTime  time.Duration               // Quiet period before a file counts as settled
+	BatchWindow time.Duration               // Time settled files are collected before a batch runs
+	OnBatch     func(*Batch)                // Called after each batch (optional)
+	OnError     func(error)                 // Called for non-fatal watcher errors (optional)
+}
+
+// Batch represents one round of organizing settled files
+type Batch struct {
+	Files  []string
+	Plan   *organizer.OrganizePlan
+	Result *organizer.BatchResult
+	Err    error
+}
+
+// pendingFile tracks a file that has not settled yet
+type pendingFile struct {
+	size      int64
+	modTime   time.Time
+	lastEvent time.Time
+}
+
+// Watcher watches a directory and organizes files once they settle
+type Watcher struct {
+	analyzer  analyzer.Analyzer
+	organizer *organizer.Organizer
+	opts      *Options
+
+	root       string
+	fsw        *fsnotify.Watcher
+	pending    map[string]*pendingFile
+	ready      map[string]bool
+	firstReady time.Time
+	produced   map[string]time.Time // Paths written by our own batches
+}
+
+// NewWatcher creates a new directory watcher
+func NewWatcher(fileAnalyzer analyzer.Analyzer, fileOrganizer *organizer.Organizer, opts *Options) *Watcher {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.ScanOptions == nil {
+		opts.ScanOptions = &analyzer.ScanOptions{}
+	}
+	if opts.SettleTime <= 0 {
+		opts.SettleTime = DefaultSettleTime
+	}
+	if opts.BatchWindow <= 0 {
+		opts.BatchWindow = DefaultBatchWindow
+	}
+
+	return &Watcher{
+		analyzer:  fileAnalyzer,
+		organizer: fileOrganizer,
+		opts:      opts,
+		pending:   make(map[string]*pendingFile),
+		ready:     make(map[string]bool),
+		produced:  make(map[string]time.Time),
+	}
+}
+
+// Run watches root until ctx is cancelled. Settled files are organized in
+// batches; a batch in progress always completes before Run returns.
+func (w *Watcher) Run(ctx context.Context, root string) error {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	w.root = absRoot
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer fsw.Close()
+	w.fsw = fsw
+
+	if err := w.addTree(w.root); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			w.handleEvent(event, time.Now())
+
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// Events were dropped, so the only safe view is a fresh scan
+				w.reportError(fmt.Errorf("event queue overflowed, rescanning %s", w.root))
+				w.rescan(w.root, time.Now())
+				continue
+			}
+			w.reportError(err)
+
+		case now := <-ticker.C:
+			w.checkSettled(now)
+			w.expireProduced(now)
+			if len(w.ready) > 0 && now.Sub(w.firstReady) >= w.opts.BatchWindow {
+				w.processBatch(ctx)
+			}
+		}
+	}
+}
+
+// handleEvent updates the pending set for a single file system event
+func (w *Watcher) handleEvent(event fsnotify.Event, now time.Time) {
+	path := filepath.Clean(event.Name)
+
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		// A renamed directory shows up again as a create at its new
+		// location, which triggers a rescan there
+		w.forget(path)
+		return
+	}
+
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	if info.IsDir() {
+		if event.Has(fsnotify.Create) && w.opts.ScanOptions.Recursive && !w.excludedDir(path) {
+			if err := w.addTree(path); err != nil {
+				w.reportError(err)
+			}
+			w.rescan(path, now)
+		}
+		return
+	}
+
+	w.track(path, info, now)
+}
+
+// track marks a file as pending, restarting its settle timer
+func (w *Watcher) track(path string, info os.FileInfo, now time.Time) {
+	if owner, ok := companionOwner(path); ok {
+		// Activity on a download companion keeps its target from settling
+		if pf, exists := w.pending[owner]; exists {
+			pf.lastEvent = now
+		}
+		return
+	}
+
+	if _, ok := w.produced[path]; ok {
+		return
+	}
+	if analyzer.IsExcluded(w.root, path, w.opts.ScanOptions) {
+		return
+	}
+
+	delete(w.ready, path)
+	w.pending[path] = &pendingFile{
+		size:      info.Size(),
+		modTime:   info.ModTime(),
+		lastEvent: now,
+	}
+}
+
+// forget drops a path, and everything below it, from the pending and ready sets
+func (w *Watcher) forget(path string) {
+	for p := range w.pending {
+		if p == path || isWithin(path, p) {
+			delete(w.pending, p)
+		}
+	}
+	for p := range w.ready {
+		if p == path || isWithin(path, p) {
+			delete(w.ready, p)
+		}
+	}
+	for _, watched := range w.fsw.WatchList() {
+		if watched == path || isWithin(path, watched) {
+			_ = w.fsw.Remove(watched) // Already gone if the directory was deleted
+		}
+	}
+}
+
+// checkSettled moves files that have been quiet long enough to the ready set
+func (w *Watcher) checkSettled(now time.Time) {
+	for path, pf := range w.pending {
+		if now.Sub(pf.lastEvent) < w.opts.SettleTime {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			delete(w.pending, path)
+			continue
+		}
+
+		if info.Size() != pf.size || !info.ModTime().Equal(pf.modTime) {
+			pf.size = info.Size()
+			pf.modTime = info.ModTime()
+			pf.lastEvent = now
+			continue
+		}
+
+		if hasCompanion(path) || isOpenForWriting(path) {
+			pf.lastEvent = now
+			continue
+		}
+
+		delete(w.pending, path)
+		if len(w.ready) == 0 {
+			w.firstReady = now
+		}
+		w.ready[path] = true
+	}
+}
+
+// processBatch analyzes and organizes all ready files
+func (w *Watcher) processBatch(ctx context.Context) {
+	paths := make([]string, 0, len(w.ready))
+	for path := range w.ready {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	w.ready = make(map[string]bool)
+
+	batch := &Batch{Files: paths}
+	defer func() {
+		if w.opts.OnBatch != nil {
+			w.opts.OnBatch(batch)
+		}
+	}()
+
+	files := make([]*analyzer.FileMetadata, 0, len(paths))
+	for _, path := range paths {
+		metadata, err := w.analyzer.Analyze(ctx, path)
+		if err != nil {
+			w.reportError(fmt.Errorf("failed to analyze %s: %w", path, err))
+			continue
+		}
+		files = append(files, metadata)
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	plan, err := w.organizer.Organize(ctx, files, w.opts.Strategy)
+	if err != nil {
+		batch.Err = fmt.Errorf("failed to generate organization plan: %w", err)
+		return
+	}
+	batch.Plan = plan
+
+	// Ignore the events our own moves and renames are about to cause
+	now := time.Now()
+	for _, op := range plan.Operations {
+		w.produced[op.Target] = now
+	}
+
+	result, err := w.organizer.ExecutePlan(ctx, plan, w.opts.Strategy)
+	if err != nil {
+		batch.Err = fmt.Errorf("failed to execute plan: %w", err)
+	}
+	batch.Result = result
+}
+
+// rescan adds every file below dir to the pending set
+func (w *Watcher) rescan(dir string, now time.Time) {
+	err := walk.Walk(dir, &w.opts.ScanOptions.Traversal, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Skip paths we can't access
+		}
+		if info.IsDir() {
+			if path == dir {
+				return nil
+			}
+			if !w.opts.ScanOptions.Recursive || w.excludedDir(path) {
+				return filepath.SkipDir
+			}
+			if err := w.fsw.Add(path); err != nil {
+				w.reportError(fmt.Errorf("failed to watch %s: %w", path, err))
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		w.track(path, info, now)
+		return nil
+	})
+	if err != nil {
+		w.reportError(fmt.Errorf("failed to rescan %s: %w", dir, err))
+	}
+}
+
+// addTree watches dir and, in recursive mode, every subdirectory below it
+func (w *Watcher) addTree(dir string) error {
+	if !w.opts.ScanOptions.Recursive {
+		if err := w.fsw.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		return nil
+	}
+
+	return walk.Walk(dir, &w.opts.ScanOptions.Traversal, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == dir {
+				return fmt.Errorf("failed to watch %s: %w", dir, err)
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != dir && w.excludedDir(path) {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// excludedDir reports whether a directory matches the configured exclusions
+func (w *Watcher) excludedDir(dir string) bool {
+	name := filepath.Base(dir)
+	for _, exclude := range w.opts.ScanOptions.ExcludeDirs {
+		if strings.EqualFold(name, exclude) {
+			return true
+		}
+	}
+	return false
+}
+
+// expireProduced forgets our own targets once their events have passed
+func (w *Watcher) expireProduced(now time.Time) {
+	for path, at := range w.produced {
+		if now.Sub(at) > w.opts.SettleTime+w.opts.BatchWindow {
+			delete(w.produced, path)
+		}
+	}
+}
+
+// pollInterval returns how often pending files are checked
+func (w *Watcher) pollInterval() time.Duration {
+	interval := w.opts.SettleTime / 4
+	if interval > 500*time.Millisecond {
+		interval = 500 * time.Millisecond
+	}
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	return interval
+}
+
+// reportError forwards a non-fatal error to the caller
+func (w *Watcher) reportError(err error) {
+	if w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
+
+// companionOwner returns the file a download companion belongs to
+func companionOwner(path string) (string, bool) {
+	for _, suffix := range temporarySuffixes {
+		if strings.HasSuffix(strings.ToLower(path), suffix) {
+			return path[:len(path)-len(suffix)], true
+		}
+	}
+	return "", false
+}
+
+// hasCompanion reports whether an in-progress download companion exists for path
+func hasCompanion(path string) bool {
+	for _, suffix := range temporarySuffixes {
+		if _, err := os.Stat(path + suffix); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithin reports whether path is strictly inside dir
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." {
+		return false
+	}
+	return !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
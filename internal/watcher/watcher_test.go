@@ -0,0 +1,223 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuanyiying/cleanup-cli/internal/analyzer"
+	"github.com/xuanyiying/cleanup-cli/internal/config"
+	"github.com/xuanyiying/cleanup-cli/internal/organizer"
+	"github.com/xuanyiying/cleanup-cli/internal/rules"
+	"github.com/xuanyiying/cleanup-cli/internal/transaction"
+)
+
+// newTestWatcher creates a watcher that moves PDFs into root/Documents
+func newTestWatcher(t *testing.T, root string, manager *transaction.Manager, batches chan *Batch) *Watcher {
+	t.Helper()
+
+	engine := rules.NewEngine()
+	require.NoError(t, engine.LoadRules([]*config.Rule{
+		{
+			Name:      "pdf",
+			Priority:  1,
+			Condition: &config.RuleCondition{Type: "extension", Value: "pdf", Operator: "match"},
+			Action:    &config.RuleAction{Type: "move", Target: "Documents"},
+		},
+	}))
+
+	fileAnalyzer := analyzer.NewAnalyzer()
+	return NewWatcher(fileAnalyzer, organizer.NewOrganizerWithDeps(manager, engine, fileAnalyzer), &Options{
+		ScanOptions: &analyzer.ScanOptions{Recursive: true},
+		Strategy: &organizer.OrganizeStrategy{
+			CreateFolders:    true,
+			ConflictStrategy: organizer.ConflictSuffix,
+			MaxConcurrency:   1,
+			BaseDir:          root,
+		},
+		SettleTime:  50 * time.Millisecond,
+		BatchWindow: 50 * time.Millisecond,
+		OnBatch: func(b *Batch) {
+			batches <- b
+		},
+	})
+}
+
+// waitForBatch waits for the next batch or fails the test
+func waitForBatch(t *testing.T, batches chan *Batch) *Batch {
+	t.Helper()
+
+	select {
+	case b := <-batches:
+		return b
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for batch")
+		return nil
+	}
+}
+
+func TestWatcherOrganizesNewFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "Downloads")
+	require.NoError(t, os.MkdirAll(root, 0755))
+	manager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+
+	batches := make(chan *Batch, 10)
+	w := newTestWatcher(t, root, manager, batches)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx, root) }()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+
+	// Give the watcher time to register before creating files
+	time.Sleep(100 * time.Millisecond)
+
+	file := filepath.Join(root, "report.pdf")
+	require.NoError(t, os.WriteFile(file, []byte("%PDF-1.4 test"), 0644))
+
+	batch := waitForBatch(t, batches)
+	require.NoError(t, batch.Err)
+	assert.Equal(t, []string{file}, batch.Files)
+	require.NotNil(t, batch.Result)
+	assert.Equal(t, 1, batch.Result.Successful)
+
+	moved := filepath.Join(root, "Documents", "report.pdf")
+	assert.FileExists(t, moved)
+	assert.NoFileExists(t, file)
+
+	// The batch is recorded as a normal, undoable transaction
+	require.Len(t, batch.Result.TransactionIDs, 1)
+	require.NoError(t, manager.Undo(batch.Result.TransactionIDs[0]))
+	assert.FileExists(t, file)
+}
+
+func TestWatcherRescansRenamedDirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "Downloads")
+	require.NoError(t, os.MkdirAll(root, 0755))
+	manager := transaction.NewManager(filepath.Join(tmpDir, "transactions.json"))
+
+	// A directory prepared elsewhere and moved in arrives as a single event
+	outside := filepath.Join(tmpDir, "incoming")
+	require.NoError(t, os.MkdirAll(outside, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "a.pdf"), []byte("a"), 0644))
+
+	batches := make(chan *Batch, 10)
+	w := newTestWatcher(t, root, manager, batches)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx, root) }()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, os.Rename(outside, filepath.Join(root, "incoming")))
+
+	batch := waitForBatch(t, batches)
+	require.NoError(t, batch.Err)
+	assert.Equal(t, []string{filepath.Join(root, "incoming", "a.pdf")}, batch.Files)
+	assert.FileExists(t, filepath.Join(root, "Documents", "a.pdf"))
+}
+
+func TestCheckSettledWaitsForCompanionsAndChanges(t *testing.T) {
+	root := t.TempDir()
+	w := NewWatcher(analyzer.NewAnalyzer(), nil, &Options{
+		SettleTime: time.Second,
+	})
+	w.root = root
+
+	file := filepath.Join(root, "movie.mp4")
+	require.NoError(t, os.WriteFile(file, []byte("partial"), 0644))
+	require.NoError(t, os.WriteFile(file+".crdownload", []byte(""), 0644))
+
+	start := time.Now()
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	w.track(file, info, start)
+
+	// Too early
+	w.checkSettled(start.Add(500 * time.Millisecond))
+	assert.Empty(t, w.ready)
+
+	// Quiet long enough, but the download companion is still there
+	w.checkSettled(start.Add(2 * time.Second))
+	assert.Empty(t, w.ready)
+	assert.Contains(t, w.pending, file)
+
+	// Companion gone, but the file grew
+	require.NoError(t, os.Remove(file+".crdownload"))
+	require.NoError(t, os.WriteFile(file, []byte("complete file"), 0644))
+	w.checkSettled(start.Add(4 * time.Second))
+	assert.Empty(t, w.ready)
+
+	// Finally stable
+	w.checkSettled(start.Add(6 * time.Second))
+	assert.True(t, w.ready[file])
+	assert.NotContains(t, w.pending, file)
+}
+
+func TestTrackIgnoresTemporaryAndExcludedFiles(t *testing.T) {
+	root := t.TempDir()
+	w := NewWatcher(analyzer.NewAnalyzer(), nil, &Options{
+		ScanOptions: &analyzer.ScanOptions{ExcludeExtensions: []string{"log"}},
+	})
+	w.root = root
+
+	now := time.Now()
+	for _, name := range []string{"file.pdf.part", "debug.log", ".hidden"} {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		w.track(path, info, now)
+	}
+
+	assert.Empty(t, w.pending)
+}
+
+func TestForgetDropsRenamedPaths(t *testing.T) {
+	root := t.TempDir()
+	fsw, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	defer fsw.Close()
+
+	w := NewWatcher(analyzer.NewAnalyzer(), nil, nil)
+	w.root = root
+	w.fsw = fsw
+
+	dir := filepath.Join(root, "sub")
+	w.pending[filepath.Join(dir, "a.txt")] = &pendingFile{}
+	w.ready[filepath.Join(dir, "b.txt")] = true
+	w.pending[filepath.Join(root, "c.txt")] = &pendingFile{}
+
+	w.forget(dir)
+
+	assert.Len(t, w.pending, 1)
+	assert.Empty(t, w.ready)
+}
+
+func TestIsOpenForWriting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "busy.bin")
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+
+	if !isOpenForWriting(path) {
+		f.Close()
+		t.Skip("open file detection not available on this platform")
+	}
+	require.NoError(t, f.Close())
+	assert.False(t, isOpenForWriting(path))
+}
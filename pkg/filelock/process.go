@@ -0,0 +1,144 @@
+package filelock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked is returned when another process holds a process lock
+var ErrLocked = errors.New("locked by another process")
+
+// ProcessLock is an exclusive lock shared by all cleanup processes on the
+// machine. It guards long operations on a resource, such as organizing a
+// directory, rather than single files.
+type ProcessLock struct {
+	key  string
+	path string
+	file *os.File
+}
+
+// TryLockProcess acquires the process lock for key without blocking. Lock
+// files live in lockDir. If another process holds the lock, the returned
+// error wraps ErrLocked and names the holder. An absolute path key locks the
+// whole directory tree: it is also refused while a lock is held on a
+// directory inside it or containing it.
+func TryLockProcess(lockDir, key string) (*ProcessLock, error) {
+	if err := os.MkdirAll(lockDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	path := filepath.Join(lockDir, hex.EncodeToString(sum[:8])+".lock")
+
+	file, err := lockFile(path)
+	if errors.Is(err, ErrLocked) {
+		if pid := holderPID(path); pid > 0 {
+			return nil, fmt.Errorf("%s is %w (pid %d)", key, ErrLocked, pid)
+		}
+		return nil, fmt.Errorf("%s is %w", key, ErrLocked)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", key, err)
+	}
+
+	// Record the holder for the error message other processes see, and the
+	// key for the overlap check of other trees
+	if err := file.Truncate(0); err == nil {
+		_, _ = file.WriteAt([]byte(fmt.Sprintf("%d\n%s\n", os.Getpid(), key)), 0)
+	}
+	lock := &ProcessLock{key: key, path: path, file: file}
+
+	// Both holders of overlapping trees see each other here, so at most one
+	// of them goes ahead
+	if filepath.IsAbs(key) {
+		if other, pid := overlappingLock(lockDir, path, key); other != "" {
+			lock.Unlock()
+			if pid > 0 {
+				return nil, fmt.Errorf("%s overlaps %s, which is %w (pid %d)", key, other, ErrLocked, pid)
+			}
+			return nil, fmt.Errorf("%s overlaps %s, which is %w", key, other, ErrLocked)
+		}
+	}
+
+	return lock, nil
+}
+
+// overlappingLock returns the key and holder of a lock held in lockDir on a
+// directory tree that overlaps dir, other than the lock file own
+func overlappingLock(lockDir, own, dir string) (string, int) {
+	paths, err := filepath.Glob(filepath.Join(lockDir, "*.lock"))
+	if err != nil {
+		return "", 0
+	}
+
+	for _, path := range paths {
+		if path == own {
+			continue
+		}
+		pid, key := readHolder(path)
+		if !filepath.IsAbs(key) || !overlaps(dir, key) || !isLocked(path) {
+			continue
+		}
+		return key, pid
+	}
+	return "", 0
+}
+
+// overlaps reports whether one of two directories contains the other
+func overlaps(a, b string) bool {
+	return within(a, b) || within(b, a)
+}
+
+// within reports whether path is dir or inside it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Key returns what the lock guards
+func (l *ProcessLock) Key() string {
+	return l.key
+}
+
+// Unlock releases the lock
+func (l *ProcessLock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	err := unlockFile(l.path, l.file)
+	l.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to unlock %s: %w", l.key, err)
+	}
+	return nil
+}
+
+// holderPID reads the process ID recorded in a lock file (0 if unknown)
+func holderPID(path string) int {
+	pid, _ := readHolder(path)
+	return pid
+}
+
+// readHolder reads the process ID (0 if unknown) and key recorded in a lock
+// file
+func readHolder(path string) (int, string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, ""
+	}
+
+	line, rest, _ := strings.Cut(string(data), "\n")
+	key, _, _ := strings.Cut(rest, "\n")
+	pid, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		return 0, key
+	}
+	return pid, key
+}
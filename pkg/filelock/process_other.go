@@ -0,0 +1,31 @@
+//go:build !unix
+
+package filelock
+
+import (
+	"os"
+)
+
+// lockFile creates path exclusively. Without flock a lock left behind by a
+// crashed process has to be removed by hand.
+func lockFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return nil, ErrLocked
+	}
+	return file, err
+}
+
+// unlockFile closes and removes the lock file
+func unlockFile(path string, file *os.File) error {
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// isLocked reports whether the lock file at path exists
+func isLocked(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
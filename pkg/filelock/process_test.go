@@ -0,0 +1,87 @@
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestProcessLockExclusive(t *testing.T) {
+	lockDir := t.TempDir()
+
+	lock, err := TryLockProcess(lockDir, "/home/user/Downloads")
+	if err != nil {
+		t.Fatalf("TryLockProcess failed: %v", err)
+	}
+
+	// A second holder is refused and told who holds the lock
+	_, err = TryLockProcess(lockDir, "/home/user/Downloads")
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("Expected ErrLocked, got %v", err)
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("pid %d", os.Getpid())) {
+		t.Errorf("Expected holder pid in error, got %v", err)
+	}
+
+	// Other keys are independent
+	other, err := TryLockProcess(lockDir, "/home/user/Pictures")
+	if err != nil {
+		t.Fatalf("TryLockProcess for other key failed: %v", err)
+	}
+	defer other.Unlock()
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+
+	relocked, err := TryLockProcess(lockDir, "/home/user/Downloads")
+	if err != nil {
+		t.Fatalf("TryLockProcess after unlock failed: %v", err)
+	}
+	if err := relocked.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+
+	// Unlocking twice is harmless
+	if err := relocked.Unlock(); err != nil {
+		t.Errorf("Second Unlock failed: %v", err)
+	}
+}
+
+func TestProcessLockOverlappingTrees(t *testing.T) {
+	lockDir := t.TempDir()
+
+	lock, err := TryLockProcess(lockDir, "/home/user/Downloads")
+	if err != nil {
+		t.Fatalf("TryLockProcess failed: %v", err)
+	}
+
+	// Directories inside or above a locked tree are refused
+	for _, key := range []string{"/home/user/Downloads/sub", "/home/user"} {
+		if _, err := TryLockProcess(lockDir, key); !errors.Is(err, ErrLocked) {
+			t.Errorf("Expected ErrLocked for %s, got %v", key, err)
+		}
+	}
+
+	// Siblings sharing a name prefix and keys that are not paths are not
+	for _, key := range []string{"/home/user/Downloads2", "junk-clean"} {
+		other, err := TryLockProcess(lockDir, key)
+		if err != nil {
+			t.Errorf("TryLockProcess(%s) failed: %v", key, err)
+			continue
+		}
+		other.Unlock()
+	}
+
+	// Released locks no longer count
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	sub, err := TryLockProcess(lockDir, "/home/user/Downloads/sub")
+	if err != nil {
+		t.Fatalf("TryLockProcess after unlock failed: %v", err)
+	}
+	sub.Unlock()
+}